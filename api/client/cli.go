@@ -11,12 +11,14 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/docker/docker/pkg/homedir"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/term"
+	"github.com/docker/docker/pkg/version"
 	"github.com/docker/docker/registry"
 )
 
@@ -38,7 +40,65 @@ type DockerCli struct {
 	isTerminalIn bool
 	// isTerminalOut describes if client's STDOUT is a TTY
 	isTerminalOut bool
-	transport     *http.Transport
+	transport     http.RoundTripper
+	// dialer, if set, replaces the default connection to proto and addr
+	dialer func(network, addr string) (net.Conn, error)
+	// maxRetries is the number of times a GET request is retried when the
+	// daemon can't be reached, waiting retryInterval before the first retry
+	// and twice as long before each of the next ones, up to maxRetryInterval
+	maxRetries    int
+	retryInterval time.Duration
+	// apiVersion is the version of the API of the requests, pinned with
+	// WithAPIVersion or negotiated with the daemon on first use. versionErr
+	// keeps the error of a failed negotiation, which is not attempted again.
+	apiVersion       version.Version
+	apiVersionPinned bool
+	versionErr       error
+	versionLock      sync.Mutex
+}
+
+// CliOption customizes the way a DockerCli connects to the daemon
+type CliOption func(*DockerCli)
+
+// WithTransport makes the client send its API requests through tr instead of
+// a transport connecting to proto and addr. Attach and exec streams are not
+// affected, as they hijack the connection.
+func WithTransport(tr http.RoundTripper) CliOption {
+	return func(cli *DockerCli) {
+		cli.transport = tr
+	}
+}
+
+// WithRetries makes the client retry GET requests up to maxRetries times
+// when the daemon can't be reached, e.g. because it is restarting. The delay
+// between attempts starts at interval and doubles after each retry, up to 30
+// seconds, or interval if it is longer. Requests
+// which change the state of the daemon, such as creating or starting a
+// container, are never retried.
+func WithRetries(maxRetries int, interval time.Duration) CliOption {
+	return func(cli *DockerCli) {
+		cli.maxRetries = maxRetries
+		cli.retryInterval = interval
+	}
+}
+
+// WithDialer makes the client connect to the daemon with dial, e.g. to go
+// through a proxy. It is used for API requests as well as for attach and
+// exec streams; TLS, if configured, is negotiated over the connections it
+// returns.
+func WithDialer(dial func(network, addr string) (net.Conn, error)) CliOption {
+	return func(cli *DockerCli) {
+		cli.dialer = dial
+	}
+}
+
+// WithAPIVersion pins the version of the API the client sends its requests
+// with, instead of negotiating it with the daemon
+func WithAPIVersion(v version.Version) CliOption {
+	return func(cli *DockerCli) {
+		cli.apiVersion = v
+		cli.apiVersionPinned = true
+	}
 }
 
 var funcMap = template.FuncMap{
@@ -125,7 +185,7 @@ func (cli *DockerCli) CheckTtyInput(attachStdin, ttyMode bool) error {
 	return nil
 }
 
-func NewDockerCli(in io.ReadCloser, out, err io.Writer, keyFile string, proto, addr string, tlsConfig *tls.Config) *DockerCli {
+func NewDockerCli(in io.ReadCloser, out, err io.Writer, keyFile string, proto, addr string, tlsConfig *tls.Config, options ...CliOption) *DockerCli {
 	var (
 		inFd          uintptr
 		outFd         uintptr
@@ -156,25 +216,7 @@ func NewDockerCli(in io.ReadCloser, out, err io.Writer, keyFile string, proto, a
 		err = out
 	}
 
-	// The transport is created here for reuse during the client session
-	tr := &http.Transport{
-		TLSClientConfig: tlsConfig,
-	}
-
-	// Why 32? See issue 8035
-	timeout := 32 * time.Second
-	if proto == "unix" {
-		// no need in compressing for local communications
-		tr.DisableCompression = true
-		tr.Dial = func(_, _ string) (net.Conn, error) {
-			return net.DialTimeout(proto, addr, timeout)
-		}
-	} else {
-		tr.Proxy = http.ProxyFromEnvironment
-		tr.Dial = (&net.Dialer{Timeout: timeout}).Dial
-	}
-
-	return &DockerCli{
+	cli := &DockerCli{
 		proto:         proto,
 		addr:          addr,
 		in:            in,
@@ -187,6 +229,37 @@ func NewDockerCli(in io.ReadCloser, out, err io.Writer, keyFile string, proto, a
 		isTerminalOut: isTerminalOut,
 		tlsConfig:     tlsConfig,
 		scheme:        scheme,
-		transport:     tr,
 	}
+	for _, option := range options {
+		option(cli)
+	}
+
+	if cli.transport == nil {
+		// The transport is created here for reuse during the client session
+		tr := &http.Transport{
+			TLSClientConfig: tlsConfig,
+		}
+
+		// Why 32? See issue 8035
+		timeout := 32 * time.Second
+		switch {
+		case cli.dialer != nil:
+			tr.Dial = func(_, _ string) (net.Conn, error) {
+				return cli.dialer(proto, addr)
+			}
+		case proto == "unix":
+			tr.Dial = func(_, _ string) (net.Conn, error) {
+				return net.DialTimeout(proto, addr, timeout)
+			}
+		default:
+			tr.Proxy = http.ProxyFromEnvironment
+			tr.Dial = (&net.Dialer{Timeout: timeout}).Dial
+		}
+		if proto == "unix" {
+			// no need in compressing for local communications
+			tr.DisableCompression = true
+		}
+		cli.transport = tr
+	}
+	return cli
 }
@@ -0,0 +1,428 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/utils"
+)
+
+// recordingTransport answers the API requests of a DockerCli without a
+// daemon and records them
+type recordingTransport struct {
+	requests  []string
+	responses map[string]string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// strip the API version
+	path := req.URL.Path
+	if i := strings.Index(path[1:], "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	t.requests = append(t.requests, req.Method+" "+path)
+
+	resp := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}
+	if body, ok := t.responses[req.Method+" "+path]; ok {
+		resp.StatusCode = http.StatusOK
+		if body == "" {
+			resp.StatusCode = http.StatusNoContent
+		}
+		resp.Header.Set("Content-Type", "application/json")
+		resp.Body = ioutil.NopCloser(strings.NewReader(body))
+	}
+	return resp, nil
+}
+
+func TestCmdRunWithTransport(t *testing.T) {
+	tr := &recordingTransport{
+		responses: map[string]string{
+			"POST /containers/create":             `{"Id":"4d3eb38b5c3b","Warnings":null}`,
+			"POST /containers/4d3eb38b5c3b/start": "",
+		},
+	}
+	var out bytes.Buffer
+	cli := NewDockerCli(nil, &out, ioutil.Discard, "", "unix", "/nonexistent.sock", nil, WithTransport(tr))
+
+	if err := cli.CmdRun("-d", "busybox", "true"); err != nil {
+		t.Fatal(err)
+	}
+	// The daemon without a version falls back to the version of the client
+	expected := []string{"GET /version", "POST /containers/create", "POST /containers/4d3eb38b5c3b/start"}
+	if strings.Join(tr.requests, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected requests %v, got %v", expected, tr.requests)
+	}
+	if strings.TrimSpace(out.String()) != "4d3eb38b5c3b" {
+		t.Fatalf("Expected the container ID to be printed, got %q", out.String())
+	}
+}
+
+func TestDockerCliWithDialer(t *testing.T) {
+	var dialed []string
+	dialErr := errors.New("dial refused by test")
+	cli := NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", "tcp", "192.0.2.1:2375", nil,
+		WithDialer(func(network, addr string) (net.Conn, error) {
+			dialed = append(dialed, network+"://"+addr)
+			return nil, dialErr
+		}), WithAPIVersion(api.APIVERSION))
+
+	if _, _, err := cli.call("GET", "/version", nil, false); err == nil {
+		t.Fatal("Expected the request to fail")
+	}
+	if _, err := cli.dial(); err != dialErr {
+		t.Fatalf("Expected %v from dial, got %v", dialErr, err)
+	}
+	if len(dialed) != 2 || dialed[0] != "tcp://192.0.2.1:2375" || dialed[1] != dialed[0] {
+		t.Fatalf("Expected the dialer to be used for requests and streams, got %v", dialed)
+	}
+}
+
+// flakyTransport fails the first failures requests as if the daemon was down
+type flakyTransport struct {
+	recordingTransport
+	failures int
+}
+
+func (t *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.failures > 0 {
+		t.failures--
+		t.requests = append(t.requests, "failed "+req.Method)
+		return nil, errors.New("dial unix /var/run/docker.sock: connection refused")
+	}
+	return t.recordingTransport.RoundTrip(req)
+}
+
+func TestCmdInspectRetries(t *testing.T) {
+	tr := &flakyTransport{
+		recordingTransport: recordingTransport{
+			responses: map[string]string{
+				"GET /containers/test/json": `{"Id":"4d3eb38b5c3b","Name":"/test"}`,
+			},
+		},
+		failures: 2,
+	}
+	var out bytes.Buffer
+	cli := NewDockerCli(nil, &out, ioutil.Discard, "", "unix", "/nonexistent.sock", nil,
+		WithTransport(tr), WithRetries(3, time.Millisecond), WithAPIVersion(api.APIVERSION))
+
+	if err := cli.CmdInspect("--format", "{{.Id}}", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out.String()) != "4d3eb38b5c3b" {
+		t.Fatalf("Expected the container ID, got %q", out.String())
+	}
+	if len(tr.requests) != 3 {
+		t.Fatalf("Expected 2 failed requests and a successful one, got %v", tr.requests)
+	}
+
+	// without enough retries the error is reported
+	tr.failures, tr.requests = 2, nil
+	cli = NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", "unix", "/nonexistent.sock", nil,
+		WithTransport(tr), WithRetries(1, time.Millisecond), WithAPIVersion(api.APIVERSION))
+	if err := cli.CmdInspect("test"); err == nil {
+		t.Fatal("Expected inspect to fail")
+	}
+}
+
+func TestRetryIntervalCapped(t *testing.T) {
+	delay := time.Second
+	for _, expected := range []time.Duration{2, 4, 8, 16, 30, 30} {
+		delay = nextRetryInterval(delay)
+		if delay != expected*time.Second {
+			t.Fatalf("Expected a delay of %ds, got %s", expected, delay)
+		}
+	}
+	// a longer interval given with WithRetries isn't shortened
+	if delay := nextRetryInterval(time.Minute); delay != time.Minute {
+		t.Fatalf("Expected a delay of 1m, got %s", delay)
+	}
+}
+
+func TestCmdRunNotRetried(t *testing.T) {
+	tr := &flakyTransport{
+		recordingTransport: recordingTransport{
+			responses: map[string]string{
+				"POST /containers/create": `{"Id":"4d3eb38b5c3b","Warnings":null}`,
+			},
+		},
+		failures: 1,
+	}
+	cli := NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", "unix", "/nonexistent.sock", nil,
+		WithTransport(tr), WithRetries(3, time.Millisecond), WithAPIVersion(api.APIVERSION))
+
+	if err := cli.CmdRun("-d", "busybox", "true"); err == nil {
+		t.Fatal("Expected run to fail")
+	}
+	if len(tr.requests) != 1 || tr.requests[0] != "failed POST" {
+		t.Fatalf("Expected a single create request, got %v", tr.requests)
+	}
+}
+
+// errorTransport answers every request with an error of the daemon
+type errorTransport struct {
+	statusCode  int
+	contentType string
+	body        string
+}
+
+func (t *errorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := &http.Response{
+		StatusCode: t.statusCode,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(t.body)),
+		Request:    req,
+	}
+	resp.Header.Set("Content-Type", t.contentType)
+	return resp, nil
+}
+
+func TestCmdAttachNotFound(t *testing.T) {
+	tr := &errorTransport{http.StatusNotFound, "text/plain", "No such container: nosuchcontainer\n"}
+	cli := NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", "unix", "/nonexistent.sock", nil, WithTransport(tr))
+
+	err := cli.CmdAttach("nosuchcontainer")
+	if !IsNotFound(err) {
+		t.Fatalf("Expected a not found error, got %v", err)
+	}
+	if IsConflict(err) {
+		t.Fatalf("Expected %v not to be a conflict", err)
+	}
+	if err.Error() != "Error response from daemon: No such container: nosuchcontainer" {
+		t.Fatalf("Unexpected message %q", err.Error())
+	}
+}
+
+func TestStreamNotFound(t *testing.T) {
+	tr := &errorTransport{http.StatusNotFound, "text/plain", "No such container: nosuchcontainer\n"}
+	cli := NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", "unix", "/nonexistent.sock", nil, WithTransport(tr))
+
+	for _, err := range []error{
+		cli.stream("GET", "/containers/nosuchcontainer/logs", nil, ioutil.Discard, nil),
+		cli.streamJSON("GET", "/containers/nosuchcontainer/logs", nil, ioutil.Discard, nil),
+	} {
+		if !IsNotFound(err) {
+			t.Fatalf("Expected a not found error, got %v", err)
+		}
+		if err.Error() != "Error response from daemon: No such container: nosuchcontainer" {
+			t.Fatalf("Unexpected message %q", err.Error())
+		}
+	}
+
+	tr.statusCode, tr.body = http.StatusConflict, ""
+	if err := cli.stream("POST", "/containers/test/attach", nil, ioutil.Discard, nil); !IsConflict(err) {
+		t.Fatalf("Expected a conflict without a message, got %v", err)
+	}
+}
+
+func TestAPIErrorJSONMessage(t *testing.T) {
+	tr := &errorTransport{http.StatusConflict, "application/json", `{"message": "Conflict, the container is running"}`}
+	cli := NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", "unix", "/nonexistent.sock", nil, WithTransport(tr))
+
+	_, _, err := cli.call("DELETE", "/containers/test", nil, false)
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("Expected an APIError, got %#v", err)
+	}
+	if !IsConflict(err) || IsNotFound(err) {
+		t.Fatalf("Expected a conflict, got the status code %d", apiErr.StatusCode)
+	}
+	if apiErr.Message != "Conflict, the container is running" {
+		t.Fatalf("Expected the message of the daemon, got %q", apiErr.Message)
+	}
+
+	if IsNotFound(errors.New("No such container")) || IsNotFound(nil) {
+		t.Fatal("Expected only errors of the daemon to be not found errors")
+	}
+}
+
+// newVersionedDaemon returns a mock daemon of the API version apiVersion, and
+// the paths of the requests it received
+func newVersionedDaemon(apiVersion string) (*httptest.Server, *[]string) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/version" {
+			fmt.Fprintf(w, `{"ApiVersion":%q}`, apiVersion)
+			return
+		}
+		fmt.Fprint(w, `{"Id":"4d3eb38b5c3b"}`)
+	}))
+	return ts, &paths
+}
+
+func TestAPIVersionNegotiation(t *testing.T) {
+	ts, paths := newVersionedDaemon("1.16")
+	defer ts.Close()
+
+	cli := NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", "tcp", ts.Listener.Addr().String(), nil)
+	if err := cli.CmdInspect("test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.CmdInspect("test"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/version", "/v1.16/containers/test/json", "/v1.16/containers/test/json"}
+	if strings.Join(*paths, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected the requests %v, got %v", expected, *paths)
+	}
+
+	// Features newer than the daemon fail without sending a request
+	*paths = nil
+	err := cli.CmdStats("test")
+	if err == nil || err.Error() != "docker stats requires API version 1.17, but the daemon only supports 1.16" {
+		t.Fatalf("Expected stats to require a newer daemon, got %v", err)
+	}
+	if len(*paths) != 0 {
+		t.Fatalf("Expected no request to be sent, got %v", *paths)
+	}
+}
+
+func TestAPIVersionNewerDaemon(t *testing.T) {
+	ts, paths := newVersionedDaemon("99.0")
+	defer ts.Close()
+
+	cli := NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", "tcp", ts.Listener.Addr().String(), nil)
+	if v := cli.APIVersion(); v != api.APIVERSION {
+		t.Fatalf("Expected the version of the client, %s, got %s", api.APIVERSION, v)
+	}
+	if len(*paths) != 1 {
+		t.Fatalf("Expected a single version request, got %v", *paths)
+	}
+}
+
+func TestAPIVersionPinned(t *testing.T) {
+	ts, paths := newVersionedDaemon("1.16")
+	defer ts.Close()
+
+	cli := NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", "tcp", ts.Listener.Addr().String(), nil, WithAPIVersion("1.15"))
+	if err := cli.CmdInspect("test"); err != nil {
+		t.Fatal(err)
+	}
+	if len(*paths) != 1 || (*paths)[0] != "/v1.15/containers/test/json" {
+		t.Fatalf("Expected a single request with the pinned version, got %v", *paths)
+	}
+
+	err := cli.CmdStats("test")
+	if err == nil || err.Error() != "docker stats requires API version 1.17, but the API version is set to 1.15 with --api-version or DOCKER_API_VERSION" {
+		t.Fatalf("Expected stats to require a newer API version, got %v", err)
+	}
+}
+
+func TestAPIVersionNegotiationFailure(t *testing.T) {
+	tr := &flakyTransport{failures: 100}
+	cli := NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", "unix", "/nonexistent.sock", nil,
+		WithTransport(tr), WithRetries(2, time.Millisecond))
+
+	if _, _, err := cli.call("GET", "/containers/test/json", nil, false); err != ErrConnectionRefused {
+		t.Fatalf("Expected %v, got %v", ErrConnectionRefused, err)
+	}
+	// the version request is retried, the request itself isn't sent
+	if len(tr.requests) != 3 {
+		t.Fatalf("Expected a version request and 2 retries, got %v", tr.requests)
+	}
+
+	// the failure is kept
+	tr.requests = nil
+	if _, _, err := cli.call("GET", "/containers/test/json", nil, false); err != ErrConnectionRefused {
+		t.Fatalf("Expected %v, got %v", ErrConnectionRefused, err)
+	}
+	if len(tr.requests) != 0 {
+		t.Fatalf("Expected no request to be sent, got %v", tr.requests)
+	}
+}
+
+func TestCmdPullFormatJSON(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"Pulling fs layer","progressDetail":{},"id":"511136ea3c5a"}`)
+		fmt.Fprint(w, `{"status":"Downloading","progressDetail":{"current":512,"total":1024},"progress":"[=====>     ] 512 B/1.024 kB","id":"511136ea3c5a"}`)
+		fmt.Fprint(w, `{"status":"Download complete","progressDetail":{},"id":"511136ea3c5a"}`)
+	}))
+	defer ts.Close()
+
+	out := &bytes.Buffer{}
+	cli := NewDockerCli(nil, out, ioutil.Discard, "", "tcp", ts.Listener.Addr().String(), nil, WithAPIVersion(api.APIVERSION))
+	if err := cli.CmdPull("--format", "json", "busybox"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected one line per message, got %q", out.String())
+	}
+	var jm utils.JSONMessage
+	if err := json.Unmarshal([]byte(lines[1]), &jm); err != nil {
+		t.Fatalf("Expected a JSON object, got %q: %v", lines[1], err)
+	}
+	if jm.ID != "511136ea3c5a" || jm.Status != "Downloading" || jm.Progress == nil || jm.Progress.Current != 512 || jm.Progress.Total != 1024 {
+		t.Fatalf("Unexpected progress %q", lines[1])
+	}
+	if jm.ProgressMessage != "" {
+		t.Fatalf("Expected the progress bar to be left out, got %q", lines[1])
+	}
+
+	if err := cli.CmdPull("--format", "table", "busybox"); err == nil || !strings.Contains(err.Error(), "Invalid --format table") {
+		t.Fatalf("Expected an invalid format to be rejected, got %v", err)
+	}
+}
+
+func TestCmdSearchFilters(t *testing.T) {
+	var query url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"name":"busybox","description":"Busybox base image.","star_count":120,"is_official":true,"is_automated":false},`+
+			`{"name":"user/busybox-auto","description":"An automated\nbusybox","star_count":12,"is_official":false,"is_automated":true},`+
+			`{"name":"user/busybox","description":"","star_count":2,"is_official":false,"is_automated":false}]`)
+	}))
+	defer ts.Close()
+
+	out := &bytes.Buffer{}
+	cli := NewDockerCli(nil, out, ioutil.Discard, "", "tcp", ts.Listener.Addr().String(), nil, WithAPIVersion(api.APIVERSION))
+	if err := cli.CmdSearch("--limit", "3", "--filter", "stars=10", "busybox"); err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("term") != "busybox" || query.Get("limit") != "3" {
+		t.Fatalf("Expected the term and limit to be sent, got %v", query)
+	}
+	expected := "NAME                DESCRIPTION            STARS     OFFICIAL   AUTOMATED\n" +
+		"busybox             Busybox base image.    120       [OK]       \n" +
+		"user/busybox-auto   An automated busybox   12                   [OK]\n"
+	if out.String() != expected {
+		t.Fatalf("Expected the table\n%s\ngot\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := cli.CmdSearch("--filter", "is-automated=true", "--filter", "is-official=false", "busybox"); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "user/busybox-auto ") {
+		t.Fatalf("Expected only the automated image, got %q", out.String())
+	}
+
+	if err := cli.CmdSearch("--filter", "stars=many", "busybox"); err == nil || err.Error() != "Invalid filter 'stars=many'" {
+		t.Fatalf("Expected an invalid stars filter to be rejected, got %v", err)
+	}
+	if err := cli.CmdSearch("--filter", "size=1", "busybox"); err == nil || err.Error() != "Invalid filter 'size'" {
+		t.Fatalf("Expected an unknown filter to be rejected, got %v", err)
+	}
+	if err := cli.CmdSearch("--limit", "101", "busybox"); err == nil || !strings.Contains(err.Error(), "outside the range") {
+		t.Fatalf("Expected a limit above 100 to be rejected, got %v", err)
+	}
+}
@@ -90,6 +90,9 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	forceRm := cmd.Bool([]string{"-force-rm"}, false, "Always remove intermediate containers")
 	pull := cmd.Bool([]string{"-pull"}, false, "Always attempt to pull a newer version of the image")
 	dockerfileName := cmd.String([]string{"f", "-file"}, "", "Name of the Dockerfile (Default is 'PATH/Dockerfile')")
+	target := cmd.String([]string{"-target"}, "", "Name of the build stage to stop after")
+	flBuildArgs := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flBuildArgs, []string{"-build-arg"}, "Set a build arg declared with ARG, as KEY=VALUE or KEY to use the environment")
 	flMemoryString := cmd.String([]string{"m", "-memory"}, "", "Memory limit")
 	flMemorySwap := cmd.String([]string{"-memory-swap"}, "", "Total memory (memory + swap), '-1' to disable swap")
 	flCpuShares := cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
@@ -98,6 +101,24 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	cmd.Require(flag.Exact, 1)
 
 	utils.ParseFlags(cmd, args, true)
+	if *target != "" {
+		if err := cli.requireAPIVersion("1.18", "docker build --target"); err != nil {
+			return err
+		}
+	}
+	buildArgs := make(map[string]string)
+	for _, arg := range flBuildArgs.GetAll() {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("Invalid --build-arg %s: the value is not given nor set in the environment", arg)
+		}
+		buildArgs[parts[0]] = parts[1]
+	}
+	if len(buildArgs) > 0 {
+		if err := cli.requireAPIVersion("1.18", "docker build --build-arg"); err != nil {
+			return err
+		}
+	}
 
 	var (
 		context  archive.Archive
@@ -309,6 +330,18 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		v.Set("pull", "1")
 	}
 
+	if *target != "" {
+		v.Set("target", *target)
+	}
+
+	if len(buildArgs) > 0 {
+		buf, err := json.Marshal(buildArgs)
+		if err != nil {
+			return err
+		}
+		v.Set("buildargs", string(buf))
+	}
+
 	v.Set("cpusetcpus", *flCpuSetCpus)
 	v.Set("cpushares", strconv.FormatInt(*flCpuShares, 10))
 	v.Set("memory", strconv.FormatInt(memory, 10))
@@ -317,6 +350,7 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	v.Set("dockerfile", *dockerfileName)
 
 	cli.LoadConfigFile()
+	cli.configFile.LoadAllCredentials()
 
 	headers := http.Header(make(map[string][]string))
 	buf, err := json.Marshal(cli.configFile)
@@ -376,7 +410,7 @@ func (cli *DockerCli) CmdLogin(args ...string) error {
 	}
 
 	cli.LoadConfigFile()
-	authconfig, ok := cli.configFile.Configs[serverAddress]
+	authconfig, ok := cli.configFile.GetAuthConfig(serverAddress)
 	if !ok {
 		authconfig = registry.AuthConfig{}
 	}
@@ -449,8 +483,12 @@ func (cli *DockerCli) CmdLogin(args ...string) error {
 		cli.configFile, _ = registry.LoadConfig(homedir.Get())
 		return err
 	}
-	registry.SaveConfig(cli.configFile)
-	fmt.Fprintf(cli.out, "WARNING: login credentials saved in %s.\n", path.Join(homedir.Get(), registry.CONFIGFILE))
+	if err := registry.SaveConfig(cli.configFile); err != nil {
+		return err
+	}
+	if cli.configFile.CredsStore == "" {
+		fmt.Fprintf(cli.out, "WARNING: login credentials saved in %s.\n", path.Join(homedir.Get(), registry.CONFIGFILE))
+	}
 
 	if out2.Get("Status") != "" {
 		fmt.Fprintf(cli.out, "%s\n", out2.Get("Status"))
@@ -652,7 +690,8 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 	}
 	if len(remoteInfo.GetList("IndexServerAddress")) != 0 {
 		cli.LoadConfigFile()
-		u := cli.configFile.Configs[remoteInfo.Get("IndexServerAddress")].Username
+		authConfig, _ := cli.configFile.GetAuthConfig(remoteInfo.Get("IndexServerAddress"))
+		u := authConfig.Username
 		if len(u) > 0 {
 			fmt.Fprintf(cli.out, "Username: %v\n", u)
 			fmt.Fprintf(cli.out, "Registry: %v\n", remoteInfo.GetList("IndexServerAddress"))
@@ -677,6 +716,111 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 	return nil
 }
 
+func (cli *DockerCli) CmdSystemDf(args ...string) error {
+	cmd := cli.Subcmd("system df", "", "Show the disk space used by images, containers and volumes", true)
+	verbose := cmd.Bool([]string{"v", "-verbose"}, false, "List the disk space used by each image, container and volume")
+	noTrunc := cmd.Bool([]string{"-no-trunc"}, false, "Don't truncate output")
+	cmd.Require(flag.Exact, 0)
+	utils.ParseFlags(cmd, args, true)
+	if err := cli.requireAPIVersion("1.18", "docker system df"); err != nil {
+		return err
+	}
+
+	v := url.Values{}
+	if *verbose {
+		v.Set("verbose", "1")
+	}
+	body, _, err := readBody(cli.call("GET", "/system/df?"+v.Encode(), nil, false))
+	if err != nil {
+		return err
+	}
+	usage := &engine.Env{}
+	if err := usage.Decode(bytes.NewReader(body)); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tTOTAL\tACTIVE\tSIZE\tRECLAIMABLE")
+	for _, kind := range []string{"Images", "Containers", "Volumes"} {
+		size, reclaimable := usage.GetInt64(kind+"Size"), usage.GetInt64(kind+"Reclaimable")
+		percent := 0
+		if size > 0 {
+			percent = int(reclaimable * 100 / size)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s (%d%%)\n", kind, usage.GetInt(kind+"Count"), usage.GetInt(kind+"Active"),
+			units.HumanSize(float64(size)), units.HumanSize(float64(reclaimable)), percent)
+	}
+	w.Flush()
+	if !*verbose {
+		return nil
+	}
+
+	truncID := func(id string) string {
+		if *noTrunc {
+			return id
+		}
+		return common.TruncateID(id)
+	}
+	readList := func(key string) (*engine.Table, error) {
+		list := engine.NewTable("", 0)
+		if _, err := list.ReadListFrom([]byte(usage.Get(key))); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+
+	images, err := readList("Images")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "\nImages space usage:\n\n")
+	w = tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY:TAG\tIMAGE ID\tSIZE\tSHARED SIZE\tUNIQUE SIZE\tCONTAINERS")
+	for _, image := range images.Data {
+		repoTags := image.GetList("RepoTags")
+		if len(repoTags) == 0 {
+			repoTags = []string{"<none>:<none>"}
+		}
+		for _, repoTag := range repoTags {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", repoTag, truncID(image.Get("Id")),
+				units.HumanSize(float64(image.GetInt64("Size"))), units.HumanSize(float64(image.GetInt64("SharedSize"))),
+				units.HumanSize(float64(image.GetInt64("UniqueSize"))), image.GetInt("Containers"))
+		}
+	}
+	w.Flush()
+
+	containers, err := readList("Containers")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "\nContainers space usage:\n\n")
+	w = tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "CONTAINER ID\tIMAGE\tNAMES\tSIZE\tRUNNING")
+	for _, container := range containers.Data {
+		var names []string
+		for _, name := range container.GetList("Names") {
+			names = append(names, strings.TrimPrefix(name, "/"))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", truncID(container.Get("Id")), container.Get("Image"),
+			strings.Join(names, ","), units.HumanSize(float64(container.GetInt64("SizeRw"))), container.GetBool("Running"))
+	}
+	w.Flush()
+
+	volumes, err := readList("Volumes")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "\nVolumes space usage:\n\n")
+	w = tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "VOLUME ID\tCONTAINERS\tSIZE")
+	for _, volume := range volumes.Data {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", truncID(volume.Get("Id")), volume.GetInt("Containers"),
+			units.HumanSize(float64(volume.GetInt64("Size"))))
+	}
+	w.Flush()
+	return nil
+}
+
 func (cli *DockerCli) CmdStop(args ...string) error {
 	cmd := cli.Subcmd("stop", "CONTAINER [CONTAINER...]", "Stop a running container by sending SIGTERM and then SIGKILL after a\ngrace period", true)
 	nSeconds := cmd.Int([]string{"t", "-time"}, 10, "Seconds to wait for stop before killing it")
@@ -739,7 +883,9 @@ func (cli *DockerCli) forwardAllSignals(cid string) chan os.Signal {
 				}
 			}
 			if sig == "" {
+				// An empty signal would kill the container
 				log.Errorf("Unsupported signal: %v. Discarding.", s)
+				continue
 			}
 			if _, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/kill?signal=%s", cid, sig), nil, false)); err != nil {
 				log.Debugf("Error sending signal: %s", err)
@@ -854,9 +1000,7 @@ func (cli *DockerCli) CmdStart(args ...string) error {
 
 	if *openStdin || *attach {
 		if tty && cli.isTerminalOut {
-			if err := cli.monitorTtySize(cmd.Arg(0), false); err != nil {
-				log.Errorf("Error monitoring TTY size: %s", err)
-			}
+			defer cli.monitorTtySize(cmd.Arg(0), false).Close()
 		}
 		if attchErr := <-cErr; attchErr != nil {
 			return attchErr
@@ -889,6 +1033,94 @@ func (cli *DockerCli) CmdUnpause(args ...string) error {
 	return encounteredError
 }
 
+func (cli *DockerCli) CmdUpdate(args ...string) error {
+	cmd := cli.Subcmd("update", "CONTAINER [CONTAINER...]", "Update the resource limits of one or more containers", true)
+	flMemory := cmd.String([]string{"m", "-memory"}, "", "Memory limit")
+	flMemorySwap := cmd.String([]string{"-memory-swap"}, "", "Total memory (memory + swap), '-1' to disable swap")
+	flCpuShares := cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
+	flCpusetCpus := cmd.String([]string{"-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
+	flPidsLimit := cmd.Int64([]string{"-pids-limit"}, 0, "Tune container pids limit (set -1 for unlimited)")
+	flBlkioWeight := cmd.Int64([]string{"-blkio-weight"}, 0, "Block IO (relative weight), between 10 and 1000")
+	flReadBps := opts.NewListOpts(nil)
+	cmd.Var(&flReadBps, []string{"-device-read-bps"}, "Limit read rate (bytes per second) from a device")
+	cmd.Require(flag.Min, 1)
+	utils.ParseFlags(cmd, args, true)
+	if err := cli.requireAPIVersion("1.18", "docker update"); err != nil {
+		return err
+	}
+
+	resources := map[string]interface{}{}
+	if *flMemory != "" {
+		memory, err := units.RAMInBytes(*flMemory)
+		if err != nil {
+			return err
+		}
+		resources["Memory"] = memory
+	}
+	if *flMemorySwap == "-1" {
+		resources["MemorySwap"] = -1
+	} else if *flMemorySwap != "" {
+		memorySwap, err := units.RAMInBytes(*flMemorySwap)
+		if err != nil {
+			return err
+		}
+		resources["MemorySwap"] = memorySwap
+	}
+	if *flCpuShares != 0 {
+		resources["CpuShares"] = *flCpuShares
+	}
+	if *flCpusetCpus != "" {
+		resources["CpusetCpus"] = *flCpusetCpus
+	}
+	if *flPidsLimit < -1 {
+		return fmt.Errorf("Invalid pids limit: %d", *flPidsLimit)
+	}
+	if *flPidsLimit != 0 {
+		resources["PidsLimit"] = *flPidsLimit
+	}
+	if err := runconfig.ValidateBlkioWeight(*flBlkioWeight); err != nil {
+		return err
+	}
+	if *flBlkioWeight != 0 {
+		resources["BlkioWeight"] = *flBlkioWeight
+	}
+	if flReadBps.Len() > 0 {
+		var readBps []runconfig.ThrottleDevice
+		for _, val := range flReadBps.GetAll() {
+			td, err := runconfig.ParseThrottleDevice(val)
+			if err != nil {
+				return err
+			}
+			readBps = append(readBps, td)
+		}
+		resources["BlkioDeviceReadBps"] = readBps
+	}
+	if len(resources) == 0 {
+		return fmt.Errorf("You must provide one or more flags when using this command.")
+	}
+
+	var encounteredError error
+	for _, name := range cmd.Args() {
+		stream, _, err := cli.call("POST", fmt.Sprintf("/containers/%s/update", name), resources, false)
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			encounteredError = fmt.Errorf("Error: failed to update container named %s", name)
+			continue
+		}
+		var response types.ContainerUpdateResponse
+		err = json.NewDecoder(stream).Decode(&response)
+		stream.Close()
+		if err != nil {
+			return err
+		}
+		for _, warning := range response.Warnings {
+			fmt.Fprintf(cli.err, "WARNING: %s\n", warning)
+		}
+		fmt.Fprintf(cli.out, "%s\n", name)
+	}
+	return encounteredError
+}
+
 func (cli *DockerCli) CmdPause(args ...string) error {
 	cmd := cli.Subcmd("pause", "CONTAINER [CONTAINER...]", "Pause all processes within a container", true)
 	cmd.Require(flag.Min, 1)
@@ -918,6 +1150,9 @@ func (cli *DockerCli) CmdRename(args ...string) error {
 	}
 	old_name := cmd.Arg(0)
 	new_name := cmd.Arg(1)
+	if err := cli.requireAPIVersion("1.17", "docker rename"); err != nil {
+		return err
+	}
 
 	if _, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/rename?name=%s", old_name, new_name), nil, false)); err != nil {
 		fmt.Fprintf(cli.err, "%s\n", err)
@@ -933,15 +1168,26 @@ func (cli *DockerCli) CmdInspect(args ...string) error {
 
 	utils.ParseFlags(cmd, args, true)
 
-	var tmpl *template.Template
+	var (
+		tmpl   *template.Template
+		header string
+		table  bool
+		out    = cli.out
+	)
 	if *tmplStr != "" {
 		var err error
-		if tmpl, err = template.New("").Funcs(funcMap).Parse(*tmplStr); err != nil {
+		if tmpl, header, table, err = parseFormat(*tmplStr); err != nil {
 			fmt.Fprintf(cli.err, "Template parsing error: %v\n", err)
 			return &utils.StatusError{StatusCode: 64,
 				Status: "Template parsing error: " + err.Error()}
 		}
 	}
+	if table {
+		w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+		defer w.Flush()
+		fmt.Fprintln(w, header)
+		out = w
+	}
 
 	indented := new(bytes.Buffer)
 	indented.WriteByte('[')
@@ -982,10 +1228,10 @@ func (cli *DockerCli) CmdInspect(args ...string) error {
 				status = 1
 				continue
 			}
-			if err := tmpl.Execute(cli.out, value); err != nil {
+			if err := tmpl.Execute(out, value); err != nil {
 				return err
 			}
-			cli.out.Write([]byte{'\n'})
+			out.Write([]byte{'\n'})
 		}
 		indented.WriteString(",")
 	}
@@ -1121,18 +1367,225 @@ func (cli *DockerCli) CmdRmi(args ...string) error {
 				encounteredError = fmt.Errorf("Error: failed to remove one or more images")
 				continue
 			}
+			var freed int64
 			for _, out := range outs.Data {
 				if out.Get("Deleted") != "" {
 					fmt.Fprintf(cli.out, "Deleted: %s\n", out.Get("Deleted"))
+					freed += out.GetInt64("Size")
 				} else {
 					fmt.Fprintf(cli.out, "Untagged: %s\n", out.Get("Untagged"))
 				}
 			}
+			if freed > 0 {
+				fmt.Fprintf(cli.out, "Freed: %s\n", units.HumanSize(float64(freed)))
+			}
 		}
 	}
 	return encounteredError
 }
 
+func (cli *DockerCli) CmdImagePrune(args ...string) error {
+	cmd := cli.Subcmd("image prune", "", "Remove the dangling images not used by any container", true)
+	return cli.prune(cmd, "/images/prune", "Only prune images matching the conditions provided", args)
+}
+
+func (cli *DockerCli) CmdContainerPrune(args ...string) error {
+	cmd := cli.Subcmd("container prune", "", "Remove all stopped containers", true)
+	return cli.prune(cmd, "/containers/prune", "Only prune containers matching the conditions provided", args)
+}
+
+// prune parses the --filter flags of a prune command, then prints what the
+// daemon deleted at path and the total space reclaimed
+func (cli *DockerCli) prune(cmd *flag.FlagSet, path, filterUsage string, args []string) error {
+	flFilter := opts.NewListOpts(nil)
+	cmd.Var(&flFilter, []string{"f", "-filter"}, filterUsage)
+	cmd.Require(flag.Exact, 0)
+
+	utils.ParseFlags(cmd, args, true)
+	if err := cli.requireAPIVersion("1.18", "docker "+cmd.Name()); err != nil {
+		return err
+	}
+
+	pruneFilterArgs := filters.Args{}
+	for _, f := range flFilter.GetAll() {
+		var err error
+		pruneFilterArgs, err = filters.ParseFlag(f, pruneFilterArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	v := url.Values{}
+	if len(pruneFilterArgs) > 0 {
+		filterJson, err := filters.ToParam(pruneFilterArgs)
+		if err != nil {
+			return err
+		}
+		v.Set("filters", filterJson)
+	}
+
+	body, _, err := readBody(cli.call("POST", path+"?"+v.Encode(), nil, false))
+	if err != nil {
+		return err
+	}
+	outs := engine.NewTable("", 0)
+	if _, err := outs.ReadListFrom(body); err != nil {
+		return err
+	}
+	var reclaimed int64
+	for _, out := range outs.Data {
+		if out.Get("Deleted") != "" {
+			fmt.Fprintf(cli.out, "Deleted: %s\n", out.Get("Deleted"))
+			reclaimed += out.GetInt64("Size")
+		}
+	}
+	fmt.Fprintf(cli.out, "Total reclaimed space: %s\n", units.HumanSize(float64(reclaimed)))
+	return nil
+}
+
+func (cli *DockerCli) CmdNetwork(args ...string) error {
+	cmd := cli.Subcmd("network", "COMMAND", "Manage the user-defined networks", true)
+	cmd.Require(flag.Exact, 0)
+	utils.ParseFlags(cmd, args, true)
+
+	fmt.Fprintf(cli.out, "Commands:\n")
+	for _, command := range [][]string{
+		{"create", "Create a network"},
+		{"inspect", "Return low-level information on one or more networks"},
+		{"ls", "List the networks"},
+		{"rm", "Remove one or more networks"},
+	} {
+		fmt.Fprintf(cli.out, "    %-10.10s%s\n", command[0], command[1])
+	}
+	fmt.Fprintf(cli.out, "\nRun 'docker network COMMAND --help' for more information on a command.\n")
+	return nil
+}
+
+func (cli *DockerCli) CmdNetworkCreate(args ...string) error {
+	cmd := cli.Subcmd("network create", "NAME", "Create a bridge network, on which containers reach each other by name", true)
+	flDriver := cmd.String([]string{"d", "-driver"}, "bridge", "Driver of the network")
+	flSubnet := cmd.String([]string{"-subnet"}, "", "Subnet of the network in CIDR format, a free one by default")
+	cmd.Require(flag.Exact, 1)
+	utils.ParseFlags(cmd, args, true)
+	if err := cli.requireAPIVersion("1.18", "docker network create"); err != nil {
+		return err
+	}
+
+	config := map[string]string{
+		"Name":   cmd.Arg(0),
+		"Driver": *flDriver,
+		"Subnet": *flSubnet,
+	}
+	stream, _, err := cli.call("POST", "/networks/create", config, false)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	var response types.NetworkCreateResponse
+	if err := json.NewDecoder(stream).Decode(&response); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", response.ID)
+	return nil
+}
+
+func (cli *DockerCli) CmdNetworkLs(args ...string) error {
+	cmd := cli.Subcmd("network ls", "", "List the networks", true)
+	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only display numeric IDs")
+	noTrunc := cmd.Bool([]string{"#notrunc", "-no-trunc"}, false, "Don't truncate output")
+	cmd.Require(flag.Exact, 0)
+	utils.ParseFlags(cmd, args, true)
+	if err := cli.requireAPIVersion("1.18", "docker network ls"); err != nil {
+		return err
+	}
+
+	body, _, err := readBody(cli.call("GET", "/networks", nil, false))
+	if err != nil {
+		return err
+	}
+	outs := engine.NewTable("", 0)
+	if _, err := outs.ReadListFrom(body); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	if !*quiet {
+		fmt.Fprintln(w, "NETWORK ID\tNAME\tDRIVER\tSUBNET")
+	}
+	for _, out := range outs.Data {
+		id := out.Get("Id")
+		if !*noTrunc {
+			id = common.TruncateID(id)
+		}
+		if *quiet {
+			fmt.Fprintln(w, id)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", id, out.Get("Name"), out.Get("Driver"), out.Get("Subnet"))
+	}
+	w.Flush()
+	return nil
+}
+
+func (cli *DockerCli) CmdNetworkRm(args ...string) error {
+	cmd := cli.Subcmd("network rm", "NETWORK [NETWORK...]", "Remove one or more networks", true)
+	cmd.Require(flag.Min, 1)
+	utils.ParseFlags(cmd, args, true)
+	if err := cli.requireAPIVersion("1.18", "docker network rm"); err != nil {
+		return err
+	}
+
+	var encounteredError error
+	for _, name := range cmd.Args() {
+		if _, _, err := readBody(cli.call("DELETE", "/networks/"+name, nil, false)); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			encounteredError = fmt.Errorf("Error: failed to remove one or more networks")
+			continue
+		}
+		fmt.Fprintf(cli.out, "%s\n", name)
+	}
+	return encounteredError
+}
+
+func (cli *DockerCli) CmdNetworkInspect(args ...string) error {
+	cmd := cli.Subcmd("network inspect", "NETWORK [NETWORK...]", "Return low-level information on one or more networks", true)
+	cmd.Require(flag.Min, 1)
+	utils.ParseFlags(cmd, args, true)
+	if err := cli.requireAPIVersion("1.18", "docker network inspect"); err != nil {
+		return err
+	}
+
+	indented := new(bytes.Buffer)
+	indented.WriteByte('[')
+	status := 0
+	for _, name := range cmd.Args() {
+		obj, _, err := readBody(cli.call("GET", "/networks/"+name, nil, false))
+		if err != nil {
+			fmt.Fprintf(cli.err, "Error: %s\n", err)
+			status = 1
+			continue
+		}
+		if err := json.Indent(indented, obj, "", "    "); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+		indented.WriteString(",")
+	}
+	if indented.Len() > 1 {
+		// Remove trailing ','
+		indented.Truncate(indented.Len() - 1)
+	}
+	indented.WriteString("]\n")
+	if _, err := io.Copy(cli.out, indented); err != nil {
+		return err
+	}
+	if status != 0 {
+		return &utils.StatusError{StatusCode: status}
+	}
+	return nil
+}
+
 func (cli *DockerCli) CmdHistory(args ...string) error {
 	cmd := cli.Subcmd("history", "IMAGE", "Show the history of an image", true)
 	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only show numeric IDs")
@@ -1165,6 +1618,11 @@ func (cli *DockerCli) CmdHistory(args ...string) error {
 				fmt.Fprintf(w, "%s\t", common.TruncateID(outID))
 			}
 
+			// Nothing is known about the layers missing from the daemon
+			if outID == graph.MissingImageID {
+				fmt.Fprintf(w, "\t\t\n")
+				continue
+			}
 			fmt.Fprintf(w, "%s ago\t", units.HumanDuration(time.Now().UTC().Sub(time.Unix(out.GetInt64("Created"), 0))))
 
 			if *noTrunc {
@@ -1282,9 +1740,14 @@ func (cli *DockerCli) CmdImport(args ...string) error {
 
 func (cli *DockerCli) CmdPush(args ...string) error {
 	cmd := cli.Subcmd("push", "NAME[:TAG]", "Push an image or a repository to the registry", true)
+	format := cmd.String([]string{"-format"}, "", "Print the progress as one JSON object per line with json")
 	cmd.Require(flag.Exact, 1)
 
 	utils.ParseFlags(cmd, args, true)
+	stream, err := cli.progressStream(*format)
+	if err != nil {
+		return err
+	}
 
 	name := cmd.Arg(0)
 
@@ -1323,7 +1786,7 @@ func (cli *DockerCli) CmdPush(args ...string) error {
 			base64.URLEncoding.EncodeToString(buf),
 		}
 
-		return cli.stream("POST", "/images/"+remote+"/push?"+v.Encode(), nil, cli.out, map[string][]string{
+		return stream("POST", "/images/"+remote+"/push?"+v.Encode(), nil, cli.out, map[string][]string{
 			"X-Registry-Auth": registryAuthHeader,
 		})
 	}
@@ -1345,9 +1808,14 @@ func (cli *DockerCli) CmdPush(args ...string) error {
 func (cli *DockerCli) CmdPull(args ...string) error {
 	cmd := cli.Subcmd("pull", "NAME[:TAG|@DIGEST]", "Pull an image or a repository from the registry", true)
 	allTags := cmd.Bool([]string{"a", "-all-tags"}, false, "Download all tagged images in the repository")
+	format := cmd.String([]string{"-format"}, "", "Print the progress as one JSON object per line with json")
 	cmd.Require(flag.Exact, 1)
 
 	utils.ParseFlags(cmd, args, true)
+	stream, err := cli.progressStream(*format)
+	if err != nil {
+		return err
+	}
 
 	var (
 		v         = url.Values{}
@@ -1384,7 +1852,7 @@ func (cli *DockerCli) CmdPull(args ...string) error {
 			base64.URLEncoding.EncodeToString(buf),
 		}
 
-		return cli.stream("POST", "/images/create?"+v.Encode(), nil, cli.out, map[string][]string{
+		return stream("POST", "/images/create?"+v.Encode(), nil, cli.out, map[string][]string{
 			"X-Registry-Auth": registryAuthHeader,
 		})
 	}
@@ -1673,13 +2141,32 @@ func (cli *DockerCli) CmdPs(args ...string) error {
 		since    = cmd.String([]string{"#sinceId", "#-since-id", "-since"}, "", "Show created since Id or Name, include non-running")
 		before   = cmd.String([]string{"#beforeId", "#-before-id", "-before"}, "", "Show only container created before Id or Name")
 		last     = cmd.Int([]string{"n"}, -1, "Show n last created containers, include non-running")
+		format   = cmd.String([]string{"-format"}, "", "Pretty-print containers using a Go template, or table or json")
 		flFilter = opts.NewListOpts(nil)
+
+		tmpl   *template.Template
+		header string
+		table  bool
 	)
 	cmd.Require(flag.Exact, 0)
 
 	cmd.Var(&flFilter, []string{"f", "-filter"}, "Filter output based on conditions provided")
 
 	utils.ParseFlags(cmd, args, true)
+
+	switch *format {
+	case "", "table":
+		// The default columns
+	case "json":
+		// One JSON object per container
+		if tmpl, header, table, err = parseFormat("{{json .}}"); err != nil {
+			return err
+		}
+	default:
+		if tmpl, header, table, err = parseFormat(*format); err != nil {
+			return fmt.Errorf("Template parsing error: %v", err)
+		}
+	}
 	if *last == -1 && *nLatest {
 		*last = 1
 	}
@@ -1732,7 +2219,11 @@ func (cli *DockerCli) CmdPs(args ...string) error {
 	}
 
 	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
-	if !*quiet {
+	if tmpl != nil {
+		if table && !*quiet {
+			fmt.Fprintln(w, header)
+		}
+	} else if !*quiet {
 		fmt.Fprint(w, "CONTAINER ID\tIMAGE\tCOMMAND\tCREATED\tSTATUS\tPORTS\tNAMES")
 
 		if *size {
@@ -1789,12 +2280,31 @@ func (cli *DockerCli) CmdPs(args ...string) error {
 			image = "<no image>"
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\t%s\t%s\t", outID, image, outCommand,
-			units.HumanDuration(time.Now().UTC().Sub(time.Unix(out.GetInt64("Created"), 0))),
-			out.Get("Status"), api.DisplayablePorts(ports), strings.Join(outNames, ","))
+		created := units.HumanDuration(time.Now().UTC().Sub(time.Unix(out.GetInt64("Created"), 0))) + " ago"
+
+		if tmpl != nil {
+			row := map[string]interface{}{
+				"ID":         outID,
+				"Image":      image,
+				"Command":    outCommand,
+				"RunningFor": created,
+				"Status":     out.Get("Status"),
+				"Ports":      api.DisplayablePorts(ports),
+				"Names":      strings.Join(outNames, ","),
+				"Size":       units.HumanSize(float64(out.GetInt64("SizeRw"))),
+			}
+			if err := tmpl.Execute(w, row); err != nil {
+				return err
+			}
+			fmt.Fprint(w, "\n")
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t", outID, image, outCommand,
+			created, out.Get("Status"), api.DisplayablePorts(ports), strings.Join(outNames, ","))
 
 		if *size {
-			if out.GetInt("SizeRootFs") > 0 {
+			if out.GetInt64("SizeRootFs") > 0 {
 				fmt.Fprintf(w, "%s (virtual %s)\n", units.HumanSize(float64(out.GetInt64("SizeRw"))), units.HumanSize(float64(out.GetInt64("SizeRootFs"))))
 			} else {
 				fmt.Fprintf(w, "%s\n", units.HumanSize(float64(out.GetInt64("SizeRw"))))
@@ -1806,7 +2316,7 @@ func (cli *DockerCli) CmdPs(args ...string) error {
 		fmt.Fprint(w, "\n")
 	}
 
-	if !*quiet {
+	if !*quiet || tmpl != nil {
 		w.Flush()
 	}
 
@@ -2021,8 +2531,8 @@ func (cli *DockerCli) CmdLogs(args ...string) error {
 		return err
 	}
 
-	if env.GetSubEnv("HostConfig").GetSubEnv("LogConfig").Get("Type") != "json-file" {
-		return fmt.Errorf("\"logs\" command is supported only for \"json-file\" logging driver")
+	if logType := env.GetSubEnv("HostConfig").GetSubEnv("LogConfig").Get("Type"); logType != "json-file" {
+		return fmt.Errorf("\"logs\" command is supported only for \"json-file\" logging driver (got: %s)", logType)
 	}
 
 	v := url.Values{}
@@ -2076,9 +2586,7 @@ func (cli *DockerCli) CmdAttach(args ...string) error {
 	}
 
 	if tty && cli.isTerminalOut {
-		if err := cli.monitorTtySize(cmd.Arg(0), false); err != nil {
-			log.Debugf("Error monitoring TTY size: %s", err)
-		}
+		defer cli.monitorTtySize(cmd.Arg(0), false).Close()
 	}
 
 	var in io.ReadCloser
@@ -2119,12 +2627,58 @@ func (cli *DockerCli) CmdSearch(args ...string) error {
 	trusted := cmd.Bool([]string{"#t", "#trusted", "#-trusted"}, false, "Only show trusted builds")
 	automated := cmd.Bool([]string{"-automated"}, false, "Only show automated builds")
 	stars := cmd.Int([]string{"s", "#stars", "-stars"}, 0, "Only displays with at least x stars")
+	limit := cmd.Int([]string{"-limit"}, 25, "Max number of search results")
+	flFilter := opts.NewListOpts(nil)
+	cmd.Var(&flFilter, []string{"f", "-filter"}, "Filter output based on conditions provided")
 	cmd.Require(flag.Exact, 1)
 
 	utils.ParseFlags(cmd, args, true)
 
 	v := url.Values{}
 	v.Set("term", cmd.Arg(0))
+	if cmd.IsSet("-limit") {
+		if err := cli.requireAPIVersion("1.18", "docker search --limit"); err != nil {
+			return err
+		}
+		if *limit < 1 || *limit > 100 {
+			return fmt.Errorf("Limit %d is outside the range of [1, 100]", *limit)
+		}
+		v.Set("limit", strconv.Itoa(*limit))
+	}
+
+	searchFilterArgs := filters.Args{}
+	for _, f := range flFilter.GetAll() {
+		var err error
+		searchFilterArgs, err = filters.ParseFlag(f, searchFilterArgs)
+		if err != nil {
+			return err
+		}
+	}
+	// The is-automated and is-official filters keep the results whose flag
+	// has the value given
+	boolFilters := map[string]bool{}
+	for name, values := range searchFilterArgs {
+		for _, value := range values {
+			switch name {
+			case "stars":
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("Invalid filter 'stars=%s'", value)
+				}
+				if n > *stars {
+					*stars = n
+				}
+			case "is-automated", "is-official":
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("Invalid filter '%s=%s'", name, value)
+				}
+				boolFilters[name] = b
+			default:
+				return fmt.Errorf("Invalid filter '%s'", name)
+			}
+		}
+	}
 
 	body, _, err := readBody(cli.call("GET", "/images/search?"+v.Encode(), nil, true))
 
@@ -2141,6 +2695,13 @@ func (cli *DockerCli) CmdSearch(args ...string) error {
 		if ((*automated || *trusted) && (!out.GetBool("is_trusted") && !out.GetBool("is_automated"))) || (*stars > out.GetInt("star_count")) {
 			continue
 		}
+		isAutomated := out.GetBool("is_automated") || out.GetBool("is_trusted")
+		if b, ok := boolFilters["is-automated"]; ok && b != isAutomated {
+			continue
+		}
+		if b, ok := boolFilters["is-official"]; ok && b != out.GetBool("is_official") {
+			continue
+		}
 		desc := strings.Replace(out.Get("description"), "\n", " ", -1)
 		desc = strings.Replace(desc, "\r", " ", -1)
 		if !*noTrunc && len(desc) > 45 {
@@ -2427,14 +2988,6 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 		waitDisplayId chan struct{}
 		errCh         chan error
 	)
-	if !config.AttachStdout && !config.AttachStderr {
-		// Make this asynchronous to allow the client to write to stdin before having to read the ID
-		waitDisplayId = make(chan struct{})
-		go func() {
-			defer close(waitDisplayId)
-			fmt.Fprintf(cli.out, "%s\n", createResponse.ID)
-		}()
-	}
 	if *flAutoRemove && (hostConfig.RestartPolicy.Name == "always" || hostConfig.RestartPolicy.Name == "on-failure") {
 		return ErrConflictRestartPolicyAndAutoRemove
 	}
@@ -2497,10 +3050,19 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 		return err
 	}
 
+	// The ID is only displayed once the daemon confirmed that the process
+	// is running, so that it can be attached to right away
+	if !config.AttachStdout && !config.AttachStderr {
+		// Make this asynchronous to allow the client to write to stdin before having to read the ID
+		waitDisplayId = make(chan struct{})
+		go func() {
+			defer close(waitDisplayId)
+			fmt.Fprintf(cli.out, "%s\n", createResponse.ID)
+		}()
+	}
+
 	if (config.AttachStdin || config.AttachStdout || config.AttachStderr) && config.Tty && cli.isTerminalOut {
-		if err := cli.monitorTtySize(createResponse.ID, false); err != nil {
-			log.Errorf("Error monitoring TTY size: %s", err)
-		}
+		defer cli.monitorTtySize(createResponse.ID, false).Close()
 	}
 
 	if errCh != nil {
@@ -2743,9 +3305,7 @@ func (cli *DockerCli) CmdExec(args ...string) error {
 	}
 
 	if execConfig.Tty && cli.isTerminalIn {
-		if err := cli.monitorTtySize(execID, true); err != nil {
-			log.Errorf("Error monitoring TTY size: %s", err)
-		}
+		defer cli.monitorTtySize(execID, true).Close()
 	}
 
 	if err := <-errCh; err != nil {
@@ -2859,6 +3419,9 @@ func (cli *DockerCli) CmdStats(args ...string) error {
 	cmd := cli.Subcmd("stats", "CONTAINER [CONTAINER...]", "Display a live stream of one or more containers' resource usage statistics", true)
 	cmd.Require(flag.Min, 1)
 	utils.ParseFlags(cmd, args, true)
+	if err := cli.requireAPIVersion("1.17", "docker stats"); err != nil {
+		return err
+	}
 
 	names := cmd.Args()
 	sort.Strings(names)
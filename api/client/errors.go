@@ -0,0 +1,56 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/docker/docker/api"
+)
+
+// APIError is an error returned by the daemon, with the HTTP status code of
+// its response. IsNotFound and IsConflict tell the common ones apart.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Error response from daemon: %s", e.Message)
+}
+
+// newAPIError returns the error of a response of the daemon from its body,
+// which is the message as text, or a JSON object with a message field
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	body = bytes.TrimSpace(body)
+	if api.MatchesContentType(resp.Header.Get("Content-Type"), "application/json") {
+		var jsonErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(body, &jsonErr); err == nil && jsonErr.Message != "" {
+			return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(jsonErr.Message)}
+		}
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: string(body)}
+}
+
+// IsNotFound returns whether err is an error of the daemon for an object which
+// does not exist, like a container or an image
+func IsNotFound(err error) bool {
+	return apiStatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict returns whether err is an error of the daemon for a request which
+// conflicts with the state of an object, like removing a running container
+func IsConflict(err error) bool {
+	return apiStatusCode(err) == http.StatusConflict
+}
+
+func apiStatusCode(err error) int {
+	if e, ok := err.(*APIError); ok {
+		return e.StatusCode
+	}
+	return 0
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -14,7 +15,6 @@ import (
 	"time"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/docker/docker/api"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/pkg/promise"
 	"github.com/docker/docker/pkg/stdcopy"
@@ -81,7 +81,12 @@ func tlsDialWithDialer(dialer *net.Dialer, network, addr string, config *tls.Con
 		tcpConn.SetKeepAlive(true)
 		tcpConn.SetKeepAlivePeriod(30 * time.Second)
 	}
+	return tlsHandshake(rawConn, addr, config, errChannel)
+}
 
+// tlsHandshake negotiates TLS over rawConn to addr. If errChannel is set, it
+// also receives a value when the dial times out.
+func tlsHandshake(rawConn net.Conn, addr string, config *tls.Config, errChannel chan error) (net.Conn, error) {
 	colonPos := strings.LastIndex(addr, ":")
 	if colonPos == -1 {
 		colonPos = len(addr)
@@ -99,7 +104,8 @@ func tlsDialWithDialer(dialer *net.Dialer, network, addr string, config *tls.Con
 
 	conn := tls.Client(rawConn, config)
 
-	if timeout == 0 {
+	var err error
+	if errChannel == nil {
 		err = conn.Handshake()
 	} else {
 		go func() {
@@ -120,6 +126,13 @@ func tlsDialWithDialer(dialer *net.Dialer, network, addr string, config *tls.Con
 }
 
 func (cli *DockerCli) dial() (net.Conn, error) {
+	if cli.dialer != nil {
+		conn, err := cli.dialer(cli.proto, cli.addr)
+		if err != nil || cli.tlsConfig == nil || cli.proto == "unix" {
+			return conn, err
+		}
+		return tlsHandshake(conn, cli.addr, cli.tlsConfig, nil)
+	}
 	if cli.tlsConfig != nil && cli.proto != "unix" {
 		// Notice this isn't Go standard's tls.Dial function
 		return tlsDial(cli.proto, cli.addr, cli.tlsConfig)
@@ -138,7 +151,11 @@ func (cli *DockerCli) hijack(method, path string, setRawTerminal bool, in io.Rea
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", api.APIVERSION, path), params)
+	versionedPath, err := cli.versionedPath(path)
+	if err != nil {
+		return cli.connectionError(err)
+	}
+	req, err := http.NewRequest(method, versionedPath, params)
 	if err != nil {
 		return err
 	}
@@ -168,7 +185,14 @@ func (cli *DockerCli) hijack(method, path string, setRawTerminal bool, in io.Rea
 	defer clientconn.Close()
 
 	// Server hijacks the connection, error 'connection closed' expected
-	clientconn.Do(req)
+	if resp, _ := clientconn.Do(req); resp != nil && resp.StatusCode >= 400 {
+		// The daemon refused the request before hijacking the connection
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return newAPIError(resp, body)
+	}
 
 	rwc, br := clientconn.Hijack()
 	defer rwc.Close()
@@ -189,6 +213,16 @@ func (cli *DockerCli) hijack(method, path string, setRawTerminal bool, in io.Rea
 		defer term.RestoreTerminal(cli.inFd, oldState)
 	}
 
+	if stdout != nil && setRawTerminal && cli.isTerminalOut && os.Getenv("NORAW") == "" {
+		outState, err := term.SetRawTerminalOutput(cli.outFd)
+		if err != nil {
+			// the output is still shown, without colors and cursor moves
+			log.Debugf("Error setting the terminal output to raw mode: %s", err)
+		} else if outState != nil {
+			defer outState.Restore()
+		}
+	}
+
 	if stdout != nil || stderr != nil {
 		receiveStdout = promise.Go(func() (err error) {
 			defer func() {
@@ -10,18 +10,19 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"os"
-	gosignal "os/signal"
+	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/engine"
-	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/pkg/term"
+	"github.com/docker/docker/pkg/version"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
 )
@@ -30,10 +31,162 @@ var (
 	ErrConnectionRefused = errors.New("Cannot connect to the Docker daemon. Is 'docker -d' running on this host?")
 )
 
+const tableFormatPrefix = "table "
+
+// templateFieldRegexp matches the field references of a template action,
+// e.g. `{{.State.Running}}` or `{{json .Config}}`.
+var templateFieldRegexp = regexp.MustCompile(`{{[^}]*?\.([A-Za-z0-9_.]+)[^}]*}}`)
+
+// parseFormat parses the value of a --format flag. A format starting with
+// "table " is rendered as aligned columns preceded by a header row, which is
+// derived from the name of the last field referenced in each column.
+func parseFormat(format string) (tmpl *template.Template, header string, table bool, err error) {
+	// Allow the column separator to be passed as an escaped sequence
+	// from the shell, e.g. 'table {{.Id}}\t{{.Name}}'.
+	format = strings.Replace(format, `\t`, "\t", -1)
+	if strings.HasPrefix(format, tableFormatPrefix) {
+		table = true
+		format = strings.TrimPrefix(format, tableFormatPrefix)
+
+		columns := strings.Split(format, "\t")
+		names := make([]string, len(columns))
+		for i, column := range columns {
+			var fields []string
+			for _, match := range templateFieldRegexp.FindAllStringSubmatch(column, -1) {
+				parts := strings.Split(match[1], ".")
+				fields = append(fields, strings.ToUpper(parts[len(parts)-1]))
+			}
+			names[i] = strings.Join(fields, " ")
+		}
+		header = strings.Join(names, "\t")
+	}
+	if tmpl, err = template.New("").Funcs(funcMap).Parse(format); err != nil {
+		return nil, "", false, err
+	}
+	return tmpl, header, table, nil
+}
+
 func (cli *DockerCli) HTTPClient() *http.Client {
 	return &http.Client{Transport: cli.transport}
 }
 
+// maxRetryInterval caps the delay between retries of a request, so that a
+// client with many retries keeps polling a restarting daemon
+const maxRetryInterval = 30 * time.Second
+
+// nextRetryInterval returns the delay before the retry following one which
+// waited delay: twice as long, up to maxRetryInterval. A delay which is
+// already longer, as set with WithRetries, is kept.
+func nextRetryInterval(delay time.Duration) time.Duration {
+	if delay >= maxRetryInterval {
+		return delay
+	}
+	if delay *= 2; delay > maxRetryInterval {
+		return maxRetryInterval
+	}
+	return delay
+}
+
+// doRequest sends req to the daemon. GET requests without a body are retried
+// with backoff as configured by WithRetries when no response was received:
+// the daemon did not see them, or they did not change anything.
+func (cli *DockerCli) doRequest(req *http.Request) (*http.Response, error) {
+	resp, err := cli.HTTPClient().Do(req)
+	if req.Method != "GET" || req.ContentLength != 0 {
+		return resp, err
+	}
+	delay := cli.retryInterval
+	for retry := 1; err != nil && retry <= cli.maxRetries; retry++ {
+		log.Debugf("%s %s failed: %v, retrying in %s (%d/%d)", req.Method, req.URL.Path, err, delay, retry, cli.maxRetries)
+		time.Sleep(delay)
+		delay = nextRetryInterval(delay)
+		resp, err = cli.HTTPClient().Do(req)
+	}
+	return resp, err
+}
+
+// APIVersion returns the version of the API the client talks to the daemon
+// with. Unless it is pinned with WithAPIVersion, it is negotiated on first use
+// as the highest version both the client and the daemon support. It is the
+// version of the client when the daemon can't be reached.
+func (cli *DockerCli) APIVersion() version.Version {
+	v, _ := cli.negotiatedAPIVersion()
+	return v
+}
+
+// negotiatedAPIVersion is like APIVersion, but also returns the error which
+// kept the daemon from being asked for its version. The error is kept, so
+// that the daemon is asked only once, and the retries of WithRetries aren't
+// run again for each request once they all failed.
+func (cli *DockerCli) negotiatedAPIVersion() (version.Version, error) {
+	cli.versionLock.Lock()
+	defer cli.versionLock.Unlock()
+	if cli.apiVersion == "" && cli.versionErr == nil {
+		if v, err := cli.negotiateAPIVersion(); err != nil {
+			log.Debugf("Error negotiating the API version: %s", err)
+			cli.versionErr = err
+		} else {
+			log.Debugf("Using the API version %s", v)
+			cli.apiVersion = v
+		}
+	}
+	if cli.versionErr != nil {
+		return api.APIVERSION, cli.versionErr
+	}
+	return cli.apiVersion, nil
+}
+
+// versionedPath prefixes path with the API version, or returns the error
+// which kept it from being negotiated
+func (cli *DockerCli) versionedPath(path string) (string, error) {
+	v, err := cli.negotiatedAPIVersion()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/v%s%s", v, path), nil
+}
+
+func (cli *DockerCli) negotiateAPIVersion() (version.Version, error) {
+	req, err := http.NewRequest("GET", "/version", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Docker-Client/"+dockerversion.VERSION)
+	req.URL.Host = cli.addr
+	req.URL.Scheme = cli.scheme
+	resp, err := cli.doRequest(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return api.APIVERSION, nil
+	}
+	var daemonVersion struct {
+		ApiVersion version.Version
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&daemonVersion); err != nil {
+		log.Debugf("Error decoding the version of the daemon: %s", err)
+		return api.APIVERSION, nil
+	}
+	if daemonVersion.ApiVersion == "" || daemonVersion.ApiVersion.GreaterThan(api.APIVERSION) {
+		return api.APIVERSION, nil
+	}
+	return daemonVersion.ApiVersion, nil
+}
+
+// requireAPIVersion fails when the API version used with the daemon is older
+// than v, which introduced feature
+func (cli *DockerCli) requireAPIVersion(v version.Version, feature string) error {
+	if current := cli.APIVersion(); current.LessThan(v) {
+		if cli.apiVersionPinned {
+			return fmt.Errorf("%s requires API version %s, but the API version is set to %s with --api-version or DOCKER_API_VERSION", feature, v, current)
+		}
+		return fmt.Errorf("%s requires API version %s, but the daemon only supports %s", feature, v, current)
+	}
+	return nil
+}
+
 func (cli *DockerCli) encodeData(data interface{}) (*bytes.Buffer, error) {
 	params := bytes.NewBuffer(nil)
 	if data != nil {
@@ -59,14 +212,18 @@ func (cli *DockerCli) call(method, path string, data interface{}, passAuthInfo b
 	if err != nil {
 		return nil, -1, err
 	}
-	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", api.APIVERSION, path), params)
+	versionedPath, err := cli.versionedPath(path)
+	if err != nil {
+		return nil, -1, cli.connectionError(err)
+	}
+	req, err := http.NewRequest(method, versionedPath, params)
 	if err != nil {
 		return nil, -1, err
 	}
 	if passAuthInfo {
 		cli.LoadConfigFile()
 		// Resolve the Auth config relevant for this server
-		authConfig := cli.configFile.Configs[registry.IndexServerAddress()]
+		authConfig, _ := cli.configFile.GetAuthConfig(registry.IndexServerAddress())
 		getHeaders := func(authConfig registry.AuthConfig) (map[string][]string, error) {
 			buf, err := json.Marshal(authConfig)
 			if err != nil {
@@ -91,17 +248,9 @@ func (cli *DockerCli) call(method, path string, data interface{}, passAuthInfo b
 	} else if method == "POST" {
 		req.Header.Set("Content-Type", "text/plain")
 	}
-	resp, err := cli.HTTPClient().Do(req)
+	resp, err := cli.doRequest(req)
 	if err != nil {
-		if strings.Contains(err.Error(), "connection refused") {
-			return nil, -1, ErrConnectionRefused
-		}
-
-		if cli.tlsConfig == nil {
-			return nil, -1, fmt.Errorf("%v. Are you trying to connect to a TLS-enabled daemon without TLS?", err)
-		}
-		return nil, -1, fmt.Errorf("An error occurred trying to connect: %v", err)
-
+		return nil, -1, cli.connectionError(err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
@@ -112,24 +261,87 @@ func (cli *DockerCli) call(method, path string, data interface{}, passAuthInfo b
 		if len(body) == 0 {
 			return nil, resp.StatusCode, fmt.Errorf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(resp.StatusCode), req.URL)
 		}
-		return nil, resp.StatusCode, fmt.Errorf("Error response from daemon: %s", bytes.TrimSpace(body))
+		return nil, resp.StatusCode, newAPIError(resp, body)
 	}
 
 	return resp.Body, resp.StatusCode, nil
 }
 
+// connectionError explains err, which kept a request from reaching the daemon
+func (cli *DockerCli) connectionError(err error) error {
+	if strings.Contains(err.Error(), "connection refused") {
+		return ErrConnectionRefused
+	}
+	if cli.tlsConfig == nil {
+		return fmt.Errorf("%v. Are you trying to connect to a TLS-enabled daemon without TLS?", err)
+	}
+	return fmt.Errorf("An error occurred trying to connect: %v", err)
+}
+
 func (cli *DockerCli) stream(method, path string, in io.Reader, out io.Writer, headers map[string][]string) error {
 	return cli.streamHelper(method, path, true, in, out, nil, headers)
 }
 
+// streamJSON is like stream for the endpoints streaming JSON messages, which
+// are copied to out one per line instead of being displayed
+func (cli *DockerCli) streamJSON(method, path string, in io.Reader, out io.Writer, headers map[string][]string) error {
+	resp, err := cli.openStream(method, path, in, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return utils.WriteJSONMessagesStream(resp.Body, out)
+}
+
+// progressStream returns the stream function printing the progress of a pull
+// or a push in the format given with --format
+func (cli *DockerCli) progressStream(format string) (func(method, path string, in io.Reader, out io.Writer, headers map[string][]string) error, error) {
+	switch format {
+	case "":
+		return cli.stream, nil
+	case "json":
+		return cli.streamJSON, nil
+	}
+	return nil, fmt.Errorf("Invalid --format %s: the progress can only be formatted as json", format)
+}
+
 func (cli *DockerCli) streamHelper(method, path string, setRawTerminal bool, in io.Reader, stdout, stderr io.Writer, headers map[string][]string) error {
+	resp, err := cli.openStream(method, path, in, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if api.MatchesContentType(resp.Header.Get("Content-Type"), "application/json") {
+		return utils.DisplayJSONMessagesStream(resp.Body, stdout, cli.outFd, cli.isTerminalOut)
+	}
+	if stdout != nil || stderr != nil {
+		// When TTY is ON, use regular copy
+		if setRawTerminal {
+			_, err = io.Copy(stdout, resp.Body)
+		} else {
+			_, err = stdcopy.StdCopy(stdout, stderr, resp.Body)
+		}
+		log.Debugf("[stream] End of stdout")
+		return err
+	}
+	return nil
+}
+
+// openStream sends a request whose response is streamed, and returns the
+// response for the caller to read and close when its status is a success
+func (cli *DockerCli) openStream(method, path string, in io.Reader, headers map[string][]string) (*http.Response, error) {
 	if (method == "POST" || method == "PUT") && in == nil {
 		in = bytes.NewReader([]byte{})
 	}
 
-	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", api.APIVERSION, path), in)
+	versionedPath, err := cli.versionedPath(path)
 	if err != nil {
-		return err
+		return nil, cli.connectionError(err)
+	}
+	req, err := http.NewRequest(method, versionedPath, in)
+	if err != nil {
+		return nil, err
 	}
 	req.Header.Set("User-Agent", "Docker-Client/"+dockerversion.VERSION)
 	req.URL.Host = cli.addr
@@ -143,40 +355,23 @@ func (cli *DockerCli) streamHelper(method, path string, setRawTerminal bool, in
 			req.Header[k] = v
 		}
 	}
-	resp, err := cli.HTTPClient().Do(req)
+	resp, err := cli.doRequest(req)
 	if err != nil {
-		if strings.Contains(err.Error(), "connection refused") {
-			return fmt.Errorf("Cannot connect to the Docker daemon. Is 'docker -d' running on this host?")
-		}
-		return err
+		return nil, cli.connectionError(err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		defer resp.Body.Close()
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if len(body) == 0 {
-			return fmt.Errorf("Error :%s", http.StatusText(resp.StatusCode))
+		if len(bytes.TrimSpace(body)) == 0 {
+			body = []byte(http.StatusText(resp.StatusCode))
 		}
-		return fmt.Errorf("Error: %s", bytes.TrimSpace(body))
-	}
-
-	if api.MatchesContentType(resp.Header.Get("Content-Type"), "application/json") {
-		return utils.DisplayJSONMessagesStream(resp.Body, stdout, cli.outFd, cli.isTerminalOut)
+		return nil, newAPIError(resp, body)
 	}
-	if stdout != nil || stderr != nil {
-		// When TTY is ON, use regular copy
-		if setRawTerminal {
-			_, err = io.Copy(stdout, resp.Body)
-		} else {
-			_, err = stdcopy.StdCopy(stdout, stderr, resp.Body)
-		}
-		log.Debugf("[stream] End of stdout")
-		return err
-	}
-	return nil
+	return resp, nil
 }
 
 func (cli *DockerCli) resizeTty(id string, isExec bool) {
@@ -254,17 +449,14 @@ func getExecExitCode(cli *DockerCli, execId string) (bool, int, error) {
 	return result.GetBool("Running"), result.GetInt("ExitCode"), nil
 }
 
-func (cli *DockerCli) monitorTtySize(id string, isExec bool) error {
+// monitorTtySize resizes the tty of the container or exec id to the size of
+// the terminal, now and whenever the terminal is resized, until the returned
+// listener is closed.
+func (cli *DockerCli) monitorTtySize(id string, isExec bool) *term.ResizeListener {
 	cli.resizeTty(id, isExec)
-
-	sigchan := make(chan os.Signal, 1)
-	gosignal.Notify(sigchan, signal.SIGWINCH)
-	go func() {
-		for _ = range sigchan {
-			cli.resizeTty(id, isExec)
-		}
-	}()
-	return nil
+	return term.NewResizeListener(cli.outFd, func(*term.Winsize) {
+		cli.resizeTty(id, isExec)
+	})
 }
 
 func (cli *DockerCli) getTtySize() (int, int) {
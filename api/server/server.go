@@ -328,6 +328,16 @@ func getInfo(eng *engine.Engine, version version.Version, w http.ResponseWriter,
 	return nil
 }
 
+func getSystemDf(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	var job = eng.Job("system_df")
+	streamJSON(job, w, false)
+	job.Setenv("verbose", r.Form.Get("verbose"))
+	return job.Run()
+}
+
 func getEvents(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -341,6 +351,21 @@ func getEvents(eng *engine.Engine, version version.Version, w http.ResponseWrite
 	return job.Run()
 }
 
+func getNetworksJSON(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	var job = eng.Job("network_ls")
+	streamJSON(job, w, false)
+	return job.Run()
+}
+
+func getNetworksByName(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	var job = eng.Job("network_inspect", vars["name"])
+	streamJSON(job, w, false)
+	return job.Run()
+}
+
 func getImagesHistory(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
@@ -625,6 +650,7 @@ func getImagesSearch(eng *engine.Engine, version version.Version, w http.Respons
 	}
 
 	var job = eng.Job("search", r.Form.Get("term"))
+	job.Setenv("limit", r.Form.Get("limit"))
 	job.SetenvJson("metaHeaders", metaHeaders)
 	job.SetenvJson("authConfig", authConfig)
 	streamJSON(job, w, false)
@@ -745,6 +771,62 @@ func postContainersCreate(eng *engine.Engine, version version.Version, w http.Re
 	})
 }
 
+func postNetworksCreate(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	config := &engine.Env{}
+	if err := config.Decode(r.Body); err != nil {
+		return err
+	}
+	var job = eng.Job("network_create", config.Get("Name"))
+	job.Setenv("Driver", config.Get("Driver"))
+	job.Setenv("Subnet", config.Get("Subnet"))
+	out, err := job.Stdout.AddEnv()
+	if err != nil {
+		return err
+	}
+	if err := job.Run(); err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, &types.NetworkCreateResponse{
+		ID: out.Get("Id"),
+	})
+}
+
+func postContainersUpdate(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	var (
+		job         = eng.Job("container_update", vars["name"])
+		outWarnings []string
+		warnings    = bytes.NewBuffer(nil)
+	)
+
+	if err := job.DecodeEnv(r.Body); err != nil {
+		return err
+	}
+	// Read warnings from stderr
+	job.Stderr.Add(warnings)
+	if err := job.Run(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(warnings)
+	for scanner.Scan() {
+		outWarnings = append(outWarnings, scanner.Text())
+	}
+	return writeJSON(w, http.StatusOK, &types.ContainerUpdateResponse{
+		Warnings: outWarnings,
+	})
+}
+
 func postContainersRestart(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -799,6 +881,39 @@ func deleteContainers(eng *engine.Engine, version version.Version, w http.Respon
 	return nil
 }
 
+func deleteNetworks(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := eng.Job("network_rm", vars["name"]).Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func postImagesPrune(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	var job = eng.Job("image_prune")
+	streamJSON(job, w, false)
+	job.Setenv("filters", r.Form.Get("filters"))
+
+	return job.Run()
+}
+
+func postContainersPrune(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	var job = eng.Job("container_prune")
+	streamJSON(job, w, false)
+	job.Setenv("filters", r.Form.Get("filters"))
+
+	return job.Run()
+}
+
 func deleteImages(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -1077,6 +1192,8 @@ func postBuild(eng *engine.Engine, version version.Version, w http.ResponseWrite
 	job.Setenv("remote", r.FormValue("remote"))
 	job.Setenv("dockerfile", r.FormValue("dockerfile"))
 	job.Setenv("t", r.FormValue("t"))
+	job.Setenv("target", r.FormValue("target"))
+	job.Setenv("buildargs", r.FormValue("buildargs"))
 	job.Setenv("q", r.FormValue("q"))
 	job.Setenv("nocache", r.FormValue("nocache"))
 	job.Setenv("forcerm", r.FormValue("forcerm"))
@@ -1317,6 +1434,7 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 			"/_ping":                          ping,
 			"/events":                         getEvents,
 			"/info":                           getInfo,
+			"/system/df":                      getSystemDf,
 			"/version":                        getVersion,
 			"/images/json":                    getImagesJSON,
 			"/images/viz":                     getImagesViz,
@@ -1335,6 +1453,8 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 			"/containers/{name:.*}/stats":     getContainersStats,
 			"/containers/{name:.*}/attach/ws": wsContainersAttach,
 			"/exec/{id:.*}/json":              getExecByID,
+			"/networks":                       getNetworksJSON,
+			"/networks/{name:.*}":             getNetworksByName,
 		},
 		"POST": {
 			"/auth":                         postAuth,
@@ -1342,12 +1462,15 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 			"/build":                        postBuild,
 			"/images/create":                postImagesCreate,
 			"/images/load":                  postImagesLoad,
+			"/images/prune":                 postImagesPrune,
 			"/images/{name:.*}/push":        postImagesPush,
 			"/images/{name:.*}/tag":         postImagesTag,
 			"/containers/create":            postContainersCreate,
+			"/containers/prune":             postContainersPrune,
 			"/containers/{name:.*}/kill":    postContainersKill,
 			"/containers/{name:.*}/pause":   postContainersPause,
 			"/containers/{name:.*}/unpause": postContainersUnpause,
+			"/containers/{name:.*}/update":  postContainersUpdate,
 			"/containers/{name:.*}/restart": postContainersRestart,
 			"/containers/{name:.*}/start":   postContainersStart,
 			"/containers/{name:.*}/stop":    postContainersStop,
@@ -1359,10 +1482,12 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 			"/exec/{name:.*}/start":         postContainerExecStart,
 			"/exec/{name:.*}/resize":        postContainerExecResize,
 			"/containers/{name:.*}/rename":  postContainerRename,
+			"/networks/create":              postNetworksCreate,
 		},
 		"DELETE": {
 			"/containers/{name:.*}": deleteContainers,
 			"/images/{name:.*}":     deleteImages,
+			"/networks/{name:.*}":   deleteNetworks,
 		},
 		"OPTIONS": {
 			"": optionsHandler,
@@ -1453,7 +1578,9 @@ func setupTls(cert, key, ca string, l net.Listener) (net.Listener, error) {
 		if err != nil {
 			return nil, fmt.Errorf("Could not read CA certificate: %v", err)
 		}
-		certPool.AppendCertsFromPEM(file)
+		if !certPool.AppendCertsFromPEM(file) {
+			return nil, fmt.Errorf("Could not parse CA certificate %s", ca)
+		}
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 		tlsConfig.ClientCAs = certPool
 	}
@@ -1539,7 +1666,10 @@ func setupTcpHttp(addr string, job *engine.Job) (*HttpServer, error) {
 	if job.GetenvBool("Tls") || job.GetenvBool("TlsVerify") {
 		var tlsCa string
 		if job.GetenvBool("TlsVerify") {
-			tlsCa = job.Getenv("TlsCa")
+			// without a CA, client certificates would not be required
+			if tlsCa = job.Getenv("TlsCa"); tlsCa == "" {
+				return nil, fmt.Errorf("--tlsverify requires a CA certificate (--tlscacert) to verify the clients")
+			}
 		}
 		l, err = setupTls(job.Getenv("TlsCert"), job.Getenv("TlsKey"), tlsCa, l)
 		if err != nil {
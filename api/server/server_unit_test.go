@@ -2,17 +2,25 @@ package server
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/testutils"
 	"github.com/docker/docker/pkg/version"
 )
 
@@ -551,3 +559,85 @@ var sampleImage getImagesJSONStruct = getImagesJSONStruct{
 	Size:        777,
 	VirtualSize: 666,
 }
+
+func TestSetupTlsVerify(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-server-tls-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	ca := testutils.NewCert(t, tmp, "ca", nil, true)
+	server := testutils.NewCert(t, tmp, "server", ca, false)
+	client := testutils.NewCert(t, tmp, "client", ca, false)
+	rogue := testutils.NewCert(t, tmp, "rogue", nil, false)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err = setupTls(server.CertFile, server.KeyFile, ca.CertFile, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	get := func(clientCert *testutils.Cert) error {
+		tlsConfig := &tls.Config{RootCAs: roots}
+		if clientCert != nil {
+			keyPair, err := tls.LoadX509KeyPair(clientCert.CertFile, clientCert.KeyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{keyPair}
+		}
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}, Timeout: 10 * time.Second}
+		resp, err := c.Get("https://" + l.Addr().String() + "/_ping")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if _, err := ioutil.ReadAll(resp.Body); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if err := get(client); err != nil {
+		t.Fatalf("A client with a certificate signed by the CA should be accepted: %v", err)
+	}
+	if err := get(nil); err == nil {
+		t.Fatal("A client without a certificate should be rejected")
+	}
+	if err := get(rogue); err == nil {
+		t.Fatal("A client with an untrusted certificate should be rejected")
+	}
+}
+
+func TestSetupTlsInvalidCA(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-server-tls-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	server := testutils.NewCert(t, tmp, "server", nil, false)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if _, err := setupTls(server.CertFile, server.KeyFile, filepath.Join(tmp, "missing.pem"), l); err == nil {
+		t.Fatal("Expected an error for a missing CA certificate")
+	}
+	// a key is not a certificate
+	if _, err := setupTls(server.CertFile, server.KeyFile, server.KeyFile, l); err == nil {
+		t.Fatal("Expected an error for an invalid CA certificate")
+	}
+}
@@ -9,3 +9,18 @@ type ContainerCreateResponse struct {
 	// Warnings are any warnings encountered during the creation of the container.
 	Warnings []string `json:"Warnings"`
 }
+
+// NetworkCreateResponse contains the information returned to a client on the
+// creation of a network.
+type NetworkCreateResponse struct {
+	// ID is the ID of the created network.
+	ID string `json:"Id"`
+}
+
+// ContainerUpdateResponse contains the information returned to a client on the
+// update of the resource limits of a container.
+type ContainerUpdateResponse struct {
+	// Warnings are any warnings encountered during the update, like limits
+	// which the kernel doesn't support.
+	Warnings []string `json:"Warnings"`
+}
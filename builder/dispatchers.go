@@ -17,6 +17,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/nat"
+	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/runconfig"
 )
@@ -107,6 +108,9 @@ func label(b *Builder, args []string, attributes map[string]bool, original strin
 	for j := 0; j < len(args); j++ {
 		// name  ==> args[j]
 		// value ==> args[j+1]
+		if err := opts.ValidateLabelKey(args[j]); err != nil {
+			return fmt.Errorf("LABEL: %v", err)
+		}
 		newVar := args[j] + "=" + args[j+1] + ""
 		commitStr += " " + newVar
 
@@ -131,9 +135,18 @@ func add(b *Builder, args []string, attributes map[string]bool, original string)
 
 // COPY foo /path
 //
-// Same as 'ADD' but without the tar and remote url handling.
+// Same as 'ADD' but without the tar and remote url handling. With
+// --from=stage the files are copied from the image of an earlier stage
+// instead of the context.
 //
 func dispatchCopy(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) > 0 && strings.HasPrefix(args[0], "--from=") {
+		stage := strings.TrimPrefix(args[0], "--from=")
+		if len(args) < 3 {
+			return fmt.Errorf("COPY requires at least two arguments")
+		}
+		return b.runStageCopy(stage, args[1:])
+	}
 	if len(args) < 2 {
 		return fmt.Errorf("COPY requires at least two arguments")
 	}
@@ -141,17 +154,34 @@ func dispatchCopy(b *Builder, args []string, attributes map[string]bool, origina
 	return b.runContextCommand(args, false, false, "COPY")
 }
 
-// FROM imagename
+// FROM imagename [AS name]
 //
-// This sets the image the dockerfile will build on top of.
+// This sets the image the dockerfile will build on top of, and begins a
+// build stage. The stage can be named for COPY --from and --target, and used
+// as the base image of a later stage.
 //
 func from(b *Builder, args []string, attributes map[string]bool, original string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("FROM requires one argument")
+	var stageName string
+	switch {
+	case len(args) == 3 && strings.EqualFold(args[1], "AS"):
+		stageName = strings.ToLower(args[2])
+	case len(args) != 1:
+		return fmt.Errorf("FROM requires either one argument, or three: FROM <image> AS <name>")
+	}
+	if err := b.beginStage(stageName); err != nil {
+		return err
 	}
 
 	name := args[0]
 
+	if id, ok := b.stages[strings.ToLower(name)]; ok && id != "" {
+		image, err := b.Daemon.Graph().Get(id)
+		if err != nil {
+			return err
+		}
+		return b.processImageFrom(image)
+	}
+
 	if name == NoBaseImageSpecifier {
 		b.image = ""
 		b.noBaseImage = true
@@ -266,15 +296,18 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 
 	log.Debugf("[BUILDER] Command to be executed: %v", b.Config.Cmd)
 
+	// The build args are in the environment of the command, and so in the
+	// cache key, but not in the config committed
+	env := b.Config.Env
+	b.Config.Env = b.buildEnv()
 	hit, err := b.probeCache()
-	if err != nil {
+	if err != nil || hit {
+		b.Config.Env = env
 		return err
 	}
-	if hit {
-		return nil
-	}
 
 	c, err := b.create()
+	b.Config.Env = env
 	if err != nil {
 		return err
 	}
@@ -435,6 +468,38 @@ func volume(b *Builder, args []string, attributes map[string]bool, original stri
 	return nil
 }
 
+// ARG name[=default]
+//
+// Declare the build arg name, set to the value given with --build-arg or to
+// the default. Build args are substituted like environment variables and set
+// in the environment of RUN, but are not kept in the image.
+//
+func arg(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("ARG requires exactly one argument")
+	}
+
+	parts := strings.SplitN(args[0], "=", 2)
+	name := parts[0]
+	if name == "" {
+		return fmt.Errorf("ARG requires a name")
+	}
+	if b.allowedBuildArgs == nil {
+		b.allowedBuildArgs = map[string]bool{}
+	}
+	b.allowedBuildArgs[name] = true
+	if b.args == nil {
+		b.args = map[string]string{}
+	}
+	if value, ok := b.buildArgs[name]; ok {
+		b.args[name] = value
+	} else if len(parts) == 2 {
+		b.args[name] = parts[1]
+	}
+
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("ARG %s", args[0]))
+}
+
 // INSERT is no longer accepted, but we still parse it.
 func insert(b *Builder, args []string, attributes map[string]bool, original string) error {
 	return fmt.Errorf("INSERT has been deprecated. Please use ADD instead")
@@ -25,6 +25,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
@@ -56,6 +57,7 @@ var replaceEnvAllowed = map[string]struct{}{
 	command.Expose:  {},
 	command.Volume:  {},
 	command.User:    {},
+	command.Arg:     {},
 }
 
 var evaluateTable map[string]func(*Builder, []string, map[string]bool, string) error
@@ -77,6 +79,7 @@ func init() {
 		command.Volume:     volume,
 		command.User:       user,
 		command.Insert:     insert,
+		command.Arg:        arg,
 	}
 }
 
@@ -126,6 +129,20 @@ type Builder struct {
 	contextPath    string        // the path of the temporary directory the local context is unpacked to (server side)
 	noBaseImage    bool          // indicates that this build does not start from any base image, but is being built from an empty file system.
 
+	// Dockerfiles with several FROM are built in stages, the earlier ones
+	// can be copied from with COPY --from
+	target    string            // the name of the stage to stop after, the build goes to the end when empty
+	stages    map[string]string // the images of the stages built, by name and by index
+	stageName string            // the name of the stage being built
+	stageN    int               // the number of stages begun
+
+	// The build args are given with --build-arg, and only used once declared
+	// with ARG. They are set in the environment of the build but not in
+	// the config of the images.
+	buildArgs        map[string]string // the values given with --build-arg
+	allowedBuildArgs map[string]bool   // the names declared with ARG
+	args             map[string]string // the values of the args declared in the stage
+
 	// Set resource restrictions for build containers
 	cpuSetCpus string
 	cpuShares  int64
@@ -165,7 +182,15 @@ func (b *Builder) Run(context io.Reader) (string, error) {
 
 	b.TmpContainers = map[string]struct{}{}
 
+	if b.target != "" && !hasStage(b.dockerfile, b.target) {
+		return "", fmt.Errorf("Target stage %q could not be found", b.target)
+	}
+
 	for i, n := range b.dockerfile.Children {
+		// The stages after the target are not built
+		if n.Value == command.From && b.target != "" && b.stageName == b.target {
+			break
+		}
 		if err := b.dispatch(i, n); err != nil {
 			if b.ForceRemove {
 				b.clearTmp()
@@ -182,6 +207,17 @@ func (b *Builder) Run(context io.Reader) (string, error) {
 		return "", fmt.Errorf("No image was generated. Is your Dockerfile empty?")
 	}
 
+	var unused []string
+	for name := range b.buildArgs {
+		if !b.allowedBuildArgs[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		fmt.Fprintf(b.ErrStream, "[Warning] One or more build-args %v were not consumed\n", unused)
+	}
+
 	fmt.Fprintf(b.OutStream, "Successfully built %s\n", common.TruncateID(b.image))
 	return b.image, nil
 }
@@ -15,6 +15,7 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -622,10 +623,15 @@ func (b *Builder) checkPathForAddition(orig string) error {
 }
 
 func (b *Builder) addContext(container *daemon.Container, orig, dest string, decompress bool) error {
+	return addToContainer(container, path.Join(b.contextPath, orig), orig, dest, decompress)
+}
+
+// addToContainer copies the file or directory at origPath, named orig in
+// errors, to dest in the container
+func addToContainer(container *daemon.Container, origPath, orig, dest string, decompress bool) error {
 	var (
 		err        error
 		destExists = true
-		origPath   = path.Join(b.contextPath, orig)
 		destPath   = path.Join(container.RootfsPath(), dest)
 	)
 
@@ -695,6 +701,122 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 	return fixPermissions(origPath, resPath, 0, 0, destExists)
 }
 
+// beginStage keeps the image of the stage built so far for COPY --from, and
+// resets the builder for the stage named name, which can be empty
+func (b *Builder) beginStage(name string) error {
+	if b.stages == nil {
+		b.stages = map[string]string{}
+	}
+	if b.stageN > 0 {
+		b.stages[strconv.Itoa(b.stageN-1)] = b.image
+		if b.stageName != "" {
+			b.stages[b.stageName] = b.image
+		}
+	}
+	if name != "" {
+		if !validStageName.MatchString(name) {
+			return fmt.Errorf("Invalid name for build stage: %q, it must match %s", name, validStageName)
+		}
+		if _, exists := b.stages[name]; exists {
+			return fmt.Errorf("Duplicate name for build stage: %s", name)
+		}
+	}
+
+	b.stageN++
+	b.stageName = name
+	b.Config = &runconfig.Config{}
+	b.image = ""
+	b.noBaseImage = false
+	b.maintainer = ""
+	b.cmdSet = false
+	// The build args are declared again in each stage
+	b.args = nil
+	// A stage has its own tree of cached images
+	b.cacheBusted = false
+	return nil
+}
+
+// runStageCopy copies the files of args from the image of the earlier build
+// stage given, to the last path of args, as COPY --from does
+func (b *Builder) runStageCopy(stage string, args []string) error {
+	id, ok := b.stages[strings.ToLower(stage)]
+	if !ok {
+		return fmt.Errorf("COPY --from: no build stage %s before this one", stage)
+	}
+	if id == "" {
+		return fmt.Errorf("COPY --from: build stage %s has no image", stage)
+	}
+
+	srcs, dest := args[:len(args)-1], args[len(args)-1]
+	if len(srcs) > 1 && !strings.HasSuffix(dest, "/") {
+		return fmt.Errorf("When using COPY with more than one source file, the destination must be a directory and end with a /")
+	}
+	if !filepath.IsAbs(dest) {
+		hasSlash := strings.HasSuffix(dest, "/")
+		dest = filepath.Join("/", b.Config.WorkingDir, dest)
+		if hasSlash {
+			dest += "/"
+		}
+	}
+
+	b.Config.Image = b.image
+	cmd := b.Config.Cmd
+	// The images are identified by their content, as a hash of the files
+	// copied from the context would be
+	b.Config.Cmd = []string{"/bin/sh", "-c", fmt.Sprintf("#(nop) COPY from:%s %s in %s", id, strings.Join(srcs, " "), dest)}
+	defer func(cmd []string) { b.Config.Cmd = cmd }(cmd)
+
+	hit, err := b.probeCache()
+	if err != nil {
+		return err
+	}
+	if hit {
+		return nil
+	}
+
+	driver := b.Daemon.GraphDriver()
+	root, err := driver.Get(id, "")
+	if err != nil {
+		return err
+	}
+	defer driver.Put(id)
+
+	container, _, err := b.Daemon.Create(b.Config, nil, "")
+	if err != nil {
+		return err
+	}
+	b.TmpContainers[container.ID] = struct{}{}
+
+	if err := container.Mount(); err != nil {
+		return err
+	}
+	defer container.Unmount()
+
+	for _, src := range srcs {
+		matches := []string{path.Join(root, src)}
+		if ContainsWildcards(src) {
+			if matches, err = filepath.Glob(path.Join(root, src)); err != nil {
+				return err
+			}
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("%s: no such file or directory", src)
+		}
+		for _, match := range matches {
+			// The links in the image of the stage are resolved within it
+			origPath, err := symlink.FollowSymlinkInScope(match, root)
+			if err != nil {
+				return err
+			}
+			if err := addToContainer(container, origPath, src, dest, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return b.commit(container.ID, cmd, fmt.Sprintf("COPY --from=%s %s in %s", stage, strings.Join(srcs, " "), dest))
+}
+
 func copyAsDirectory(source, destination string, destExisted bool) error {
 	if err := chrootarchive.CopyWithTar(source, destination); err != nil {
 		return err
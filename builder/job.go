@@ -57,16 +57,21 @@ func (b *BuilderJob) CmdBuild(job *engine.Job) engine.Status {
 		rm             = job.GetenvBool("rm")
 		forceRm        = job.GetenvBool("forcerm")
 		pull           = job.GetenvBool("pull")
+		target         = strings.ToLower(job.Getenv("target"))
 		memory         = job.GetenvInt64("memory")
 		memorySwap     = job.GetenvInt64("memswap")
 		cpuShares      = job.GetenvInt64("cpushares")
 		cpuSetCpus     = job.Getenv("cpusetcpus")
+		buildArgs      = map[string]string{}
 		authConfig     = &registry.AuthConfig{}
 		configFile     = &registry.ConfigFile{}
 		tag            string
 		context        io.ReadCloser
 	)
 
+	if err := job.GetenvJson("buildargs", &buildArgs); err != nil {
+		return job.Error(err)
+	}
 	job.GetenvJson("authConfig", authConfig)
 	job.GetenvJson("configFile", configFile)
 
@@ -149,6 +154,8 @@ func (b *BuilderJob) CmdBuild(job *engine.Job) engine.Status {
 		AuthConfig:      authConfig,
 		AuthConfigFile:  configFile,
 		dockerfileName:  dockerfileName,
+		target:          target,
+		buildArgs:       buildArgs,
 		cpuShares:       cpuShares,
 		cpuSetCpus:      cpuSetCpus,
 		memory:          memory,
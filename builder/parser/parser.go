@@ -52,7 +52,7 @@ func init() {
 		command.Env:        parseEnv,
 		command.Label:      parseLabel,
 		command.Maintainer: parseString,
-		command.From:       parseString,
+		command.From:       parseStringsWhitespaceDelimited,
 		command.Add:        parseMaybeJSONToList,
 		command.Copy:       parseMaybeJSONToList,
 		command.Run:        parseMaybeJSON,
@@ -61,6 +61,7 @@ func init() {
 		command.Expose:     parseStringsWhitespaceDelimited,
 		command.Volume:     parseMaybeJSONToList,
 		command.Insert:     parseIgnore,
+		command.Arg:        parseString,
 	}
 }
 
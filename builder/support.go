@@ -2,7 +2,11 @@ package builder
 
 import (
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/docker/docker/builder/command"
+	"github.com/docker/docker/builder/parser"
 )
 
 var (
@@ -12,8 +16,29 @@ var (
 	// `{[[:alnum:]_]+}` - match things like `${SOME_VAR}`
 	tokenEnvInterpolation = regexp.MustCompile(`(\\|\\\\+|[^\\]|\b|\A)\$([[:alnum:]_]+|{[[:alnum:]_]+})`)
 	// this intentionally punts on more exotic interpolations like ${SOME_VAR%suffix} and lets the shell handle those directly
+
+	// validStageName matches the names given to build stages with FROM ... AS
+	validStageName = regexp.MustCompile(`^[a-z][a-z0-9_.-]*$`)
 )
 
+// hasStage returns whether the Dockerfile has a stage named name, with
+// FROM image AS name
+func hasStage(dockerfile *parser.Node, name string) bool {
+	for _, n := range dockerfile.Children {
+		if n.Value != command.From {
+			continue
+		}
+		var args []string
+		for next := n.Next; next != nil; next = next.Next {
+			args = append(args, next.Value)
+		}
+		if len(args) == 3 && strings.EqualFold(args[1], "AS") && strings.EqualFold(args[2], name) {
+			return true
+		}
+	}
+	return false
+}
+
 // handle environment replacement. Used in dispatcher.
 func (b *Builder) replaceEnv(str string) string {
 	for _, match := range tokenEnvInterpolation.FindAllString(str, -1) {
@@ -33,7 +58,7 @@ func (b *Builder) replaceEnv(str string) string {
 		match = match[strings.Index(match, "$"):]
 		matchKey := strings.Trim(match, "${}")
 
-		for _, keyval := range b.Config.Env {
+		for _, keyval := range b.buildEnv() {
 			tmp := strings.SplitN(keyval, "=", 2)
 			if tmp[0] == matchKey {
 				str = strings.Replace(str, match, tmp[1], -1)
@@ -45,6 +70,31 @@ func (b *Builder) replaceEnv(str string) string {
 	return str
 }
 
+// buildEnv returns the environment of the build: the one of the config, and
+// the build args declared which it doesn't override
+func (b *Builder) buildEnv() []string {
+	if len(b.args) == 0 {
+		return b.Config.Env
+	}
+	env := append([]string{}, b.Config.Env...)
+	set := make(map[string]struct{}, len(env))
+	for _, keyval := range env {
+		set[strings.SplitN(keyval, "=", 2)[0]] = struct{}{}
+	}
+	names := make([]string, 0, len(b.args))
+	for name := range b.args {
+		names = append(names, name)
+	}
+	// The environment is part of the cache key of RUN
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := set[name]; !ok {
+			env = append(env, name+"="+b.args[name])
+		}
+	}
+	return env
+}
+
 func handleJsonArgs(args []string, attributes map[string]bool) []string {
 	if len(args) == 0 {
 		return []string{}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger/jsonfilelog"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/jsonlog"
 	"github.com/docker/docker/pkg/promise"
@@ -35,7 +36,11 @@ func (daemon *Daemon) ContainerAttach(job *engine.Job) engine.Status {
 
 	//logs
 	if logs {
-		cLog, err := container.ReadLog("json")
+		pth, err := container.logPath("json")
+		if err != nil {
+			return job.Error(err)
+		}
+		cLog, closeLog, err := jsonfilelog.ReadLogs(pth)
 		if err != nil && os.IsNotExist(err) {
 			// Legacy logs
 			log.Debugf("Old logs format")
@@ -75,6 +80,7 @@ func (daemon *Daemon) ContainerAttach(job *engine.Job) engine.Status {
 					io.WriteString(job.Stderr, l.Log)
 				}
 			}
+			closeLog()
 		}
 	}
 
@@ -85,12 +91,19 @@ func (daemon *Daemon) ContainerAttach(job *engine.Job) engine.Status {
 			cStdout, cStderr io.Writer
 		)
 
+		idle := false
 		if stdin {
+			var src io.Reader = job.Stdin
+			if container.Config.IdleTimeout > 0 && container.Config.OpenStdin {
+				container.attachIdle()
+				idle = true
+				src = &idleReader{container: container, src: src}
+			}
 			r, w := io.Pipe()
 			go func() {
 				defer w.Close()
 				defer log.Debugf("Closing buffered stdin pipe")
-				io.Copy(w, job.Stdin)
+				io.Copy(w, src)
 			}()
 			cStdin = r
 		}
@@ -102,6 +115,10 @@ func (daemon *Daemon) ContainerAttach(job *engine.Job) engine.Status {
 		}
 
 		<-daemon.Attach(&container.StreamConfig, container.Config.OpenStdin, container.Config.StdinOnce, container.Config.Tty, cStdin, cStdout, cStderr)
+		// the client is gone or detached, there is nobody left to be idle
+		if idle {
+			container.detachIdle()
+		}
 		// If we are in stdinonce mode, wait for the process to end
 		// otherwise, simply return
 		if container.Config.StdinOnce && !container.Config.Tty {
@@ -212,3 +229,81 @@ func (daemon *Daemon) Attach(streamConfig *StreamConfig, openStdin, stdinOnce, t
 		return nil
 	})
 }
+
+// idleTimer kills a container when none of the clients attached to its stdin
+// sent input for the container's idle timeout. It only runs while a client is
+// attached, and the input of every client resets it.
+type idleTimer struct {
+	mu       sync.Mutex
+	timer    *time.Timer
+	attached int
+}
+
+// attachIdle starts the idle timer of the container for the first client
+// attached to its stdin
+func (container *Container) attachIdle() {
+	t := &container.idle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.attached++
+	if t.attached > 1 {
+		return
+	}
+	if t.timer == nil {
+		t.timer = time.AfterFunc(container.Config.IdleTimeout, container.killIdle)
+	} else {
+		t.timer.Reset(container.Config.IdleTimeout)
+	}
+}
+
+// detachIdle stops the idle timer of the container once the last client
+// attached to its stdin is gone
+func (container *Container) detachIdle() {
+	t := &container.idle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.attached--
+	if t.attached == 0 {
+		t.timer.Stop()
+	}
+}
+
+// resetIdle restarts the idle timer of the container on input
+func (container *Container) resetIdle() {
+	t := &container.idle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.attached > 0 {
+		t.timer.Reset(container.Config.IdleTimeout)
+	}
+}
+
+func (container *Container) killIdle() {
+	t := &container.idle
+	t.mu.Lock()
+	attached := t.attached
+	t.mu.Unlock()
+	// the last client detached as the timer fired
+	if attached == 0 {
+		return
+	}
+	log.Infof("Killing container %s: no input for %s", container.ID, container.Config.IdleTimeout)
+	if err := container.Kill(); err != nil {
+		log.Errorf("Error killing idle container %s: %s", container.ID, err)
+	}
+}
+
+// idleReader is the stdin of an attached client, resetting the idle timer of
+// the container on every read
+type idleReader struct {
+	container *Container
+	src       io.Reader
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+	if n > 0 {
+		r.container.resetIdle()
+	}
+	return n, err
+}
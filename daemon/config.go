@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"net"
+	"time"
 
 	"github.com/docker/docker/daemon/networkdriver"
 	"github.com/docker/docker/opts"
@@ -49,6 +50,9 @@ type Config struct {
 	Labels                      []string
 	Ulimits                     map[string]*ulimit.Ulimit
 	LogConfig                   runconfig.LogConfig
+	MaxConcurrentDownloads      int
+	LiveRestore                 bool
+	LinkHealthTimeout           time.Duration
 }
 
 // InstallFlags adds command-line options to the top-level flag parser for
@@ -83,7 +87,10 @@ func (config *Config) InstallFlags() {
 	opts.LabelListVar(&config.Labels, []string{"-label"}, "Set key=value labels to the daemon")
 	config.Ulimits = make(map[string]*ulimit.Ulimit)
 	opts.UlimitMapVar(config.Ulimits, []string{"-default-ulimit"}, "Set default ulimits for containers")
-	flag.StringVar(&config.LogConfig.Type, []string{"-log-driver"}, "json-file", "Containers logging driver(json-file/none)")
+	flag.StringVar(&config.LogConfig.Type, []string{"-log-driver"}, "json-file", "Containers logging driver(json-file/syslog/none)")
+	flag.IntVar(&config.MaxConcurrentDownloads, []string{"-max-concurrent-downloads"}, 3, "Set the max concurrent downloads for each pull")
+	flag.BoolVar(&config.LiveRestore, []string{"-live-restore"}, false, "Leave containers running when the daemon shuts down")
+	flag.DurationVar(&config.LinkHealthTimeout, []string{"-link-health-timeout"}, time.Minute, "Time to wait for a container linked to with :healthy to be healthy")
 }
 
 func getDefaultNetworkMtu() int {
@@ -22,7 +22,6 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/daemon/logger"
-	"github.com/docker/docker/daemon/logger/jsonfilelog"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/links"
@@ -35,6 +34,7 @@ import (
 	"github.com/docker/docker/pkg/networkfs/etchosts"
 	"github.com/docker/docker/pkg/networkfs/resolvconf"
 	"github.com/docker/docker/pkg/promise"
+	"github.com/docker/docker/pkg/reaper"
 	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/runconfig"
@@ -88,8 +88,15 @@ type Container struct {
 	daemon                   *Daemon
 	MountLabel, ProcessLabel string
 	AppArmorProfile          string
+	SeccompProfile           string
 	RestartCount             int
 	UpdateDns                bool
+	// ManuallyStopped is set when the container is stopped or killed by the
+	// user, so its restart policy doesn't start it again when the daemon boots
+	ManuallyStopped bool
+	// HealthyLinks are the aliases of the links whose container must be
+	// healthy before the container starts
+	HealthyLinks []string
 
 	// Maps container paths to volume paths.  The key in this is the path to which
 	// the volume is being mounted inside the container.  Value is the path of the
@@ -103,6 +110,21 @@ type Container struct {
 	activeLinks  map[string]*links.Link
 	monitor      *containerMonitor
 	execCommands *execStore
+	// restarting is set while Restart starts the container, which logs a
+	// restart event instead of a start event
+	restarting bool
+	// reattached is set when the daemon took back the process of the container
+	// from the previous daemon on boot. It only follows the process, which is
+	// signalled by its pid since the exec driver doesn't know it.
+	reattached bool
+	// healthStop is closed to stop the health check of the container
+	healthStop chan struct{}
+	// idle kills the container when no attached client sends input for its
+	// idle timeout
+	idle idleTimer
+	// healthChanged is closed when the health status of the container
+	// changes, to wake the containers waiting for it to be healthy
+	healthChanged chan struct{}
 	// logDriver for closing
 	logDriver          logger.Logger
 	logCopier          *logger.Copier
@@ -220,7 +242,12 @@ func populateCommand(c *Container, env []string) error {
 		Interface: nil,
 	}
 
-	parts := strings.SplitN(string(c.hostConfig.NetworkMode), ":", 2)
+	mode := string(c.hostConfig.NetworkMode)
+	if c.hostConfig.NetworkMode.IsUserDefined() {
+		// The veth pair is put on the bridge of the network
+		mode = "bridge"
+	}
+	parts := strings.SplitN(mode, ":", 2)
 	switch parts[0] {
 	case "none":
 	case "host":
@@ -263,7 +290,16 @@ func populateCommand(c *Container, env []string) error {
 	}
 
 	pid := &execdriver.Pid{}
-	pid.HostPid = c.hostConfig.PidMode.IsHost()
+
+	if c.hostConfig.PidMode.IsContainer() {
+		pc, err := c.getPidContainer()
+		if err != nil {
+			return err
+		}
+		pid.ContainerID = pc.ID
+	} else {
+		pid.HostPid = c.hostConfig.PidMode.IsHost()
+	}
 
 	// Build lists of devices allowed and created within the container.
 	userSpecifiedDevices := make([]*configs.Device, len(c.hostConfig.Devices))
@@ -307,12 +343,22 @@ func populateCommand(c *Container, env []string) error {
 		rlimits = append(rlimits, rl)
 	}
 
+	readBps, err := blkioThrottleDevices(c.hostConfig.BlkioDeviceReadBps)
+	if err != nil {
+		return err
+	}
+
 	resources := &execdriver.Resources{
-		Memory:     c.hostConfig.Memory,
-		MemorySwap: c.hostConfig.MemorySwap,
-		CpuShares:  c.hostConfig.CpuShares,
-		CpusetCpus: c.hostConfig.CpusetCpus,
-		Rlimits:    rlimits,
+		Memory:         c.hostConfig.Memory,
+		MemorySwap:     c.hostConfig.MemorySwap,
+		CpuShares:      c.hostConfig.CpuShares,
+		CpusetCpus:     c.hostConfig.CpusetCpus,
+		OomKillDisable: c.hostConfig.OomKillDisable,
+		PidsLimit:      c.hostConfig.PidsLimit,
+		Rlimits:        rlimits,
+
+		BlkioWeight:                c.hostConfig.BlkioWeight,
+		BlkioThrottleReadBpsDevice: readBps,
 	}
 
 	processConfig := execdriver.ProcessConfig{
@@ -323,6 +369,12 @@ func populateCommand(c *Container, env []string) error {
 		User:       c.Config.User,
 	}
 
+	if c.hostConfig.Init {
+		// The init mounted by setupMounts runs the command as its child
+		processConfig.Entrypoint = reaper.InitPath
+		processConfig.Arguments = append([]string{c.Path}, c.Args...)
+	}
+
 	processConfig.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
 	processConfig.Env = env
 
@@ -345,13 +397,24 @@ func populateCommand(c *Container, env []string) error {
 		MountLabel:         c.GetMountLabel(),
 		LxcConfig:          lxcConfig,
 		AppArmorProfile:    c.AppArmorProfile,
+		SeccompProfile:     c.SeccompProfile,
 		CgroupParent:       c.hostConfig.CgroupParent,
+		Sysctls:            c.hostConfig.Sysctls,
+		Domainname:         c.Config.Domainname,
+		OomScoreAdj:        c.hostConfig.OomScoreAdj,
+		KeepRunning:        c.daemon.config.LiveRestore && !c.Config.Tty,
 	}
 
 	return nil
 }
 
 func (container *Container) Start() (err error) {
+	// The containers linked to with :healthy are waited for before anything
+	// is set up, without holding the lock of the container
+	if err := container.waitHealthyLinks(); err != nil {
+		return err
+	}
+
 	container.Lock()
 	defer container.Unlock()
 
@@ -385,6 +448,9 @@ func (container *Container) Start() (err error) {
 	if err := container.updateParentsHosts(); err != nil {
 		return err
 	}
+	if err := container.updateNetworkPeersHosts(true); err != nil {
+		return err
+	}
 	container.verifyDaemonSettings()
 	if err := container.prepareVolumes(); err != nil {
 		return err
@@ -432,18 +498,23 @@ func (container *Container) Output() (output []byte, err error) {
 // which can be used to retrieve the standard output (and error) generated
 // by the container's active process. The output (and error) are actually
 // copied and delivered to all StdoutPipe and StderrPipe consumers, using
-// a kind of "broadcaster".
+// a kind of "broadcaster". They return io.EOF once the process exits.
 
 func (streamConfig *StreamConfig) StdinPipe() io.WriteCloser {
 	return streamConfig.stdinPipe
 }
 
+// StdoutPipe returns a reader of the standard output of the container's
+// active process, which doesn't need to be attached to. It must be obtained
+// before the container is started to get all the output, and closed when
+// done to stop receiving it.
 func (streamConfig *StreamConfig) StdoutPipe() io.ReadCloser {
 	reader, writer := io.Pipe()
 	streamConfig.stdout.AddWriter(writer, "")
 	return ioutils.NewBufReader(reader)
 }
 
+// StderrPipe is like StdoutPipe for the standard error of the process.
 func (streamConfig *StreamConfig) StderrPipe() io.ReadCloser {
 	reader, writer := io.Pipe()
 	streamConfig.stderr.AddWriter(writer, "")
@@ -501,6 +572,14 @@ func (container *Container) buildHostsFiles(IP string) error {
 		extraContent = append(extraContent, etchosts.Record{Hosts: aliasList, IP: child.NetworkSettings.IPAddress})
 	}
 
+	peers, err := container.networkPeers()
+	if err != nil {
+		return err
+	}
+	for peer, ip := range peers {
+		extraContent = append(extraContent, peer.hostsRecord(ip))
+	}
+
 	for _, extraHost := range container.hostConfig.ExtraHosts {
 		// allow IPv6 addresses in extra hosts; only split on first ":"
 		parts := strings.SplitN(extraHost, ":", 2)
@@ -532,6 +611,13 @@ func (container *Container) AllocateNetwork() error {
 
 	job := eng.Job("allocate_interface", container.ID)
 	job.Setenv("RequestedMac", container.Config.MacAddress)
+	if mode.IsUserDefined() {
+		job.Setenv("Network", string(mode))
+	}
+	if ip := container.hostConfig.IPAddress; ip != "" {
+		job.Setenv("RequestedIP", ip)
+		job.SetenvBool("ReserveIP", true)
+	}
 	if env, err = job.Stdout.AddEnv(); err != nil {
 		return err
 	}
@@ -632,6 +718,10 @@ func (container *Container) RestoreNetwork() error {
 	job := eng.Job("allocate_interface", container.ID)
 	job.Setenv("RequestedIP", container.NetworkSettings.IPAddress)
 	job.Setenv("RequestedMac", container.NetworkSettings.MacAddress)
+	if mode.IsUserDefined() {
+		job.Setenv("Network", string(mode))
+	}
+	job.SetenvBool("ReserveIP", container.hostConfig.IPAddress != "")
 	if err := job.Run(); err != nil {
 		return err
 	}
@@ -648,6 +738,9 @@ func (container *Container) RestoreNetwork() error {
 // cleanup releases any network resources allocated to the container along with any rules
 // around how containers are linked together.  It also unmounts the container's root filesystem.
 func (container *Container) cleanup() {
+	if err := container.updateNetworkPeersHosts(false); err != nil {
+		log.Errorf("%v: Failed to update /etc/hosts of the containers on its network: %v", container.ID, err)
+	}
 	container.ReleaseNetwork()
 
 	// Disable all active links
@@ -682,7 +775,9 @@ func (container *Container) KillSig(sig int) error {
 
 	// signal to the monitor that it should not restart the container
 	// after we send the kill signal
-	container.monitor.ExitOnNext()
+	if container.monitor != nil {
+		container.monitor.ExitOnNext()
+	}
 
 	// if the container is currently restarting we do not need to send the signal
 	// to the process.  Telling the monitor that it should exit on it's next event
@@ -724,6 +819,45 @@ func (container *Container) Unpause() error {
 	return container.daemon.Unpause(container)
 }
 
+// UpdateResources changes the resource limits of the container to those of
+// hostConfig, in its cgroups first when it is running, and saves them.
+func (container *Container) UpdateResources(hostConfig *runconfig.HostConfig) error {
+	container.Lock()
+	defer container.Unlock()
+
+	if container.Running {
+		if container.reattached {
+			return container.errReattached("update")
+		}
+		resources := container.command.Resources
+		previous := *resources
+		resources.Memory = hostConfig.Memory
+		resources.MemorySwap = hostConfig.MemorySwap
+		resources.CpuShares = hostConfig.CpuShares
+		resources.CpusetCpus = hostConfig.CpusetCpus
+		resources.PidsLimit = hostConfig.PidsLimit
+		resources.BlkioWeight = hostConfig.BlkioWeight
+		readBps, err := blkioThrottleDevices(hostConfig.BlkioDeviceReadBps)
+		if err != nil {
+			return err
+		}
+		resources.BlkioThrottleReadBpsDevice = readBps
+		if err := container.daemon.execDriver.Update(container.command); err != nil {
+			*resources = previous
+			return err
+		}
+	}
+
+	container.hostConfig.Memory = hostConfig.Memory
+	container.hostConfig.MemorySwap = hostConfig.MemorySwap
+	container.hostConfig.CpuShares = hostConfig.CpuShares
+	container.hostConfig.CpusetCpus = hostConfig.CpusetCpus
+	container.hostConfig.PidsLimit = hostConfig.PidsLimit
+	container.hostConfig.BlkioWeight = hostConfig.BlkioWeight
+	container.hostConfig.BlkioDeviceReadBps = hostConfig.BlkioDeviceReadBps
+	return container.toDisk()
+}
+
 func (container *Container) Kill() error {
 	if !container.IsRunning() {
 		return nil
@@ -752,14 +886,33 @@ func (container *Container) Kill() error {
 	return nil
 }
 
+// DefaultStopTimeout is the number of seconds Stop waits by default for the
+// container to exit after its stop signal before killing it.
+const DefaultStopTimeout = 10
+
+// stopSignal returns the signal sent to stop the container: its StopSignal,
+// or SIGTERM
+func (container *Container) stopSignal() int {
+	if container.Config.StopSignal != "" {
+		if sig, err := parseSignal(container.Config.StopSignal); err == nil {
+			return int(sig)
+		}
+	}
+	return int(syscall.SIGTERM)
+}
+
+// Stop sends the stop signal, SIGTERM by default, to the container's main
+// process and waits up to seconds for it to exit. Only a process still
+// running after that grace period is killed with SIGKILL.
 func (container *Container) Stop(seconds int) error {
 	if !container.IsRunning() {
 		return nil
 	}
 
-	// 1. Send a SIGTERM
-	if err := container.killPossiblyDeadProcess(15); err != nil {
-		log.Infof("Failed to send SIGTERM to the process, force killing")
+	// 1. Send the stop signal, SIGTERM by default
+	stopSignal := container.stopSignal()
+	if err := container.killPossiblyDeadProcess(stopSignal); err != nil {
+		log.Infof("Failed to send signal %d to the process, force killing", stopSignal)
 		if err := container.killPossiblyDeadProcess(9); err != nil {
 			return err
 		}
@@ -767,7 +920,7 @@ func (container *Container) Stop(seconds int) error {
 
 	// 2. Wait for the process to exit on its own
 	if _, err := container.WaitStop(time.Duration(seconds) * time.Second); err != nil {
-		log.Infof("Container %v failed to exit within %d seconds of SIGTERM - using the force", container.ID, seconds)
+		log.Infof("Container %v failed to exit within %d seconds of signal %d - using the force", container.ID, seconds, stopSignal)
 		// 3. If it doesn't, then send SIGKILL
 		if err := container.Kill(); err != nil {
 			container.WaitStop(-1 * time.Second)
@@ -777,6 +930,28 @@ func (container *Container) Stop(seconds int) error {
 	return nil
 }
 
+// setManuallyStopped records whether the user stopped the container, and saves
+// it to disk
+func (container *Container) setManuallyStopped(stopped bool) {
+	if container.ManuallyStopped == stopped {
+		return
+	}
+	container.ManuallyStopped = stopped
+	if err := container.ToDisk(); err != nil {
+		log.Errorf("Error saving container %s to disk: %v", container.ID, err)
+	}
+}
+
+// shouldRestartOnBoot returns whether the restart policy of the container asks
+// for it to be started when the daemon boots
+func (container *Container) shouldRestartOnBoot() bool {
+	if container.ManuallyStopped || container.IsRunning() {
+		return false
+	}
+	policy := container.hostConfig.RestartPolicy
+	return policy.Name == "always" || (policy.Name == "on-failure" && container.ExitCode != 0)
+}
+
 func (container *Container) Restart(seconds int) error {
 	// Avoid unnecessarily unmounting and then directly mounting
 	// the container when the container stops and then starts
@@ -788,6 +963,8 @@ func (container *Container) Restart(seconds int) error {
 	if err := container.Stop(seconds); err != nil {
 		return err
 	}
+	container.restarting = true
+	defer func() { container.restarting = false }()
 	return container.Start()
 }
 
@@ -795,6 +972,9 @@ func (container *Container) Resize(h, w int) error {
 	if !container.IsRunning() {
 		return fmt.Errorf("Cannot resize container %s, container is not running", container.ID)
 	}
+	if container.reattached {
+		return container.errReattached("resize")
+	}
 	return container.command.ProcessConfig.Terminal.Resize(h, w)
 }
 
@@ -1070,11 +1250,14 @@ func (container *Container) setupContainerDns() error {
 			} else if len(daemon.config.DnsSearch) > 0 {
 				dnsSearch = daemon.config.DnsSearch
 			}
-			return resolvconf.Build(container.ResolvConfPath, dns, dnsSearch)
+			return resolvconf.Build(container.ResolvConfPath, dns, dnsSearch, config.DnsOptions)
 		}
 
 		// replace any localhost/127.*, and remove IPv6 nameservers if IPv6 disabled in daemon
 		resolvConf, _ = resolvconf.FilterResolvDns(resolvConf, daemon.config.EnableIPv6)
+		if len(config.DnsOptions) > 0 {
+			return resolvconf.Build(container.ResolvConfPath, resolvconf.GetNameservers(resolvConf), resolvconf.GetSearchDomains(resolvConf), config.DnsOptions)
+		}
 	}
 	//get a sha256 hash of the resolv conf at this point so we can check
 	//for changes when the host resolv.conf changes (e.g. network update)
@@ -1184,6 +1367,74 @@ func (container *Container) updateParentsHosts() error {
 	return nil
 }
 
+// networkPeers returns the other containers attached to the user-defined
+// network of the container, with their IP address
+func (container *Container) networkPeers() (map[*Container]string, error) {
+	mode := container.hostConfig.NetworkMode
+	if !mode.IsUserDefined() || container.Config.NetworkDisabled {
+		return nil, nil
+	}
+	job := container.daemon.eng.Job("network_inspect", string(mode))
+	network, err := job.Stdout.AddEnv()
+	if err != nil {
+		return nil, err
+	}
+	if err := job.Run(); err != nil {
+		return nil, err
+	}
+	var attached map[string]struct{ IPAddress string }
+	if err := network.GetJson("Containers", &attached); err != nil {
+		return nil, err
+	}
+	peers := make(map[*Container]string)
+	for id, iface := range attached {
+		if id == container.ID {
+			continue
+		}
+		if c, err := container.daemon.Get(id); err == nil {
+			peers[c] = iface.IPAddress
+		}
+	}
+	return peers, nil
+}
+
+// hostsRecord resolves the name and hostname of the container to ip
+func (container *Container) hostsRecord(ip string) etchosts.Record {
+	hosts := container.Name[1:]
+	if container.Config.Hostname != hosts {
+		hosts += " " + container.Config.Hostname
+	}
+	return etchosts.Record{Hosts: hosts, IP: ip}
+}
+
+// updateNetworkPeersHosts adds, or removes, the container to /etc/hosts of
+// the other containers on its network, so they resolve it by name
+func (container *Container) updateNetworkPeersHosts(add bool) error {
+	ip := container.NetworkSettings.IPAddress
+	if ip == "" {
+		return nil
+	}
+	peers, err := container.networkPeers()
+	if err != nil {
+		return err
+	}
+	record := container.hostsRecord(ip)
+	for peer := range peers {
+		if peer.HostsPath == "" {
+			continue
+		}
+		if add {
+			err = etchosts.Add(peer.HostsPath, []etchosts.Record{record})
+		} else {
+			err = etchosts.Delete(peer.HostsPath, []etchosts.Record{record})
+		}
+		if err != nil {
+			log.Errorf("Failed to update /etc/hosts in container %s for %s: %v", peer.ID, container.Name, err)
+		}
+	}
+	return nil
+}
+
 func (container *Container) initializeNetworking() error {
 	var err error
 	if container.hostConfig.NetworkMode.IsHost() {
@@ -1280,7 +1531,6 @@ func (container *Container) setupLinkedContainers() ([]string, error) {
 			if !child.IsRunning() {
 				return nil, fmt.Errorf("Cannot link to a non running container: %s AS %s", child.Name, linkAlias)
 			}
-
 			link, err := links.NewLink(
 				container.NetworkSettings.IPAddress,
 				child.NetworkSettings.IPAddress,
@@ -1308,6 +1558,75 @@ func (container *Container) setupLinkedContainers() ([]string, error) {
 	return env, nil
 }
 
+// waitHealthyLinks waits for the containers linked to with :healthy to be
+// healthy, up to the link health timeout of the daemon for all of them
+func (container *Container) waitHealthyLinks() error {
+	if len(container.HealthyLinks) == 0 {
+		return nil
+	}
+	daemon := container.daemon
+	children, err := daemon.Children(container.Name)
+	if err != nil {
+		return err
+	}
+	// the links share the timeout, they are waited for one after the other
+	deadline := time.Now().Add(daemon.config.LinkHealthTimeout)
+	for linkAlias, child := range children {
+		if container.isHealthyLink(path.Base(linkAlias)) {
+			if err := daemon.waitHealthy(child, deadline); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isHealthyLink returns whether the link of the alias waits for its container
+// to be healthy
+func (container *Container) isHealthyLink(alias string) bool {
+	for _, a := range container.HealthyLinks {
+		if a == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// removeHealthyLink forgets that the link of the alias waits for its
+// container to be healthy, once the link is removed
+func (container *Container) removeHealthyLink(alias string) {
+	for i, a := range container.HealthyLinks {
+		if a == alias {
+			container.HealthyLinks = append(container.HealthyLinks[:i], container.HealthyLinks[i+1:]...)
+			if err := container.ToDisk(); err != nil {
+				log.Errorf("Error saving container %s to disk: %v", container.ID, err)
+			}
+			return
+		}
+	}
+}
+
+// blkioThrottleDevices looks up the major and minor numbers of the block
+// devices of IO rate limits
+func blkioThrottleDevices(limits []runconfig.ThrottleDevice) ([]*execdriver.ThrottleDevice, error) {
+	var throttleDevices []*execdriver.ThrottleDevice
+	for _, limit := range limits {
+		device, err := devices.DeviceFromPath(limit.Path, "")
+		if err != nil {
+			return nil, fmt.Errorf("error gathering device information of %q: %s", limit.Path, err)
+		}
+		if device.Type != 'b' {
+			return nil, fmt.Errorf("%s is not a block device", limit.Path)
+		}
+		throttleDevices = append(throttleDevices, &execdriver.ThrottleDevice{
+			Major: device.Major,
+			Minor: device.Minor,
+			Rate:  limit.Rate,
+		})
+	}
+	return throttleDevices, nil
+}
+
 func (container *Container) createDaemonEnvironment(linkedEnv []string) []string {
 	// if a domain name was specified, append it to the hostname (see #7851)
 	fullHostname := container.Config.Hostname
@@ -1339,7 +1658,7 @@ func (container *Container) setupWorkingDirectory() error {
 	if container.Config.WorkingDir != "" {
 		container.Config.WorkingDir = path.Clean(container.Config.WorkingDir)
 
-		pth, err := container.getResourcePath(container.Config.WorkingDir)
+		pth, writable, err := container.getWorkingDirectoryPath()
 		if err != nil {
 			return err
 		}
@@ -1349,6 +1668,9 @@ func (container *Container) setupWorkingDirectory() error {
 			if !os.IsNotExist(err) {
 				return err
 			}
+			if !writable {
+				return fmt.Errorf("Cannot mkdir: %s is in a read only volume", container.Config.WorkingDir)
+			}
 
 			if err := os.MkdirAll(pth, 0755); err != nil {
 				return err
@@ -1361,29 +1683,60 @@ func (container *Container) setupWorkingDirectory() error {
 	return nil
 }
 
+// getWorkingDirectoryPath returns the host path of the working directory and
+// whether it can be created. When the working directory is in a volume, the
+// volume is mounted over the rootfs so the path is in the volume instead.
+func (container *Container) getWorkingDirectoryPath() (string, bool, error) {
+	workingDir := container.Config.WorkingDir
+	var mountPoint string
+	for dest := range container.Volumes {
+		if (workingDir == dest || strings.HasPrefix(workingDir, dest+"/")) && len(dest) > len(mountPoint) {
+			mountPoint = dest
+		}
+	}
+	if mountPoint == "" {
+		pth, err := container.getResourcePath(workingDir)
+		return pth, true, err
+	}
+
+	source := container.Volumes[mountPoint]
+	rel, err := filepath.Rel(mountPoint, workingDir)
+	if err != nil {
+		return "", false, err
+	}
+	pth, err := symlink.FollowSymlinkInScope(filepath.Join(source, rel), source)
+	return pth, container.VolumesRW[mountPoint], err
+}
+
 func (container *Container) startLogging() error {
 	cfg := container.hostConfig.LogConfig
 	if cfg.Type == "" {
 		cfg = container.daemon.defaultLogConfig
 	}
-	var l logger.Logger
-	switch cfg.Type {
-	case "json-file":
-		pth, err := container.logPath("json")
-		if err != nil {
-			return err
-		}
-
-		dl, err := jsonfilelog.New(pth)
-		if err != nil {
-			return err
-		}
-		l = dl
-	case "none":
+	// the none driver discards the output, so it isn't copied at all
+	if cfg.Type == "none" {
 		return nil
-	default:
+	}
+	c, err := logger.GetLogDriver(cfg.Type)
+	if err != nil {
 		return fmt.Errorf("Unknown logging driver: %s", cfg.Type)
 	}
+	pth, err := container.logPath("json")
+	if err != nil {
+		return err
+	}
+	l, err := c(logger.Context{
+		Config:        cfg.Config,
+		ContainerID:   container.ID,
+		ContainerName: container.Name,
+		LogPath:       pth,
+	})
+	if err != nil {
+		return err
+	}
+	if cfg.Type == "json-file" {
+		container.LogPath = pth
+	}
 
 	copier, err := logger.NewCopier(container.ID, map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l)
 	if err != nil {
@@ -1469,6 +1822,18 @@ func (container *Container) getIpcContainer() (*Container, error) {
 	return c, nil
 }
 
+func (container *Container) getPidContainer() (*Container, error) {
+	containerID := container.hostConfig.PidMode.Container()
+	c, err := container.daemon.Get(containerID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsRunning() {
+		return nil, fmt.Errorf("cannot join PID of a non running container: %s", containerID)
+	}
+	return c, nil
+}
+
 func (container *Container) getNetworkedContainer() (*Container, error) {
 	parts := strings.SplitN(string(container.hostConfig.NetworkMode), ":", 2)
 	switch parts[0] {
@@ -1,8 +1,13 @@
 package daemon
 
 import (
-	"github.com/docker/docker/nat"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/docker/docker/nat"
+	"github.com/docker/docker/runconfig"
 )
 
 func TestParseNetworkOptsPrivateOnly(t *testing.T) {
@@ -195,3 +200,50 @@ func TestValidContainerNames(t *testing.T) {
 		}
 	}
 }
+
+func TestGetWorkingDirectoryPath(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-workdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	rootfs := filepath.Join(tmp, "rootfs")
+	data := filepath.Join(tmp, "data")
+	ro := filepath.Join(tmp, "ro")
+	for _, dir := range []string{rootfs, data, ro} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("/", filepath.Join(data, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	container := &Container{
+		basefs:    rootfs,
+		Volumes:   map[string]string{"/data": data, "/data/ro": ro},
+		VolumesRW: map[string]bool{"/data": true, "/data/ro": false},
+		Config:    &runconfig.Config{},
+	}
+	for _, c := range []struct {
+		workingDir string
+		path       string
+		writable   bool
+	}{
+		{"/app", filepath.Join(rootfs, "app"), true},
+		{"/database", filepath.Join(rootfs, "database"), true},
+		{"/data", data, true},
+		{"/data/app", filepath.Join(data, "app"), true},
+		{"/data/escape/app", filepath.Join(data, "app"), true},
+		{"/data/ro/app", filepath.Join(ro, "app"), false},
+	} {
+		container.Config.WorkingDir = c.workingDir
+		pth, writable, err := container.getWorkingDirectoryPath()
+		if err != nil {
+			t.Fatalf("%s: %v", c.workingDir, err)
+		}
+		if pth != c.path || writable != c.writable {
+			t.Fatalf("%s: expected %s (writable %v), got %s (writable %v)", c.workingDir, c.path, c.writable, pth, writable)
+		}
+	}
+}
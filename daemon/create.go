@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/image"
@@ -23,28 +25,7 @@ func (daemon *Daemon) ContainerCreate(job *engine.Job) engine.Status {
 	config := runconfig.ContainerConfigFromJob(job)
 	hostConfig := runconfig.ContainerHostConfigFromJob(job)
 
-	if len(hostConfig.LxcConf) > 0 && !strings.Contains(daemon.ExecutionDriver().Name(), "lxc") {
-		return job.Errorf("Cannot use --lxc-conf with execdriver: %s", daemon.ExecutionDriver().Name())
-	}
-	if hostConfig.Memory != 0 && hostConfig.Memory < 4194304 {
-		return job.Errorf("Minimum memory limit allowed is 4MB")
-	}
-	if hostConfig.Memory > 0 && !daemon.SystemConfig().MemoryLimit {
-		job.Errorf("Your kernel does not support memory limit capabilities. Limitation discarded.\n")
-		hostConfig.Memory = 0
-	}
-	if hostConfig.Memory > 0 && !daemon.SystemConfig().SwapLimit {
-		job.Errorf("Your kernel does not support swap limit capabilities. Limitation discarded.\n")
-		hostConfig.MemorySwap = -1
-	}
-	if hostConfig.Memory > 0 && hostConfig.MemorySwap > 0 && hostConfig.MemorySwap < hostConfig.Memory {
-		return job.Errorf("Minimum memoryswap limit should be larger than memory limit, see usage.\n")
-	}
-	if hostConfig.Memory == 0 && hostConfig.MemorySwap > 0 {
-		return job.Errorf("You should always set the Memory limit when using Memoryswap limit, see usage.\n")
-	}
-
-	container, buildWarnings, err := daemon.Create(config, hostConfig, name)
+	container, warnings, err := daemon.Create(config, hostConfig, name)
 	if err != nil {
 		if daemon.Graph().IsNotExist(err) {
 			_, tag := parsers.ParseRepositoryTag(config.Image)
@@ -62,14 +43,16 @@ func (daemon *Daemon) ContainerCreate(job *engine.Job) engine.Status {
 
 	job.Printf("%s\n", container.ID)
 
-	for _, warning := range buildWarnings {
+	for _, warning := range warnings {
 		job.Errorf("%s\n", warning)
 	}
 
 	return engine.StatusOK
 }
 
-// Create creates a new container from the given configuration with a given name.
+// Create creates a new container from the given configuration with a given
+// name. The configuration is verified as for `docker create`, and the
+// container is registered with the daemon but not started.
 func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.HostConfig, name string) (*Container, []string, error) {
 	var (
 		container *Container
@@ -96,6 +79,11 @@ func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.Hos
 	if hostConfig == nil {
 		hostConfig = &runconfig.HostConfig{}
 	}
+	hostWarnings, err := daemon.verifyHostConfig(hostConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, hostWarnings...)
 	if hostConfig.SecurityOpt == nil {
 		hostConfig.SecurityOpt, err = daemon.GenerateSecurityOpt(hostConfig.IpcMode, hostConfig.PidMode)
 		if err != nil {
@@ -144,5 +132,130 @@ func (daemon *Daemon) GenerateSecurityOpt(ipcMode runconfig.IpcMode, pidMode run
 
 		return label.DupSecOpt(c.ProcessLabel), nil
 	}
+	if pidContainer := pidMode.Container(); pidContainer != "" {
+		c, err := daemon.Get(pidContainer)
+		if err != nil {
+			return nil, err
+		}
+		if !c.IsRunning() {
+			return nil, fmt.Errorf("cannot join PID of a non running container: %s", pidContainer)
+		}
+
+		return label.DupSecOpt(c.ProcessLabel), nil
+	}
 	return nil, nil
 }
+
+// verifyHostConfig checks hostConfig against the daemon's execution driver
+// and the kernel's capabilities. Limits which aren't supported are discarded
+// with a warning.
+func (daemon *Daemon) verifyHostConfig(hostConfig *runconfig.HostConfig) ([]string, error) {
+	if len(hostConfig.LxcConf) > 0 && !strings.Contains(daemon.ExecutionDriver().Name(), "lxc") {
+		return nil, fmt.Errorf("Cannot use --lxc-conf with execdriver: %s", daemon.ExecutionDriver().Name())
+	}
+	if err := execdriver.ValidateCapabilities(hostConfig.CapAdd, hostConfig.CapDrop); err != nil {
+		return nil, err
+	}
+	for _, opt := range hostConfig.SecurityOpt {
+		if profile, ok := seccompSecurityOpt(opt); ok {
+			if _, err := execdriver.ParseSeccompProfile(profile); err != nil {
+				return nil, err
+			}
+		}
+	}
+	warnings, err := daemon.verifyResources(hostConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := runconfig.ValidateSysctls(hostConfig.Sysctls, hostConfig.NetworkMode, hostConfig.IpcMode); err != nil {
+		return nil, err
+	}
+	if err := runconfig.ValidateNetworkPorts(hostConfig.NetworkMode, hostConfig.PortBindings, hostConfig.PublishAllPorts); err != nil {
+		return nil, err
+	}
+	if err := runconfig.ValidateIPAddress(hostConfig.NetworkMode, hostConfig.IPAddress); err != nil {
+		return nil, err
+	}
+	if hostConfig.NetworkMode.IsUserDefined() {
+		if daemon.config.DisableNetwork {
+			return nil, fmt.Errorf("Cannot attach to network %s: the networking of the daemon is disabled", hostConfig.NetworkMode)
+		}
+		if err := daemon.eng.Job("network_inspect", string(hostConfig.NetworkMode)).Run(); err != nil {
+			return nil, err
+		}
+	}
+	if err := runconfig.ValidateTmpfs(hostConfig.Tmpfs); err != nil {
+		return nil, err
+	}
+	if hostConfig.Memory == 0 && hostConfig.OomKillDisable {
+		return nil, fmt.Errorf("You should always set the Memory limit when disabling the OOM killer, see usage.")
+	}
+	if err := runconfig.ValidateOomScoreAdj(hostConfig.OomScoreAdj); err != nil {
+		return nil, err
+	}
+	if err := validateLogConfig(hostConfig.LogConfig); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// verifyResources checks the resource limits of hostConfig, the ones which
+// can be changed with docker update, against the kernel's capabilities.
+// Limits which aren't supported are discarded with a warning.
+func (daemon *Daemon) verifyResources(hostConfig *runconfig.HostConfig) ([]string, error) {
+	var warnings []string
+	if hostConfig.Memory != 0 && hostConfig.Memory < 4194304 {
+		return nil, fmt.Errorf("Minimum memory limit allowed is 4MB")
+	}
+	if hostConfig.Memory > 0 && !daemon.SystemConfig().MemoryLimit {
+		warnings = append(warnings, "Your kernel does not support memory limit capabilities. Limitation discarded.")
+		hostConfig.Memory = 0
+	}
+	if hostConfig.Memory > 0 && !daemon.SystemConfig().SwapLimit {
+		warnings = append(warnings, "Your kernel does not support swap limit capabilities. Limitation discarded.")
+		hostConfig.MemorySwap = -1
+	}
+	if hostConfig.Memory > 0 && hostConfig.MemorySwap > 0 && hostConfig.MemorySwap < hostConfig.Memory {
+		return nil, fmt.Errorf("Minimum memoryswap limit should be larger than memory limit, see usage.")
+	}
+	if hostConfig.Memory == 0 && hostConfig.MemorySwap != 0 {
+		return nil, fmt.Errorf("You should always set the Memory limit when using Memoryswap limit, see usage.")
+	}
+	if hostConfig.PidsLimit > 0 && !daemon.SystemConfig().PidsLimit {
+		warnings = append(warnings, "Your kernel does not support pids limit capabilities. Limitation discarded.")
+		hostConfig.PidsLimit = 0
+	}
+	if err := runconfig.ValidateBlkioWeight(hostConfig.BlkioWeight); err != nil {
+		return nil, err
+	}
+	if _, err := blkioThrottleDevices(hostConfig.BlkioDeviceReadBps); err != nil {
+		return nil, err
+	}
+	if hostConfig.BlkioWeight > 0 && !daemon.SystemConfig().BlkioWeight {
+		warnings = append(warnings, "Your kernel does not support block IO weight. Weight discarded.")
+		hostConfig.BlkioWeight = 0
+	}
+	if len(hostConfig.BlkioDeviceReadBps) > 0 && !daemon.SystemConfig().BlkioReadBpsDevice {
+		warnings = append(warnings, "Your kernel does not support block IO read limits. Limits discarded.")
+		hostConfig.BlkioDeviceReadBps = nil
+	}
+	return warnings, nil
+}
+
+// validateLogConfig checks that the logging driver of a container exists and
+// accepts its options. An empty type selects the daemon's driver.
+func validateLogConfig(cfg runconfig.LogConfig) error {
+	switch cfg.Type {
+	case "":
+		return nil
+	case "none":
+		for key := range cfg.Config {
+			return fmt.Errorf("unknown log opt '%s' for none log driver", key)
+		}
+		return nil
+	}
+	if _, err := logger.GetLogDriver(cfg.Type); err != nil {
+		return fmt.Errorf("Unknown logging driver: %s", cfg.Type)
+	}
+	return logger.ValidateLogOpts(cfg.Type, cfg.Config)
+}
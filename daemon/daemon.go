@@ -10,8 +10,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/docker/libcontainer/label"
@@ -120,6 +122,7 @@ func (daemon *Daemon) Install(eng *engine.Engine) error {
 		"container_rename":  daemon.ContainerRename,
 		"container_inspect": daemon.ContainerInspect,
 		"container_stats":   daemon.ContainerStats,
+		"container_update":  daemon.ContainerUpdate,
 		"containers":        daemon.Containers,
 		"create":            daemon.ContainerCreate,
 		"rm":                daemon.ContainerRm,
@@ -136,6 +139,9 @@ func (daemon *Daemon) Install(eng *engine.Engine) error {
 		"unpause":           daemon.ContainerUnpause,
 		"wait":              daemon.ContainerWait,
 		"image_delete":      daemon.ImageDelete, // FIXME: see above
+		"image_prune":       daemon.ImagePrune,
+		"container_prune":   daemon.ContainerPrune,
+		"system_df":         daemon.SystemDiskUsage,
 		"execCreate":        daemon.ContainerExecCreate,
 		"execStart":         daemon.ContainerExecStart,
 		"execResize":        daemon.ContainerExecResize,
@@ -259,6 +265,23 @@ func (daemon *Daemon) register(container *Container, updateSuffixarray bool) err
 	// FIXME: if the container is supposed to be running but is not, auto restart it?
 	//        if so, then we need to restart monitor and init a new lock
 	// If the container is supposed to be running, make sure of it
+	if container.IsRunning() && daemon.config.LiveRestore {
+		if container.processAlive() {
+			return daemon.reattach(container)
+		}
+		// The process died while no daemon was running, without telling
+		// its exit code
+		log.Debugf("running container %s exited while the daemon was down", container.ID)
+		// Nothing cleaned up after it, its state would keep the exec
+		// driver from starting it again
+		if err := daemon.execDriver.Clean(container.ID); err != nil {
+			log.Debugf("cleaning the exec driver state of %s: %s", container.ID, err)
+		}
+		container.SetStopped(&execdriver.ExitStatus{ExitCode: -1})
+		if err := container.ToDisk(); err != nil {
+			log.Debugf("saving stopped state to disk %s", err)
+		}
+	}
 	if container.IsRunning() {
 		log.Debugf("killing old running container %s", container.ID)
 
@@ -406,17 +429,7 @@ func (daemon *Daemon) restore() error {
 	// the restart policy of "always"
 	if daemon.config.AutoRestart {
 		log.Debugf("Restarting containers...")
-
-		for _, container := range registeredContainers {
-			if container.hostConfig.RestartPolicy.Name == "always" ||
-				(container.hostConfig.RestartPolicy.Name == "on-failure" && container.ExitCode != 0) {
-				log.Debugf("Starting container %s", container.ID)
-
-				if err := container.Start(); err != nil {
-					log.Debugf("Failed to start container %s: %s", container.ID, err)
-				}
-			}
-		}
+		daemon.restartContainers(registeredContainers)
 	}
 
 	if !debug {
@@ -429,6 +442,70 @@ func (daemon *Daemon) restore() error {
 	return nil
 }
 
+// restartContainers starts the containers whose restart policy asks for it when
+// the daemon boots, the oldest first. The containers a container is linked to
+// are started before it. A container linking to another with :healthy is
+// started in the background once it's healthy, like those linking to it.
+func (daemon *Daemon) restartContainers(containers []*Container) {
+	history := History(containers)
+	history.Sort()
+
+	// done is closed once the start of the container was tried
+	done := make(map[string]chan struct{})
+	var start func(container *Container) chan struct{}
+	start = func(container *Container) chan struct{} {
+		if ch, ok := done[container.ID]; ok {
+			return ch
+		}
+		ch := make(chan struct{})
+		done[container.ID] = ch
+		if !container.shouldRestartOnBoot() {
+			close(ch)
+			return ch
+		}
+
+		children, err := daemon.Children(container.Name)
+		if err != nil {
+			log.Debugf("Failed to get the links of container %s: %s", container.ID, err)
+		}
+		names := make([]string, 0, len(children))
+		for name := range children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var pending []chan struct{}
+		for _, name := range names {
+			childDone := start(children[name])
+			select {
+			case <-childDone:
+			default:
+				pending = append(pending, childDone)
+			}
+		}
+
+		run := func() {
+			defer close(ch)
+			for _, childDone := range pending {
+				<-childDone
+			}
+			log.Debugf("Starting container %s", container.ID)
+			if err := container.Start(); err != nil {
+				log.Debugf("Failed to start container %s: %s", container.ID, err)
+			}
+		}
+		// waiting for a link to be healthy mustn't hold up the boot
+		if len(container.HealthyLinks) > 0 || len(pending) > 0 {
+			go run()
+		} else {
+			run()
+		}
+		return ch
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		start(history[i])
+	}
+}
+
 // set up the watch on the host's /etc/resolv.conf so that we can update container's
 // live resolv.conf when the network changes on the host
 func (daemon *Daemon) setupResolvconfWatcher() error {
@@ -511,6 +588,11 @@ func (daemon *Daemon) mergeAndVerifyConfig(config *runconfig.Config, img *image.
 	if len(config.Entrypoint) == 0 && len(config.Cmd) == 0 {
 		return nil, fmt.Errorf("No command specified")
 	}
+	if config.StopSignal != "" {
+		if _, err := parseSignal(config.StopSignal); err != nil {
+			return nil, err
+		}
+	}
 	return warnings, nil
 }
 
@@ -622,6 +704,10 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 	)
 
 	for _, opt := range config.SecurityOpt {
+		if profile, ok := seccompSecurityOpt(opt); ok {
+			container.SeccompProfile = profile
+			continue
+		}
 		con := strings.SplitN(opt, ":", 2)
 		if len(con) == 1 {
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
@@ -640,6 +726,15 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 	return err
 }
 
+// seccompSecurityOpt returns the profile of a seccomp security option, given as
+// seccomp:profile or seccomp=profile, where the profile is JSON or unconfined
+func seccompSecurityOpt(opt string) (string, bool) {
+	if strings.HasPrefix(opt, "seccomp:") || strings.HasPrefix(opt, "seccomp=") {
+		return opt[len("seccomp:"):], true
+	}
+	return "", false
+}
+
 func (daemon *Daemon) newContainer(name string, config *runconfig.Config, imgID string) (*Container, error) {
 	var (
 		id  string
@@ -768,14 +863,14 @@ func (daemon *Daemon) RegisterLink(parent, child *Container, alias string) error
 func (daemon *Daemon) RegisterLinks(container *Container, hostConfig *runconfig.HostConfig) error {
 	if hostConfig != nil && hostConfig.Links != nil {
 		for _, l := range hostConfig.Links {
-			parts, err := parsers.PartParser("name:alias", l)
+			name, alias, healthy, err := parsers.ParseLink(l)
 			if err != nil {
 				return err
 			}
-			child, err := daemon.Get(parts["name"])
+			child, err := daemon.Get(name)
 			if err != nil {
 				//An error from daemon.Get() means this name could not be found
-				return fmt.Errorf("Could not get container for %s", parts["name"])
+				return fmt.Errorf("Could not get container for %s", name)
 			}
 			for child.hostConfig.NetworkMode.IsContainer() {
 				parts := strings.SplitN(string(child.hostConfig.NetworkMode), ":", 2)
@@ -787,9 +882,12 @@ func (daemon *Daemon) RegisterLinks(container *Container, hostConfig *runconfig.
 			if child.hostConfig.NetworkMode.IsHost() {
 				return runconfig.ErrConflictHostNetworkAndLinks
 			}
-			if err := daemon.RegisterLink(container, child, parts["alias"]); err != nil {
+			if err := daemon.RegisterLink(container, child, alias); err != nil {
 				return err
 			}
+			if healthy && !container.isHealthyLink(alias) {
+				container.HealthyLinks = append(container.HealthyLinks, alias)
+			}
 		}
 
 		// After we load all the links into the daemon
@@ -826,6 +924,12 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		config.EnableIpMasq = false
 	}
 	config.DisableNetwork = config.BridgeIface == disableNetworkBridge
+	if err := validateLogConfig(config.LogConfig); err != nil {
+		return nil, err
+	}
+	if config.MaxConcurrentDownloads < 0 {
+		return nil, fmt.Errorf("Invalid --max-concurrent-downloads %d: use a positive number", config.MaxConcurrentDownloads)
+	}
 
 	// register portallocator release on shutdown
 	eng.OnShutdown(func() {
@@ -892,8 +996,12 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		return nil, fmt.Errorf("error intializing graphdriver: %v", err)
 	}
 	log.Debugf("Using graph driver %s", driver)
-	// register cleanup for graph driver
+	// register cleanup for graph driver, which unmounts the filesystems of
+	// the containers left running too
 	eng.OnShutdown(func() {
+		if config.LiveRestore {
+			return
+		}
 		if err := driver.Cleanup(); err != nil {
 			log.Errorf("Error during graph storage driver.Cleanup(): %v", err)
 		}
@@ -950,6 +1058,7 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 	if err != nil {
 		return nil, fmt.Errorf("Couldn't create Tag store: %s", err)
 	}
+	repositories.MaxConcurrentDownloads = config.MaxConcurrentDownloads
 
 	trustDir := path.Join(config.Root, "trust")
 	if err := os.MkdirAll(trustDir, 0700); err != nil && !os.IsExist(err) {
@@ -973,6 +1082,7 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		job.Setenv("FixedCIDR", config.FixedCIDR)
 		job.Setenv("FixedCIDRv6", config.FixedCIDRv6)
 		job.Setenv("DefaultBindingIP", config.DefaultIp.String())
+		job.Setenv("NetworkRoot", path.Join(config.Root, "network"))
 
 		if err := job.Run(); err != nil {
 			return nil, err
@@ -1016,6 +1126,10 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 	if err != nil {
 		return nil, err
 	}
+	// The lxc driver doesn't leave its containers to the next daemon
+	if config.LiveRestore && strings.HasPrefix(ed.Name(), lxc.DriverName) {
+		return nil, fmt.Errorf("--live-restore isn't supported by the lxc execution driver")
+	}
 
 	daemon := &Daemon{
 		ID:               trustKey.PublicKey().KeyID(),
@@ -1056,21 +1170,31 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 	return daemon, nil
 }
 
+// shutdown stops the running containers like docker stop does, or leaves
+// them running with --live-restore so that the next daemon takes them back.
+// The containers with a TTY are stopped either way, the daemon holding their
+// terminal.
 func (daemon *Daemon) shutdown() error {
 	group := sync.WaitGroup{}
 	log.Debugf("starting clean shutdown of all containers...")
 	for _, container := range daemon.List() {
 		c := container
+		if c.IsRunning() && c.keepRunning() {
+			log.Debugf("leaving %s running for the next daemon", c.ID)
+			if err := c.ToDisk(); err != nil {
+				log.Errorf("Error saving container %s to disk: %v", c.ID, err)
+			}
+			continue
+		}
 		if c.IsRunning() {
 			log.Debugf("stopping %s", c.ID)
 			group.Add(1)
 
 			go func() {
 				defer group.Done()
-				if err := c.KillSig(15); err != nil {
-					log.Debugf("kill 15 error for %s - %s", c.ID, err)
+				if err := c.Stop(DefaultStopTimeout); err != nil {
+					log.Debugf("stop error for %s - %s", c.ID, err)
 				}
-				c.WaitStop(-1 * time.Second)
 				log.Debugf("container stopped %s", c.ID)
 			}()
 		}
@@ -1115,6 +1239,9 @@ func (daemon *Daemon) Run(c *Container, pipes *execdriver.Pipes, startCallback e
 }
 
 func (daemon *Daemon) Pause(c *Container) error {
+	if c.reattached {
+		return c.errReattached("pause")
+	}
 	if err := daemon.execDriver.Pause(c.command); err != nil {
 		return err
 	}
@@ -1123,6 +1250,9 @@ func (daemon *Daemon) Pause(c *Container) error {
 }
 
 func (daemon *Daemon) Unpause(c *Container) error {
+	if c.reattached {
+		return c.errReattached("unpause")
+	}
 	if err := daemon.execDriver.Unpause(c.command); err != nil {
 		return err
 	}
@@ -1131,6 +1261,9 @@ func (daemon *Daemon) Unpause(c *Container) error {
 }
 
 func (daemon *Daemon) Kill(c *Container, sig int) error {
+	if c.reattached {
+		return syscall.Kill(c.Pid, syscall.Signal(sig))
+	}
 	return daemon.execDriver.Kill(c.command, sig)
 }
 
@@ -3,6 +3,8 @@ package daemon
 import (
 	"testing"
 
+	"github.com/docker/docker/nat"
+	"github.com/docker/docker/pkg/sysinfo"
 	"github.com/docker/docker/runconfig"
 )
 
@@ -19,6 +21,22 @@ func TestParseSecurityOpt(t *testing.T) {
 		t.Fatalf("Unexpected AppArmorProfile, expected: \"test_profile\", got %q", container.AppArmorProfile)
 	}
 
+	// test seccomp, with both separators
+	config.SecurityOpt = []string{"seccomp={\"defaultAction\":\"SCMP_ACT_ALLOW\"}"}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != `{"defaultAction":"SCMP_ACT_ALLOW"}` {
+		t.Fatalf("Unexpected SeccompProfile, got %q", container.SeccompProfile)
+	}
+	config.SecurityOpt = []string{"seccomp:unconfined"}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != "unconfined" {
+		t.Fatalf("Unexpected SeccompProfile, expected: \"unconfined\", got %q", container.SeccompProfile)
+	}
+
 	// test valid label
 	config.SecurityOpt = []string{"label:user:USER"}
 	if err := parseSecurityOpt(container, config); err != nil {
@@ -37,3 +55,26 @@ func TestParseSecurityOpt(t *testing.T) {
 		t.Fatal("Expected parseSecurityOpt error, got nil")
 	}
 }
+
+func TestVerifyResourcesOnly(t *testing.T) {
+	daemon := &Daemon{sysInfo: &sysinfo.SysInfo{MemoryLimit: true, SwapLimit: true}}
+
+	// a configuration the current checks reject, as an older daemon may have
+	// created it
+	hostConfig := &runconfig.HostConfig{
+		NetworkMode:  "host",
+		PortBindings: nat.PortMap{"80/tcp": []nat.PortBinding{{HostPort: "80"}}},
+		Memory:       8 * 1024 * 1024,
+	}
+	if _, err := daemon.verifyHostConfig(hostConfig); err != runconfig.ErrConflictNetworkPublishPorts {
+		t.Fatalf("Expected ErrConflictNetworkPublishPorts, got %v", err)
+	}
+	if _, err := daemon.verifyResources(hostConfig); err != nil {
+		t.Fatalf("Expected only the limits to be checked, got %v", err)
+	}
+
+	hostConfig.Memory = 1024
+	if _, err := daemon.verifyResources(hostConfig); err == nil {
+		t.Fatal("Expected a memory limit under 4MB to be rejected")
+	}
+}
@@ -40,6 +40,7 @@ func (daemon *Daemon) ContainerRm(job *engine.Job) engine.Status {
 
 		if parentContainer != nil {
 			parentContainer.DisableLink(n)
+			parentContainer.removeHealthyLink(n)
 		}
 
 		if err := daemon.ContainerGraph().Delete(name); err != nil {
@@ -103,6 +104,11 @@ func (daemon *Daemon) Rm(container *Container) error {
 	if _, err := daemon.containerGraph.Purge(container.ID); err != nil {
 		log.Debugf("Unable to remove container from link graph: %s", err)
 	}
+	if container.hostConfig.IPAddress != "" {
+		if err := daemon.eng.Job("release_static_ip", container.ID).Run(); err != nil {
+			log.Errorf("Unable to release the static IP of %s: %v", container.ID, err)
+		}
+	}
 
 	if err := daemon.driver.Remove(container.ID); err != nil {
 		return fmt.Errorf("Driver %s failed to remove root filesystem %s: %s", daemon.driver, container.ID, err)
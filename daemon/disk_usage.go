@@ -0,0 +1,186 @@
+package daemon
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/directory"
+)
+
+// SystemDiskUsage reports the disk space used by the images, containers and
+// volumes of the daemon, and how much of it pruning them would reclaim.
+//
+// The size of an image is the sum of its layers, which are shared when another
+// image listed has them too. The layers of images used by a container are
+// never reclaimable.
+func (daemon *Daemon) SystemDiskUsage(job *engine.Job) engine.Status {
+	if n := len(job.Args); n != 0 {
+		return job.Errorf("Usage: %s", job.Name)
+	}
+
+	allImages, err := daemon.Graph().Map()
+	if err != nil {
+		return job.Error(err)
+	}
+	heads, err := daemon.Graph().Heads()
+	if err != nil {
+		return job.Error(err)
+	}
+	byID := daemon.Repositories().ByID()
+
+	// The images listed are the tagged ones and the dangling ones, like
+	// docker images does
+	listed := make(map[string]*image.Image)
+	for id, img := range heads {
+		listed[id] = img
+	}
+	for id := range byID {
+		if img, ok := allImages[id]; ok {
+			listed[id] = img
+		}
+	}
+	layerUsers := make(map[string]int)
+	for id := range listed {
+		for _, layer := range imageLayers(allImages, id) {
+			layerUsers[layer.ID]++
+		}
+	}
+
+	var (
+		containers            = daemon.List()
+		imageContainers       = make(map[string]int)
+		usedLayers            = make(map[string]struct{})
+		containerList         = engine.NewTable("", 0)
+		containersSize        int64
+		containersReclaimable int64
+		activeContainers      int
+	)
+	for _, container := range containers {
+		imageContainers[container.ImageID]++
+		for _, layer := range imageLayers(allImages, container.ImageID) {
+			usedLayers[layer.ID] = struct{}{}
+		}
+
+		sizeRw, _ := container.GetSize()
+		containersSize += sizeRw
+		if container.IsRunning() {
+			activeContainers++
+		} else {
+			containersReclaimable += sizeRw
+		}
+		out := &engine.Env{}
+		out.Set("Id", container.ID)
+		out.SetList("Names", []string{container.Name})
+		out.Set("Image", container.Config.Image)
+		out.SetBool("Running", container.IsRunning())
+		out.SetInt64("SizeRw", sizeRw)
+		containerList.Add(out)
+	}
+
+	var imagesSize, imagesReclaimable int64
+	for id, img := range allImages {
+		if img.Size < 0 {
+			continue
+		}
+		imagesSize += img.Size
+		if _, used := usedLayers[id]; !used {
+			imagesReclaimable += img.Size
+		}
+	}
+
+	imageList := engine.NewTable("Size", 0)
+	activeImages := 0
+	for id := range listed {
+		var size, shared int64
+		for _, layer := range imageLayers(allImages, id) {
+			if layer.Size < 0 {
+				continue
+			}
+			size += layer.Size
+			if layerUsers[layer.ID] > 1 {
+				shared += layer.Size
+			}
+		}
+		if imageContainers[id] > 0 {
+			activeImages++
+		}
+		out := &engine.Env{}
+		out.Set("Id", id)
+		out.SetList("RepoTags", byID[id])
+		out.SetInt64("Size", size)
+		out.SetInt64("SharedSize", shared)
+		out.SetInt64("UniqueSize", size-shared)
+		out.SetInt("Containers", imageContainers[id])
+		imageList.Add(out)
+	}
+	imageList.ReverseSort()
+
+	volumeList := engine.NewTable("Size", 0)
+	var volumesSize, volumesReclaimable int64
+	activeVolumes := 0
+	for _, v := range daemon.volumes.List() {
+		// Bind mounts belong to the host, not to the daemon
+		if v.IsBindMount {
+			continue
+		}
+		size, err := directory.Size(v.Path)
+		if err != nil {
+			log.Errorf("Error computing the size of volume %s: %v", v.ID, err)
+			continue
+		}
+		volumesSize += size
+		users := len(v.Containers())
+		if users > 0 {
+			activeVolumes++
+		} else {
+			volumesReclaimable += size
+		}
+		out := &engine.Env{}
+		out.Set("Id", v.ID)
+		out.Set("Path", v.Path)
+		out.SetInt64("Size", size)
+		out.SetInt("Containers", users)
+		volumeList.Add(out)
+	}
+	volumeList.ReverseSort()
+
+	v := &engine.Env{}
+	v.SetInt("ImagesCount", len(listed))
+	v.SetInt("ImagesActive", activeImages)
+	v.SetInt64("ImagesSize", imagesSize)
+	v.SetInt64("ImagesReclaimable", imagesReclaimable)
+	v.SetInt("ContainersCount", len(containers))
+	v.SetInt("ContainersActive", activeContainers)
+	v.SetInt64("ContainersSize", containersSize)
+	v.SetInt64("ContainersReclaimable", containersReclaimable)
+	v.SetInt("VolumesCount", len(volumeList.Data))
+	v.SetInt("VolumesActive", activeVolumes)
+	v.SetInt64("VolumesSize", volumesSize)
+	v.SetInt64("VolumesReclaimable", volumesReclaimable)
+	if job.GetenvBool("verbose") {
+		for key, list := range map[string]*engine.Table{
+			"Images":     imageList,
+			"Containers": containerList,
+			"Volumes":    volumeList,
+		} {
+			s, err := list.ToListString()
+			if err != nil {
+				return job.Error(err)
+			}
+			v.Set(key, s)
+		}
+	}
+	if _, err := v.WriteTo(job.Stdout); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// imageLayers returns the image id and its parents, from the images given
+func imageLayers(images map[string]*image.Image, id string) []*image.Image {
+	var layers []*image.Image
+	for img, ok := images[id]; ok; img, ok = images[img.Parent] {
+		layers = append(layers, img)
+	}
+	return layers
+}
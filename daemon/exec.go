@@ -108,6 +108,9 @@ func (d *Daemon) getActiveContainer(name string) (*Container, error) {
 	if container.IsPaused() {
 		return nil, fmt.Errorf("Container %s is paused, unpause the container before exec", name)
 	}
+	if container.reattached {
+		return nil, container.errReattached("exec in")
+	}
 	return container, nil
 }
 
@@ -175,6 +178,11 @@ func (d *Daemon) ContainerExecStart(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
+	// the container may have been paused since the exec was created, and the
+	// command would not run until it is unpaused
+	if execConfig.Container.IsPaused() {
+		return job.Errorf("Container %s is paused, unpause the container before exec", execConfig.Container.ID)
+	}
 
 	func() {
 		execConfig.Lock()
@@ -3,6 +3,7 @@ package execdriver
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/docker/docker/daemon/execdriver/native/template"
@@ -57,6 +59,11 @@ type ExitStatus struct {
 
 	// Whether the container encountered an OOM.
 	OOMKilled bool
+
+	// Whether the container's process was terminated by a signal, and which
+	// one. ExitCode is then 128 plus the signal number.
+	Signaled bool
+	Signal   syscall.Signal
 }
 
 type Driver interface {
@@ -72,6 +79,7 @@ type Driver interface {
 	Terminate(c *Command) error                   // kill it with fire
 	Clean(id string) error                        // clean all traces of container exec
 	Stats(id string) (*ResourceStats, error)      // Get resource stats for a running container
+	Update(c *Command) error                      // Apply the resources of c to the running container
 }
 
 // Network settings of the container
@@ -90,7 +98,8 @@ type Ipc struct {
 
 // PID settings of the container
 type Pid struct {
-	HostPid bool `json:"host_pid"`
+	ContainerID string `json:"container_id"` // id of the container to join pid.
+	HostPid     bool   `json:"host_pid"`
 }
 
 type NetworkInterface struct {
@@ -106,11 +115,28 @@ type NetworkInterface struct {
 }
 
 type Resources struct {
-	Memory     int64            `json:"memory"`
-	MemorySwap int64            `json:"memory_swap"`
-	CpuShares  int64            `json:"cpu_shares"`
-	CpusetCpus string           `json:"cpuset_cpus"`
-	Rlimits    []*ulimit.Rlimit `json:"rlimits"`
+	Memory         int64            `json:"memory"`
+	MemorySwap     int64            `json:"memory_swap"`
+	CpuShares      int64            `json:"cpu_shares"`
+	CpusetCpus     string           `json:"cpuset_cpus"`
+	OomKillDisable bool             `json:"oom_kill_disable"`
+	PidsLimit      int64            `json:"pids_limit"`
+	Rlimits        []*ulimit.Rlimit `json:"rlimits"`
+
+	BlkioWeight                int64             `json:"blkio_weight"`
+	BlkioThrottleReadBpsDevice []*ThrottleDevice `json:"blkio_throttle_read_bps_device"`
+}
+
+// ThrottleDevice is a limit of the IO rate of a block device
+type ThrottleDevice struct {
+	Major int64  `json:"major"`
+	Minor int64  `json:"minor"`
+	Rate  uint64 `json:"rate"` // in bytes per second
+}
+
+// String formats the limit like the blkio.throttle files of the cgroup do
+func (td *ThrottleDevice) String() string {
+	return fmt.Sprintf("%d:%d %d", td.Major, td.Minor, td.Rate)
 }
 
 type ResourceStats struct {
@@ -121,13 +147,21 @@ type ResourceStats struct {
 }
 
 type Mount struct {
+	// Type is the type of the filesystem mounted at Destination, TmpfsMount,
+	// or empty for a bind mount of Source
+	Type        string `json:"type,omitempty"`
 	Source      string `json:"source"`
 	Destination string `json:"destination"`
 	Writable    bool   `json:"writable"`
 	Private     bool   `json:"private"`
 	Slave       bool   `json:"slave"`
+	// Data holds the mount options of a tmpfs mount
+	Data string `json:"data,omitempty"`
 }
 
+// TmpfsMount is the Type of a Mount of a tmpfs filesystem
+const TmpfsMount = "tmpfs"
+
 // Describes a process that will be run inside a container.
 type ProcessConfig struct {
 	exec.Cmd `json:"-"`
@@ -164,7 +198,12 @@ type Command struct {
 	MountLabel         string            `json:"mount_label"`
 	LxcConfig          []string          `json:"lxc_config"`
 	AppArmorProfile    string            `json:"apparmor_profile"`
-	CgroupParent       string            `json:"cgroup_parent"` // The parent cgroup for this command.
+	CgroupParent       string            `json:"cgroup_parent"`   // The parent cgroup for this command.
+	Sysctls            map[string]string `json:"sysctls"`         // Namespaced kernel parameters to set in the container.
+	Domainname         string            `json:"domainname"`      // NIS domain name of the container.
+	SeccompProfile     string            `json:"seccomp_profile"` // JSON seccomp profile, if any.
+	OomScoreAdj        int               `json:"oom_score_adj"`   // Adjustment of the OOM score of the container's processes.
+	KeepRunning        bool              `json:"keep_running"`    // Leave the process running when the daemon exits.
 }
 
 func InitContainer(c *Command) *configs.Config {
@@ -205,6 +244,8 @@ func SetupCgroups(container *configs.Config, c *Command) error {
 		container.Cgroups.MemoryReservation = c.Resources.Memory
 		container.Cgroups.MemorySwap = c.Resources.MemorySwap
 		container.Cgroups.CpusetCpus = c.Resources.CpusetCpus
+		container.Cgroups.OomKillDisable = c.Resources.OomKillDisable
+		container.Cgroups.BlkioWeight = c.Resources.BlkioWeight
 	}
 
 	return nil
@@ -32,6 +32,9 @@ import (
 const DriverName = "lxc"
 
 var ErrExec = errors.New("Unsupported: Exec is not supported by the lxc driver")
+var ErrSysctl = errors.New("Unsupported: Sysctls are not supported by the lxc driver")
+var ErrSharePid = errors.New("Unsupported: Joining the PID namespace of a container is not supported by the lxc driver")
+var ErrSeccomp = errors.New("Unsupported: Seccomp profiles are not supported by the lxc driver")
 
 type driver struct {
 	root             string // root path for the driver to use
@@ -79,6 +82,18 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		dataPath = d.containerDir(c.ID)
 	)
 
+	if len(c.Sysctls) > 0 {
+		return execdriver.ExitStatus{ExitCode: -1}, ErrSysctl
+	}
+
+	if c.Pid != nil && c.Pid.ContainerID != "" {
+		return execdriver.ExitStatus{ExitCode: -1}, ErrSharePid
+	}
+
+	if c.SeccompProfile != "" && c.SeccompProfile != execdriver.SeccompUnconfined {
+		return execdriver.ExitStatus{ExitCode: -1}, ErrSeccomp
+	}
+
 	if c.ProcessConfig.Tty {
 		term, err = NewTtyConsole(&c.ProcessConfig, pipes)
 	} else {
@@ -162,6 +177,10 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		params = append(params, "-w", c.WorkingDir)
 	}
 
+	if c.OomScoreAdj != 0 {
+		params = append(params, "-oom-score-adj", strconv.Itoa(c.OomScoreAdj))
+	}
+
 	params = append(params, "--", c.ProcessConfig.Entrypoint)
 	params = append(params, c.ProcessConfig.Arguments...)
 
@@ -475,6 +494,73 @@ func (d *driver) Unpause(c *execdriver.Command) error {
 	return err
 }
 
+func (d *driver) Update(c *execdriver.Command) error {
+	r := c.Resources
+	if r.Memory != 0 {
+		memory := strconv.FormatInt(r.Memory, 10)
+		memorySwap := strconv.FormatInt(getMemorySwap(r), 10)
+		err := setLxcCgroup(c.ID, "memory.limit_in_bytes", memory)
+		if err != nil && getMemorySwap(r) > 0 {
+			// The memory limit can't be raised above the swap limit,
+			// raise the swap limit first
+			if err := setLxcCgroup(c.ID, "memory.memsw.limit_in_bytes", memorySwap); err != nil {
+				return err
+			}
+			err = setLxcCgroup(c.ID, "memory.limit_in_bytes", memory)
+		}
+		if err != nil {
+			return err
+		}
+		if err := setLxcCgroup(c.ID, "memory.soft_limit_in_bytes", memory); err != nil {
+			return err
+		}
+		if getMemorySwap(r) > 0 {
+			if err := setLxcCgroup(c.ID, "memory.memsw.limit_in_bytes", memorySwap); err != nil {
+				return err
+			}
+		}
+	}
+	if r.PidsLimit != 0 {
+		pidsMax := "max"
+		if r.PidsLimit > 0 {
+			pidsMax = strconv.FormatInt(r.PidsLimit, 10)
+		}
+		if err := setLxcCgroup(c.ID, "pids.max", pidsMax); err != nil {
+			return err
+		}
+	}
+	if r.CpuShares != 0 {
+		if err := setLxcCgroup(c.ID, "cpu.shares", strconv.FormatInt(r.CpuShares, 10)); err != nil {
+			return err
+		}
+	}
+	if r.CpusetCpus != "" {
+		if err := setLxcCgroup(c.ID, "cpuset.cpus", r.CpusetCpus); err != nil {
+			return err
+		}
+	}
+	if r.BlkioWeight != 0 {
+		if err := setLxcCgroup(c.ID, "blkio.weight", strconv.FormatInt(r.BlkioWeight, 10)); err != nil {
+			return err
+		}
+	}
+	for _, td := range r.BlkioThrottleReadBpsDevice {
+		if err := setLxcCgroup(c.ID, "blkio.throttle.read_bps_device", td.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setLxcCgroup writes value to the cgroup file key of a running container
+func setLxcCgroup(id, key, value string) error {
+	output, err := exec.Command("lxc-cgroup", "-n", id, key, value).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Err: %s Output: %s", err, output)
+	}
+	return nil
+}
+
 func (d *driver) Terminate(c *execdriver.Command) error {
 	return KillLxc(c.ID, 9)
 }
@@ -17,19 +17,20 @@ import (
 
 // Args provided to the init function for a driver
 type InitArgs struct {
-	User       string
-	Gateway    string
-	Ip         string
-	WorkDir    string
-	Privileged bool
-	Env        []string
-	Args       []string
-	Mtu        int
-	Console    string
-	Pipe       int
-	Root       string
-	CapAdd     string
-	CapDrop    string
+	User        string
+	Gateway     string
+	Ip          string
+	WorkDir     string
+	Privileged  bool
+	Env         []string
+	Args        []string
+	Mtu         int
+	Console     string
+	Pipe        int
+	Root        string
+	CapAdd      string
+	CapDrop     string
+	OomScoreAdj int
 }
 
 func init() {
@@ -78,28 +79,30 @@ func setupNamespace(args *InitArgs) error {
 func getArgs() *InitArgs {
 	var (
 		// Get cmdline arguments
-		user       = flag.String("u", "", "username or uid")
-		gateway    = flag.String("g", "", "gateway address")
-		ip         = flag.String("i", "", "ip address")
-		workDir    = flag.String("w", "", "workdir")
-		privileged = flag.Bool("privileged", false, "privileged mode")
-		mtu        = flag.Int("mtu", 1500, "interface mtu")
-		capAdd     = flag.String("cap-add", "", "capabilities to add")
-		capDrop    = flag.String("cap-drop", "", "capabilities to drop")
+		user        = flag.String("u", "", "username or uid")
+		gateway     = flag.String("g", "", "gateway address")
+		ip          = flag.String("i", "", "ip address")
+		workDir     = flag.String("w", "", "workdir")
+		privileged  = flag.Bool("privileged", false, "privileged mode")
+		mtu         = flag.Int("mtu", 1500, "interface mtu")
+		capAdd      = flag.String("cap-add", "", "capabilities to add")
+		capDrop     = flag.String("cap-drop", "", "capabilities to drop")
+		oomScoreAdj = flag.Int("oom-score-adj", 0, "adjustment of the oom score")
 	)
 
 	flag.Parse()
 
 	return &InitArgs{
-		User:       *user,
-		Gateway:    *gateway,
-		Ip:         *ip,
-		WorkDir:    *workDir,
-		Privileged: *privileged,
-		Args:       flag.Args(),
-		Mtu:        *mtu,
-		CapAdd:     *capAdd,
-		CapDrop:    *capDrop,
+		User:        *user,
+		Gateway:     *gateway,
+		Ip:          *ip,
+		WorkDir:     *workDir,
+		Privileged:  *privileged,
+		Args:        flag.Args(),
+		Mtu:         *mtu,
+		CapAdd:      *capAdd,
+		CapDrop:     *capDrop,
+		OomScoreAdj: *oomScoreAdj,
 	}
 }
 
@@ -2,6 +2,8 @@ package lxc
 
 import (
 	"fmt"
+	"io/ioutil"
+	"strconv"
 
 	"github.com/docker/libcontainer/utils"
 )
@@ -10,6 +12,12 @@ func finalizeNamespace(args *InitArgs) error {
 	if err := utils.CloseExecFrom(3); err != nil {
 		return err
 	}
+	// set before the command runs, so that every process it starts inherits it
+	if args.OomScoreAdj != 0 {
+		if err := ioutil.WriteFile("/proc/self/oom_score_adj", []byte(strconv.Itoa(args.OomScoreAdj)), 0644); err != nil {
+			return fmt.Errorf("setup oom score adj %s", err)
+		}
+	}
 	if err := setupUser(args.User); err != nil {
 		return fmt.Errorf("setup user %s", err)
 	}
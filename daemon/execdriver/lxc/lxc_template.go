@@ -9,6 +9,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/execdriver"
 	nativeTemplate "github.com/docker/docker/daemon/execdriver/native/template"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/utils"
 	"github.com/docker/libcontainer/label"
 )
@@ -33,6 +34,9 @@ lxc.network.mtu = {{.Network.Mtu}}
 # root filesystem
 {{$ROOTFS := .Rootfs}}
 lxc.rootfs = {{$ROOTFS}}
+{{if .ReadonlyRootfs}}
+lxc.rootfs.options = ro
+{{end}}
 
 # use a dedicated pts for the container (and limit the number of pseudo terminal
 # available)
@@ -87,6 +91,9 @@ lxc.mount.entry = devpts {{escapeFstabSpaces $ROOTFS}}/dev/pts devpts {{formatMo
 lxc.mount.entry = shm {{escapeFstabSpaces $ROOTFS}}/dev/shm tmpfs {{formatMountLabel "size=65536k,nosuid,nodev,noexec" ""}} 0 0
 
 {{range $value := .Mounts}}
+{{if eq $value.Type "tmpfs"}}
+lxc.mount.entry = tmpfs {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces (trimLeadingSlash $value.Destination)}} tmpfs {{tmpfsOptions $value.Data}},create=dir 0 0
+{{else}}
 {{$createVal := isDirectory $value.Source}}
 {{if $value.Writable}}
 lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} none rbind,rw,create={{$createVal}} 0 0
@@ -94,6 +101,7 @@ lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabS
 lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} none rbind,ro,create={{$createVal}} 0 0
 {{end}}
 {{end}}
+{{end}}
 
 # limits
 {{if .Resources}}
@@ -103,10 +111,22 @@ lxc.cgroup.memory.soft_limit_in_bytes = {{.Resources.Memory}}
 {{with $memSwap := getMemorySwap .Resources}}
 lxc.cgroup.memory.memsw.limit_in_bytes = {{$memSwap}}
 {{end}}
+{{if .Resources.OomKillDisable}}
+lxc.cgroup.memory.oom_control = 1
+{{end}}
+{{end}}
+{{if gt .Resources.PidsLimit 0}}
+lxc.cgroup.pids.max = {{.Resources.PidsLimit}}
 {{end}}
 {{if .Resources.CpuShares}}
 lxc.cgroup.cpu.shares = {{.Resources.CpuShares}}
 {{end}}
+{{if .Resources.BlkioWeight}}
+lxc.cgroup.blkio.weight = {{.Resources.BlkioWeight}}
+{{end}}
+{{range .Resources.BlkioThrottleReadBpsDevice}}
+lxc.cgroup.blkio.throttle.read_bps_device = {{.}}
+{{end}}
 {{if .Resources.CpusetCpus}}
 lxc.cgroup.cpuset.cpus = {{.Resources.CpusetCpus}}
 {{end}}
@@ -133,7 +153,13 @@ lxc.utsname = {{getHostname .ProcessConfig.Env}}
 {{end}}
 
 {{if .ProcessConfig.Privileged}}
+	{{ with privilegedDropList .CapDrop }}
+		{{range .}}
+lxc.cap.drop = {{.}}
+		{{end}}
+	{{else}}
 # No cap values are needed, as lxc is starting in privileged mode
+	{{end}}
 {{else}}
 	{{ with keepCapabilities .CapAdd .CapDrop }}
 		{{range .}}
@@ -158,6 +184,11 @@ func escapeFstabSpaces(field string) string {
 	return strings.Replace(field, " ", "\\040", -1)
 }
 
+// The destination of a mount is joined to the rootfs after a slash already
+func trimLeadingSlash(path string) string {
+	return strings.TrimLeft(path, "/")
+}
+
 func keepCapabilities(adds []string, drops []string) ([]string, error) {
 	container := nativeTemplate.New()
 	log.Debugf("adds %s drops %s\n", adds, drops)
@@ -190,6 +221,23 @@ func dropList(drops []string) ([]string, error) {
 	return []string{}, nil
 }
 
+// privilegedDropList returns the capabilities dropped from a privileged
+// container, which has all the others
+func privilegedDropList(drops []string) ([]string, error) {
+	if utils.StringsContainsNoCase(drops, "all") {
+		return dropList(drops)
+	}
+	if err := execdriver.ValidateCapabilities(nil, drops); err != nil {
+		return nil, err
+	}
+	var newCaps []string
+	for _, capName := range drops {
+		cap := execdriver.GetCapability(strings.ToUpper(capName))
+		newCaps = append(newCaps, fmt.Sprintf("%d", cap.Value))
+	}
+	return newCaps, nil
+}
+
 func isDirectory(source string) string {
 	f, err := os.Stat(source)
 	log.Debugf("dir: %s\n", source)
@@ -238,13 +286,16 @@ func getHostname(env []string) string {
 func init() {
 	var err error
 	funcMap := template.FuncMap{
-		"getMemorySwap":     getMemorySwap,
-		"escapeFstabSpaces": escapeFstabSpaces,
-		"formatMountLabel":  label.FormatMountLabel,
-		"isDirectory":       isDirectory,
-		"keepCapabilities":  keepCapabilities,
-		"dropList":          dropList,
-		"getHostname":       getHostname,
+		"getMemorySwap":      getMemorySwap,
+		"escapeFstabSpaces":  escapeFstabSpaces,
+		"formatMountLabel":   label.FormatMountLabel,
+		"isDirectory":        isDirectory,
+		"keepCapabilities":   keepCapabilities,
+		"dropList":           dropList,
+		"privilegedDropList": privilegedDropList,
+		"getHostname":        getHostname,
+		"tmpfsOptions":       mount.TmpfsOptions,
+		"trimLeadingSlash":   trimLeadingSlash,
 	}
 	LxcTemplateCompiled, err = template.New("lxc").Funcs(funcMap).Parse(LxcTemplate)
 	if err != nil {
@@ -135,6 +135,93 @@ func grepFileWithReverse(t *testing.T, path string, pattern string, inverseGrep
 	t.Fatalf("grepFile: pattern \"%s\" not found in \"%s\"", pattern, path)
 }
 
+func TestLXCConfigReadonlyRootfs(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigReadonlyRootfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+	driver, err := NewDriver(root, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu: 1500,
+		},
+		AllowedDevices: make([]*configs.Device, 0),
+		ReadonlyRootfs: true,
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.rootfs.options = ro")
+
+	command.ReadonlyRootfs = false
+	p, err = driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFileWithReverse(t, p, "lxc.rootfs.options = ro", true)
+}
+
+func TestLXCConfigTmpfs(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigTmpfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+	driver, err := NewDriver(root, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu: 1500,
+		},
+		AllowedDevices: make([]*configs.Device, 0),
+		Mounts: []execdriver.Mount{
+			{Type: execdriver.TmpfsMount, Destination: "/run", Writable: true, Data: "exec,size=64m"},
+			{Type: execdriver.TmpfsMount, Destination: "/tmp", Writable: true},
+		},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.mount.entry = tmpfs /run tmpfs noexec,nosuid,nodev,exec,size=64m,create=dir 0 0")
+	grepFile(t, p, "lxc.mount.entry = tmpfs /tmp tmpfs noexec,nosuid,nodev,create=dir 0 0")
+}
+
+func TestPrivilegedDropList(t *testing.T) {
+	drops, err := privilegedDropList([]string{"net_admin", "MKNOD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		fmt.Sprintf("%d", execdriver.GetCapability("NET_ADMIN").Value),
+		fmt.Sprintf("%d", execdriver.GetCapability("MKNOD").Value),
+	}
+	if len(drops) != 2 || drops[0] != expected[0] || drops[1] != expected[1] {
+		t.Fatalf("Expected the drops %v, got %v", expected, drops)
+	}
+
+	if drops, err := privilegedDropList([]string{"ALL"}); err != nil || len(drops) != len(execdriver.GetAllCapabilities()) {
+		t.Fatalf("Expected all the capabilities to be dropped, got %v, %v", drops, err)
+	}
+	if drops, err := privilegedDropList(nil); err != nil || len(drops) != 0 {
+		t.Fatalf("Expected no capability to be dropped, got %v, %v", drops, err)
+	}
+	if _, err := privilegedDropList([]string{"CHPASS"}); err == nil {
+		t.Fatal("Expected an error for an unknown capability")
+	}
+}
+
 func TestEscapeFstabSpaces(t *testing.T) {
 	var testInputs = map[string]string{
 		" ":                      "\\040",
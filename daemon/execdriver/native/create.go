@@ -3,6 +3,7 @@
 package native
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -11,6 +12,8 @@ import (
 	"syscall"
 
 	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/pkg/seccomp"
 	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/libcontainer/apparmor"
 	"github.com/docker/libcontainer/configs"
@@ -44,7 +47,7 @@ func (d *driver) createContainer(c *execdriver.Command) (*configs.Config, error)
 		}
 		container.ReadonlyPaths = nil
 		container.MaskPaths = nil
-		if err := d.setPrivileged(container); err != nil {
+		if err := d.setPrivileged(container, c); err != nil {
 			return nil, err
 		}
 	} else {
@@ -57,6 +60,12 @@ func (d *driver) createContainer(c *execdriver.Command) (*configs.Config, error)
 		container.AppArmorProfile = c.AppArmorProfile
 	}
 
+	// libcontainer kills the process with SIGKILL when the daemon dies, unless
+	// told another signal. SIGCONT leaves a running process alone.
+	if c.KeepRunning {
+		container.ParentDeathSignal = int(syscall.SIGCONT)
+	}
+
 	if err := execdriver.SetupCgroups(container, c); err != nil {
 		return nil, err
 	}
@@ -173,11 +182,31 @@ func (d *driver) createPid(container *configs.Config, c *execdriver.Command) err
 		return nil
 	}
 
+	if c.Pid.ContainerID != "" {
+		d.Lock()
+		active := d.activeContainers[c.Pid.ContainerID]
+		d.Unlock()
+
+		if active == nil {
+			return fmt.Errorf("%s is not a valid running container to join", c.Pid.ContainerID)
+		}
+
+		state, err := active.State()
+		if err != nil {
+			return err
+		}
+		container.Namespaces.Add(configs.NEWPID, state.NamespacePaths[configs.NEWPID])
+	}
+
 	return nil
 }
 
-func (d *driver) setPrivileged(container *configs.Config) (err error) {
-	container.Capabilities = execdriver.GetAllCapabilities()
+func (d *driver) setPrivileged(container *configs.Config, c *execdriver.Command) (err error) {
+	// The capabilities dropped are still dropped from a privileged container
+	container.Capabilities, err = execdriver.TweakCapabilities(execdriver.GetAllCapabilities(), nil, c.CapDrop)
+	if err != nil {
+		return err
+	}
 	container.Cgroups.AllowAllDevices = true
 
 	hostDevices, err := devices.HostDevices()
@@ -232,6 +261,20 @@ func (d *driver) setupMounts(container *configs.Config, c *execdriver.Command) e
 		if err != nil {
 			return err
 		}
+		if m.Type == execdriver.TmpfsMount {
+			flags, data, err := mount.ParseTmpfsOptions(m.Data)
+			if err != nil {
+				return err
+			}
+			container.Mounts = append(container.Mounts, &configs.Mount{
+				Source:      "tmpfs",
+				Destination: dest,
+				Device:      "tmpfs",
+				Flags:       flags,
+				Data:        data,
+			})
+			continue
+		}
 		flags := syscall.MS_BIND | syscall.MS_REC
 		if !m.Writable {
 			flags |= syscall.MS_RDONLY
@@ -256,3 +299,21 @@ func (d *driver) setupLabels(container *configs.Config, c *execdriver.Command) e
 
 	return nil
 }
+
+// seccompArgs makes the command given by args run by the init installing the
+// syscall filter of the container, which the daemon mounts in it. A
+// privileged container has no syscall filter.
+func seccompArgs(c *execdriver.Command, args []string) ([]string, error) {
+	if c.ProcessConfig.Privileged {
+		return args, nil
+	}
+	filter, err := execdriver.ParseSeccompProfile(c.SeccompProfile)
+	if err != nil || filter == nil {
+		return args, err
+	}
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{seccomp.InitPath, string(data)}, args...), nil
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -22,6 +23,7 @@ import (
 	"github.com/docker/docker/pkg/term"
 	"github.com/docker/libcontainer"
 	"github.com/docker/libcontainer/apparmor"
+	"github.com/docker/libcontainer/cgroups"
 	"github.com/docker/libcontainer/cgroups/systemd"
 	"github.com/docker/libcontainer/configs"
 	"github.com/docker/libcontainer/system"
@@ -38,7 +40,7 @@ type driver struct {
 	initPath         string
 	activeContainers map[string]libcontainer.Container
 	machineMemory    int64
-	factory          libcontainer.Factory
+	cgroupManager    func(*libcontainer.LinuxFactory) error
 	sync.Mutex
 }
 
@@ -60,23 +62,28 @@ func NewDriver(root, initPath string) (*driver, error) {
 		cgm = libcontainer.SystemdCgroups
 	}
 
-	f, err := libcontainer.New(
-		root,
-		cgm,
-		libcontainer.InitPath(reexec.Self(), DriverName),
-		libcontainer.TmpfsRoot,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return &driver{
+	d := &driver{
 		root:             root,
 		initPath:         initPath,
 		activeContainers: make(map[string]libcontainer.Container),
 		machineMemory:    meminfo.MemTotal,
-		factory:          f,
-	}, nil
+		cgroupManager:    cgm,
+	}
+	if _, err := d.factory(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// factory returns a libcontainer factory whose init is given args, telling it
+// what it sets up itself for the container
+func (d *driver) factory(args ...string) (libcontainer.Factory, error) {
+	return libcontainer.New(
+		d.root,
+		d.cgroupManager,
+		libcontainer.InitPath(reexec.Self(), append([]string{DriverName}, args...)...),
+		libcontainer.TmpfsRoot,
+	)
 }
 
 type execOutput struct {
@@ -91,10 +98,15 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
 
+	args, err := seccompArgs(c, append([]string{c.ProcessConfig.Entrypoint}, c.ProcessConfig.Arguments...))
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
 	var term execdriver.Terminal
 
 	p := &libcontainer.Process{
-		Args: append([]string{c.ProcessConfig.Entrypoint}, c.ProcessConfig.Arguments...),
+		Args: args,
 		Env:  c.ProcessConfig.Env,
 		Cwd:  c.WorkingDir,
 		User: c.ProcessConfig.User,
@@ -131,21 +143,43 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 	}
 	c.ProcessConfig.Terminal = term
 
-	cont, err := d.factory.Create(c.ID, container)
+	factory, err := d.factory(initArgs(c)...)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	cont, err := factory.Create(c.ID, container)
 	if err != nil {
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
 	d.Lock()
 	d.activeContainers[c.ID] = cont
 	d.Unlock()
+	var pidsPath string
 	defer func() {
 		cont.Destroy()
+		if pidsPath != "" {
+			os.Remove(pidsPath)
+		}
 		d.cleanContainer(c.ID)
 	}()
 
 	if err := cont.Start(p); err != nil {
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
+	if err := setBlkioThrottle(cont, c.Resources); err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	pidsPath, err = pidsCgroup(cont)
+	if err == nil {
+		err = setPidsLimit(cont, pidsPath, c.Resources)
+	}
+	if err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
 
 	if startCallback != nil {
 		pid, err := p.Pid()
@@ -180,7 +214,13 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 
 	_, oomKill := <-oomKillNotification
 
-	return execdriver.ExitStatus{ExitCode: utils.ExitStatus(ps.Sys().(syscall.WaitStatus)), OOMKilled: oomKill}, nil
+	ws := ps.Sys().(syscall.WaitStatus)
+	exitStatus := execdriver.ExitStatus{ExitCode: utils.ExitStatus(ws), OOMKilled: oomKill}
+	if ws.Signaled() {
+		exitStatus.Signaled = true
+		exitStatus.Signal = ws.Signal()
+	}
+	return exitStatus, nil
 }
 
 func waitInPIDHost(p *libcontainer.Process, c libcontainer.Container) func() (*os.ProcessState, error) {
@@ -246,6 +286,178 @@ func (d *driver) Unpause(c *execdriver.Command) error {
 	return active.Resume()
 }
 
+func (d *driver) Update(c *execdriver.Command) error {
+	active := d.activeContainers[c.ID]
+	if active == nil {
+		return fmt.Errorf("active container for %s does not exist", c.ID)
+	}
+	if systemd.UseSystemd() {
+		return fmt.Errorf("Updating the resources of a container isn't supported with systemd cgroups")
+	}
+	if err := updateCgroups(active, c); err != nil {
+		return err
+	}
+	if err := setBlkioThrottle(active, c.Resources); err != nil {
+		return err
+	}
+	path, err := pidsCgroup(active)
+	if err != nil {
+		return err
+	}
+	return setPidsLimit(active, path, c.Resources)
+}
+
+func updateCgroups(active libcontainer.Container, c *execdriver.Command) error {
+	config := active.Config()
+	cgroup := *config.Cgroups
+	config.Cgroups = &cgroup
+	if err := execdriver.SetupCgroups(&config, c); err != nil {
+		return err
+	}
+	err := active.Set(config)
+	if err == nil || c.Resources.Memory <= 0 {
+		return err
+	}
+
+	// The memory limit can't be raised above the swap limit, which is written
+	// after it: raise the swap limit alone first
+	memorySwap := c.Resources.MemorySwap
+	if memorySwap == 0 {
+		memorySwap = c.Resources.Memory * 2
+	}
+	if memorySwap < 0 {
+		return err
+	}
+	swapFirst := config
+	swapCgroup := cgroup
+	swapCgroup.Memory = 0
+	swapCgroup.MemorySwap = memorySwap
+	swapFirst.Cgroups = &swapCgroup
+	if active.Set(swapFirst) != nil {
+		return err
+	}
+	return active.Set(config)
+}
+
+// setBlkioThrottle writes the limits of the IO rates of block devices to the
+// blkio cgroup of the container, which libcontainer doesn't know about. All
+// the processes of the container join the cgroup afterwards, systemd only
+// making it for a blkio weight.
+func setBlkioThrottle(active libcontainer.Container, r *execdriver.Resources) error {
+	if r == nil || len(r.BlkioThrottleReadBpsDevice) == 0 {
+		return nil
+	}
+	state, err := active.State()
+	if err != nil {
+		return err
+	}
+	path, ok := state.CgroupPaths["blkio"]
+	if !ok {
+		return fmt.Errorf("The blkio cgroup isn't mounted, the device read rates can't be limited")
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	for _, td := range r.BlkioThrottleReadBpsDevice {
+		if err := ioutil.WriteFile(filepath.Join(path, "blkio.throttle.read_bps_device"), []byte(td.String()), 0700); err != nil {
+			return err
+		}
+	}
+	return joinCgroup(active, path)
+}
+
+// pidsCgroup returns the path of the pids cgroup of the container, which
+// libcontainer doesn't know about. The cgroup is at the same place in the
+// pids hierarchy as the devices cgroup of the container in its own. The path
+// is empty when the pids cgroup isn't mounted.
+func pidsCgroup(active libcontainer.Container) (string, error) {
+	pidsRoot, err := cgroups.FindCgroupMountpoint("pids")
+	if err != nil {
+		return "", nil
+	}
+	state, err := active.State()
+	if err != nil {
+		return "", err
+	}
+	devices, ok := state.CgroupPaths["devices"]
+	if !ok {
+		return "", fmt.Errorf("The devices cgroup isn't mounted, the pids cgroup of the container can't be found")
+	}
+	devicesRoot, err := cgroups.FindCgroupMountpoint("devices")
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(devicesRoot, devices)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(pidsRoot, rel), nil
+}
+
+// setPidsLimit writes the limit of the number of processes of the container
+// to its pids cgroup at path, which all its processes join afterwards. A
+// limit of -1 removes it.
+func setPidsLimit(active libcontainer.Container, path string, r *execdriver.Resources) error {
+	if r == nil || r.PidsLimit == 0 {
+		return nil
+	}
+	if path == "" {
+		return fmt.Errorf("The pids cgroup isn't mounted, the number of processes can't be limited")
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	limit := "max"
+	if r.PidsLimit > 0 {
+		limit = strconv.FormatInt(r.PidsLimit, 10)
+	}
+	if err := ioutil.WriteFile(filepath.Join(path, "pids.max"), []byte(limit), 0700); err != nil {
+		return err
+	}
+	return joinCgroup(active, path)
+}
+
+// joinCgroup moves every process of the container to the cgroup at path.
+// The user command has already run when libcontainer returns from Start, so
+// the processes are those of the devices cgroup of the container, which are
+// read again until no new one shows up: a process forked while they're being
+// moved is either in the devices cgroup on the next read or born in path.
+func joinCgroup(active libcontainer.Container, path string) error {
+	state, err := active.State()
+	if err != nil {
+		return err
+	}
+	devices, ok := state.CgroupPaths["devices"]
+	if !ok {
+		return fmt.Errorf("The devices cgroup isn't mounted, the processes of the container can't be found")
+	}
+	moved := make(map[string]bool)
+	for {
+		procs, err := ioutil.ReadFile(filepath.Join(devices, "cgroup.procs"))
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, pid := range strings.Fields(string(procs)) {
+			if moved[pid] {
+				continue
+			}
+			moved[pid] = true
+			found = true
+			if err := ioutil.WriteFile(filepath.Join(path, "cgroup.procs"), []byte(pid), 0700); err != nil {
+				// the process may have exited since the read
+				if perr, ok := err.(*os.PathError); ok && perr.Err == syscall.ESRCH {
+					continue
+				}
+				return err
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+}
+
 func (d *driver) Terminate(c *execdriver.Command) error {
 	defer d.cleanContainer(c.ID)
 	// lets check the start time for the process
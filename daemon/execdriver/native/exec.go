@@ -18,14 +18,29 @@ import (
 func (d *driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessConfig, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (int, error) {
 	active := d.activeContainers[c.ID]
 	if active == nil {
-		return -1, fmt.Errorf("No active container exists with ID %s", c.ID)
+		// A container left running by the previous daemon is only known
+		// from the state it left
+		factory, err := d.factory()
+		if err != nil {
+			return -1, err
+		}
+		if active, err = factory.Load(c.ID); err != nil {
+			return -1, fmt.Errorf("No active container exists with ID %s", c.ID)
+		}
+		if status, err := active.Status(); err != nil || status != libcontainer.Running {
+			return -1, fmt.Errorf("No active container exists with ID %s", c.ID)
+		}
 	}
 
 	var term execdriver.Terminal
-	var err error
+
+	args, err := seccompArgs(c, append([]string{processConfig.Entrypoint}, processConfig.Arguments...))
+	if err != nil {
+		return -1, err
+	}
 
 	p := &libcontainer.Process{
-		Args: append([]string{processConfig.Entrypoint}, processConfig.Arguments...),
+		Args: args,
 		Env:  c.ProcessConfig.Env,
 		Cwd:  c.WorkingDir,
 		User: c.ProcessConfig.User,
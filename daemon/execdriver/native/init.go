@@ -3,10 +3,19 @@
 package native
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
 
+	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/pkg/reexec"
 	"github.com/docker/libcontainer"
 )
@@ -25,9 +34,87 @@ func fatal(err error) {
 	os.Exit(1)
 }
 
+// initArgs are the arguments of the init of a container, for what it sets up
+// itself before libcontainer initializes the container. It runs in the
+// namespaces of the container then, still as root.
+func initArgs(c *execdriver.Command) []string {
+	var args []string
+	if c.OomScoreAdj != 0 {
+		args = append(args, "-oom-score-adj="+strconv.Itoa(c.OomScoreAdj))
+	}
+	if c.Domainname != "" {
+		args = append(args, "-domainname="+c.Domainname)
+	}
+	keys := make([]string, 0, len(c.Sysctls))
+	for key := range c.Sysctls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-sysctl="+key+"="+c.Sysctls[key])
+	}
+	return args
+}
+
+// sysctls are kernel parameters given as key=value
+type sysctls []string
+
+func (s *sysctls) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *sysctls) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// writeSysctl writes the value of a kernel parameter to its file under
+// /proc/sys, e.g. /proc/sys/net/ipv4/ip_forward for net.ipv4.ip_forward
+func writeSysctl(sysctl string) error {
+	parts := strings.SplitN(sysctl, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("bad format for sysctl: %q, expected key=value", sysctl)
+	}
+	path := filepath.Join("/proc/sys", strings.Replace(parts[0], ".", "/", -1))
+	return ioutil.WriteFile(path, []byte(parts[1]), 0644)
+}
+
 func initializer() {
 	runtime.GOMAXPROCS(1)
 	runtime.LockOSThread()
+
+	flags := flag.NewFlagSet(DriverName, flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	oomScoreAdj := flags.Int("oom-score-adj", 0, "Adjustment of the OOM score of the container's processes")
+	domainname := flags.String("domainname", "", "NIS domain name of the container")
+	var kernelParams sysctls
+	flags.Var(&kernelParams, "sysctl", "Namespaced kernel parameter to set in the container")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		initError(err)
+	}
+	// Set before the command of the container runs, so that every process
+	// it starts inherits it. /proc is still the one of the host.
+	if *oomScoreAdj != 0 {
+		if err := ioutil.WriteFile("/proc/self/oom_score_adj", []byte(strconv.Itoa(*oomScoreAdj)), 0644); err != nil {
+			initError(err)
+		}
+	}
+
+	// The namespaces are set up once, by the init of the container rather
+	// than by those of the processes exec'ed in it
+	if os.Getenv("_LIBCONTAINER_INITTYPE") != "setns" {
+		if *domainname != "" {
+			if err := syscall.Setdomainname([]byte(*domainname)); err != nil {
+				initError(err)
+			}
+		}
+		for _, sysctl := range kernelParams {
+			if err := writeSysctl(sysctl); err != nil {
+				initError(err)
+			}
+		}
+	}
+
 	factory, err := libcontainer.New("")
 	if err != nil {
 		fatal(err)
@@ -39,6 +126,20 @@ func initializer() {
 	panic("unreachable")
 }
 
+// initError fails the start of the container with an error of the init,
+// sending it to the daemon like libcontainer does for its own errors
+func initError(err error) {
+	pipe := os.NewFile(3, "pipe")
+	// The config sent by the daemon is consumed first, so that it doesn't get
+	// ECONNRESET
+	ioutil.ReadAll(pipe)
+	json.NewEncoder(pipe).Encode(struct {
+		ECode   libcontainer.ErrorCode
+		Message string
+	}{libcontainer.SystemError, err.Error()})
+	os.Exit(1)
+}
+
 func writeError(err error) {
 	fmt.Fprint(os.Stderr, err)
 	os.Exit(1)
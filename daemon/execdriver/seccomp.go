@@ -0,0 +1,69 @@
+package execdriver
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/docker/pkg/seccomp"
+)
+
+// SeccompUnconfined is the seccomp profile of the containers without filter
+const SeccompUnconfined = "unconfined"
+
+var seccompActions = map[string]seccomp.Action{
+	"SCMP_ACT_KILL":  seccomp.Kill,
+	"SCMP_ACT_ERRNO": seccomp.Errno,
+	"SCMP_ACT_TRAP":  seccomp.Trap,
+	"SCMP_ACT_ALLOW": seccomp.Allow,
+}
+
+type seccompProfile struct {
+	DefaultAction string `json:"defaultAction"`
+	Syscalls      []struct {
+		Name   string            `json:"name"`
+		Action string            `json:"action"`
+		Args   []json.RawMessage `json:"args"`
+	} `json:"syscalls"`
+}
+
+func seccompAction(action string) (seccomp.Action, error) {
+	a, ok := seccompActions[action]
+	if !ok {
+		return 0, fmt.Errorf("Unknown action %q in the seccomp profile", action)
+	}
+	return a, nil
+}
+
+// ParseSeccompProfile parses a seccomp profile, given as JSON, to the filter
+// it stands for. It is nil for the unconfined profile.
+func ParseSeccompProfile(data string) (*seccomp.Filter, error) {
+	if data == "" || data == SeccompUnconfined {
+		return nil, nil
+	}
+	if !seccomp.IsSupported() {
+		return nil, fmt.Errorf("Seccomp profiles are not supported on this platform")
+	}
+	var profile seccompProfile
+	if err := json.Unmarshal([]byte(data), &profile); err != nil {
+		return nil, fmt.Errorf("Invalid seccomp profile: %v", err)
+	}
+	defaultAction, err := seccompAction(profile.DefaultAction)
+	if err != nil {
+		return nil, err
+	}
+	filter := &seccomp.Filter{DefaultAction: defaultAction}
+	for _, s := range profile.Syscalls {
+		if _, ok := seccomp.SyscallNumber(s.Name); !ok {
+			return nil, fmt.Errorf("Unknown syscall %q in the seccomp profile", s.Name)
+		}
+		if len(s.Args) > 0 {
+			return nil, fmt.Errorf("Arguments of syscall %q in the seccomp profile are not supported", s.Name)
+		}
+		action, err := seccompAction(s.Action)
+		if err != nil {
+			return nil, err
+		}
+		filter.Syscalls = append(filter.Syscalls, &seccomp.Syscall{Name: s.Name, Action: action})
+	}
+	return filter, nil
+}
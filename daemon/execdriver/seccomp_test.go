@@ -0,0 +1,51 @@
+package execdriver
+
+import (
+	"testing"
+
+	"github.com/docker/docker/pkg/seccomp"
+)
+
+func TestParseSeccompProfile(t *testing.T) {
+	for _, profile := range []string{"", SeccompUnconfined} {
+		config, err := ParseSeccompProfile(profile)
+		if err != nil || config != nil {
+			t.Fatalf("%q: expected no filter, got %v, %v", profile, config, err)
+		}
+	}
+	if !seccomp.IsSupported() {
+		t.Skip("seccomp is not supported on this platform")
+	}
+
+	config, err := ParseSeccompProfile(`{
+		"defaultAction": "SCMP_ACT_ALLOW",
+		"syscalls": [
+			{"name": "mkdir", "action": "SCMP_ACT_ERRNO"},
+			{"name": "reboot", "action": "SCMP_ACT_KILL", "args": []}
+		]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DefaultAction != seccomp.Allow || len(config.Syscalls) != 2 {
+		t.Fatalf("Unexpected filter %+v", config)
+	}
+	if s := config.Syscalls[0]; s.Name != "mkdir" || s.Action != seccomp.Errno {
+		t.Fatalf("Unexpected rule %+v", s)
+	}
+	if s := config.Syscalls[1]; s.Name != "reboot" || s.Action != seccomp.Kill {
+		t.Fatalf("Unexpected rule %+v", s)
+	}
+
+	for _, profile := range []string{
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "nosuchcall", "action": "SCMP_ACT_ERRNO"}]}`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "mkdir", "action": "SCMP_ACT_LOG"}]}`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "mkdir", "action": "SCMP_ACT_ERRNO", "args": [{"index": 0}]}]}`,
+		`{"syscalls": []}`,
+		`{"defaultAction": `,
+	} {
+		if _, err := ParseSeccompProfile(profile); err == nil {
+			t.Fatalf("Expected an error for the profile %s", profile)
+		}
+	}
+}
@@ -78,20 +78,31 @@ func GetAllCapabilities() []string {
 	return output
 }
 
+// ValidateCapabilities checks that the capabilities to add and drop are known
+// ones, or all
+func ValidateCapabilities(adds, drops []string) error {
+	allCaps := GetAllCapabilities()
+	for _, cap := range drops {
+		if strings.ToLower(cap) != "all" && !utils.StringsContainsNoCase(allCaps, cap) {
+			return fmt.Errorf("Unknown capability drop: %q", cap)
+		}
+	}
+	for _, cap := range adds {
+		if strings.ToLower(cap) != "all" && !utils.StringsContainsNoCase(allCaps, cap) {
+			return fmt.Errorf("Unknown capability to add: %q", cap)
+		}
+	}
+	return nil
+}
+
 func TweakCapabilities(basics, adds, drops []string) ([]string, error) {
 	var (
 		newCaps []string
 		allCaps = GetAllCapabilities()
 	)
 
-	// look for invalid cap in the drop list
-	for _, cap := range drops {
-		if strings.ToLower(cap) == "all" {
-			continue
-		}
-		if !utils.StringsContainsNoCase(allCaps, cap) {
-			return nil, fmt.Errorf("Unknown capability drop: %q", cap)
-		}
+	if err := ValidateCapabilities(adds, drops); err != nil {
+		return nil, err
 	}
 
 	// handle --cap-add=all
@@ -119,10 +130,6 @@ func TweakCapabilities(basics, adds, drops []string) ([]string, error) {
 			continue
 		}
 
-		if !utils.StringsContainsNoCase(allCaps, cap) {
-			return nil, fmt.Errorf("Unknown capability to add: %q", cap)
-		}
-
 		// add cap if not already in the list
 		if !utils.StringsContainsNoCase(newCaps, cap) {
 			newCaps = append(newCaps, strings.ToUpper(cap))
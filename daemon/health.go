@@ -0,0 +1,271 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/daemon/execdriver/lxc"
+	"github.com/docker/docker/pkg/common"
+)
+
+const (
+	// HealthStarting is the status of a container whose health check has
+	// neither succeeded nor failed often enough yet
+	HealthStarting = "starting"
+	// HealthHealthy is the status of a container whose last check succeeded
+	HealthHealthy = "healthy"
+	// HealthUnhealthy is the status of a container whose check failed
+	// Retries times in a row
+	HealthUnhealthy = "unhealthy"
+
+	defaultProbeInterval = 30 * time.Second
+	defaultProbeRetries  = 3
+
+	// maxProbeOutput is the number of bytes of a check's output kept in
+	// its result
+	maxProbeOutput = 4096
+	// maxHealthLog is the number of check results kept in the health state
+	maxHealthLog = 5
+)
+
+// Health holds the health state of a running container
+type Health struct {
+	Status        string
+	FailingStreak int
+	Log           []*HealthcheckResult
+}
+
+// HealthcheckResult is the outcome of a single run of a health check
+type HealthcheckResult struct {
+	Start    time.Time
+	End      time.Time
+	ExitCode int
+	Output   string
+}
+
+// String returns the health status as displayed by ps
+func (h *Health) String() string {
+	if h.Status == HealthStarting {
+		return "health: starting"
+	}
+	return h.Status
+}
+
+// limitedBuffer keeps the first maxProbeOutput bytes written to it
+type limitedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := maxProbeOutput - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// initHealthMonitor resets the health state of the container and starts
+// running its health check, if it has one. It must be called with the
+// container locked, once the container's process is running.
+func (daemon *Daemon) initHealthMonitor(c *Container) {
+	c.stopHealthMonitor()
+	c.Health = nil
+
+	config := c.Config.Healthcheck
+	if config == nil || len(config.Test) == 0 {
+		return
+	}
+	if strings.HasPrefix(daemon.execDriver.Name(), lxc.DriverName) {
+		log.Warnf("Health checks are not supported by the %s execution driver, not checking %s", daemon.execDriver.Name(), common.TruncateID(c.ID))
+		return
+	}
+
+	c.Health = &Health{Status: HealthStarting}
+	stop := make(chan struct{})
+	c.healthStop = stop
+	go daemon.monitorHealth(c, stop)
+}
+
+// stopHealthMonitor stops the health check of the container, if any. The
+// health state is kept so that it can still be inspected.
+func (c *Container) stopHealthMonitor() {
+	if c.healthStop != nil {
+		close(c.healthStop)
+		c.healthStop = nil
+	}
+}
+
+// monitorHealth runs the container's health check every interval until stop
+// is closed
+func (daemon *Daemon) monitorHealth(c *Container, stop chan struct{}) {
+	config := c.Config.Healthcheck
+	interval := config.Interval
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	retries := config.Retries
+	if retries <= 0 {
+		retries = defaultProbeRetries
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+
+		// a paused container can't run the check, and it would only be
+		// reported as failing because of it
+		if c.IsPaused() {
+			continue
+		}
+
+		result := daemon.probeHealth(c, config.Test)
+
+		select {
+		case <-stop:
+			// the container stopped while the check was running
+			return
+		default:
+		}
+		daemon.handleProbeResult(c, result, retries)
+	}
+}
+
+// waitHealthy waits until deadline for the health check of the container to
+// pass. A container without a health check is used like it is. The state is
+// checked again each time the health status of the container changes or the
+// container stops.
+func (daemon *Daemon) waitHealthy(c *Container, deadline time.Time) error {
+	timer := time.NewTimer(deadline.Sub(time.Now()))
+	defer timer.Stop()
+	for {
+		c.Lock()
+		running, health := c.Running, c.Health
+		status := ""
+		if health != nil {
+			status = health.Status
+		}
+		if c.healthChanged == nil {
+			c.healthChanged = make(chan struct{})
+		}
+		changed, stopped := c.healthChanged, c.waitChan
+		c.Unlock()
+
+		if !running {
+			return fmt.Errorf("Cannot link to a non running container: %s", c.Name)
+		}
+		if health == nil || status == HealthHealthy {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-stopped:
+		case <-timer.C:
+			return fmt.Errorf("Container %s is still %s after %s, not linking to it", c.Name, status, daemon.config.LinkHealthTimeout)
+		}
+	}
+}
+
+// notifyHealthChanged wakes the containers waiting for the container to be
+// healthy. It must be called with the container locked.
+func (c *Container) notifyHealthChanged() {
+	if c.healthChanged != nil {
+		close(c.healthChanged)
+		c.healthChanged = nil
+	}
+}
+
+// probeHealth runs test in the container and returns its result
+func (daemon *Daemon) probeHealth(c *Container, test []string) *HealthcheckResult {
+	entrypoint, args := daemon.getEntrypointAndArgs(nil, test)
+	processConfig := &execdriver.ProcessConfig{
+		Entrypoint: entrypoint,
+		Arguments:  args,
+	}
+	output := &limitedBuffer{}
+	pipes := execdriver.NewPipes(nil, output, output, false)
+
+	command := c.command
+	if c.reattached {
+		// The exec driver finds the container from the state its previous
+		// daemon left
+		command = &execdriver.Command{
+			ID:             c.ID,
+			WorkingDir:     c.Config.WorkingDir,
+			SeccompProfile: c.SeccompProfile,
+			ProcessConfig: execdriver.ProcessConfig{
+				Privileged: c.hostConfig.Privileged,
+				User:       c.Config.User,
+				Env:        c.createDaemonEnvironment(nil),
+			},
+		}
+	}
+
+	result := &HealthcheckResult{Start: time.Now().UTC()}
+	exitCode, err := daemon.execDriver.Exec(command, processConfig, pipes, nil)
+	result.End = time.Now().UTC()
+	result.ExitCode = exitCode
+	result.Output = output.String()
+	if err != nil {
+		log.Debugf("Health check for container %s failed: %s", common.TruncateID(c.ID), err)
+		if exitCode == 0 {
+			result.ExitCode = -1
+		}
+		result.Output = fmt.Sprintf("%s%s", result.Output, err)
+	}
+	return result
+}
+
+// handleProbeResult records result in the container's health state and logs
+// a health_status event when the status changes
+func (daemon *Daemon) handleProbeResult(c *Container, result *HealthcheckResult, retries int) {
+	c.Lock()
+	h := c.Health
+	if h == nil {
+		c.Unlock()
+		return
+	}
+	previous := h.Status
+
+	h.Log = append(h.Log, result)
+	if len(h.Log) > maxHealthLog {
+		h.Log = h.Log[len(h.Log)-maxHealthLog:]
+	}
+	if result.ExitCode == 0 {
+		h.FailingStreak = 0
+		h.Status = HealthHealthy
+	} else {
+		h.FailingStreak++
+		if h.FailingStreak >= retries {
+			h.Status = HealthUnhealthy
+		}
+	}
+	status := h.Status
+	if status != previous {
+		c.notifyHealthChanged()
+	}
+	c.Unlock()
+
+	if status != previous {
+		c.LogEvent("health_status: " + status)
+	}
+}
@@ -119,6 +119,9 @@ func (daemon *Daemon) DeleteImage(eng *engine.Engine, name string, imgs *engine.
 			}
 			out := &engine.Env{}
 			out.SetJson("Deleted", img.ID)
+			if img.Size >= 0 {
+				out.SetInt64("Size", img.Size)
+			}
 			imgs.Add(out)
 			eng.Job("log", "delete", img.ID, "").Run()
 			if img.Parent != "" && !noprune {
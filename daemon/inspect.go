@@ -3,6 +3,7 @@ package daemon
 import (
 	"encoding/json"
 	"fmt"
+	"path"
 
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/runconfig"
@@ -59,7 +60,11 @@ func (daemon *Daemon) ContainerInspect(job *engine.Job) engine.Status {
 
 	if children, err := daemon.Children(container.Name); err == nil {
 		for linkAlias, child := range children {
-			container.hostConfig.Links = append(container.hostConfig.Links, fmt.Sprintf("%s:%s", child.Name, linkAlias))
+			link := fmt.Sprintf("%s:%s", child.Name, linkAlias)
+			if container.isHealthyLink(path.Base(linkAlias)) {
+				link += ":healthy"
+			}
+			container.hostConfig.Links = append(container.hostConfig.Links, link)
 		}
 	}
 	// we need this trick to preserve empty log driver, so
@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"syscall"
@@ -25,16 +26,8 @@ func (daemon *Daemon) ContainerKill(job *engine.Job) engine.Status {
 
 	// If we have a signal, look at it. Otherwise, do nothing
 	if len(job.Args) == 2 && job.Args[1] != "" {
-		// Check if we passed the signal as a number:
-		// The largest legal signal is 31, so let's parse on 5 bits
-		sig, err = strconv.ParseUint(job.Args[1], 10, 5)
-		if err != nil {
-			// The signal is not a number, treat it as a string (either like "KILL" or like "SIGKILL")
-			sig = uint64(signal.SignalMap[strings.TrimPrefix(job.Args[1], "SIG")])
-		}
-
-		if sig == 0 {
-			return job.Errorf("Invalid signal: %s", job.Args[1])
+		if sig, err = parseSignal(job.Args[1]); err != nil {
+			return job.Error(err)
 		}
 	}
 
@@ -48,13 +41,30 @@ func (daemon *Daemon) ContainerKill(job *engine.Job) engine.Status {
 		if err := container.Kill(); err != nil {
 			return job.Errorf("Cannot kill container %s: %s", name, err)
 		}
+		container.setManuallyStopped(true)
 		container.LogEvent("kill")
 	} else {
 		// Otherwise, just send the requested signal
 		if err := container.KillSig(int(sig)); err != nil {
 			return job.Errorf("Cannot kill container %s: %s", name, err)
 		}
-		// FIXME: Add event for signals
+		container.LogEvent("kill")
 	}
 	return engine.StatusOK
 }
+
+// parseSignal translates a signal number, or a name like "KILL" or
+// "SIGKILL", to its number
+func parseSignal(rawSignal string) (uint64, error) {
+	// Check if we passed the signal as a number:
+	// The largest legal signal is 31, so let's parse on 5 bits
+	sig, err := strconv.ParseUint(rawSignal, 10, 5)
+	if err != nil {
+		// The signal is not a number, treat it as a string (either like "KILL" or like "SIGKILL")
+		sig = uint64(signal.SignalMap[strings.TrimPrefix(rawSignal, "SIG")])
+	}
+	if sig == 0 {
+		return 0, fmt.Errorf("Invalid signal: %s", rawSignal)
+	}
+	return sig, nil
+}
@@ -46,11 +46,13 @@ func (daemon *Daemon) Containers(job *engine.Job) engine.Status {
 			}
 			filt_exited = append(filt_exited, code)
 		}
+		// Only stopped containers have an exit code
+		all = true
 	}
 
 	if i, ok := psFilters["status"]; ok {
 		for _, value := range i {
-			if value == "exited" {
+			if value == "exited" || value == "created" {
 				all = true
 			}
 		}
@@ -112,7 +114,7 @@ func (daemon *Daemon) Containers(job *engine.Job) engine.Status {
 		if len(filt_exited) > 0 {
 			should_skip := true
 			for _, code := range filt_exited {
-				if code == container.ExitCode && !container.Running {
+				if code == container.ExitCode && !container.Running && !container.StartedAt.IsZero() {
 					should_skip = false
 					break
 				}
@@ -0,0 +1,212 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+)
+
+// keepRunning returns whether the process of the container is left running
+// when the daemon shuts down
+func (container *Container) keepRunning() bool {
+	return container.reattached || (container.command != nil && container.command.KeepRunning)
+}
+
+// processAlive returns whether the main process of the container is still
+// running, in the cgroup of the container so that a reused pid isn't taken
+// for it
+func (container *Container) processAlive() bool {
+	if container.Pid <= 0 {
+		return false
+	}
+	if err := syscall.Kill(container.Pid, 0); err != nil {
+		return false
+	}
+	cgroups, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", container.Pid))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(cgroups), container.ID)
+}
+
+// errReattached is returned for what needs the exec driver to know the
+// process of a reattached container
+func (container *Container) errReattached(action string) error {
+	return fmt.Errorf("Cannot %s container %s: it was reattached after a daemon restart, restart the container first", action, container.ID)
+}
+
+// openFifo opens the named pipe at path, creating it first if needed
+func openFifo(path string, flag int) (*os.File, error) {
+	if err := syscall.Mkfifo(path, 0600); err != nil && err != syscall.EEXIST {
+		return nil, err
+	}
+	return os.OpenFile(path, flag, 0)
+}
+
+// stdioFifos are the stdout and stderr of a container kept running with
+// --live-restore. They are named pipes in the directory of the container
+// instead of the pipes of the daemon, which would break with SIGPIPE once
+// it exits. The process holds them open for reading too, so that it blocks
+// once they are full rather than getting SIGPIPE while no daemon reads
+// them; the next daemon opens them again to read the rest.
+type stdioFifos struct {
+	// files are the ends of the fifos given to the process
+	files []*os.File
+	// copies are done once every process of the container closed its
+	// stdout and stderr, usually because they exited
+	copies sync.WaitGroup
+}
+
+// copyStdio copies the output of the process from the stdout and stderr
+// fifos of the container to its streams. With create, the fifos are made and
+// opened for the process too, which are set in pipes.
+func (container *Container) copyStdio(pipes *execdriver.Pipes, create bool) (*stdioFifos, error) {
+	fifos := &stdioFifos{}
+	for _, s := range []struct {
+		name   string
+		stream io.Writer
+		pipe   *io.Writer
+	}{
+		{"stdout", container.stdout, &pipes.Stdout},
+		{"stderr", container.stderr, &pipes.Stderr},
+	} {
+		path, err := container.getRootResourcePath(s.name + ".fifo")
+		if err != nil {
+			fifos.Close()
+			return nil, err
+		}
+		if create {
+			// Opened for reading and writing first, so that opening it
+			// for reading below doesn't wait for a writer
+			f, err := openFifo(path, os.O_RDWR)
+			if err != nil {
+				fifos.Close()
+				return nil, err
+			}
+			fifos.files = append(fifos.files, f)
+			*s.pipe = f
+		}
+		r, err := os.OpenFile(path, os.O_RDONLY, 0)
+		if err != nil {
+			fifos.Close()
+			return nil, err
+		}
+		fifos.copies.Add(1)
+		go func(stream io.Writer) {
+			defer fifos.copies.Done()
+			io.Copy(stream, r)
+			r.Close()
+		}(s.stream)
+	}
+	return fifos, nil
+}
+
+// Close closes the ends of the fifos given to the process and waits for the
+// rest of its output to be copied. It's called once the process exited.
+func (fifos *stdioFifos) Close() {
+	for _, f := range fifos.files {
+		f.Close()
+	}
+	fifos.copies.Wait()
+}
+
+// reattach takes back a container left running by the previous daemon with
+// --live-restore. Its filesystem and network are claimed again, its output
+// is copied from its fifos again and its process is followed until it
+// exits, when its restart policy applies, and its health check runs again.
+// Its stdin went away with the previous daemon, and it can't be exec'ed
+// into, paused, resized or updated as the exec driver doesn't know its
+// process.
+func (daemon *Daemon) reattach(container *Container) error {
+	log.Debugf("reattaching running container %s (pid %d)", container.ID, container.Pid)
+
+	if err := container.Mount(); err != nil {
+		return err
+	}
+	if err := container.RestoreNetwork(); err != nil {
+		log.Errorf("%v: Failed to restore the network: %v", container.ID, err)
+	}
+	if err := container.startLogging(); err != nil {
+		log.Errorf("%v: Failed to start logging: %v", container.ID, err)
+	}
+	fifos, err := container.copyStdio(&execdriver.Pipes{}, false)
+	if err != nil {
+		log.Errorf("%v: Failed to open the output of the container: %v", container.ID, err)
+		fifos = &stdioFifos{}
+	}
+	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+	container.Lock()
+	container.reattached = true
+	daemon.initHealthMonitor(container)
+	container.Unlock()
+
+	go daemon.waitReattached(container, fifos)
+	return nil
+}
+
+// waitReattached marks a reattached container stopped once its process
+// exits, and restarts it if its restart policy says so. The end of its
+// output tells when it exits, the process being then polled in case it
+// closed its stdout and stderr itself. The exit code of a process which
+// isn't a child of the daemon is unknown, it's recorded as -1 and the
+// on-failure policy doesn't restart it.
+func (daemon *Daemon) waitReattached(container *Container, fifos *stdioFifos) {
+	fifos.Close()
+	for container.processAlive() {
+		time.Sleep(time.Second)
+	}
+	log.Debugf("reattached container %s exited", container.ID)
+
+	// The previous daemon never got to clean up after the process, its
+	// state would keep the exec driver from starting the container again
+	if err := daemon.execDriver.Clean(container.ID); err != nil {
+		log.Errorf("%v: Failed to clean the exec driver state: %v", container.ID, err)
+	}
+
+	exitStatus := execdriver.ExitStatus{ExitCode: -1}
+	container.Lock()
+	container.stopHealthMonitor()
+	container.reattached = false
+	container.setStopped(&exitStatus)
+	container.cleanup()
+	container.stdout.Clean()
+	container.stderr.Clean()
+	if container.logCopier != nil {
+		container.logCopier.Wait()
+	}
+	if container.logDriver != nil {
+		container.logDriver.Close()
+	}
+	container.logCopier = nil
+	container.logDriver = nil
+	if err := container.toDisk(); err != nil {
+		log.Errorf("Error dumping container %s state to disk: %s", container.ID, err)
+	}
+	container.Unlock()
+
+	container.LogEvent("die")
+
+	m := container.monitor
+	if m.restartPolicy.Name == "on-failure" {
+		log.Debugf("not restarting reattached container %s: its exit code is unknown", container.ID)
+		return
+	}
+	if !m.shouldRestart(exitStatus.ExitCode) {
+		return
+	}
+	m.waitForNextRestart()
+	if m.shouldStop {
+		return
+	}
+	if err := container.Start(); err != nil {
+		log.Errorf("Error restarting reattached container %s: %v", container.ID, err)
+	}
+}
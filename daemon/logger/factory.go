@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Context holds what a logging driver needs to know about the container it
+// logs for
+type Context struct {
+	Config        map[string]string
+	ContainerID   string
+	ContainerName string
+	// LogPath is the path of the container's json log file
+	LogPath string
+}
+
+// Creator creates a logging driver for a container
+type Creator func(Context) (Logger, error)
+
+// LogOptValidator checks the --log-opt options given to a logging driver
+type LogOptValidator func(config map[string]string) error
+
+type logdriverFactory struct {
+	mu         sync.Mutex
+	registry   map[string]Creator
+	validators map[string]LogOptValidator
+}
+
+var factory = &logdriverFactory{
+	registry:   make(map[string]Creator),
+	validators: make(map[string]LogOptValidator),
+}
+
+// RegisterLogDriver makes the logging driver name available to containers.
+// Drivers usually register themselves in their package's init function.
+func RegisterLogDriver(name string, c Creator) error {
+	factory.mu.Lock()
+	defer factory.mu.Unlock()
+	if _, exists := factory.registry[name]; exists {
+		return fmt.Errorf("logger: log driver named '%s' is already registered", name)
+	}
+	factory.registry[name] = c
+	return nil
+}
+
+// RegisterLogOptValidator sets the function checking the options of the
+// logging driver name. Drivers without a validator don't accept any option.
+func RegisterLogOptValidator(name string, v LogOptValidator) error {
+	factory.mu.Lock()
+	defer factory.mu.Unlock()
+	if _, exists := factory.validators[name]; exists {
+		return fmt.Errorf("logger: log opt validator named '%s' is already registered", name)
+	}
+	factory.validators[name] = v
+	return nil
+}
+
+// GetLogDriver returns the creator of the logging driver name
+func GetLogDriver(name string) (Creator, error) {
+	factory.mu.Lock()
+	defer factory.mu.Unlock()
+	c, ok := factory.registry[name]
+	if !ok {
+		return nil, fmt.Errorf("logger: no log driver named '%s' is registered", name)
+	}
+	return c, nil
+}
+
+// ValidateLogOpts checks that the logging driver name exists and accepts
+// the options in config
+func ValidateLogOpts(name string, config map[string]string) error {
+	factory.mu.Lock()
+	_, ok := factory.registry[name]
+	v := factory.validators[name]
+	factory.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("logger: no log driver named '%s' is registered", name)
+	}
+	if v != nil {
+		return v(config)
+	}
+	for key := range config {
+		return fmt.Errorf("unknown log opt '%s' for %s log driver", key, name)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRegisterLogDriver(t *testing.T) {
+	creator := func(ctx Context) (Logger, error) { return nil, nil }
+	if err := RegisterLogDriver("test-driver", creator); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterLogDriver("test-driver", creator); err == nil {
+		t.Fatal("Expected an error registering a driver twice")
+	}
+	if _, err := GetLogDriver("test-driver"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetLogDriver("missing-driver"); err == nil {
+		t.Fatal("Expected an error for a driver which isn't registered")
+	}
+}
+
+func TestValidateLogOpts(t *testing.T) {
+	creator := func(ctx Context) (Logger, error) { return nil, nil }
+	if err := RegisterLogDriver("test-noopts", creator); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterLogDriver("test-opts", creator); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterLogOptValidator("test-opts", func(config map[string]string) error {
+		for key := range config {
+			if key != "known" {
+				return fmt.Errorf("unknown log opt '%s'", key)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateLogOpts("test-noopts", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLogOpts("test-noopts", map[string]string{"known": "1"}); err == nil {
+		t.Fatal("Expected an error for an option given to a driver without options")
+	}
+	if err := ValidateLogOpts("test-opts", map[string]string{"known": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLogOpts("test-opts", map[string]string{"unknown": "1"}); err == nil {
+		t.Fatal("Expected an error for an unknown option")
+	}
+	if err := ValidateLogOpts("missing-driver", nil); err == nil {
+		t.Fatal("Expected an error for a driver which isn't registered")
+	}
+}
@@ -2,11 +2,16 @@ package jsonfilelog
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"sync"
 
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/pkg/jsonlog"
+	"github.com/docker/docker/pkg/tailfile"
+	"github.com/docker/docker/pkg/units"
 )
 
 // JSONFileLogger is Logger implementation for default docker logging:
@@ -14,21 +19,90 @@ import (
 type JSONFileLogger struct {
 	buf *bytes.Buffer
 	f   *os.File   // store for closing
-	mu  sync.Mutex // protects buffer
+	mu  sync.Mutex // protects buffer and file
+
+	filename string
+	size     int64 // size of the current file
+	capacity int64 // maximum size of a file, -1 for no limit
+	maxFiles int   // number of files kept when rotating, including the current one
+}
+
+func init() {
+	if err := logger.RegisterLogDriver("json-file", func(ctx logger.Context) (logger.Logger, error) {
+		capacity, maxFiles, err := parseLogOpts(ctx.Config)
+		if err != nil {
+			return nil, err
+		}
+		return NewRotating(ctx.LogPath, capacity, maxFiles)
+	}); err != nil {
+		panic(err)
+	}
+	if err := logger.RegisterLogOptValidator("json-file", ValidateLogOpts); err != nil {
+		panic(err)
+	}
 }
 
 // New creates new JSONFileLogger which writes to filename
 func New(filename string) (logger.Logger, error) {
+	return NewRotating(filename, -1, 1)
+}
+
+// NewRotating creates a JSONFileLogger which writes to filename and rotates
+// it once it grows past capacity bytes, keeping maxFiles files: filename,
+// filename.1 and so on, the highest number being the oldest.
+func NewRotating(filename string, capacity int64, maxFiles int) (logger.Logger, error) {
 	log, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
 	if err != nil {
 		return nil, err
 	}
+	fi, err := log.Stat()
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
 	return &JSONFileLogger{
-		f:   log,
-		buf: bytes.NewBuffer(nil),
+		f:        log,
+		buf:      bytes.NewBuffer(nil),
+		filename: filename,
+		size:     fi.Size(),
+		capacity: capacity,
+		maxFiles: maxFiles,
 	}, nil
 }
 
+// ValidateLogOpts checks the max-size and max-file options of the json-file
+// driver
+func ValidateLogOpts(config map[string]string) error {
+	for key := range config {
+		if key != "max-size" && key != "max-file" {
+			return fmt.Errorf("unknown log opt '%s' for json-file log driver", key)
+		}
+	}
+	_, _, err := parseLogOpts(config)
+	return err
+}
+
+func parseLogOpts(config map[string]string) (int64, int, error) {
+	capacity := int64(-1)
+	if s, ok := config["max-size"]; ok {
+		var err error
+		if capacity, err = units.FromHumanSize(s); err != nil || capacity <= 0 {
+			return 0, 0, fmt.Errorf("invalid max-size %s: must be a positive size, as in 10m", s)
+		}
+	}
+	maxFiles := 1
+	if s, ok := config["max-file"]; ok {
+		var err error
+		if maxFiles, err = strconv.Atoi(s); err != nil || maxFiles < 1 {
+			return 0, 0, fmt.Errorf("invalid max-file %s: must be a positive number", s)
+		}
+		if capacity == -1 {
+			return 0, 0, fmt.Errorf("max-file can only be set together with max-size")
+		}
+	}
+	return capacity, maxFiles, nil
+}
+
 // Log converts logger.Message to jsonlog.JSONLog and serializes it to file
 func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	l.mu.Lock()
@@ -38,7 +112,14 @@ func (l *JSONFileLogger) Log(msg *logger.Message) error {
 		return err
 	}
 	l.buf.WriteByte('\n')
-	_, err = l.buf.WriteTo(l.f)
+	if l.capacity > 0 && l.size > 0 && l.size+int64(l.buf.Len()) > l.capacity {
+		if err := l.rotate(); err != nil {
+			l.buf.Reset()
+			return err
+		}
+	}
+	n, err := l.buf.WriteTo(l.f)
+	l.size += n
 	if err != nil {
 		// this buffer is screwed, replace it with another to avoid races
 		l.buf = bytes.NewBuffer(nil)
@@ -47,8 +128,119 @@ func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	return nil
 }
 
+// rotate shifts the log files by one, dropping the oldest one, and starts a
+// new current file. It must be called with mu held.
+func (l *JSONFileLogger) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if l.maxFiles > 1 {
+		for i := l.maxFiles - 1; i > 1; i-- {
+			if err := os.Rename(segmentPath(l.filename, i-1), segmentPath(l.filename, i)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(l.filename, segmentPath(l.filename, 1)); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(l.filename, os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	l.f = f
+	l.size = 0
+	return nil
+}
+
+func segmentPath(filename string, i int) string {
+	return fmt.Sprintf("%s.%d", filename, i)
+}
+
+// Segments returns the paths of the existing log files written to filename,
+// oldest first
+func Segments(filename string) []string {
+	var rotated []string
+	for i := 1; ; i++ {
+		if _, err := os.Stat(segmentPath(filename, i)); err != nil {
+			break
+		}
+		rotated = append(rotated, segmentPath(filename, i))
+	}
+	segments := []string{}
+	for i := len(rotated) - 1; i >= 0; i-- {
+		segments = append(segments, rotated[i])
+	}
+	return append(segments, filename)
+}
+
+// ReadLogs returns a reader of all the log files written to filename, oldest
+// first, and a function closing them. It returns an error satisfying
+// os.IsNotExist when filename doesn't exist.
+func ReadLogs(filename string) (io.Reader, func(), error) {
+	var (
+		readers []io.Reader
+		files   []*os.File
+	)
+	closeAll := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	for _, pth := range Segments(filename) {
+		f, err := os.Open(pth)
+		if err != nil {
+			if pth != filename && os.IsNotExist(err) {
+				// rotated away while the files were opened
+				continue
+			}
+			closeAll()
+			return nil, nil, err
+		}
+		files = append(files, f)
+		readers = append(readers, f)
+	}
+	return io.MultiReader(readers...), closeAll, nil
+}
+
+// TailLogs returns the last n lines of the log files written to filename,
+// oldest first
+func TailLogs(filename string, n int) ([][]byte, error) {
+	segments := Segments(filename)
+	var lines [][]byte
+	for i := len(segments) - 1; i >= 0 && len(lines) < n; i-- {
+		f, err := os.Open(segments[i])
+		if err != nil {
+			if i < len(segments)-1 && os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		ls, err := tailSegment(f, n-len(lines))
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		lines = append(ls, lines...)
+	}
+	return lines, nil
+}
+
+func tailSegment(f *os.File, n int) ([][]byte, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		return nil, nil
+	}
+	return tailfile.TailFile(f, n)
+}
+
 // Close closes underlying file
 func (l *JSONFileLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	return l.f.Close()
 }
 
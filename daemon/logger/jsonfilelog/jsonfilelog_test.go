@@ -1,9 +1,14 @@
 package jsonfilelog
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -47,6 +52,137 @@ func TestJSONFileLogger(t *testing.T) {
 	}
 }
 
+func TestJSONFileLoggerRotate(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	// each line is 63 bytes, so two lines fit in a file
+	l, err := NewRotating(filename, 130, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := l.Log(&logger.Message{Line: []byte(fmt.Sprintf("line%d", i)), Source: "src"}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	segments := Segments(filename)
+	if len(segments) != 3 {
+		t.Fatalf("Expected 3 log files, got %v", segments)
+	}
+	for _, pth := range segments {
+		fi, err := os.Stat(pth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() > 130 {
+			t.Fatalf("%s is larger than the maximum size: %d", pth, fi.Size())
+		}
+	}
+	if _, err := os.Stat(filename + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("Expected the oldest file to be removed, got %v", err)
+	}
+
+	// the files are read back in order, each line once
+	r, closeLog, err := ReadLogs(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeLog()
+	res, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(res), "\n"); lines != 6 {
+		t.Fatalf("Expected the 6 lines of the 3 files, got %d:\n%s", lines, res)
+	}
+	tail, err := TailLogs(filename, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tail) != 3 || !bytes.Equal(tail[0], bytes.Split(res, []byte("\n"))[3]) {
+		t.Fatalf("Expected the last 3 lines of %q, got %q", res, tail)
+	}
+}
+
+func TestJSONFileLoggerRotateReadsInOrder(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	l, err := NewRotating(filename, 130, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 0; i < 7; i++ {
+		if err := l.Log(&logger.Message{Line: []byte(fmt.Sprintf("line%d", i)), Source: "src"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, closeLog, err := ReadLogs(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeLog()
+	dec := json.NewDecoder(r)
+	for i := 0; i < 7; i++ {
+		var entry jsonlog.JSONLog
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf("line%d\n", i); entry.Log != expected {
+			t.Fatalf("Expected %q, got %q", expected, entry.Log)
+		}
+	}
+
+	tail, err := TailLogs(filename, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tail) != 4 || !strings.Contains(string(tail[0]), "line3") || !strings.Contains(string(tail[3]), "line6") {
+		t.Fatalf("Expected lines 3 to 6, got %q", tail)
+	}
+}
+
+func TestValidateLogOpts(t *testing.T) {
+	for _, config := range []map[string]string{
+		nil,
+		{"max-size": "10m"},
+		{"max-size": "1k", "max-file": "3"},
+	} {
+		if err := ValidateLogOpts(config); err != nil {
+			t.Fatalf("%v: %v", config, err)
+		}
+	}
+	for _, config := range []map[string]string{
+		{"max-size": "big"},
+		{"max-size": "0"},
+		{"max-size": "1k", "max-file": "0"},
+		{"max-file": "3"},
+		{"syslog-address": "udp://127.0.0.1"},
+	} {
+		if err := ValidateLogOpts(config); err == nil {
+			t.Fatalf("Expected an error for %v", config)
+		}
+	}
+}
+
 func BenchmarkJSONFileLogger(b *testing.B) {
 	tmp, err := ioutil.TempDir("", "docker-logger-")
 	if err != nil {
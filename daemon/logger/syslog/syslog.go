@@ -0,0 +1,334 @@
+package syslog
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/pkg/common"
+)
+
+const (
+	severityErr  = 3
+	severityInfo = 6
+
+	dialTimeout = 10 * time.Second
+)
+
+var (
+	errClosed = errors.New("syslog logger is closed")
+
+	// localSockets are the usual paths of the local syslog socket
+	localSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+	validOpts = map[string]bool{
+		"syslog-address":         true,
+		"syslog-tls-ca-cert":     true,
+		"syslog-tls-cert":        true,
+		"syslog-tls-key":         true,
+		"syslog-tls-skip-verify": true,
+		"syslog-facility":        true,
+	}
+
+	// facilities are the syslog facilities by name
+	facilities = map[string]int{
+		"kern":     0,
+		"user":     1,
+		"mail":     2,
+		"daemon":   3,
+		"auth":     4,
+		"syslog":   5,
+		"lpr":      6,
+		"news":     7,
+		"uucp":     8,
+		"cron":     9,
+		"authpriv": 10,
+		"ftp":      11,
+		"local0":   16,
+		"local1":   17,
+		"local2":   18,
+		"local3":   19,
+		"local4":   20,
+		"local5":   21,
+		"local6":   22,
+		"local7":   23,
+	}
+)
+
+func init() {
+	if err := logger.RegisterLogDriver("syslog", func(ctx logger.Context) (logger.Logger, error) {
+		return New("docker/"+common.TruncateID(ctx.ContainerID), ctx.Config)
+	}); err != nil {
+		panic(err)
+	}
+	if err := logger.RegisterLogOptValidator("syslog", ValidateLogOpts); err != nil {
+		panic(err)
+	}
+}
+
+// Syslog is Logger implementation which forwards container output to a
+// local or remote syslog endpoint, optionally over TLS.
+type Syslog struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config
+	facility  int
+	tag       string
+	hostname  string
+
+	mu     sync.Mutex // protects conn and closed
+	conn   net.Conn
+	closed bool
+}
+
+// New creates a new Syslog logger tagging messages with tag. The endpoint is
+// configured by the syslog-* options of config and the connection is
+// established immediately, so that configuration errors are reported when
+// the container starts.
+func New(tag string, config map[string]string) (logger.Logger, error) {
+	if err := ValidateLogOpts(config); err != nil {
+		return nil, err
+	}
+	network, address, err := parseAddress(config["syslog-address"])
+	if err != nil {
+		return nil, err
+	}
+	facility, err := parseFacility(config["syslog-facility"])
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsConfig *tls.Config
+	if network == "tcp+tls" {
+		if tlsConfig, err = parseTLSConfig(config); err != nil {
+			return nil, err
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	s := &Syslog{
+		network:   network,
+		address:   address,
+		tlsConfig: tlsConfig,
+		facility:  facility,
+		tag:       tag,
+		hostname:  hostname,
+	}
+	if s.conn, err = s.dial(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ValidateLogOpts checks that config only holds options known to the syslog
+// driver.
+func ValidateLogOpts(config map[string]string) error {
+	for key := range config {
+		if !validOpts[key] {
+			return fmt.Errorf("unknown log opt '%s' for syslog log driver", key)
+		}
+	}
+	if _, err := parseFacility(config["syslog-facility"]); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseFacility returns the syslog-facility given by name, as in local0, or
+// by number. It defaults to daemon.
+func parseFacility(facility string) (int, error) {
+	if facility == "" {
+		return facilities["daemon"] << 3, nil
+	}
+	if f, ok := facilities[facility]; ok {
+		return f << 3, nil
+	}
+	if f, err := strconv.Atoi(facility); err == nil && f >= 0 && f <= 23 {
+		return f << 3, nil
+	}
+	return 0, fmt.Errorf("invalid syslog-facility %s", facility)
+}
+
+// parseAddress splits a syslog-address such as udp://host:514 or
+// tcp+tls://host:6514 into a network and an address. An empty address
+// selects the local syslog socket.
+func parseAddress(address string) (string, string, error) {
+	if address == "" {
+		return "", "", nil
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid syslog-address %s: %v", address, err)
+	}
+	switch u.Scheme {
+	case "unix", "unixgram":
+		if u.Path == "" {
+			return "", "", fmt.Errorf("invalid syslog-address %s: missing socket path", address)
+		}
+		return u.Scheme, u.Path, nil
+	case "udp", "tcp", "tcp+tls":
+		host := u.Host
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			port := "514"
+			if u.Scheme == "tcp+tls" {
+				port = "6514"
+			}
+			host = net.JoinHostPort(host, port)
+		}
+		return u.Scheme, host, nil
+	default:
+		return "", "", fmt.Errorf("unsupported syslog-address scheme %s: use one of unix, unixgram, udp, tcp or tcp+tls", u.Scheme)
+	}
+}
+
+func parseTLSConfig(config map[string]string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if v, ok := config["syslog-tls-skip-verify"]; ok {
+		skip, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syslog-tls-skip-verify value %s: %v", v, err)
+		}
+		tlsConfig.InsecureSkipVerify = skip
+	}
+
+	if ca := config["syslog-tls-ca-cert"]; ca != "" {
+		file, err := ioutil.ReadFile(ca)
+		if err != nil {
+			return nil, fmt.Errorf("could not read syslog CA certificate %s: %v", ca, err)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(file) {
+			return nil, fmt.Errorf("could not parse syslog CA certificate %s", ca)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	cert, key := config["syslog-tls-cert"], config["syslog-tls-key"]
+	if cert != "" || key != "" {
+		if cert == "" || key == "" {
+			return nil, errors.New("syslog-tls-cert and syslog-tls-key must be set together")
+		}
+		keyPair, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("could not load syslog client certificate %s: %v", cert, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{keyPair}
+	}
+	return tlsConfig, nil
+}
+
+// dial opens a new connection to the syslog endpoint. It doesn't touch the
+// state of s, so it can be called without holding mu.
+func (s *Syslog) dial() (net.Conn, error) {
+	switch s.network {
+	case "":
+		for _, network := range []string{"unixgram", "unix"} {
+			for _, path := range localSockets {
+				if conn, err := net.DialTimeout(network, path, dialTimeout); err == nil {
+					return conn, nil
+				}
+			}
+		}
+		return nil, errors.New("unable to connect to the local syslog daemon")
+	case "tcp+tls":
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", s.address, s.tlsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to syslog at %s: %v", s.address, err)
+		}
+		return conn, nil
+	default:
+		conn, err := net.DialTimeout(s.network, s.address, dialTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to syslog at %s: %v", s.address, err)
+		}
+		return conn, nil
+	}
+}
+
+// format renders msg as a syslog line. Messages sent to the local socket
+// omit the hostname, as the local syslog daemon adds it itself.
+func (s *Syslog) format(msg *logger.Message) []byte {
+	severity := severityInfo
+	if msg.Source == "stderr" {
+		severity = severityErr
+	}
+	priority := s.facility | severity
+
+	if s.network == "" {
+		return []byte(fmt.Sprintf("<%d>%s %s[%d]: %s\n",
+			priority, msg.Timestamp.Format(time.Stamp), s.tag, os.Getpid(), msg.Line))
+	}
+	return []byte(fmt.Sprintf("<%d>%s %s %s[%d]: %s\n",
+		priority, msg.Timestamp.Format(time.RFC3339), s.hostname, s.tag, os.Getpid(), msg.Line))
+}
+
+// Log sends msg to the syslog endpoint, reconnecting once if the connection
+// was lost.
+func (s *Syslog) Log(msg *logger.Message) error {
+	line := s.format(msg)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errClosed
+	}
+	if s.conn != nil {
+		_, err := s.conn.Write(line)
+		if err == nil {
+			s.mu.Unlock()
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	s.mu.Unlock()
+
+	// the dial can take up to dialTimeout, don't make Close wait for it
+	conn, err := s.dial()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		conn.Close()
+		return errClosed
+	}
+	if s.conn != nil {
+		// another Log reconnected in the meantime
+		conn.Close()
+	} else {
+		s.conn = conn
+	}
+	_, err = s.conn.Write(line)
+	return err
+}
+
+// Close closes the connection to the syslog endpoint
+func (s *Syslog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// Name returns name of this logger
+func (s *Syslog) Name() string {
+	return "Syslog"
+}
@@ -0,0 +1,303 @@
+package syslog
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/pkg/testutils"
+)
+
+// writeTestCerts generates a CA and a server certificate for 127.0.0.1 in
+// dir and returns the paths of the CA, certificate and key.
+func writeTestCerts(t *testing.T, dir string) (string, string, string) {
+	ca := testutils.NewCert(t, dir, "ca", nil, true)
+	server := testutils.NewCert(t, dir, "server", ca, false)
+	return ca.CertFile, server.CertFile, server.KeyFile
+}
+
+// startTLSReceiver runs a mock syslog receiver which sends every line it
+// reads to lines and closes each connection after dropAfter lines, if set.
+func startTLSReceiver(t *testing.T, certFile, keyFile string, dropAfter int) (net.Listener, chan string) {
+	keyPair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{keyPair}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for n := 1; scanner.Scan(); n++ {
+					lines <- scanner.Text()
+					if n == dropAfter {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+	return l, lines
+}
+
+func expectLine(t *testing.T, lines chan string, suffix string) {
+	select {
+	case line := <-lines:
+		if !strings.HasSuffix(line, suffix) {
+			t.Fatalf("Expected a line ending with %q, got %q", suffix, line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timeout waiting for %q", suffix)
+	}
+}
+
+func TestSyslogTLS(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-syslog-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	caFile, certFile, keyFile := writeTestCerts(t, tmp)
+
+	l, lines := startTLSReceiver(t, certFile, keyFile, 0)
+	defer l.Close()
+
+	s, err := New("docker/test", map[string]string{
+		"syslog-address":     "tcp+tls://" + l.Addr().String(),
+		"syslog-tls-ca-cert": caFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Log(&logger.Message{Line: []byte("hello"), Source: "stdout", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	expectLine(t, lines, ": hello")
+	if err := s.Log(&logger.Message{Line: []byte("oops"), Source: "stderr", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	expectLine(t, lines, ": oops")
+}
+
+func TestSyslogTLSReconnect(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-syslog-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	caFile, certFile, keyFile := writeTestCerts(t, tmp)
+
+	// the receiver drops every connection after the first line
+	l, lines := startTLSReceiver(t, certFile, keyFile, 1)
+	defer l.Close()
+
+	s, err := New("docker/test", map[string]string{
+		"syslog-address":     "tcp+tls://" + l.Addr().String(),
+		"syslog-tls-ca-cert": caFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Log(&logger.Message{Line: []byte("line1"), Source: "stdout", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	expectLine(t, lines, ": line1")
+
+	// a write on a connection closed by the peer may still succeed once, so
+	// keep logging until a line makes it through the new connection
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if err := s.Log(&logger.Message{Line: []byte("line2"), Source: "stdout", Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case line := <-lines:
+			if !strings.HasSuffix(line, ": line2") {
+				t.Fatalf("Expected line2, got %q", line)
+			}
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	t.Fatal("Logger did not reconnect after the connection was dropped")
+}
+
+func TestSyslogLogAfterClose(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	s, err := New("docker/test", map[string]string{"syslog-address": "udp://" + l.LocalAddr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// a closed logger must not reconnect
+	if err := s.Log(&logger.Message{Line: []byte("hello"), Source: "stdout", Timestamp: time.Now()}); err != errClosed {
+		t.Fatalf("Expected %v, got %v", errClosed, err)
+	}
+}
+
+func TestSyslogTLSInvalidCerts(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-syslog-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	_, certFile, keyFile := writeTestCerts(t, tmp)
+
+	for _, config := range []map[string]string{
+		{"syslog-tls-ca-cert": filepath.Join(tmp, "missing.pem")},
+		{"syslog-tls-ca-cert": keyFile},
+		{"syslog-tls-cert": filepath.Join(tmp, "missing.pem"), "syslog-tls-key": keyFile},
+		{"syslog-tls-cert": certFile},
+		{"syslog-tls-skip-verify": "maybe"},
+	} {
+		config["syslog-address"] = "tcp+tls://127.0.0.1:6514"
+		if _, err := New("docker/test", config); err == nil {
+			t.Fatalf("Expected an error for %v", config)
+		}
+	}
+}
+
+func TestSyslogTLSUntrustedServer(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-syslog-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	_, certFile, keyFile := writeTestCerts(t, tmp)
+
+	l, _ := startTLSReceiver(t, certFile, keyFile, 0)
+	defer l.Close()
+
+	// without the CA the server certificate can't be verified
+	if _, err := New("docker/test", map[string]string{"syslog-address": "tcp+tls://" + l.Addr().String()}); err == nil {
+		t.Fatal("Expected an error connecting to an untrusted server")
+	}
+	s, err := New("docker/test", map[string]string{
+		"syslog-address":         "tcp+tls://" + l.Addr().String(),
+		"syslog-tls-skip-verify": "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+}
+
+func TestParseAddress(t *testing.T) {
+	valid := map[string][2]string{
+		"":                        {"", ""},
+		"udp://127.0.0.1":         {"udp", "127.0.0.1:514"},
+		"tcp://example.com:1514":  {"tcp", "example.com:1514"},
+		"tcp+tls://10.0.0.1:6514": {"tcp+tls", "10.0.0.1:6514"},
+		"tcp+tls://10.0.0.1":      {"tcp+tls", "10.0.0.1:6514"},
+		"tcp://10.0.0.1":          {"tcp", "10.0.0.1:514"},
+		"unix:///dev/log":         {"unix", "/dev/log"},
+	}
+	for address, expected := range valid {
+		network, addr, err := parseAddress(address)
+		if err != nil {
+			t.Fatalf("%s: %v", address, err)
+		}
+		if network != expected[0] || addr != expected[1] {
+			t.Fatalf("%s: expected %v, got %s %s", address, expected, network, addr)
+		}
+	}
+	for _, address := range []string{"http://127.0.0.1", "unix://", "127.0.0.1:514"} {
+		if _, _, err := parseAddress(address); err == nil {
+			t.Fatalf("Expected an error for %s", address)
+		}
+	}
+}
+
+func TestValidateLogOpts(t *testing.T) {
+	if err := ValidateLogOpts(map[string]string{"syslog-address": "udp://127.0.0.1", "syslog-tls-skip-verify": "true"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLogOpts(map[string]string{"max-size": "10m"}); err == nil {
+		t.Fatal("Expected an error for an unknown option")
+	}
+}
+
+func TestParseFacility(t *testing.T) {
+	valid := map[string]int{
+		"":       3 << 3,
+		"daemon": 3 << 3,
+		"user":   1 << 3,
+		"local7": 23 << 3,
+		"16":     16 << 3,
+	}
+	for facility, expected := range valid {
+		f, err := parseFacility(facility)
+		if err != nil {
+			t.Fatalf("%s: %v", facility, err)
+		}
+		if f != expected {
+			t.Fatalf("%s: expected %d, got %d", facility, expected, f)
+		}
+	}
+	for _, facility := range []string{"local8", "24", "-1", "Daemon"} {
+		if _, err := parseFacility(facility); err == nil {
+			t.Fatalf("Expected an error for %s", facility)
+		}
+	}
+	if err := ValidateLogOpts(map[string]string{"syslog-facility": "bogus"}); err == nil {
+		t.Fatal("Expected an error for an invalid facility")
+	}
+}
+
+func TestSyslogFacility(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	s, err := New("docker/test", map[string]string{
+		"syslog-address":  "udp://" + l.LocalAddr().String(),
+		"syslog-facility": "local0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Log(&logger.Message{Line: []byte("hello"), Source: "stderr", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	l.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := l.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local0 is 16, and stderr is logged with the err severity
+	if expected := "<131>"; !strings.HasPrefix(string(buf[:n]), expected) {
+		t.Fatalf("Expected a message starting with %s, got %q", expected, buf[:n])
+	}
+}
@@ -10,9 +10,9 @@ import (
 	"sync"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger/jsonfilelog"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/jsonlog"
-	"github.com/docker/docker/pkg/tailfile"
 	"github.com/docker/docker/pkg/timeutils"
 )
 
@@ -44,10 +44,14 @@ func (daemon *Daemon) ContainerLogs(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
-	if container.LogDriverType() != "json-file" {
-		return job.Errorf("\"logs\" endpoint is supported only for \"json-file\" logging driver")
+	if logType := container.LogDriverType(); logType != "json-file" {
+		return job.Errorf("\"logs\" endpoint is supported only for \"json-file\" logging driver (got: %s)", logType)
 	}
-	cLog, err := container.ReadLog("json")
+	pth, err := container.logPath("json")
+	if err != nil {
+		return job.Error(err)
+	}
+	cLog, closeLog, err := jsonfilelog.ReadLogs(pth)
 	if err != nil && os.IsNotExist(err) {
 		// Legacy logs
 		log.Debugf("Old logs format")
@@ -80,9 +84,9 @@ func (daemon *Daemon) ContainerLogs(job *engine.Job) engine.Status {
 		}
 		if lines != 0 {
 			if lines > 0 {
-				f := cLog.(*os.File)
-				ls, err := tailfile.TailFile(f, lines)
+				ls, err := jsonfilelog.TailLogs(pth, lines)
 				if err != nil {
+					closeLog()
 					return job.Error(err)
 				}
 				tmp := bytes.NewBuffer([]byte{})
@@ -114,6 +118,7 @@ func (daemon *Daemon) ContainerLogs(job *engine.Job) engine.Status {
 				l.Reset()
 			}
 		}
+		closeLog()
 	}
 	if follow && container.IsRunning() {
 		errors := make(chan error, 2)
@@ -131,11 +131,29 @@ func (m *containerMonitor) Start() error {
 
 		pipes := execdriver.NewPipes(m.container.stdin, m.container.stdout, m.container.stderr, m.container.Config.OpenStdin)
 
-		m.container.LogEvent("start")
+		// The output of a process kept running by the daemon shutdown goes
+		// through fifos which outlive the daemon
+		var fifos *stdioFifos
+		if m.container.command.KeepRunning {
+			if fifos, err = m.container.copyStdio(pipes, true); err != nil {
+				m.resetContainer(false)
+
+				return err
+			}
+		}
+
+		// A restart asked for by the user is logged as a single restart event
+		if m.container.RestartCount > 0 || !m.container.restarting {
+			m.container.LogEvent("start")
+		}
 
 		m.lastStartTime = time.Now()
 
-		if exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback); err != nil {
+		exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback)
+		if fifos != nil {
+			fifos.Close()
+		}
+		if err != nil {
 			// if we receive an internal error from the initial start of a container then lets
 			// return it instead of entering the restart loop
 			if m.container.RestartCount == 0 {
@@ -151,6 +169,10 @@ func (m *containerMonitor) Start() error {
 		// here container.Lock is already lost
 		afterRun = true
 
+		m.container.Lock()
+		m.container.stopHealthMonitor()
+		m.container.Unlock()
+
 		m.resetMonitor(err == nil && exitStatus.ExitCode == 0)
 
 		if m.shouldRestart(exitStatus.ExitCode) {
@@ -258,7 +280,13 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 	// close channel only if not closed
 	select {
 	case <-m.startSignal:
+		// restarted by its policy, nobody holds the lock of the container
+		m.container.Lock()
+		m.container.daemon.initHealthMonitor(m.container)
+		m.container.Unlock()
 	default:
+		// Container.Start holds the lock until it gets the signal
+		m.container.daemon.initHealthMonitor(m.container)
 		close(m.startSignal)
 	}
 
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 
@@ -32,6 +33,7 @@ type networkInterface struct {
 	IP           net.IP
 	IPv6         net.IP
 	PortMappings []net.Addr // There are mappings to the host interfaces
+	Network      *userNetwork // The user-defined network of the interface, if any
 }
 
 type ifaces struct {
@@ -52,6 +54,25 @@ func (i *ifaces) Get(key string) *networkInterface {
 	return res
 }
 
+func (i *ifaces) Delete(key string) {
+	i.Lock()
+	delete(i.c, key)
+	i.Unlock()
+}
+
+// onNetwork returns the interfaces on a user-defined network, by container
+func (i *ifaces) onNetwork(n *userNetwork) map[string]*networkInterface {
+	i.Lock()
+	defer i.Unlock()
+	res := make(map[string]*networkInterface)
+	for key, iface := range i.c {
+		if iface.Network == n {
+			res[key] = iface
+		}
+	}
+	return res
+}
+
 var (
 	addrs = []string{
 		// Here we don't follow the convention of using the 1st IP of the range for the gateway.
@@ -195,6 +216,9 @@ func InitDriver(job *engine.Job) engine.Status {
 		bridgeIPv6Addr = networkv6.IP
 	}
 
+	iptablesEnabled = enableIPTables
+	ipMasqEnabled = ipMasq
+
 	// Configure iptables for link support
 	if enableIPTables {
 		if err := setupIPTables(addrv4, icc, ipMasq); err != nil {
@@ -267,11 +291,22 @@ func InitDriver(job *engine.Job) engine.Status {
 	// https://github.com/docker/docker/issues/2768
 	job.Eng.Hack_SetGlobalVar("httpapi.bridgeIP", bridgeIPv4Network.IP)
 
+	if root := job.Getenv("NetworkRoot"); root != "" {
+		if err := restoreNetworks(filepath.Join(root, "networks.json")); err != nil {
+			return job.Error(err)
+		}
+	}
+
 	for name, f := range map[string]engine.Handler{
 		"allocate_interface": Allocate,
 		"release_interface":  Release,
+		"release_static_ip":  ReleaseStaticIP,
 		"allocate_port":      AllocatePort,
 		"link":               LinkContainers,
+		"network_create":     CreateNetwork,
+		"network_rm":         RemoveNetwork,
+		"network_ls":         ListNetworks,
+		"network_inspect":    InspectNetwork,
 	} {
 		if err := job.Eng.Register(name, f); err != nil {
 			return job.Error(err)
@@ -507,10 +542,31 @@ func Allocate(job *engine.Job) engine.Status {
 		requestedIP   = net.ParseIP(job.Getenv("RequestedIP"))
 		requestedIPv6 = net.ParseIP(job.Getenv("RequestedIPv6"))
 		globalIPv6    net.IP
+		network       *userNetwork
+		ipv4Network   = bridgeIPv4Network
+		gateway       = bridgeIPv4Network.IP
+		bridge        = bridgeIface
 	)
 
-	ip, err = ipallocator.RequestIP(bridgeIPv4Network, requestedIP)
-	if err != nil {
+	if name := job.Getenv("Network"); name != "" {
+		// The network can't be removed before the interface is known
+		networks.Lock()
+		defer networks.Unlock()
+		if network, err = networks.getLocked(name); err != nil {
+			return job.Error(err)
+		}
+		ipv4Network, gateway, bridge = network.subnet, network.gateway, network.Bridge
+	}
+
+	if job.GetenvBool("ReserveIP") {
+		if network == nil || requestedIP == nil {
+			return job.Errorf("A static IP can only be reserved on a user-defined network")
+		}
+		if err = reserveIPLocked(network, requestedIP, id); err != nil {
+			return job.Error(err)
+		}
+		ip = requestedIP
+	} else if ip, err = ipallocator.RequestIP(ipv4Network, requestedIP); err != nil {
 		return job.Error(err)
 	}
 
@@ -519,7 +575,7 @@ func Allocate(job *engine.Job) engine.Status {
 		mac = generateMacAddr(ip)
 	}
 
-	if globalIPv6Network != nil {
+	if globalIPv6Network != nil && network == nil {
 		// If globalIPv6Network Size is at least a /80 subnet generate IPv6 address from MAC address
 		netmask_ones, _ := globalIPv6Network.Mask.Size()
 		if requestedIPv6 == nil && netmask_ones <= 80 {
@@ -540,12 +596,12 @@ func Allocate(job *engine.Job) engine.Status {
 
 	out := engine.Env{}
 	out.Set("IP", ip.String())
-	out.Set("Mask", bridgeIPv4Network.Mask.String())
-	out.Set("Gateway", bridgeIPv4Network.IP.String())
+	out.Set("Mask", ipv4Network.Mask.String())
+	out.Set("Gateway", gateway.String())
 	out.Set("MacAddress", mac.String())
-	out.Set("Bridge", bridgeIface)
+	out.Set("Bridge", bridge)
 
-	size, _ := bridgeIPv4Network.Mask.Size()
+	size, _ := ipv4Network.Mask.Size()
 	out.SetInt("IPPrefixLen", size)
 
 	// If linklocal IPv6
@@ -557,7 +613,7 @@ func Allocate(job *engine.Job) engine.Status {
 	out.Set("LinkLocalIPv6", localIPv6.String())
 	out.Set("MacAddress", mac.String())
 
-	if globalIPv6Network != nil {
+	if globalIPv6Network != nil && network == nil {
 		out.Set("GlobalIPv6", globalIPv6.String())
 		sizev6, _ := globalIPv6Network.Mask.Size()
 		out.SetInt("GlobalIPv6PrefixLen", sizev6)
@@ -565,8 +621,9 @@ func Allocate(job *engine.Job) engine.Status {
 	}
 
 	currentInterfaces.Set(id, &networkInterface{
-		IP:   ip,
-		IPv6: globalIPv6,
+		IP:      ip,
+		IPv6:    globalIPv6,
+		Network: network,
 	})
 
 	out.WriteTo(job.Stdout)
@@ -591,6 +648,19 @@ func Release(job *engine.Job) engine.Status {
 		}
 	}
 
+	if n := containerInterface.Network; n != nil {
+		networks.Lock()
+		defer networks.Unlock()
+		// A static IP stays reserved until the container is removed
+		if n.Reserved[containerInterface.IP.String()] != id {
+			if err := ipallocator.ReleaseIP(n.subnet, containerInterface.IP); err != nil {
+				log.Infof("Unable to release IPv4 %s", err)
+			}
+		}
+		currentInterfaces.Delete(id)
+		return engine.StatusOK
+	}
+
 	if err := ipallocator.ReleaseIP(bridgeIPv4Network, containerInterface.IP); err != nil {
 		log.Infof("Unable to release IPv4 %s", err)
 	}
@@ -616,6 +686,13 @@ func AllocatePort(job *engine.Job) engine.Status {
 		network       = currentInterfaces.Get(id)
 	)
 
+	if network == nil {
+		return job.Errorf("No network information for %s", id)
+	}
+	if network.Network != nil {
+		return job.Errorf("Publishing ports is not supported on network %s", network.Network.Name)
+	}
+
 	if hostIP != "" {
 		ip = net.ParseIP(hostIP)
 		if ip == nil {
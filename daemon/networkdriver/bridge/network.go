@@ -0,0 +1,487 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/networkdriver"
+	"github.com/docker/docker/daemon/networkdriver/ipallocator"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/common"
+	"github.com/docker/docker/pkg/iptables"
+	"github.com/docker/docker/pkg/networkfs/resolvconf"
+	"github.com/docker/libcontainer/netlink"
+)
+
+// userBridgePrefix starts the name of the bridges of user-defined networks
+const userBridgePrefix = "br-"
+
+var (
+	validNetworkName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+	// The names of the network modes can't be used by a network
+	reservedNetworkNames = map[string]struct{}{
+		"bridge": {}, "host": {}, "none": {}, "container": {}, "default": {},
+	}
+
+	networks = &networkStore{byID: make(map[string]*userNetwork)}
+
+	iptablesEnabled bool
+	ipMasqEnabled   bool
+)
+
+// userNetwork is a bridge network created with docker network create. Its
+// containers can reach each other, but not the containers of other networks.
+type userNetwork struct {
+	ID     string
+	Name   string
+	Bridge string
+	Subnet string
+	// The static IP addresses of containers, kept until they are removed
+	Reserved map[string]string `json:",omitempty"`
+
+	subnet  *net.IPNet
+	gateway net.IP
+}
+
+func (n *userNetwork) parse() error {
+	ip, subnet, err := net.ParseCIDR(n.Subnet)
+	if err != nil {
+		return err
+	}
+	if !ip.Equal(subnet.IP) {
+		return fmt.Errorf("Invalid subnet %s: use the network address %s", n.Subnet, subnet)
+	}
+	ones, bits := subnet.Mask.Size()
+	if bits != 32 || ones > 30 {
+		return fmt.Errorf("Invalid subnet %s: an IPv4 subnet of at least 4 addresses is needed", n.Subnet)
+	}
+	n.subnet = subnet
+	n.gateway = make(net.IP, len(subnet.IP.To4()))
+	copy(n.gateway, subnet.IP.To4())
+	n.gateway[len(n.gateway)-1]++
+	return nil
+}
+
+// networkStore holds the user-defined networks, saved in a file so they are
+// set up again when the daemon restarts
+type networkStore struct {
+	sync.Mutex
+	path string
+	byID map[string]*userNetwork
+}
+
+// get finds a network by name, id or unique id prefix
+func (s *networkStore) get(nameOrID string) (*userNetwork, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.getLocked(nameOrID)
+}
+
+func (s *networkStore) getLocked(nameOrID string) (*userNetwork, error) {
+	var found *userNetwork
+	for _, n := range s.byID {
+		if n.Name == nameOrID || n.ID == nameOrID {
+			return n, nil
+		}
+		if nameOrID != "" && strings.HasPrefix(n.ID, nameOrID) {
+			if found != nil {
+				return nil, fmt.Errorf("Network id prefix %s is ambiguous", nameOrID)
+			}
+			found = n
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("No such network: %s", nameOrID)
+	}
+	return found, nil
+}
+
+func (s *networkStore) list() []*userNetwork {
+	s.Lock()
+	defer s.Unlock()
+	list := make([]*userNetwork, 0, len(s.byID))
+	for _, n := range s.byID {
+		list = append(list, n)
+	}
+	return list
+}
+
+// saveLocked writes the networks to the file of the store, if it has one
+func (s *networkStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	list := make([]*userNetwork, 0, len(s.byID))
+	for _, n := range s.byID {
+		list = append(list, n)
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0600)
+}
+
+// readNetworks reads the networks saved at path
+func readNetworks(path string) ([]*userNetwork, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var list []*userNetwork
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("Error reading the networks in %s: %v", path, err)
+	}
+	for _, n := range list {
+		if err := n.parse(); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// restoreNetworks sets up again the networks saved at path
+func restoreNetworks(path string) error {
+	list, err := readNetworks(path)
+	if err != nil {
+		return err
+	}
+	networks.Lock()
+	defer networks.Unlock()
+	networks.path = path
+	for _, n := range list {
+		if err := setupNetwork(n); err != nil {
+			log.Errorf("Error setting up network %s: %v", n.Name, err)
+			continue
+		}
+		networks.byID[n.ID] = n
+	}
+	return nil
+}
+
+// setupNetwork creates the bridge of a network if it doesn't exist, and the
+// iptables rules isolating it
+func setupNetwork(n *userNetwork) error {
+	if _, err := net.InterfaceByName(n.Bridge); err != nil {
+		if err := createBridgeIface(n.Bridge); err != nil && !os.IsExist(err) {
+			return err
+		}
+		iface, err := net.InterfaceByName(n.Bridge)
+		if err != nil {
+			return err
+		}
+		if err := netlink.NetworkLinkAddIp(iface, n.gateway, n.subnet); err != nil {
+			netlink.DeleteBridge(n.Bridge)
+			return fmt.Errorf("Unable to add the network %s to bridge %s: %v", n.Subnet, n.Bridge, err)
+		}
+		if err := netlink.NetworkLinkUp(iface); err != nil {
+			netlink.DeleteBridge(n.Bridge)
+			return fmt.Errorf("Unable to start network bridge %s: %v", n.Bridge, err)
+		}
+	}
+	if err := setupNetworkIPTables(n, true); err != nil {
+		setupNetworkIPTables(n, false)
+		netlink.DeleteBridge(n.Bridge)
+		return err
+	}
+	if _, err := ipallocator.RequestIP(n.subnet, n.gateway); err != nil && err != ipallocator.ErrIPAlreadyAllocated {
+		return err
+	}
+	for ip := range n.Reserved {
+		if _, err := ipallocator.RequestIP(n.subnet, net.ParseIP(ip)); err != nil && err != ipallocator.ErrIPAlreadyAllocated {
+			return err
+		}
+	}
+	return nil
+}
+
+// reserveIPLocked allocates a static IP address of a network to a container,
+// which keeps it when it stops. The networks must be locked.
+func reserveIPLocked(n *userNetwork, ip net.IP, id string) error {
+	if !n.subnet.Contains(ip) {
+		return fmt.Errorf("IP %s is outside the subnet %s of network %s", ip, n.Subnet, n.Name)
+	}
+	if owner, ok := n.Reserved[ip.String()]; ok {
+		if owner != id {
+			return fmt.Errorf("IP %s is already reserved on network %s", ip, n.Name)
+		}
+		return nil
+	}
+	if _, err := ipallocator.RequestIP(n.subnet, ip); err != nil {
+		return fmt.Errorf("Unable to assign IP %s on network %s: %v", ip, n.Name, err)
+	}
+	if n.Reserved == nil {
+		n.Reserved = make(map[string]string)
+	}
+	n.Reserved[ip.String()] = id
+	if err := networks.saveLocked(); err != nil {
+		delete(n.Reserved, ip.String())
+		ipallocator.ReleaseIP(n.subnet, ip)
+		return err
+	}
+	return nil
+}
+
+// ReleaseStaticIP releases the static IP addresses reserved by a container,
+// once it is removed
+func ReleaseStaticIP(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s CONTAINER", job.Name)
+	}
+	id := job.Args[0]
+	networks.Lock()
+	defer networks.Unlock()
+	changed := false
+	for _, n := range networks.byID {
+		for ip, owner := range n.Reserved {
+			if owner != id {
+				continue
+			}
+			delete(n.Reserved, ip)
+			ipallocator.ReleaseIP(n.subnet, net.ParseIP(ip))
+			changed = true
+		}
+	}
+	if changed {
+		if err := networks.saveLocked(); err != nil {
+			return job.Error(err)
+		}
+	}
+	return engine.StatusOK
+}
+
+// networkRules returns the FORWARD rules of a network, from the first to the
+// last. Its containers reach each other and the outside, but no other bridge,
+// and only the replies to their connections come in.
+func networkRules(n *userNetwork) [][]string {
+	return [][]string{
+		{"-i", n.Bridge, "-o", n.Bridge, "-j", "ACCEPT"},
+		{"-o", n.Bridge, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"},
+		{"-i", n.Bridge, "-o", bridgeIface, "-j", "DROP"},
+		{"-i", n.Bridge, "-o", userBridgePrefix + "+", "-j", "DROP"},
+		{"-o", n.Bridge, "-j", "DROP"},
+		{"-i", n.Bridge, "-j", "ACCEPT"},
+	}
+}
+
+// setupNetworkIPTables adds, or deletes, the iptables rules of a network
+func setupNetworkIPTables(n *userNetwork, add bool) error {
+	if !iptablesEnabled {
+		return nil
+	}
+	natArgs := []string{"-s", n.Subnet, "!", "-o", n.Bridge, "-j", "MASQUERADE"}
+	rules := networkRules(n)
+	if !add {
+		if ipMasqEnabled {
+			iptables.Raw(append([]string{"-t", string(iptables.Nat), "-D", "POSTROUTING"}, natArgs...)...)
+		}
+		for _, rule := range rules {
+			iptables.Raw(append([]string{"-D", "FORWARD"}, rule...)...)
+		}
+		return nil
+	}
+
+	if ipMasqEnabled && !iptables.Exists(iptables.Nat, "POSTROUTING", natArgs...) {
+		if output, err := iptables.Raw(append([]string{"-t", string(iptables.Nat), "-I", "POSTROUTING"}, natArgs...)...); err != nil {
+			return fmt.Errorf("Unable to enable NAT for network %s: %s", n.Name, err)
+		} else if len(output) != 0 {
+			return &iptables.ChainError{Chain: "POSTROUTING", Output: output}
+		}
+	}
+	// The rules are inserted at the top of FORWARD, so the last goes first
+	for i := len(rules) - 1; i >= 0; i-- {
+		if iptables.Exists(iptables.Filter, "FORWARD", rules[i]...) {
+			continue
+		}
+		if output, err := iptables.Raw(append([]string{"-I", "FORWARD"}, rules[i]...)...); err != nil {
+			return fmt.Errorf("Unable to isolate network %s: %s", n.Name, err)
+		} else if len(output) != 0 {
+			return &iptables.ChainError{Chain: "FORWARD", Output: output}
+		}
+	}
+	return nil
+}
+
+// freeSubnet picks the first of the default bridge ranges which overlaps
+// neither a route of the host nor its nameservers
+func freeSubnet() (string, error) {
+	var nameservers []string
+	if resolvConf, _ := resolvconf.Get(); resolvConf != nil {
+		nameservers = resolvconf.GetNameserversAsCIDR(resolvConf)
+	}
+	for _, addr := range addrs {
+		_, subnet, err := net.ParseCIDR(addr)
+		if err != nil {
+			return "", err
+		}
+		if networkdriver.CheckNameserverOverlaps(nameservers, subnet) != nil {
+			continue
+		}
+		if networkdriver.CheckRouteOverlaps(subnet) != nil {
+			continue
+		}
+		return subnet.String(), nil
+	}
+	return "", fmt.Errorf("Could not find a free subnet for the network, use --subnet")
+}
+
+// CreateNetwork creates a user-defined bridge network, with the subnet given
+// or a free one.
+func CreateNetwork(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s NAME", job.Name)
+	}
+	name := job.Args[0]
+	if !validNetworkName.MatchString(name) {
+		return job.Errorf("Invalid network name %q, only [a-zA-Z0-9][a-zA-Z0-9_.-] are allowed", name)
+	}
+	if _, ok := reservedNetworkNames[name]; ok {
+		return job.Errorf("Network name %s is reserved", name)
+	}
+	if driver := job.Getenv("Driver"); driver != "" && driver != "bridge" {
+		return job.Errorf("Unsupported network driver: %s", driver)
+	}
+
+	networks.Lock()
+	defer networks.Unlock()
+	for _, n := range networks.byID {
+		if n.Name == name {
+			return job.Errorf("Network %s already exists", name)
+		}
+	}
+
+	subnet := job.Getenv("Subnet")
+	if subnet == "" {
+		var err error
+		if subnet, err = freeSubnet(); err != nil {
+			return job.Error(err)
+		}
+	}
+	id := common.GenerateRandomID()
+	n := &userNetwork{
+		ID:     id,
+		Name:   name,
+		Bridge: userBridgePrefix + common.TruncateID(id),
+		Subnet: subnet,
+	}
+	if err := n.parse(); err != nil {
+		return job.Error(err)
+	}
+	if err := networkdriver.CheckRouteOverlaps(n.subnet); err != nil {
+		return job.Errorf("Subnet %s overlaps with another network of the host", n.Subnet)
+	}
+	if err := setupNetwork(n); err != nil {
+		return job.Error(err)
+	}
+	networks.byID[n.ID] = n
+	if err := networks.saveLocked(); err != nil {
+		delete(networks.byID, n.ID)
+		removeNetwork(n)
+		return job.Error(err)
+	}
+
+	out := engine.Env{}
+	out.Set("Id", n.ID)
+	if _, err := out.WriteTo(job.Stdout); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// removeNetwork deletes the bridge and iptables rules of a network
+func removeNetwork(n *userNetwork) {
+	setupNetworkIPTables(n, false)
+	if err := netlink.DeleteBridge(n.Bridge); err != nil {
+		log.Errorf("Error deleting bridge %s of network %s: %v", n.Bridge, n.Name, err)
+	}
+	ipallocator.ReleaseIP(n.subnet, n.gateway)
+	for ip := range n.Reserved {
+		ipallocator.ReleaseIP(n.subnet, net.ParseIP(ip))
+	}
+}
+
+// RemoveNetwork removes a user-defined network. It fails while containers
+// are attached to it.
+func RemoveNetwork(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s NETWORK", job.Name)
+	}
+	networks.Lock()
+	defer networks.Unlock()
+	n, err := networks.getLocked(job.Args[0])
+	if err != nil {
+		return job.Error(err)
+	}
+	if attached := currentInterfaces.onNetwork(n); len(attached) > 0 {
+		return job.Errorf("Network %s has active endpoints", n.Name)
+	}
+	delete(networks.byID, n.ID)
+	if err := networks.saveLocked(); err != nil {
+		networks.byID[n.ID] = n
+		return job.Error(err)
+	}
+	removeNetwork(n)
+	return engine.StatusOK
+}
+
+func networkEnv(n *userNetwork) *engine.Env {
+	out := &engine.Env{}
+	out.Set("Id", n.ID)
+	out.Set("Name", n.Name)
+	out.Set("Driver", "bridge")
+	out.Set("Bridge", n.Bridge)
+	out.Set("Subnet", n.Subnet)
+	out.Set("Gateway", n.gateway.String())
+	return out
+}
+
+// ListNetworks lists the user-defined networks
+func ListNetworks(job *engine.Job) engine.Status {
+	outs := engine.NewTable("Name", 0)
+	for _, n := range networks.list() {
+		outs.Add(networkEnv(n))
+	}
+	outs.Sort()
+	if _, err := outs.WriteListTo(job.Stdout); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// InspectNetwork describes a user-defined network, with the address of each
+// container attached to it
+func InspectNetwork(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s NETWORK", job.Name)
+	}
+	n, err := networks.get(job.Args[0])
+	if err != nil {
+		return job.Error(err)
+	}
+	containers := make(map[string]map[string]string)
+	for id, iface := range currentInterfaces.onNetwork(n) {
+		containers[id] = map[string]string{"IPAddress": iface.IP.String()}
+	}
+	out := networkEnv(n)
+	if err := out.SetJson("Containers", containers); err != nil {
+		return job.Error(err)
+	}
+	if _, err := out.WriteTo(job.Stdout); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
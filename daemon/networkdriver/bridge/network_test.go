@@ -0,0 +1,117 @@
+package bridge
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/daemon/networkdriver/ipallocator"
+)
+
+func TestUserNetworkParse(t *testing.T) {
+	n := &userNetwork{Subnet: "10.99.0.0/24"}
+	if err := n.parse(); err != nil {
+		t.Fatal(err)
+	}
+	if n.gateway.String() != "10.99.0.1" {
+		t.Fatalf("Expected the gateway 10.99.0.1, got %s", n.gateway)
+	}
+	if n.subnet.String() != "10.99.0.0/24" {
+		t.Fatalf("Expected the subnet 10.99.0.0/24, got %s", n.subnet)
+	}
+
+	for _, subnet := range []string{"10.99.0.1/24", "10.99.0.0/31", "fd00::/64", "10.99.0.0"} {
+		if err := (&userNetwork{Subnet: subnet}).parse(); err == nil {
+			t.Fatalf("Expected an error for the subnet %s", subnet)
+		}
+	}
+}
+
+func TestNetworkStoreGet(t *testing.T) {
+	s := &networkStore{byID: map[string]*userNetwork{
+		"abc123": {ID: "abc123", Name: "front"},
+		"abd456": {ID: "abd456", Name: "back"},
+	}}
+	for nameOrID, expected := range map[string]string{
+		"front":  "abc123",
+		"abd456": "abd456",
+		"abc":    "abc123",
+		"abd4":   "abd456",
+	} {
+		n, err := s.get(nameOrID)
+		if err != nil {
+			t.Fatalf("%s: %v", nameOrID, err)
+		}
+		if n.ID != expected {
+			t.Fatalf("%s: expected the network %s, got %s", nameOrID, expected, n.ID)
+		}
+	}
+	for _, nameOrID := range []string{"ab", "other", ""} {
+		if _, err := s.get(nameOrID); err == nil {
+			t.Fatalf("Expected an error looking up %q", nameOrID)
+		}
+	}
+}
+
+func TestNetworkStoreSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "networks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "network", "networks.json")
+	if list, err := readNetworks(path); err != nil || len(list) != 0 {
+		t.Fatalf("Expected no network before saving, got %v, %v", list, err)
+	}
+
+	s := &networkStore{path: path, byID: map[string]*userNetwork{
+		"abc123": {ID: "abc123", Name: "front", Bridge: "br-abc123", Subnet: "10.99.0.0/24"},
+	}}
+	if err := s.saveLocked(); err != nil {
+		t.Fatal(err)
+	}
+	list, err := readNetworks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected one network, got %d", len(list))
+	}
+	n := list[0]
+	if n.ID != "abc123" || n.Name != "front" || n.Bridge != "br-abc123" || n.Subnet != "10.99.0.0/24" {
+		t.Fatalf("Unexpected network read: %+v", n)
+	}
+	if n.gateway.String() != "10.99.0.1" {
+		t.Fatalf("Expected the gateway of the network read to be 10.99.0.1, got %s", n.gateway)
+	}
+}
+
+func TestReserveIP(t *testing.T) {
+	n := &userNetwork{Name: "static", Subnet: "10.98.0.0/24"}
+	if err := n.parse(); err != nil {
+		t.Fatal(err)
+	}
+	ip := net.ParseIP("10.98.0.5")
+	if err := reserveIPLocked(n, ip, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if n.Reserved["10.98.0.5"] != "first" {
+		t.Fatalf("Expected 10.98.0.5 to be reserved by first, got %v", n.Reserved)
+	}
+	// The owner gets it again when it restarts
+	if err := reserveIPLocked(n, ip, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reserveIPLocked(n, ip, "second"); err == nil {
+		t.Fatal("Expected a reserved IP not to be given to another container")
+	}
+	if err := reserveIPLocked(n, net.ParseIP("10.97.0.5"), "second"); err == nil {
+		t.Fatal("Expected an IP outside the subnet to be rejected")
+	}
+	if _, err := ipallocator.RequestIP(n.subnet, ip); err != ipallocator.ErrIPAlreadyAllocated {
+		t.Fatalf("Expected a reserved IP to be allocated, got %v", err)
+	}
+}
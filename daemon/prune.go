@@ -0,0 +1,136 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/parsers/filters"
+)
+
+var (
+	acceptedImagePruneFilterTags = map[string]struct{}{
+		"until": {},
+	}
+	acceptedContainerPruneFilterTags = map[string]struct{}{
+		"until": {},
+		"label": {},
+	}
+)
+
+// pruneCutoff returns the time before which objects must have been created to
+// be pruned, following the until filter. It is the zero time when no until
+// filter is given.
+func pruneCutoff(pruneFilters filters.Args) (time.Time, error) {
+	var cutoff time.Time
+	values := pruneFilters["until"]
+	if len(values) == 0 {
+		return cutoff, nil
+	}
+	if len(values) > 1 {
+		return cutoff, fmt.Errorf("Only one until filter can be given")
+	}
+	d, err := time.ParseDuration(values[0])
+	if err != nil {
+		return cutoff, fmt.Errorf("Invalid until filter '%s': %v", values[0], err)
+	}
+	return time.Now().Add(-d), nil
+}
+
+// ImagePrune deletes the dangling images: those without a tag, without
+// children and not used by any container. Their untagged parents are deleted
+// with them, so ancestors of tagged images are always kept.
+func (daemon *Daemon) ImagePrune(job *engine.Job) engine.Status {
+	if n := len(job.Args); n != 0 {
+		return job.Errorf("Usage: %s", job.Name)
+	}
+	pruneFilters, err := filters.FromParam(job.Getenv("filters"))
+	if err != nil {
+		return job.Error(err)
+	}
+	for name := range pruneFilters {
+		if _, ok := acceptedImagePruneFilterTags[name]; !ok {
+			return job.Errorf("Invalid filter '%s'", name)
+		}
+	}
+	cutoff, err := pruneCutoff(pruneFilters)
+	if err != nil {
+		return job.Error(err)
+	}
+
+	heads, err := daemon.Graph().Heads()
+	if err != nil {
+		return job.Error(err)
+	}
+	byID := daemon.Repositories().ByID()
+	imgs := engine.NewTable("", 0)
+	for id, img := range heads {
+		if len(byID[id]) > 0 {
+			continue
+		}
+		if !cutoff.IsZero() && !img.Created.Before(cutoff) {
+			continue
+		}
+		if err := daemon.canDeleteImage(id, false); err != nil {
+			continue
+		}
+		if err := daemon.DeleteImage(job.Eng, id, imgs, true, false, false); err != nil {
+			// One of its parents is in use, what was deleted is reported
+			log.Debugf("Error pruning image %s: %v", id, err)
+		}
+	}
+	if _, err := imgs.WriteListTo(job.Stdout); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// ContainerPrune removes the stopped containers. Running, paused and
+// restarting containers are never removed.
+func (daemon *Daemon) ContainerPrune(job *engine.Job) engine.Status {
+	if n := len(job.Args); n != 0 {
+		return job.Errorf("Usage: %s", job.Name)
+	}
+	pruneFilters, err := filters.FromParam(job.Getenv("filters"))
+	if err != nil {
+		return job.Error(err)
+	}
+	for name := range pruneFilters {
+		if _, ok := acceptedContainerPruneFilterTags[name]; !ok {
+			return job.Errorf("Invalid filter '%s'", name)
+		}
+	}
+	cutoff, err := pruneCutoff(pruneFilters)
+	if err != nil {
+		return job.Error(err)
+	}
+
+	outs := engine.NewTable("", 0)
+	for _, container := range daemon.List() {
+		if container.IsRunning() || container.IsPaused() || container.IsRestarting() {
+			continue
+		}
+		if !cutoff.IsZero() && !container.Created.Before(cutoff) {
+			continue
+		}
+		if !pruneFilters.MatchKVList("label", container.Config.Labels) {
+			continue
+		}
+		sizeRw, _ := container.GetSize()
+		daemon.statsCollector.stopCollection(container)
+		if err := daemon.Rm(container); err != nil {
+			log.Errorf("Error pruning container %s: %v", container.ID, err)
+			continue
+		}
+		container.LogEvent("destroy")
+		out := &engine.Env{}
+		out.Set("Deleted", container.ID)
+		out.SetInt64("Size", sizeRw)
+		outs.Add(out)
+	}
+	if _, err := outs.WriteListTo(job.Stdout); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
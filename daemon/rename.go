@@ -1,6 +1,9 @@
 package daemon
 
-import "github.com/docker/docker/engine"
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/engine"
+)
 
 func (daemon *Daemon) ContainerRename(job *engine.Job) engine.Status {
 	if len(job.Args) != 2 {
@@ -22,12 +25,20 @@ func (daemon *Daemon) ContainerRename(job *engine.Job) engine.Status {
 		return job.Errorf("Error when allocating new name: %s", err)
 	}
 
+	// The other containers on its network resolve it by its new name
+	updatePeersHosts := func(add bool) {
+		if err := container.updateNetworkPeersHosts(add); err != nil {
+			log.Errorf("%v: Failed to update /etc/hosts of the containers on its network: %v", container.ID, err)
+		}
+	}
+	updatePeersHosts(false)
 	container.Name = newName
 
 	undo := func() {
 		container.Name = oldName
 		daemon.reserveName(container.ID, oldName)
 		daemon.containerGraph.Delete(newName)
+		updatePeersHosts(true)
 	}
 
 	if err := daemon.containerGraph.Delete(oldName); err != nil {
@@ -40,5 +51,7 @@ func (daemon *Daemon) ContainerRename(job *engine.Job) engine.Status {
 		return job.Error(err)
 	}
 
+	updatePeersHosts(true)
+	container.LogEvent("rename")
 	return engine.StatusOK
 }
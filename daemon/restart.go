@@ -10,7 +10,7 @@ func (daemon *Daemon) ContainerRestart(job *engine.Job) engine.Status {
 	}
 	var (
 		name = job.Args[0]
-		t    = 10
+		t    = DefaultStopTimeout
 	)
 	if job.EnvExists("t") {
 		t = job.GetenvInt("t")
@@ -19,6 +19,7 @@ func (daemon *Daemon) ContainerRestart(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
+	container.setManuallyStopped(false)
 	if err := container.Restart(int(t)); err != nil {
 		return job.Errorf("Cannot restart container %s: %s\n", name, err)
 	}
@@ -39,6 +39,7 @@ func (daemon *Daemon) ContainerStart(job *engine.Job) engine.Status {
 			return job.Error(err)
 		}
 	}
+	container.setManuallyStopped(false)
 	if err := container.Start(); err != nil {
 		container.LogEvent("die")
 		return job.Errorf("Cannot start container %s: %s", name, err)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/pkg/units"
+	"golang.org/x/net/context"
 )
 
 type State struct {
@@ -20,6 +21,8 @@ type State struct {
 	Error      string // contains last known error when starting the container
 	StartedAt  time.Time
 	FinishedAt time.Time
+	exitStatus execdriver.ExitStatus
+	Health     *Health `json:",omitempty"` // set when the container has a health check
 	waitChan   chan struct{}
 }
 
@@ -39,9 +42,17 @@ func (s *State) String() string {
 			return fmt.Sprintf("Restarting (%d) %s ago", s.ExitCode, units.HumanDuration(time.Now().UTC().Sub(s.FinishedAt)))
 		}
 
+		if s.Health != nil {
+			return fmt.Sprintf("Up %s (%s)", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)), s.Health)
+		}
 		return fmt.Sprintf("Up %s", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)))
 	}
 
+	// A container made by docker create has never been started
+	if s.StartedAt.IsZero() {
+		return "Created"
+	}
+
 	if s.FinishedAt.IsZero() {
 		return ""
 	}
@@ -60,6 +71,9 @@ func (s *State) StateString() string {
 		}
 		return "running"
 	}
+	if s.StartedAt.IsZero() {
+		return "created"
+	}
 	return "exited"
 }
 
@@ -112,6 +126,54 @@ func (s *State) WaitStop(timeout time.Duration) (int, error) {
 	return s.GetExitCode(), nil
 }
 
+// WaitStopStatus is like WaitStop, but returns the full exit status of the
+// process, which tells whether it was terminated by a signal.
+func (s *State) WaitStopStatus(timeout time.Duration) (execdriver.ExitStatus, error) {
+	s.Lock()
+	if !s.Running {
+		exitStatus := s.getExitStatus()
+		s.Unlock()
+		return exitStatus, nil
+	}
+	waitChan := s.waitChan
+	s.Unlock()
+	if err := wait(waitChan, timeout); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	s.Lock()
+	defer s.Unlock()
+	return s.getExitStatus(), nil
+}
+
+// getExitStatus returns the last exit status. The signal isn't saved to disk,
+// so the exit code and OOM kill are taken from the state itself.
+func (s *State) getExitStatus() execdriver.ExitStatus {
+	exitStatus := s.exitStatus
+	exitStatus.ExitCode = s.ExitCode
+	exitStatus.OOMKilled = s.OOMKilled
+	return exitStatus
+}
+
+// WaitStopContext is like WaitStop, but waits until ctx is done instead of a
+// timeout. If ctx is done first, it returns ctx.Err() and leaves the
+// container as it is.
+func (s *State) WaitStopContext(ctx context.Context) (int, error) {
+	s.Lock()
+	if !s.Running {
+		exitCode := s.ExitCode
+		s.Unlock()
+		return exitCode, nil
+	}
+	waitChan := s.waitChan
+	s.Unlock()
+	select {
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	case <-waitChan:
+		return s.GetExitCode(), nil
+	}
+}
+
 func (s *State) IsRunning() bool {
 	s.Lock()
 	res := s.Running
@@ -147,6 +209,8 @@ func (s *State) setRunning(pid int) {
 	s.ExitCode = 0
 	s.Pid = pid
 	s.StartedAt = time.Now().UTC()
+	// a running container hasn't finished yet, whatever its previous run did
+	s.FinishedAt = time.Time{}
 	close(s.waitChan) // fire waiters for start
 	s.waitChan = make(chan struct{})
 }
@@ -164,6 +228,7 @@ func (s *State) setStopped(exitStatus *execdriver.ExitStatus) {
 	s.FinishedAt = time.Now().UTC()
 	s.ExitCode = exitStatus.ExitCode
 	s.OOMKilled = exitStatus.OOMKilled
+	s.exitStatus = *exitStatus
 	close(s.waitChan) // fire waiters for stop
 	s.waitChan = make(chan struct{})
 }
@@ -180,6 +245,7 @@ func (s *State) SetRestarting(exitStatus *execdriver.ExitStatus) {
 	s.FinishedAt = time.Now().UTC()
 	s.ExitCode = exitStatus.ExitCode
 	s.OOMKilled = exitStatus.OOMKilled
+	s.exitStatus = *exitStatus
 	close(s.waitChan) // fire waiters for stop
 	s.waitChan = make(chan struct{})
 	s.Unlock()
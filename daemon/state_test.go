@@ -1,11 +1,15 @@
 package daemon
 
 import (
+	"fmt"
+	"strings"
 	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/daemon/execdriver"
+	"golang.org/x/net/context"
 )
 
 func TestStateRunStop(t *testing.T) {
@@ -28,6 +32,12 @@ func TestStateRunStop(t *testing.T) {
 		if s.ExitCode != 0 {
 			t.Fatalf("ExitCode %v, expected 0", s.ExitCode)
 		}
+		if s.StartedAt.IsZero() {
+			t.Fatal("StartedAt not set")
+		}
+		if !s.FinishedAt.IsZero() {
+			t.Fatalf("FinishedAt %v, expected the zero time while running", s.FinishedAt)
+		}
 		select {
 		case <-time.After(100 * time.Millisecond):
 			t.Fatal("Start callback doesn't fire in 100 milliseconds")
@@ -59,6 +69,9 @@ func TestStateRunStop(t *testing.T) {
 		if s.Pid != 0 {
 			t.Fatalf("Pid %v, expected 0", s.Pid)
 		}
+		if s.FinishedAt.Before(s.StartedAt) {
+			t.Fatalf("FinishedAt %v, expected after StartedAt %v", s.FinishedAt, s.StartedAt)
+		}
 		select {
 		case <-time.After(100 * time.Millisecond):
 			t.Fatal("Stop callback doesn't fire in 100 milliseconds")
@@ -102,3 +115,86 @@ func TestStateTimeoutWait(t *testing.T) {
 	}
 
 }
+
+func TestStateWaitStopContext(t *testing.T) {
+	s := NewState()
+	s.SetRunning(42)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		_, err := s.WaitStopContext(ctx)
+		stopped <- err
+	}()
+	cancel()
+	select {
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("WaitStopContext doesn't return in 100 milliseconds after cancel")
+	case err := <-stopped:
+		if err != context.Canceled {
+			t.Fatalf("Expected %v, got %v", context.Canceled, err)
+		}
+	}
+	if !s.IsRunning() {
+		t.Fatal("Cancelling the wait shouldn't stop the state")
+	}
+
+	go func() {
+		exitCode, err := s.WaitStopContext(context.Background())
+		if err == nil && exitCode != 3 {
+			err = fmt.Errorf("ExitCode %v, expected 3", exitCode)
+		}
+		stopped <- err
+	}()
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 3})
+	select {
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Stop callback doesn't fire in 100 milliseconds")
+	case err := <-stopped:
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStateWaitStopStatus(t *testing.T) {
+	s := NewState()
+	s.SetRunning(42)
+
+	stopped := make(chan execdriver.ExitStatus)
+	go func() {
+		exitStatus, _ := s.WaitStopStatus(-1 * time.Second)
+		stopped <- exitStatus
+	}()
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 137, Signaled: true, Signal: syscall.SIGKILL})
+	select {
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Stop callback doesn't fire in 100 milliseconds")
+	case exitStatus := <-stopped:
+		if exitStatus.ExitCode != 137 || !exitStatus.Signaled || exitStatus.Signal != syscall.SIGKILL {
+			t.Fatalf("Expected exit code 137 from SIGKILL, got %+v", exitStatus)
+		}
+	}
+
+	s.SetRunning(43)
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 1})
+	if exitStatus, err := s.WaitStopStatus(-1 * time.Second); err != nil || exitStatus.Signaled || exitStatus.ExitCode != 1 {
+		t.Fatalf("Expected exit code 1 without a signal, got %+v, %v", exitStatus, err)
+	}
+}
+
+func TestStateCreated(t *testing.T) {
+	s := NewState()
+	if str, state := s.String(), s.StateString(); str != "Created" || state != "created" {
+		t.Fatalf("Expected a new state to be created, got %q and %q", str, state)
+	}
+
+	s.SetRunning(100)
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 1})
+	if state := s.StateString(); state != "exited" {
+		t.Fatalf("Expected a stopped state to be exited, got %q", state)
+	}
+	if str := s.String(); !strings.HasPrefix(str, "Exited (1) ") {
+		t.Fatalf("Expected a stopped state to be exited, got %q", str)
+	}
+}
@@ -10,7 +10,7 @@ func (daemon *Daemon) ContainerStop(job *engine.Job) engine.Status {
 	}
 	var (
 		name = job.Args[0]
-		t    = 10
+		t    = DefaultStopTimeout
 	)
 	if job.EnvExists("t") {
 		t = job.GetenvInt("t")
@@ -25,6 +25,7 @@ func (daemon *Daemon) ContainerStop(job *engine.Job) engine.Status {
 	if err := container.Stop(int(t)); err != nil {
 		return job.Errorf("Cannot stop container %s: %s\n", name, err)
 	}
+	container.setManuallyStopped(true)
 	container.LogEvent("stop")
 	return engine.StatusOK
 }
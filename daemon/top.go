@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"fmt"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -25,22 +26,35 @@ func (daemon *Daemon) ContainerTop(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
+	titles, processes, err := container.Top(strings.Split(psArgs, " "))
+	if err != nil {
+		return job.Error(err)
+	}
+	out := &engine.Env{}
+	out.SetList("Titles", titles)
+	out.SetJson("Processes", processes)
+	out.WriteTo(job.Stdout)
+	return engine.StatusOK
+}
+
+// Top runs ps with the given arguments on the host and keeps the processes
+// of the container. It returns the titles of the ps columns and a row of
+// fields for every process.
+func (container *Container) Top(psArgs []string) ([]string, [][]string, error) {
 	if !container.IsRunning() {
-		return job.Errorf("Container %s is not running", name)
+		return nil, nil, fmt.Errorf("Container %s is not running", container.ID)
 	}
-	pids, err := daemon.ExecutionDriver().GetPidsForContainer(container.ID)
+	pids, err := container.daemon.ExecutionDriver().GetPidsForContainer(container.ID)
 	if err != nil {
-		return job.Error(err)
+		return nil, nil, err
 	}
-	output, err := exec.Command("ps", strings.Split(psArgs, " ")...).Output()
+	output, err := exec.Command("ps", psArgs...).Output()
 	if err != nil {
-		return job.Errorf("Error running ps: %s", err)
+		return nil, nil, fmt.Errorf("Error running ps: %s", err)
 	}
 
 	lines := strings.Split(string(output), "\n")
 	header := strings.Fields(lines[0])
-	out := &engine.Env{}
-	out.SetList("Titles", header)
 
 	pidIndex := -1
 	for i, name := range header {
@@ -49,7 +63,7 @@ func (daemon *Daemon) ContainerTop(job *engine.Job) engine.Status {
 		}
 	}
 	if pidIndex == -1 {
-		return job.Errorf("Couldn't find PID field in ps output")
+		return nil, nil, fmt.Errorf("Couldn't find PID field in ps output")
 	}
 
 	processes := [][]string{}
@@ -58,9 +72,12 @@ func (daemon *Daemon) ContainerTop(job *engine.Job) engine.Status {
 			continue
 		}
 		fields := strings.Fields(line)
+		if len(fields) < len(header) {
+			return nil, nil, fmt.Errorf("Unexpected ps output line '%s'", line)
+		}
 		p, err := strconv.Atoi(fields[pidIndex])
 		if err != nil {
-			return job.Errorf("Unexpected pid '%s': %s", fields[pidIndex], err)
+			return nil, nil, fmt.Errorf("Unexpected pid '%s': %s", fields[pidIndex], err)
 		}
 
 		for _, pid := range pids {
@@ -73,7 +90,5 @@ func (daemon *Daemon) ContainerTop(job *engine.Job) engine.Status {
 			}
 		}
 	}
-	out.SetJson("Processes", processes)
-	out.WriteTo(job.Stdout)
-	return engine.StatusOK
+	return header, processes, nil
 }
@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/runconfig"
+)
+
+// ContainerUpdate changes the resource limits of a container: its memory and
+// swap limits, cpu shares, cpuset, pids limit, block IO weight and device read
+// rates. The limits which are left
+// to zero are unchanged. A running container gets the new ones right away, a
+// stopped one when it starts.
+func (daemon *Daemon) ContainerUpdate(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s CONTAINER", job.Name)
+	}
+	name := job.Args[0]
+	container, err := daemon.Get(name)
+	if err != nil {
+		return job.Error(err)
+	}
+
+	hostConfig := *container.hostConfig
+	if memory := job.GetenvInt64("Memory"); memory != 0 {
+		hostConfig.Memory = memory
+	}
+	if memorySwap := job.GetenvInt64("MemorySwap"); memorySwap != 0 {
+		hostConfig.MemorySwap = memorySwap
+	}
+	if cpuShares := job.GetenvInt64("CpuShares"); cpuShares != 0 {
+		hostConfig.CpuShares = cpuShares
+	}
+	if cpusetCpus := job.Getenv("CpusetCpus"); cpusetCpus != "" {
+		hostConfig.CpusetCpus = cpusetCpus
+	}
+	if pidsLimit := job.GetenvInt64("PidsLimit"); pidsLimit != 0 {
+		hostConfig.PidsLimit = pidsLimit
+	}
+	if blkioWeight := job.GetenvInt64("BlkioWeight"); blkioWeight != 0 {
+		hostConfig.BlkioWeight = blkioWeight
+	}
+	if job.EnvExists("BlkioDeviceReadBps") {
+		var readBps []runconfig.ThrottleDevice
+		job.GetenvJson("BlkioDeviceReadBps", &readBps)
+		hostConfig.BlkioDeviceReadBps = mergeThrottleDevices(hostConfig.BlkioDeviceReadBps, readBps)
+	}
+	// only the limits are checked, the rest of the configuration was accepted
+	// when the container was created and may predate the current checks
+	warnings, err := daemon.verifyResources(&hostConfig)
+	if err != nil {
+		return job.Errorf("Cannot update container %s: %s", name, err)
+	}
+
+	if err := container.UpdateResources(&hostConfig); err != nil {
+		return job.Errorf("Cannot update container %s: %s", name, err)
+	}
+	container.LogEvent("update")
+	for _, warning := range warnings {
+		job.Errorf("%s\n", warning)
+	}
+	return engine.StatusOK
+}
+
+// mergeThrottleDevices replaces the limits of the devices given again, and
+// keeps the other ones
+func mergeThrottleDevices(limits, updates []runconfig.ThrottleDevice) []runconfig.ThrottleDevice {
+	var merged []runconfig.ThrottleDevice
+	for _, limit := range limits {
+		updated := false
+		for _, update := range updates {
+			if update.Path == limit.Path {
+				updated = true
+				break
+			}
+		}
+		if !updated {
+			merged = append(merged, limit)
+		}
+	}
+	return append(merged, updates...)
+}
@@ -12,6 +12,8 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/reaper"
+	"github.com/docker/docker/pkg/seccomp"
 	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/volumes"
@@ -331,6 +333,21 @@ func (container *Container) setupMounts() error {
 		})
 	}
 
+	// Mount the tmpfs directories over the volumes, sorted the same way
+	tmpfsDests := make([]string, 0, len(container.hostConfig.Tmpfs))
+	for dest := range container.hostConfig.Tmpfs {
+		tmpfsDests = append(tmpfsDests, dest)
+	}
+	sort.Strings(tmpfsDests)
+	for _, dest := range tmpfsDests {
+		mounts = append(mounts, execdriver.Mount{
+			Type:        execdriver.TmpfsMount,
+			Destination: dest,
+			Writable:    true,
+			Data:        container.hostConfig.Tmpfs[dest],
+		})
+	}
+
 	if container.ResolvConfPath != "" {
 		mounts = append(mounts, execdriver.Mount{Source: container.ResolvConfPath, Destination: "/etc/resolv.conf", Writable: true, Private: true})
 	}
@@ -343,6 +360,16 @@ func (container *Container) setupMounts() error {
 		mounts = append(mounts, execdriver.Mount{Source: container.HostsPath, Destination: "/etc/hosts", Writable: true, Private: true})
 	}
 
+	// dockerinit is static, it runs as the init of any image
+	if container.hostConfig.Init {
+		mounts = append(mounts, execdriver.Mount{Source: container.daemon.SystemInitPath(), Destination: reaper.InitPath, Writable: false, Private: true})
+	}
+
+	// and installs the syscall filter of the container before its command
+	if container.SeccompProfile != "" && container.SeccompProfile != execdriver.SeccompUnconfined && !container.hostConfig.Privileged {
+		mounts = append(mounts, execdriver.Mount{Source: container.daemon.SystemInitPath(), Destination: seccomp.InitPath, Writable: false, Private: true})
+	}
+
 	container.command.Mounts = mounts
 	return nil
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/docker/docker/autogen/dockerversion"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/version"
 	"github.com/docker/docker/utils"
 )
 
@@ -123,10 +124,25 @@ func main() {
 		tlsConfig.MinVersion = tls.VersionTLS10
 	}
 
+	if *flMaxRetries < 0 {
+		log.Fatalf("Invalid --max-retries %d: must be 0 or more", *flMaxRetries)
+	}
+	if *flRetryInterval <= 0 {
+		log.Fatalf("Invalid --retry-interval %s: must be positive", *flRetryInterval)
+	}
+	options := []client.CliOption{client.WithRetries(*flMaxRetries, *flRetryInterval)}
+	if *flAPIVersion != "" {
+		apiVersion := version.Version(*flAPIVersion)
+		if apiVersion.GreaterThan(api.APIVERSION) {
+			log.Fatalf("Invalid --api-version %s: the client supports up to %s", apiVersion, api.APIVERSION)
+		}
+		options = append(options, client.WithAPIVersion(apiVersion))
+	}
+
 	if *flTls || *flTlsVerify {
-		cli = client.NewDockerCli(os.Stdin, os.Stdout, os.Stderr, *flTrustKey, protoAddrParts[0], protoAddrParts[1], &tlsConfig)
+		cli = client.NewDockerCli(os.Stdin, os.Stdout, os.Stderr, *flTrustKey, protoAddrParts[0], protoAddrParts[1], &tlsConfig, options...)
 	} else {
-		cli = client.NewDockerCli(os.Stdin, os.Stdout, os.Stderr, *flTrustKey, protoAddrParts[0], protoAddrParts[1], nil)
+		cli = client.NewDockerCli(os.Stdin, os.Stdout, os.Stderr, *flTrustKey, protoAddrParts[0], protoAddrParts[1], nil, options...)
 	}
 
 	if err := cli.Cmd(flag.Args()...); err != nil {
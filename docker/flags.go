@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/homedir"
@@ -39,6 +40,10 @@ var (
 	flHelp      = flag.Bool([]string{"h", "-help"}, false, "Print usage")
 	flTlsVerify = flag.Bool([]string{"-tlsverify"}, dockerTlsVerify, "Use TLS and verify the remote")
 
+	flMaxRetries    = flag.Int([]string{"-max-retries"}, 0, "Number of times to retry read-only requests while the daemon is unavailable")
+	flRetryInterval = flag.Duration([]string{"-retry-interval"}, time.Second, "Delay before the first retry, doubled after each one up to 30s")
+	flAPIVersion    = flag.String([]string{"-api-version"}, os.Getenv("DOCKER_API_VERSION"), "API version to use instead of negotiating it with the daemon")
+
 	// these are initialized in init() below since their default values depend on dockerCertPath which isn't fully initialized until init() runs
 	flTrustKey *string
 	flCa       *string
@@ -95,6 +100,7 @@ func init() {
 			{"login", "Register or log in to a Docker registry server"},
 			{"logout", "Log out from a Docker registry server"},
 			{"logs", "Fetch the logs of a container"},
+			{"network", "Manage the user-defined networks"},
 			{"port", "Lookup the public-facing port that is NAT-ed to PRIVATE_PORT"},
 			{"pause", "Pause all processes within a container"},
 			{"ps", "List containers"},
@@ -113,6 +119,7 @@ func init() {
 			{"tag", "Tag an image into a repository"},
 			{"top", "Lookup the running processes of a container"},
 			{"unpause", "Unpause a paused container"},
+			{"update", "Update the resource limits of one or more containers"},
 			{"version", "Show the Docker version information"},
 			{"wait", "Block until a container stops, then print its exit code"},
 		} {
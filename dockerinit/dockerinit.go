@@ -3,7 +3,9 @@ package main
 import (
 	_ "github.com/docker/docker/daemon/execdriver/lxc"
 	_ "github.com/docker/docker/daemon/execdriver/native"
+	_ "github.com/docker/docker/pkg/reaper"
 	"github.com/docker/docker/pkg/reexec"
+	_ "github.com/docker/docker/pkg/seccomp"
 )
 
 func main() {
@@ -30,7 +30,18 @@ func (s *TagStore) CmdImageExport(job *engine.Job) engine.Status {
 	}
 	defer os.RemoveAll(tempdir)
 
-	rootRepoMap := map[string]Repository{}
+	var (
+		rootRepoMap = map[string]Repository{}
+		// the exported images in order, with their tags for the manifest
+		roots    []string
+		rootTags = map[string][]string{}
+	)
+	addRoot := func(id string) {
+		if _, ok := rootTags[id]; !ok {
+			roots = append(roots, id)
+			rootTags[id] = []string{}
+		}
+	}
 	addKey := func(name string, tag string, id string) {
 		log.Debugf("add key [%s:%s]", name, tag)
 		if repo, ok := rootRepoMap[name]; !ok {
@@ -38,6 +49,8 @@ func (s *TagStore) CmdImageExport(job *engine.Job) engine.Status {
 		} else {
 			repo[tag] = id
 		}
+		addRoot(id)
+		rootTags[id] = append(rootTags[id], name+":"+tag)
 	}
 	for _, name := range job.Args {
 		name = registry.NormalizeLocalName(name)
@@ -65,6 +78,8 @@ func (s *TagStore) CmdImageExport(job *engine.Job) engine.Status {
 				// and will not need to be added to this map
 				if len(repoTag) > 0 {
 					addKey(repoName, repoTag, img.ID)
+				} else {
+					addRoot(img.ID)
 				}
 				if err := s.exportImage(job.Eng, img.ID, tempdir); err != nil {
 					return job.Error(err)
@@ -75,6 +90,7 @@ func (s *TagStore) CmdImageExport(job *engine.Job) engine.Status {
 				if err := s.exportImage(job.Eng, name, tempdir); err != nil {
 					return job.Error(err)
 				}
+				addRoot(name)
 			}
 		}
 		log.Debugf("End Serializing %s", name)
@@ -89,6 +105,10 @@ func (s *TagStore) CmdImageExport(job *engine.Job) engine.Status {
 		log.Debugf("There were no repositories to write")
 	}
 
+	if err := s.writeManifest(tempdir, roots, rootTags); err != nil {
+		return job.Error(err)
+	}
+
 	fs, err := archive.Tar(tempdir, archive.Uncompressed)
 	if err != nil {
 		return job.Error(err)
@@ -102,6 +122,43 @@ func (s *TagStore) CmdImageExport(job *engine.Job) engine.Status {
 	return engine.StatusOK
 }
 
+// manifestItem describes one of the images given to save: its config, its
+// repository tags and its layers, from the base layer up
+type manifestItem struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+const manifestFileName = "manifest.json"
+
+// writeManifest writes the manifest of the exported images to tempdir
+func (s *TagStore) writeManifest(tempdir string, roots []string, rootTags map[string][]string) error {
+	manifest := []manifestItem{}
+	for _, id := range roots {
+		var layers []string
+		for n := id; n != ""; {
+			img, err := s.graph.Get(n)
+			if err != nil {
+				return err
+			}
+			// the directories are named like exportImage names them
+			layers = append([]string{path.Join(n, "layer.tar")}, layers...)
+			n = img.Parent
+		}
+		manifest = append(manifest, manifestItem{
+			Config:   path.Join(id, "json"),
+			RepoTags: rootTags[id],
+			Layers:   layers,
+		})
+	}
+	manifestJson, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(tempdir, manifestFileName), manifestJson, os.FileMode(0644))
+}
+
 // FIXME: this should be a top-level function, not a class method
 func (s *TagStore) exportImage(eng *engine.Engine, name, tempdir string) error {
 	for n := name; n != ""; {
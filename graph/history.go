@@ -3,11 +3,15 @@ package graph
 import (
 	"strings"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
-	"github.com/docker/docker/image"
 	"github.com/docker/docker/utils"
 )
 
+// MissingImageID stands in the history of an image for the layers missing
+// from the graph
+const MissingImageID = "<missing>"
+
 func (s *TagStore) CmdHistory(job *engine.Job) engine.Status {
 	if n := len(job.Args); n != 1 {
 		return job.Errorf("Usage: %s IMAGE", job.Name)
@@ -30,7 +34,7 @@ func (s *TagStore) CmdHistory(job *engine.Job) engine.Status {
 	}
 
 	outs := engine.NewTable("Created", 0)
-	err = foundImage.WalkHistory(func(img *image.Image) error {
+	for img := foundImage; img != nil; {
 		out := &engine.Env{}
 		out.SetJson("Id", img.ID)
 		out.SetInt64("Created", img.Created.Unix())
@@ -38,8 +42,19 @@ func (s *TagStore) CmdHistory(job *engine.Job) engine.Status {
 		out.SetList("Tags", lookupMap[img.ID])
 		out.SetInt64("Size", img.Size)
 		outs.Add(out)
-		return nil
-	})
+
+		parent, err := img.GetParent()
+		if err != nil {
+			// The parent layers are not in the graph, like for a squashed
+			// image, nothing is known about them
+			log.Debugf("Error getting the parent of image %s: %v", img.ID, err)
+			out := &engine.Env{}
+			out.SetJson("Id", MissingImageID)
+			outs.Add(out)
+			break
+		}
+		img = parent
+	}
 	if _, err := outs.WriteListTo(job.Stdout); err != nil {
 		return job.Error(err)
 	}
@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/utils"
+)
+
+func TestCmdHistoryMissingParent(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	childID := "c3e7dfa2a2a2a21a2a2ad2d2341a2d3c4d4e5fa2d2a21acea242a5e2345d3aef"
+	archive, err := fakeTar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.graph.Register(&image.Image{ID: childID, Parent: testOfficialImageID}, archive); err != nil {
+		t.Fatal(err)
+	}
+
+	eng := engine.New()
+	eng.Logging = false
+	eng.Register("history", store.CmdHistory)
+	history := func() *engine.Table {
+		job := eng.Job("history", childID)
+		buf := &bytes.Buffer{}
+		job.Stdout.Add(buf)
+		if err := job.Run(); err != nil {
+			t.Fatal(err)
+		}
+		outs := engine.NewTable("", 0)
+		if _, err := outs.ReadListFrom(buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		return outs
+	}
+
+	outs := history()
+	if len(outs.Data) != 2 || outs.Data[0].Get("Id") != childID || outs.Data[1].Get("Id") != testOfficialImageID {
+		t.Fatalf("Expected the image and its parent, got %v", outs.Data)
+	}
+
+	// The parent of a squashed image is not in the graph
+	if err := store.graph.Delete(testOfficialImageID); err != nil {
+		t.Fatal(err)
+	}
+	outs = history()
+	if len(outs.Data) != 2 || outs.Data[0].Get("Id") != childID || outs.Data[1].Get("Id") != MissingImageID {
+		t.Fatalf("Expected the image and a missing layer, got %v", outs.Data)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/utils"
 )
 
@@ -75,11 +76,39 @@ func (s *TagStore) CmdLoad(job *engine.Job) engine.Status {
 		}
 	} else if !os.IsNotExist(err) {
 		return job.Error(err)
+	} else if err := s.loadManifestTags(tmpImageDir); err != nil {
+		return job.Error(err)
 	}
 
 	return engine.StatusOK
 }
 
+// loadManifestTags restores the tags of archives listing them in their
+// manifest only
+func (s *TagStore) loadManifestTags(tmpImageDir string) error {
+	manifestJson, err := ioutil.ReadFile(path.Join(tmpImageDir, "repo", manifestFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	manifest := []manifestItem{}
+	if err := json.Unmarshal(manifestJson, &manifest); err != nil {
+		return err
+	}
+	for _, item := range manifest {
+		id := path.Dir(item.Config)
+		for _, repoTag := range item.RepoTags {
+			repo, tag := parsers.ParseRepositoryTag(repoTag)
+			if err := s.Set(repo, tag, id, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (s *TagStore) recursiveLoad(eng *engine.Engine, address, tmpImageDir string) error {
 	if err := eng.Job("image_get", address).Run(); err != nil {
 		log.Debugf("Loading %s", address)
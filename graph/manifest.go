@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/registry"
 	"github.com/docker/libtrust"
@@ -71,6 +72,20 @@ func (s *TagStore) loadManifest(eng *engine.Engine, manifestBytes []byte) (*regi
 	return &manifest, verified, nil
 }
 
+// signedManifestDigest returns the digest of a signed manifest, which is the
+// digest of its payload without the signatures
+func signedManifestDigest(manifestBytes []byte) (digest.Digest, error) {
+	sig, err := libtrust.ParsePrettySignature(manifestBytes, "signatures")
+	if err != nil {
+		return "", fmt.Errorf("error parsing payload: %s", err)
+	}
+	payload, err := sig.Payload()
+	if err != nil {
+		return "", fmt.Errorf("error retrieving payload: %s", err)
+	}
+	return digest.FromBytes(payload)
+}
+
 func checkValidManifest(manifest *registry.ManifestData) error {
 	if len(manifest.FSLayers) != len(manifest.History) {
 		return fmt.Errorf("length of history not equal to number of layers")
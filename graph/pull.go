@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -20,6 +21,8 @@ import (
 	"github.com/docker/docker/utils"
 )
 
+var errPullCancelled = errors.New("pull cancelled")
+
 func (s *TagStore) CmdPull(job *engine.Job) engine.Status {
 	if n := len(job.Args); n != 1 && n != 2 {
 		return job.Errorf("Usage: %s IMAGE [TAG|DIGEST]", job.Name)
@@ -435,6 +438,22 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 		return false, err
 	}
 
+	// a manifest pulled by digest must have this digest, so the image
+	// can't change behind the reference
+	if utils.DigestReference(tag) {
+		expected, err := digest.ParseDigest(tag)
+		if err != nil {
+			return false, err
+		}
+		computed, err := signedManifestDigest(manifestBytes)
+		if err != nil {
+			return false, err
+		}
+		if computed != expected {
+			return false, fmt.Errorf("manifest digest %s does not match the requested digest %s", computed, expected)
+		}
+	}
+
 	manifest, verified, err := s.loadManifest(eng, manifestBytes)
 	if err != nil {
 		return false, fmt.Errorf("error verifying manifest: %s", err)
@@ -449,7 +468,37 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 	}
 	out.Write(sf.FormatStatus(tag, "Pulling from %s", repoInfo.CanonicalName))
 
-	downloads := make([]downloadInfo, len(manifest.FSLayers))
+	var (
+		downloads = make([]downloadInfo, len(manifest.FSLayers))
+		// closed when the pull fails, to stop the downloads still running
+		cancel = make(chan struct{})
+		// limits the number of layers downloaded at once
+		slots chan struct{}
+	)
+	if parallel && s.MaxConcurrentDownloads > 0 {
+		slots = make(chan struct{}, s.MaxConcurrentDownloads)
+	}
+	// the layers are extracted from their temp files, which are removed
+	// once the pull is over, whether it succeeded or not
+	defer func() {
+		for _, d := range downloads {
+			if d.tmpFile != nil {
+				d.tmpFile.Close()
+				os.Remove(d.tmpFile.Name())
+			}
+		}
+	}()
+	// abort stops the downloads and waits for them to be over, so their
+	// temp files can be removed
+	abort := func(err error) (bool, error) {
+		close(cancel)
+		for i := range downloads {
+			if downloads[i].err != nil {
+				<-downloads[i].err
+			}
+		}
+		return false, err
+	}
 
 	for i := len(manifest.FSLayers) - 1; i >= 0; i-- {
 		var (
@@ -459,7 +508,7 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 
 		img, err := image.NewImgJSON(imgJSON)
 		if err != nil {
-			return false, fmt.Errorf("failed to parse json: %s", err)
+			return abort(fmt.Errorf("failed to parse json: %s", err))
 		}
 		downloads[i].img = img
 
@@ -471,13 +520,21 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 
 		dgst, err := digest.ParseDigest(sumStr)
 		if err != nil {
-			return false, err
+			return abort(err)
 		}
 		downloads[i].digest = dgst
 
 		out.Write(sf.FormatProgress(common.TruncateID(img.ID), "Pulling fs layer", nil))
 
 		downloadFunc := func(di *downloadInfo) error {
+			if slots != nil {
+				select {
+				case slots <- struct{}{}:
+					defer func() { <-slots }()
+				case <-cancel:
+					return errPullCancelled
+				}
+			}
 			log.Debugf("pulling blob %q to V1 img %s", sumStr, img.ID)
 
 			if c, err := s.poolAdd("pull", "img:"+img.ID); err != nil {
@@ -494,6 +551,7 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 				if err != nil {
 					return err
 				}
+				di.tmpFile = tmpFile
 
 				r, l, err := r.GetV2ImageBlobReader(endpoint, repoInfo.RemoteName, di.digest.Algorithm(), di.digest.Hex(), auth)
 				if err != nil {
@@ -501,6 +559,18 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 				}
 				defer r.Close()
 
+				// closing the blob interrupts the download when the pull is
+				// cancelled
+				done := make(chan struct{})
+				defer close(done)
+				go func() {
+					select {
+					case <-cancel:
+						r.Close()
+					case <-done:
+					}
+				}()
+
 				verifier, err := digest.NewDigestVerifier(di.digest)
 				if err != nil {
 					return err
@@ -520,15 +590,17 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 
 				out.Write(sf.FormatProgress(common.TruncateID(img.ID), "Verifying Checksum", nil))
 
+				// the digest was computed while downloading, a layer that
+				// doesn't match its manifest rejects the whole image
 				if !verifier.Verified() {
 					log.Infof("Image verification failed: checksum mismatch for %q", di.digest.String())
-					verified = false
+					out.Write(sf.FormatProgress(common.TruncateID(img.ID), "Verification failed", nil))
+					return fmt.Errorf("filesystem layer verification failed for layer %s: digest %s does not match its content", img.ID, di.digest)
 				}
 
 				out.Write(sf.FormatProgress(common.TruncateID(img.ID), "Download complete", nil))
 
 				log.Debugf("Downloaded %s to tempfile %s", img.ID, tmpFile.Name())
-				di.tmpFile = tmpFile
 				di.length = l
 				di.downloaded = true
 			}
@@ -538,7 +610,7 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 		}
 
 		if parallel {
-			downloads[i].err = make(chan error)
+			downloads[i].err = make(chan error, 1)
 			go func(di *downloadInfo) {
 				di.err <- downloadFunc(di)
 			}(&downloads[i])
@@ -552,19 +624,20 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 
 	var tagUpdated bool
 	for i := len(downloads) - 1; i >= 0; i-- {
+		// the layers are extracted from the base up, each one waits for
+		// the download of its parent
 		d := &downloads[i]
 		if d.err != nil {
 			err := <-d.err
+			d.err = nil
 			if err != nil {
-				return false, err
+				return abort(err)
 			}
 		}
 		if d.downloaded {
 			// if tmpFile is empty assume download and extracted elsewhere
-			defer os.Remove(d.tmpFile.Name())
-			defer d.tmpFile.Close()
-			d.tmpFile.Seek(0, 0)
 			if d.tmpFile != nil {
+				d.tmpFile.Seek(0, 0)
 				err = s.graph.Register(d.img,
 					progressreader.New(progressreader.Config{
 						In:        d.tmpFile,
@@ -575,7 +648,7 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 						Action:    "Extracting",
 					}))
 				if err != nil {
-					return false, err
+					return abort(err)
 				}
 
 				// FIXME: Pool release here for parallel tag pull (ensures any downloads block until fully extracted)
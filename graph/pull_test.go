@@ -0,0 +1,358 @@
+package graph
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/registry"
+	"github.com/docker/docker/utils"
+	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
+	"github.com/docker/libtrust"
+)
+
+var pullTestLayerIDs = []string{
+	"1111111111111111111111111111111111111111111111111111111111111111",
+	"2222222222222222222222222222222222222222222222222222222222222222",
+	"3333333333333333333333333333333333333333333333333333333333333333",
+}
+
+// mockV2Registry serves a foo/bar:latest image made of the pullTestLayerIDs
+// layers, the last one being the top layer
+type mockV2Registry struct {
+	sync.Mutex
+	server   *httptest.Server
+	manifest []byte
+	// manifestDigest is the digest the manifest is also served by
+	manifestDigest string
+	blobs          map[string][]byte
+	// digests of the layers, base layer first
+	digests  []string
+	inFlight int
+	maxInUse int
+	// serveBlob sends a blob once headers are written, it defaults to
+	// sending it after a short wait
+	serveBlob func(w http.ResponseWriter, dgst string, blob []byte)
+}
+
+func newMockV2Registry(t *testing.T) *mockV2Registry {
+	m := &mockV2Registry{blobs: make(map[string][]byte)}
+	manifest := registry.ManifestData{Name: "foo/bar", Tag: "latest", SchemaVersion: 1}
+	parent := ""
+	for i, id := range pullTestLayerIDs {
+		buf := new(bytes.Buffer)
+		tw := tar.NewWriter(buf)
+		content := []byte(id)
+		if err := tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("layer%d", i), Size: int64(len(content)), Mode: 0644}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(content)
+		tw.Close()
+		sum := sha256.Sum256(buf.Bytes())
+		dgst := "sha256:" + hex.EncodeToString(sum[:])
+		m.blobs[dgst] = buf.Bytes()
+		m.digests = append(m.digests, dgst)
+
+		imgJSON, err := json.Marshal(map[string]string{"id": id, "parent": parent})
+		if err != nil {
+			t.Fatal(err)
+		}
+		parent = id
+		// the manifest lists the top layer first
+		manifest.FSLayers = append([]*registry.FSLayer{{BlobSum: dgst}}, manifest.FSLayers...)
+		manifest.History = append([]*registry.ManifestHistory{{V1Compatibility: string(imgJSON)}}, manifest.History...)
+	}
+
+	payload, err := json.MarshalIndent(manifest, "", "   ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := libtrust.NewJSONSignature(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := js.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if m.manifest, err = js.PrettySignature("signatures"); err != nil {
+		t.Fatal(err)
+	}
+	dgst, err := digest.FromBytes(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.manifestDigest = dgst.String()
+
+	m.server = httptest.NewServer(m)
+	return m
+}
+
+func (m *mockV2Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v2/foo/bar/manifests/latest", r.URL.Path == "/v2/foo/bar/manifests/"+m.manifestDigest:
+		w.Write(m.manifest)
+	case strings.HasPrefix(r.URL.Path, "/v2/foo/bar/blobs/"):
+		dgst := strings.TrimPrefix(r.URL.Path, "/v2/foo/bar/blobs/")
+		blob, ok := m.blobs[dgst]
+		if !ok {
+			w.WriteHeader(404)
+			return
+		}
+		m.Lock()
+		m.inFlight++
+		if m.inFlight > m.maxInUse {
+			m.maxInUse = m.inFlight
+		}
+		m.Unlock()
+		defer func() {
+			m.Lock()
+			m.inFlight--
+			m.Unlock()
+		}()
+
+		if m.serveBlob != nil {
+			m.serveBlob(w, dgst, blob)
+			return
+		}
+		// give the other downloads the time to start
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(blob)))
+		w.Write(blob)
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+func pullMockImage(t *testing.T, store *TagStore, m *mockV2Registry, ref string) error {
+	eng := engine.New()
+	eng.Register("trust_key_check", func(job *engine.Job) engine.Status {
+		job.Printf("not verified\n")
+		return engine.StatusOK
+	})
+	u, err := url.Parse(m.server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep := &registry.Endpoint{URL: u, Version: registry.APIVersion2}
+	r, err := registry.NewSession(&registry.AuthConfig{}, utils.NewHTTPRequestFactory(), ep, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoInfo := &registry.RepositoryInfo{RemoteName: "foo/bar", LocalName: "foo/bar", CanonicalName: "foo/bar"}
+	auth := registry.NewRequestAuthorization(&registry.AuthConfig{}, ep, "repository", "foo/bar", []string{"pull"})
+	_, err = store.pullV2Tag(eng, r, ioutil.Discard, ep, repoInfo, ref, utils.NewStreamFormatter(false), true, auth)
+	return err
+}
+
+func countTempBlobs(t *testing.T) int {
+	files, err := filepath.Glob(filepath.Join(os.TempDir(), "GetV2ImageBlob*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(files)
+}
+
+func TestPullV2ConcurrentDownloads(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+	store.MaxConcurrentDownloads = 2
+
+	m := newMockV2Registry(t)
+	defer m.server.Close()
+	tempBlobs := countTempBlobs(t)
+
+	if err := pullMockImage(t, store, m, "latest"); err != nil {
+		t.Fatal(err)
+	}
+	if m.maxInUse != 2 {
+		t.Fatalf("Expected 2 layers to be downloaded at once, got %d", m.maxInUse)
+	}
+	for _, id := range pullTestLayerIDs {
+		if !store.graph.Exists(id) {
+			t.Fatalf("Expected layer %s to be pulled", id)
+		}
+	}
+	top, err := store.graph.Get(pullTestLayerIDs[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if top.Parent != pullTestLayerIDs[1] {
+		t.Fatalf("Expected the top layer to have the parent %s, got %s", pullTestLayerIDs[1], top.Parent)
+	}
+	repo, err := store.Get("foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo["latest"] != pullTestLayerIDs[2] {
+		t.Fatalf("Expected foo/bar:latest to be %s, got %v", pullTestLayerIDs[2], repo)
+	}
+	if n := countTempBlobs(t); n != tempBlobs {
+		t.Fatalf("Expected the downloaded blobs to be removed, %d are left", n-tempBlobs)
+	}
+}
+
+func TestPullV2CancelsDownloadsOnFailure(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+	store.MaxConcurrentDownloads = 2
+
+	m := newMockV2Registry(t)
+	defer m.server.Close()
+	// the base layer fails while the others hang in the middle of the blob
+	release := make(chan struct{})
+	defer close(release)
+	m.serveBlob = func(w http.ResponseWriter, dgst string, blob []byte) {
+		if dgst == m.digests[0] {
+			w.WriteHeader(500)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(blob)))
+		w.Write(blob[:len(blob)/2])
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-time.After(10 * time.Second):
+		}
+	}
+	tempBlobs := countTempBlobs(t)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- pullMockImage(t, store, m, "latest")
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Expected the pull to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the downloads to be cancelled")
+	}
+	for _, id := range pullTestLayerIDs {
+		if store.graph.Exists(id) {
+			t.Fatalf("Expected layer %s not to be registered", id)
+		}
+	}
+	if n := countTempBlobs(t); n != tempBlobs {
+		t.Fatalf("Expected the partial blobs to be removed, %d are left", n-tempBlobs)
+	}
+}
+
+func TestPullV2RejectsLayerDigestMismatch(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	m := newMockV2Registry(t)
+	defer m.server.Close()
+	// the middle layer is served with other bytes than its digest
+	corrupted := m.digests[1]
+	m.serveBlob = func(w http.ResponseWriter, dgst string, blob []byte) {
+		if dgst == corrupted {
+			blob = append([]byte(nil), blob...)
+			blob[len(blob)-1] ^= 0xff
+		}
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(blob)))
+		w.Write(blob)
+	}
+
+	err = pullMockImage(t, store, m, "latest")
+	if err == nil {
+		t.Fatal("Expected the pull of a corrupted layer to fail")
+	}
+	if !strings.Contains(err.Error(), pullTestLayerIDs[1]) || !strings.Contains(err.Error(), corrupted) {
+		t.Fatalf("Expected the error to name the corrupted layer, got %q", err)
+	}
+	if store.graph.Exists(pullTestLayerIDs[1]) || store.graph.Exists(pullTestLayerIDs[2]) {
+		t.Fatal("Expected the corrupted layer and its children not to be registered")
+	}
+	if repo, _ := store.Get("foo/bar"); repo != nil {
+		t.Fatalf("Expected foo/bar not to be tagged, got %v", repo)
+	}
+}
+
+func TestPullV2ByDigest(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	m := newMockV2Registry(t)
+	defer m.server.Close()
+
+	if err := pullMockImage(t, store, m, m.manifestDigest); err != nil {
+		t.Fatal(err)
+	}
+	img, err := store.GetImage("foo/bar", m.manifestDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img == nil || img.ID != pullTestLayerIDs[2] {
+		t.Fatalf("Expected foo/bar@%s to be %s, got %v", m.manifestDigest, pullTestLayerIDs[2], img)
+	}
+	// pulling by digest doesn't tag the image
+	if repo, _ := store.Get("foo/bar"); repo["latest"] != "" {
+		t.Fatalf("Expected foo/bar not to be tagged, got %v", repo)
+	}
+}
+
+func TestPullV2ByDigestRejectsOtherManifest(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	m := newMockV2Registry(t)
+	defer m.server.Close()
+	// the registry answers with the manifest for another digest
+	requested := "sha256:" + strings.Repeat("a", 64)
+	m.manifestDigest = requested
+
+	err = pullMockImage(t, store, m, requested)
+	if err == nil || !strings.Contains(err.Error(), "does not match the requested digest") {
+		t.Fatalf("Expected a digest mismatch error, got %v", err)
+	}
+	for _, id := range pullTestLayerIDs {
+		if store.graph.Exists(id) {
+			t.Fatalf("Expected layer %s not to be pulled", id)
+		}
+	}
+}
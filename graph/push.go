@@ -477,16 +477,21 @@ func (s *TagStore) pushV2Image(r *registry.Session, img *image.Image, endpoint *
 	// Send the layer
 	log.Debugf("rendered layer for %s of [%d] size", img.ID, size)
 
-	if err := r.PutV2ImageBlob(endpoint, imageName, dgst.Algorithm(), dgst.Hex(),
-		progressreader.New(progressreader.Config{
-			In:        tf,
-			Out:       out,
-			Formatter: sf,
-			Size:      int(size),
-			NewLines:  false,
-			ID:        common.TruncateID(img.ID),
-			Action:    "Pushing",
-		}), auth); err != nil {
+	// the progress of a resumed upload starts at the offset of the chunk
+	progress := func(chunk io.Reader, offset int64) io.Reader {
+		return progressreader.New(progressreader.Config{
+			In:         ioutil.NopCloser(chunk),
+			Out:        out,
+			Formatter:  sf,
+			Size:       int(size),
+			Current:    int(offset),
+			LastUpdate: int(offset),
+			NewLines:   false,
+			ID:         common.TruncateID(img.ID),
+			Action:     "Pushing",
+		})
+	}
+	if err := r.PutV2ImageBlob(endpoint, imageName, dgst.Algorithm(), dgst.Hex(), tf, size, progress, auth); err != nil {
 		out.Write(sf.FormatProgress(common.TruncateID(img.ID), "Image push failed", nil))
 		return "", err
 	}
@@ -3,6 +3,7 @@ package graph
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
@@ -149,6 +150,13 @@ func (s *TagStore) CmdLookup(job *engine.Job) engine.Status {
 		out.Set("Os", image.OS)
 		out.SetInt64("Size", image.Size)
 		out.SetInt64("VirtualSize", image.GetParentsSize(0)+image.Size)
+		repoDigests := []string{}
+		for _, ref := range s.ByID()[image.ID] {
+			if strings.Contains(ref, "@") {
+				repoDigests = append(repoDigests, ref)
+			}
+		}
+		out.SetList("RepoDigests", repoDigests)
 		if _, err = out.WriteTo(job.Stdout); err != nil {
 			return job.Error(err)
 		}
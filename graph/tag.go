@@ -2,6 +2,7 @@ package graph
 
 import (
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/registry"
 )
 
 func (s *TagStore) CmdTag(job *engine.Job) engine.Status {
@@ -12,6 +13,16 @@ func (s *TagStore) CmdTag(job *engine.Job) engine.Status {
 	if len(job.Args) == 3 {
 		tag = job.Args[2]
 	}
+	// Check the new reference before looking anything up, the client may
+	// not have done it
+	if err := registry.ValidateRepositoryName(job.Args[1]); err != nil {
+		return job.Error(err)
+	}
+	if tag != "" {
+		if err := ValidateTagName(tag); err != nil {
+			return job.Error(err)
+		}
+	}
 	if err := s.Set(job.Args[1], tag, job.Args[0], job.GetenvBool("force")); err != nil {
 		return job.Error(err)
 	}
@@ -33,6 +33,9 @@ type TagStore struct {
 	graph        *Graph
 	Repositories map[string]Repository
 	trustKey     libtrust.PrivateKey
+	// MaxConcurrentDownloads is the number of layers a parallel pull
+	// downloads at once, 0 for no limit
+	MaxConcurrentDownloads int
 	sync.Mutex
 	// FIXME: move push/pull-related fields
 	// to a helper type
@@ -219,12 +222,6 @@ func (store *TagStore) Delete(repoName, ref string) (bool, error) {
 }
 
 func (store *TagStore) Set(repoName, tag, imageName string, force bool) error {
-	img, err := store.LookupImage(imageName)
-	store.Lock()
-	defer store.Unlock()
-	if err != nil {
-		return err
-	}
 	if tag == "" {
 		tag = DEFAULTTAG
 	}
@@ -234,6 +231,12 @@ func (store *TagStore) Set(repoName, tag, imageName string, force bool) error {
 	if err := ValidateTagName(tag); err != nil {
 		return err
 	}
+	img, err := store.LookupImage(imageName)
+	if err != nil {
+		return err
+	}
+	store.Lock()
+	defer store.Unlock()
 	if err := store.reload(); err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/engine"
 	_ "github.com/docker/docker/daemon/graphdriver/vfs" // import the vfs driver so it is used in the tests
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/utils"
@@ -176,6 +177,85 @@ func TestLookupImage(t *testing.T) {
 	}
 }
 
+func TestTagSecondName(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	if err := store.Set("other/app", "v1", testOfficialImageName, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{testOfficialImageName, "other/app:v1"} {
+		img, err := store.LookupImage(name)
+		if err != nil {
+			t.Fatalf("Error looking up %s: %s", name, err)
+		}
+		if img.ID != testOfficialImageID {
+			t.Fatalf("Expected %s to resolve to '%s', found '%s'", name, testOfficialImageID, img.ID)
+		}
+	}
+	if refs := store.ByID()[testOfficialImageID]; len(refs) != 2 {
+		t.Fatalf("Expected the image to have 2 references, found %v", refs)
+	}
+}
+
+func TestTagMove(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	if err := store.Set(testOfficialImageName, "", testPrivateImageID, false); err == nil {
+		t.Fatal("Expected moving a tag without force to fail")
+	}
+	if err := store.Set(testOfficialImageName, "", testPrivateImageID, true); err != nil {
+		t.Fatal(err)
+	}
+	img, err := store.LookupImage(testOfficialImageName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ID != testPrivateImageID {
+		t.Fatalf("Expected the tag to be moved to '%s', found '%s'", testPrivateImageID, img.ID)
+	}
+	if refs, exists := store.ByID()[testOfficialImageID]; exists {
+		t.Fatalf("Expected the old image to have no references left, found %v", refs)
+	}
+}
+
+func TestCmdTagRejectsInvalidReference(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	eng := engine.New()
+	eng.Logging = false
+	eng.Register("tag", store.CmdTag)
+	for _, args := range [][]string{
+		{testOfficialImageName, "Foo$3"},
+		{testOfficialImageName, "other/app", "-foo"},
+		{"nosuchimage", "Foo$3"},
+	} {
+		if err := eng.Job("tag", args...).Run(); err == nil {
+			t.Fatalf("Expected tagging %v to fail", args)
+		}
+	}
+	if refs := store.ByID()[testOfficialImageID]; len(refs) != 1 {
+		t.Fatalf("Expected the store to be left untouched, found %v", refs)
+	}
+}
+
 func TestValidTagName(t *testing.T) {
 	validTags := []string{"9", "foo", "foo-test", "bar.baz.boo"}
 	for _, tag := range validTags {
@@ -11,8 +11,9 @@ clone() {
 	vcs=$1
 	pkg=$2
 	rev=$3
+	url=$4
 	
-	pkg_url=https://$pkg
+	pkg_url=${url:-https://$pkg}
 	target_dir=src/$pkg
 	
 	echo -n "$pkg @ $rev: "
@@ -57,6 +58,8 @@ clone git github.com/Sirupsen/logrus v0.6.6
 
 clone git github.com/go-fsnotify/fsnotify v1.0.4
 
+clone git gopkg.in/yaml.v2 c1cd2254a6dd
+
 # get Go tip's archive/tar, for xattr support and improved performance
 # TODO after Go 1.4 drops, bump our minimum supported version and drop this vendored dep
 if [ "$1" = '--go' ]; then
@@ -75,6 +78,13 @@ rm -rf src/github.com/docker/distribution
 mkdir -p src/github.com/docker/distribution
 mv tmp-digest src/github.com/docker/distribution/digest
 
+# get the context package from go.net
+clone git golang.org/x/net 3cffabab72adf04f8e3b01c5baf775361837b5fe https://github.com/golang/net.git
+mv src/golang.org/x/net/context tmp-context
+rm -rf src/golang.org/x/net
+mkdir -p src/golang.org/x/net
+mv tmp-context src/golang.org/x/net/context
+
 clone git github.com/docker/libcontainer 4a72e540feb67091156b907c4700e580a99f5a9d
 # see src/github.com/docker/libcontainer/update-vendor.sh which is the "source of truth" for libcontainer deps (just like this file)
 rm -rf src/github.com/docker/libcontainer/vendor
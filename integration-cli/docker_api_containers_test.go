@@ -51,6 +51,44 @@ func TestContainerApiGetAll(t *testing.T) {
 	logDone("container REST API - check GET json/all=1")
 }
 
+func TestContainerApiGetAllSizeOnlyWhenAsked(t *testing.T) {
+	defer deleteAllContainers()
+
+	name := "getallsize"
+	runCmd := exec.Command(dockerBinary, "run", "--name", name, "busybox", "sh", "-c", "echo hello > /file")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatalf("Error on container creation: %v, output: %q", err, out)
+	}
+
+	sizes := func(endpoint string) map[string]interface{} {
+		body, err := sockRequest("GET", endpoint, nil)
+		if err != nil {
+			t.Fatalf("GET %s sockRequest failed: %v", endpoint, err)
+		}
+		var containers []map[string]interface{}
+		if err = json.Unmarshal(body, &containers); err != nil {
+			t.Fatalf("unable to unmarshal response body: %v", err)
+		}
+		if len(containers) == 0 {
+			t.Fatalf("Expected container %s to be listed", name)
+		}
+		return containers[0]
+	}
+
+	if c := sizes("/containers/json?all=1&limit=1"); c["SizeRw"] != nil || c["SizeRootFs"] != nil {
+		t.Fatalf("Expected no size without size=1, got %v", c)
+	}
+	c := sizes("/containers/json?all=1&limit=1&size=1")
+	if sizeRw, ok := c["SizeRw"].(float64); !ok || sizeRw <= 0 {
+		t.Fatalf("Expected a non zero SizeRw, got %v", c["SizeRw"])
+	}
+	if sizeRootFs, ok := c["SizeRootFs"].(float64); !ok || sizeRootFs <= 0 {
+		t.Fatalf("Expected a non zero SizeRootFs, got %v", c["SizeRootFs"])
+	}
+
+	logDone("container REST API - only compute the sizes with size=1")
+}
+
 func TestContainerApiGetExport(t *testing.T) {
 	defer deleteAllContainers()
 
@@ -4,9 +4,12 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os/exec"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Regression test for #9414
@@ -25,3 +28,52 @@ func TestExecApiCreateNoCmd(t *testing.T) {
 
 	logDone("exec create API - returns error when missing Cmd")
 }
+
+func TestExecApiStartPausedContainer(t *testing.T) {
+	defer deleteAllContainers()
+	defer unpauseAllContainers()
+	name := "exec_paused"
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", name, "busybox", "top")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := stripTrailingCharacters(out)
+
+	// the exec is created while the container is running...
+	body, err := sockRequest("POST", fmt.Sprintf("/containers/%s/exec", name), map[string]interface{}{"Cmd": []string{"true"}})
+	if err != nil && !strings.Contains(err.Error(), "201 Created") {
+		t.Fatalf("Could not create exec: %v, %s", err, body)
+	}
+	var created struct{ Id string }
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatal(err)
+	}
+
+	// ...and started once it is paused
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "pause", name)); err != nil {
+		t.Fatal(out, err)
+	}
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		body, err := sockRequest("POST", fmt.Sprintf("/exec/%s/start", created.Id), map[string]interface{}{"Detach": true})
+		done <- result{body, err}
+	}()
+
+	select {
+	case r := <-done:
+		expected := "Container " + id + " is paused, unpause the container before exec"
+		if r.err == nil || !bytes.Contains(r.body, []byte(expected)) {
+			t.Fatalf("Expected %q when starting an exec in a paused container, got %v: %q", expected, r.err, r.body)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Starting an exec in a paused container blocked")
+	}
+
+	logDone("exec start API - returns error when the container is paused")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"io"
 	"os/exec"
 	"strings"
@@ -134,3 +135,117 @@ func TestAttachTtyWithoutStdin(t *testing.T) {
 
 	logDone("attach - forbid piped stdin to tty enabled container")
 }
+
+// attachedClient is a docker attach process whose output lines are sent to
+// lines
+type attachedClient struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string
+}
+
+func startAttach(t *testing.T, name string) *attachedClient {
+	c := &attachedClient{
+		cmd:   exec.Command(dockerBinary, "attach", "--sig-proxy=false", name),
+		lines: make(chan string, 10),
+	}
+	var err error
+	if c.stdin, err = c.cmd.StdinPipe(); err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			c.lines <- scanner.Text()
+		}
+		close(c.lines)
+	}()
+	return c
+}
+
+// expectLine skips the pings sent while the clients were attaching
+func (c *attachedClient) expectLine(t *testing.T, expected string) {
+	for {
+		select {
+		case line := <-c.lines:
+			if line == "ping" {
+				continue
+			}
+			if line != expected {
+				t.Fatalf("Expected %q, got %q", expected, line)
+			}
+			return
+		case <-time.After(attachWait):
+			t.Fatalf("Timeout waiting for %q", expected)
+		}
+	}
+}
+
+func (c *attachedClient) close() {
+	c.stdin.Close()
+	c.cmd.Process.Kill()
+	c.cmd.Wait()
+}
+
+func TestAttachMultipleClientsStdin(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "-i", "--name", "attacher", "busybox", "cat")
+
+	first := startAttach(t, "attacher")
+	defer first.close()
+	second := startAttach(t, "attacher")
+	defer second.close()
+
+	// the attaches are set up asynchronously, so write until both clients
+	// got the echo
+	deadline := time.Now().Add(attachWait)
+	for firstReady, secondReady := false, false; !firstReady || !secondReady; {
+		if time.Now().After(deadline) {
+			t.Fatal("Timeout waiting for the clients to be attached")
+		}
+		if _, err := io.WriteString(first.stdin, "ping\n"); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-first.lines:
+			firstReady = true
+		case <-second.lines:
+			secondReady = true
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// the input of either client is sent to the process, and its output to
+	// both clients
+	if _, err := io.WriteString(first.stdin, "hello from first\n"); err != nil {
+		t.Fatal(err)
+	}
+	first.expectLine(t, "hello from first")
+	second.expectLine(t, "hello from first")
+
+	if _, err := io.WriteString(second.stdin, "hello from second\n"); err != nil {
+		t.Fatal(err)
+	}
+	first.expectLine(t, "hello from second")
+	second.expectLine(t, "hello from second")
+
+	// a client going away doesn't affect the others
+	first.close()
+	if _, err := io.WriteString(second.stdin, "still here\n"); err != nil {
+		t.Fatal(err)
+	}
+	second.expectLine(t, "still here")
+	if running, err := inspectField("attacher", "State.Running"); err != nil || running != "true" {
+		t.Fatalf("Expected the container to keep running, running: %s, %v", running, err)
+	}
+
+	logDone("attach - multiple clients share stdin and stdout")
+}
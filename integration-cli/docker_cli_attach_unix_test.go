@@ -5,6 +5,7 @@ package main
 import (
 	"os/exec"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -137,3 +138,102 @@ func TestAttachAfterDetach(t *testing.T) {
 
 	logDone("attach - reconnect after detaching")
 }
+
+// detaching stops the idle timer instead of killing the container
+func TestAttachDetachStopsIdleTimeout(t *testing.T) {
+	defer deleteAllContainers()
+
+	name := "idledetach"
+
+	cpty, tty, err := pty.Open()
+	if err != nil {
+		t.Fatalf("Could not open pty: %v", err)
+	}
+	defer cpty.Close()
+	cmd := exec.Command(dockerBinary, "run", "-ti", "--idle-timeout", "2s", "--name", name, "busybox")
+	cmd.Stdin = tty
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+
+	detached := make(chan error, 1)
+	go func() {
+		detached <- cmd.Run()
+	}()
+
+	if err := waitRun(name); err != nil {
+		t.Fatal(err)
+	}
+	cpty.Write([]byte{16})
+	time.Sleep(100 * time.Millisecond)
+	cpty.Write([]byte{17})
+
+	select {
+	case err := <-detached:
+		if err != nil {
+			t.Fatalf("attach returned error %s", err)
+		}
+	case <-time.After(attachWait):
+		t.Fatal("timed out waiting for the client to detach")
+	}
+
+	time.Sleep(4 * time.Second)
+	if running, err := inspectField(name, "State.Running"); err != nil || running != "true" {
+		t.Fatalf("Expected the detached container to keep running, got %s (%v)", running, err)
+	}
+
+	logDone("attach - detaching stops the idle timeout")
+}
+
+func TestAttachSigProxy(t *testing.T) {
+	defer deleteAllContainers()
+
+	name := "attachsigproxy"
+	dockerCmd(t, "run", "-d", "--name", name, "busybox", "sh", "-c", "trap 'echo got SIGINT; exit 3' INT; while true; do sleep 1; done")
+	if err := waitRun(name); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without --sig-proxy the client is interrupted and the container is left running
+	cmd := exec.Command(dockerBinary, "attach", "--sig-proxy=false", name)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	if err := cmd.Process.Signal(syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Wait()
+	if running, err := inspectField(name, "State.Running"); err != nil || running != "true" {
+		t.Fatalf("Expected the container to keep running, got %s (%v)", running, err)
+	}
+
+	// By default, the signal is relayed to the process of the container
+	cmd = exec.Command(dockerBinary, "attach", name)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	if err := cmd.Process.Signal(syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	errChan := make(chan error)
+	go func() {
+		errChan <- cmd.Wait()
+	}()
+	select {
+	case <-errChan:
+	case <-time.After(attachWait):
+		cmd.Process.Kill()
+		t.Fatal("timed out waiting for the attached container to exit")
+	}
+	if code, err := inspectField(name, "State.ExitCode"); err != nil || code != "3" {
+		t.Fatalf("Expected the container to exit with 3 on SIGINT, got %s (%v)", code, err)
+	}
+
+	out, _, _ := dockerCmd(t, "logs", name)
+	if !strings.Contains(out, "got SIGINT") {
+		t.Fatalf("Expected the container to trap SIGINT, got %q", out)
+	}
+
+	logDone("attach - signals are proxied to the container unless --sig-proxy=false")
+}
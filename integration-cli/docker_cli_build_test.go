@@ -2625,6 +2625,59 @@ func TestBuildWithCache(t *testing.T) {
 	logDone("build - with cache")
 }
 
+// cachedSteps returns whether each step of the build output used the cache
+func cachedSteps(t *testing.T, out string) []bool {
+	steps := strings.Split(out, "Step ")[1:]
+	if len(steps) == 0 {
+		t.Fatalf("No steps in the build output:\n%s", out)
+	}
+	cached := make([]bool, len(steps))
+	for i, step := range steps {
+		cached[i] = strings.Contains(step, " ---> Using cache")
+	}
+	return cached
+}
+
+func TestBuildCacheStepsAfterChange(t *testing.T) {
+	name := "testbuildcachesteps"
+	defer deleteImages(name)
+	dockerfile := `FROM busybox
+		RUN echo one > /one
+		RUN echo %s > /two
+		RUN echo three > /three`
+
+	id1, _, err := buildImageWithOut(name, fmt.Sprintf(dockerfile, "two"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an unchanged Dockerfile is built entirely from the cache
+	id2, out, err := buildImageWithOut(name, fmt.Sprintf(dockerfile, "two"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id1 != id2 {
+		t.Fatalf("Expected the same image %s from the cache, got %s", id1, id2)
+	}
+	if cached := cachedSteps(t, out); !reflect.DeepEqual(cached, []bool{false, true, true, true}) {
+		t.Fatalf("Expected every instruction after FROM to use the cache, got %v:\n%s", cached, out)
+	}
+
+	// changing an instruction rebuilds it and everything after it
+	id3, out, err := buildImageWithOut(name, fmt.Sprintf(dockerfile, "changed"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id3 == id1 {
+		t.Fatal("Expected a new image after changing an instruction")
+	}
+	if cached := cachedSteps(t, out); !reflect.DeepEqual(cached, []bool{false, true, false, false}) {
+		t.Fatalf("Expected only the instructions before the change to use the cache, got %v:\n%s", cached, out)
+	}
+
+	logDone("build - cache is used up to the first changed instruction")
+}
+
 func TestBuildWithoutCache(t *testing.T) {
 	name := "testbuildwithoutcache"
 	name2 := "testbuildwithoutcache2"
@@ -5382,3 +5435,231 @@ func TestBuildResourceConstraintsAreUsed(t *testing.T) {
 
 	logDone("build - resource constraints applied")
 }
+
+func TestBuildRunResultingImage(t *testing.T) {
+	name := "testbuildrunresultingimage"
+	defer deleteImages(name)
+	defer deleteAllContainers()
+
+	ctx, err := fakeContext(`FROM busybox
+RUN touch /x
+COPY . /ctx/
+CMD cat`, map[string]string{
+		"kept":          "kept",
+		"ignored":       "ignored",
+		".dockerignore": "ignored",
+	})
+	defer ctx.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := buildImageFromContext(name, ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The image runs its CMD
+	runCmd := exec.Command(dockerBinary, "run", "-i", name)
+	runCmd.Stdin = strings.NewReader("hello")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil || out != "hello" {
+		t.Fatalf("Expected the image to run cat, got %v: %q", err, out)
+	}
+
+	dockerCmd(t, "run", name, "ls", "/x", "/ctx/kept")
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", name, "ls", "/ctx/ignored")); err == nil {
+		t.Fatalf("Expected the file ignored not to be sent in the context, got %q", out)
+	}
+
+	// RUN and COPY each commit a layer
+	out, _, _ = dockerCmd(t, "history", "--no-trunc", name)
+	if !strings.Contains(out, "touch /x") || !strings.Contains(out, "in /ctx/") {
+		t.Fatalf("Expected the layers of RUN and COPY, got %q", out)
+	}
+
+	logDone("build - run the image built")
+}
+
+func TestBuildPullRefreshesBase(t *testing.T) {
+	defer setupRegistry(t)()
+
+	base := fmt.Sprintf("%v/dockercli/buildbase", privateRegistryURL)
+	name := "testbuildpullrefreshesbase"
+	defer deleteImages(base, name, "testbuildlocalbase")
+
+	dockerCmd(t, "tag", "busybox", base)
+	dockerCmd(t, "push", base)
+	busyboxID, err := getIDByName("busybox")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The local base is replaced by another image
+	localID, err := buildImage("testbuildlocalbase", "FROM busybox\nRUN touch /local", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dockerCmd(t, "tag", "-f", "testbuildlocalbase", base)
+
+	dockerfile := fmt.Sprintf("FROM %s\nRUN echo built", base)
+	if _, err := buildImage(name, dockerfile, true); err != nil {
+		t.Fatal(err)
+	}
+	if parent, _ := inspectField(name, "Parent"); parent != localID {
+		t.Fatalf("Expected the local base %s without --pull, got %s", localID, parent)
+	}
+
+	buildCmd := exec.Command(dockerBinary, "build", "--pull", "-t", name, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		t.Fatalf("Failed to build with --pull: %s, %v", out, err)
+	}
+	if parent, _ := inspectField(name, "Parent"); parent != busyboxID {
+		t.Fatalf("Expected the base pulled from the registry %s with --pull, got %s", busyboxID, parent)
+	}
+
+	logDone("build - --pull refreshes the base image")
+}
+
+func TestBuildAddRemoteModeAndCopyTar(t *testing.T) {
+	server, err := fakeStorage(map[string]string{
+		"remote.txt": "remote",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	name := "testbuildaddremotemodeandcopytar"
+	defer deleteImages(name)
+	ctx, err := fakeContext(fmt.Sprintf(`FROM busybox
+ADD %s/remote.txt /remote/
+RUN [ "$(cat /remote/remote.txt)" = remote ]
+RUN [ $(ls -l /remote/remote.txt | awk '{print $1}') = '-rw-------' ]
+COPY test.tar /copied/
+RUN [ -f /copied/test.tar ] && [ ! -e /copied/foo ]
+ADD test.tar /added/
+RUN [ "$(cat /added/foo)" = Hi ] && [ ! -e /added/test.tar ]`, server.URL()), nil)
+	defer ctx.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "foo", Size: 2, Mode: 0644}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("Hi")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Add("test.tar", buf.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := buildImageFromContext(name, ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	logDone("build - ADD downloads with mode 600 and extracts tars, COPY does not")
+}
+
+func TestBuildMultiStage(t *testing.T) {
+	name := "testbuildmultistage"
+	defer deleteImages(name)
+
+	dockerfile := `FROM busybox AS build
+RUN echo built > /artifact && touch /leftover
+
+FROM busybox
+COPY --from=build /artifact /app/
+RUN echo second stage
+CMD ["cat", "/app/artifact"]`
+
+	_, out, err := buildImageWithOut(name, dockerfile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "second stage") {
+		t.Fatalf("Expected the second stage to be built, got %q", out)
+	}
+	if out, _, _ := dockerCmd(t, "run", "--rm", name); strings.TrimSpace(out) != "built" {
+		t.Fatalf("Expected the artifact of the first stage, got %q", out)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--rm", name, "ls", "/leftover")); err == nil {
+		t.Fatalf("Expected only the artifact to be copied from the first stage, got %q", out)
+	}
+
+	// --target stops after the stage named
+	buildCmd := exec.Command(dockerBinary, "build", "--target", "build", "-t", name, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err = runCommandWithOutput(buildCmd)
+	if err != nil {
+		t.Fatalf("Failed to build the target: %s, %v", out, err)
+	}
+	if strings.Contains(out, "second stage") {
+		t.Fatalf("Expected the stages after the target not to be built, got %q", out)
+	}
+	dockerCmd(t, "run", "--rm", name, "ls", "/artifact", "/leftover")
+
+	buildCmd = exec.Command(dockerBinary, "build", "--target", "nosuchstage", "-t", name, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	if out, _, err := runCommandWithOutput(buildCmd); err == nil || !strings.Contains(out, `Target stage "nosuchstage" could not be found`) {
+		t.Fatalf("Expected an unknown target to be rejected, got %v: %q", err, out)
+	}
+
+	_, out, err = buildImageWithOut(name, "FROM busybox\nCOPY --from=nosuchstage /bin/sh /sh", true)
+	if err == nil || !strings.Contains(out, "no build stage nosuchstage") {
+		t.Fatalf("Expected copying from an unknown stage to fail, got %v: %q", err, out)
+	}
+
+	logDone("build - multi stage build with COPY --from and --target")
+}
+
+func TestBuildArgs(t *testing.T) {
+	name := "testbuildargs"
+	defer deleteImages(name)
+	defer deleteAllContainers()
+
+	dockerfile := `FROM busybox
+ARG VERSION
+ARG FLAVOR=plain
+RUN echo $VERSION-$FLAVOR > /version
+ENV KEPT $FLAVOR
+CMD ["cat", "/version"]`
+	build := func(args ...string) string {
+		args = append(append([]string{"build", "-t", name}, args...), "-")
+		buildCmd := exec.Command(dockerBinary, args...)
+		buildCmd.Stdin = strings.NewReader(dockerfile)
+		out, _, err := runCommandWithOutput(buildCmd)
+		if err != nil {
+			t.Fatalf("Failed to build: %s, %v", out, err)
+		}
+		return out
+	}
+
+	out := build("--build-arg", "VERSION=1.2", "--build-arg", "UNUSED=x")
+	if !strings.Contains(out, "[Warning] One or more build-args [UNUSED] were not consumed") {
+		t.Fatalf("Expected a warning for the build arg not declared, got %q", out)
+	}
+	if out, _, _ := dockerCmd(t, "run", "--rm", name); strings.TrimSpace(out) != "1.2-plain" {
+		t.Fatalf("Expected the build args to be substituted, got %q", out)
+	}
+	env, err := inspectFieldJSON(name, "Config.Env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(env, "VERSION") || strings.Contains(env, "FLAVOR") || !strings.Contains(env, "KEPT=plain") {
+		t.Fatalf("Expected only the env set with ENV in the image, got %s", env)
+	}
+
+	// The value of a build arg is part of the cache key
+	build("--build-arg", "VERSION=1.3", "--build-arg", "FLAVOR=extra")
+	if out, _, _ := dockerCmd(t, "run", "--rm", name); strings.TrimSpace(out) != "1.3-extra" {
+		t.Fatalf("Expected the new build args to be used, got %q", out)
+	}
+
+	logDone("build - build args declared with ARG")
+}
@@ -126,6 +126,32 @@ func TestPullByDigest(t *testing.T) {
 	logDone("by_digest - pull by digest")
 }
 
+func TestInspectImageShowsRepoDigest(t *testing.T) {
+	defer setupRegistry(t)()
+
+	pushDigest, err := setupImage()
+	if err != nil {
+		t.Fatalf("error setting up image: %v", err)
+	}
+
+	imageReference := fmt.Sprintf("%s@%s", repoName, pushDigest)
+	c := exec.Command(dockerBinary, "pull", imageReference)
+	if out, _, err := runCommandWithOutput(c); err != nil {
+		t.Fatalf("error pulling by digest: %s, %v", out, err)
+	}
+	defer deleteImages(imageReference)
+
+	repoDigests, err := inspectField(imageReference, "RepoDigests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := fmt.Sprintf("[%s]", imageReference); repoDigests != expected {
+		t.Fatalf("Expected RepoDigests %s, got %s", expected, repoDigests)
+	}
+
+	logDone("by_digest - inspect shows the digest references of an image")
+}
+
 func TestCreateByDigest(t *testing.T) {
 	defer setupRegistry(t)()
 
@@ -279,3 +279,56 @@ func TestCommitChange(t *testing.T) {
 
 	logDone("commit - commit --change")
 }
+
+func TestCommitMetadataAndCmd(t *testing.T) {
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "--name", "snapshot", "busybox", "sh", "-c", "echo snapshot > /committed")
+	if out, _, err := runCommandWithOutput(cmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	cmd = exec.Command(dockerBinary, "commit",
+		"-m", "add a file",
+		"-a", "Jane Doe <jane@example.com>",
+		"-c", "CMD [\"cat\", \"/committed\"]",
+		"snapshot", "snapshot:v1")
+	imageID, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(imageID, err)
+	}
+	imageID = strings.Trim(imageID, "\r\n")
+	defer deleteImages("snapshot:v1")
+
+	parentID, err := inspectField("busybox", "Id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"Comment":    "add a file",
+		"Author":     "Jane Doe <jane@example.com>",
+		"Config.Cmd": "[cat /committed]",
+		"Parent":     parentID,
+		"Id":         imageID,
+	}
+	for field, value := range expected {
+		res, err := inspectField("snapshot:v1", field)
+		if err != nil {
+			t.Fatalf("failed to get %s: %s", field, err)
+		}
+		if res != value {
+			t.Errorf("%s: expected %q, got %q", field, value, res)
+		}
+	}
+
+	// the new image runs the committed CMD against the committed file
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--rm", "snapshot:v1"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if actual := strings.Trim(out, "\r\n"); actual != "snapshot" {
+		t.Fatalf("expected output snapshot received %q", actual)
+	}
+
+	logDone("commit - message, author and CMD change on a tagged image")
+}
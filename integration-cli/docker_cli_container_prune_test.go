@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContainerPruneStopped(t *testing.T) {
+	defer deleteAllContainers()
+	defer unpauseAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "-d", "-l", "prune=test", "busybox", "top")
+	running := stripTrailingCharacters(out)
+	out, _, _ = dockerCmd(t, "run", "-d", "-l", "prune=test", "busybox", "top")
+	paused := stripTrailingCharacters(out)
+	dockerCmd(t, "pause", paused)
+	out, _, _ = dockerCmd(t, "run", "-d", "-l", "prune=test", "busybox", "top")
+	stopped := stripTrailingCharacters(out)
+	dockerCmd(t, "stop", stopped)
+	out, _, _ = dockerCmd(t, "create", "-l", "prune=test", "busybox", "true")
+	created := stripTrailingCharacters(out)
+	out, _, _ = dockerCmd(t, "run", "-d", "busybox", "true")
+	unlabeled := stripTrailingCharacters(out)
+	dockerCmd(t, "wait", unlabeled)
+
+	out, _, _ = dockerCmd(t, "container", "prune", "--filter", "label=prune=test")
+	for _, id := range []string{stopped, created} {
+		if !strings.Contains(out, "Deleted: "+id) {
+			t.Fatalf("Expected the stopped container %s to be pruned, got %q", id, out)
+		}
+	}
+	for _, id := range []string{running, paused, unlabeled} {
+		if strings.Contains(out, id) {
+			t.Fatalf("Expected the container %s to be kept, got %q", id, out)
+		}
+	}
+	if !strings.Contains(out, "Total reclaimed space: ") {
+		t.Fatalf("Expected the reclaimed space to be reported, got %q", out)
+	}
+
+	out, _, _ = dockerCmd(t, "container", "prune")
+	if !strings.Contains(out, "Deleted: "+unlabeled) {
+		t.Fatalf("Expected the stopped container %s to be pruned, got %q", unlabeled, out)
+	}
+
+	out, _, _ = dockerCmd(t, "ps", "-a", "-q", "--no-trunc")
+	for _, id := range []string{running, paused} {
+		if !strings.Contains(out, id) {
+			t.Fatalf("Expected the container %s to be kept, got %q", id, out)
+		}
+	}
+	for _, id := range []string{stopped, created, unlabeled} {
+		if strings.Contains(out, id) {
+			t.Fatalf("Expected the container %s to be gone, got %q", id, out)
+		}
+	}
+
+	logDone("container prune - remove the stopped containers only")
+}
+
+func TestContainerPruneUntil(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "create", "busybox", "true")
+	id := stripTrailingCharacters(out)
+
+	out, _, _ = dockerCmd(t, "container", "prune", "--filter", "until=1h")
+	if strings.Contains(out, id) {
+		t.Fatalf("Expected the new container %s to be kept, got %q", id, out)
+	}
+	if _, err := inspectField(id, "Id"); err != nil {
+		t.Fatalf("Expected the container %s to be kept: %v", id, err)
+	}
+
+	logDone("container prune - keep the containers newer than until")
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -556,3 +557,95 @@ func TestCpToStdout(t *testing.T) {
 	}
 	logDone("cp - to stdout")
 }
+
+// Test that the mode and mtime of a file survive copying it out of a
+// container and back in again
+func TestCpPreservesModeAndMtime(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+
+	// tar only keeps the mtime to the second
+	mtime := time.Date(2015, time.January, 2, 3, 4, 5, 0, time.UTC)
+	const mode os.FileMode = 0751
+
+	checkFile := func(path string) {
+		stat, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Mode().Perm() != mode {
+			t.Fatalf("Expected mode %v for %s, got %v", mode, path, stat.Mode().Perm())
+		}
+		if !stat.ModTime().Equal(mtime) {
+			t.Fatalf("Expected mtime %s for %s, got %s", mtime, path, stat.ModTime().UTC())
+		}
+	}
+
+	out, exitCode, err := dockerCmd(t, "run", "-d", "busybox", "/bin/sh", "-c", "echo -n '"+cpContainerContents+"' > /test && chmod 751 /test && touch -t 201501020304.05 /test")
+	if err != nil || exitCode != 0 {
+		t.Fatal("failed to create a container", out, err)
+	}
+
+	cleanedContainerID := stripTrailingCharacters(out)
+	defer deleteContainer(cleanedContainerID)
+
+	out, _, err = dockerCmd(t, "wait", cleanedContainerID)
+	if err != nil || stripTrailingCharacters(out) != "0" {
+		t.Fatal("failed to set up container", out, err)
+	}
+
+	hostDir, err := ioutil.TempDir("", "cp-test-metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(hostDir)
+
+	// container to host
+	if _, _, err := dockerCmd(t, "cp", cleanedContainerID+":/test", hostDir); err != nil {
+		t.Fatalf("couldn't copy from container: %s:%s %v", cleanedContainerID, "/test", err)
+	}
+	checkFile(filepath.Join(hostDir, "test"))
+
+	// host to a different path in a second container, which sees the
+	// same metadata
+	out, exitCode, err = dockerCmd(t, "run", "-d", "-v", hostDir+":/back", "busybox", "stat", "-c", "%a %Y", "/back/test")
+	if err != nil || exitCode != 0 {
+		t.Fatal("failed to create a container", out, err)
+	}
+
+	backContainerID := stripTrailingCharacters(out)
+	defer deleteContainer(backContainerID)
+
+	out, _, err = dockerCmd(t, "wait", backContainerID)
+	if err != nil || stripTrailingCharacters(out) != "0" {
+		t.Fatal("failed to stat the file in the container", out, err)
+	}
+	out, _, err = dockerCmd(t, "logs", backContainerID)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if expected := fmt.Sprintf("751 %d", mtime.Unix()); stripTrailingCharacters(out) != expected {
+		t.Fatalf("Expected %q in the container, got %q", expected, out)
+	}
+
+	// and back out of that container again
+	outDir, err := ioutil.TempDir("", "cp-test-metadata-out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+
+	if _, _, err := dockerCmd(t, "cp", backContainerID+":/back/test", outDir); err != nil {
+		t.Fatalf("couldn't copy from container: %s:%s %v", backContainerID, "/back/test", err)
+	}
+	checkFile(filepath.Join(outDir, "test"))
+
+	content, err := ioutil.ReadFile(filepath.Join(outDir, "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != cpContainerContents {
+		t.Fatalf("Expected %q, got %q", cpContainerContents, content)
+	}
+
+	logDone("cp - preserves mode and mtime")
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -304,3 +305,58 @@ func TestCreateLabelFromImage(t *testing.T) {
 
 	logDone("create - labels from image")
 }
+
+func TestCreateCapAddInvalid(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "create", "--name", "badcap", "--cap-add=CHPASS", "busybox", "true"))
+	if err == nil || !strings.Contains(out, `Unknown capability to add: "CHPASS"`) {
+		t.Fatalf("Expected an unknown capability to be rejected, got %v: %q", err, out)
+	}
+	if _, err := inspectField("badcap", "Id"); err == nil {
+		t.Fatal("Expected the container not to be created")
+	}
+
+	logDone("create - unknown capability is rejected")
+}
+
+func TestCreateListedNotRunning(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "create", "--name", "staged", "-e", "FOO=bar", "-p", "8080", "-v", "/data", "busybox", "top")
+	id := strings.TrimSpace(out)
+
+	out, _, _ = dockerCmd(t, "ps", "-q", "--no-trunc")
+	if strings.Contains(out, id) {
+		t.Fatalf("Expected the created container not to be running, got %q", out)
+	}
+	out, _, _ = dockerCmd(t, "ps", "-a", "--no-trunc")
+	var listed bool
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, id) {
+			listed = strings.Contains(line, "Created")
+		}
+	}
+	if !listed {
+		t.Fatalf("Expected the container to be listed as Created, got %q", out)
+	}
+	out, _, _ = dockerCmd(t, "ps", "-q", "--no-trunc", "--filter", "status=created")
+	if strings.TrimSpace(out) != id {
+		t.Fatalf("Expected the container to match status=created, got %q", out)
+	}
+
+	dockerCmd(t, "start", "staged")
+	out, _, _ = dockerCmd(t, "ps", "-q", "--no-trunc")
+	if !strings.Contains(out, id) {
+		t.Fatalf("Expected the container to be running once started, got %q", out)
+	}
+	out, _, _ = dockerCmd(t, "exec", "staged", "sh", "-c", "echo $FOO; ls -d /data")
+	if out != "bar\n/data\n" {
+		t.Fatalf("Expected the environment and volume given to create, got %q", out)
+	}
+	if port, _ := inspectField("staged", "NetworkSettings.Ports"); !strings.Contains(port, "8080/tcp") {
+		t.Fatalf("Expected the port given to create to be published, got %q", port)
+	}
+
+	logDone("create - a created container is listed but not running until started")
+}
@@ -6,10 +6,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -60,6 +67,66 @@ func TestDaemonRestartWithRunningContainersPorts(t *testing.T) {
 	logDone("daemon - running containers on daemon restart")
 }
 
+func TestDaemonRestartPolicyAlwaysRelaunched(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox(); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "always", "--restart=always", "busybox:latest", "top"); err != nil {
+		t.Fatalf("Could not run container with --restart=always: err=%v\n%s", err, out)
+	}
+	if out, err := d.Cmd("run", "-d", "--name", "nopolicy", "busybox:latest", "top"); err != nil {
+		t.Fatalf("Could not run container without restart policy: err=%v\n%s", err, out)
+	}
+
+	inspect := func(name, format string) string {
+		out, err := d.Cmd("inspect", "--format", format, name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: err=%v\n%s", name, err, out)
+		}
+		return strings.TrimSpace(out)
+	}
+	// waitRunning waits for the daemon to relaunch the container on startup
+	waitRunning := func(name string) {
+		for i := 0; i < 50; i++ {
+			if inspect(name, "{{.State.Running}}") == "true" {
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		t.Fatalf("Container %s was not relaunched after the daemon restarted", name)
+	}
+
+	pid := inspect("always", "{{.State.Pid}}")
+
+	for i := 0; i < 2; i++ {
+		if err := d.Stop(); err != nil {
+			t.Fatalf("Could not stop daemon: %v", err)
+		}
+		if err := d.Start(); err != nil {
+			t.Fatalf("Could not start daemon: %v", err)
+		}
+
+		waitRunning("always")
+		newPid := inspect("always", "{{.State.Pid}}")
+		if newPid == pid || newPid == "0" {
+			t.Fatalf("Expected a new process for the relaunched container, got pid %s (was %s)", newPid, pid)
+		}
+		pid = newPid
+		if policy := inspect("always", "{{.HostConfig.RestartPolicy.Name}}"); policy != "always" {
+			t.Fatalf("Expected the restart policy to be persisted, got %q", policy)
+		}
+
+		if running := inspect("nopolicy", "{{.State.Running}}"); running != "false" {
+			t.Fatalf("Container without restart policy should not be relaunched, running: %s", running)
+		}
+	}
+
+	logDone("daemon - restart=always relaunches containers on daemon startup")
+}
+
 func TestDaemonRestartWithVolumesRefs(t *testing.T) {
 	d := NewDaemon(t)
 	if err := d.StartWithBusybox(); err != nil {
@@ -800,3 +867,500 @@ func TestDaemonDots(t *testing.T) {
 
 	logDone("daemon - test dots on INFO")
 }
+
+func TestDaemonRegistryMirror(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+
+	// The mirror serves the layers of the Docker Hub registry
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	hub, _ := url.Parse("https://registry-1.docker.io")
+	hubProxy := httputil.NewSingleHostReverseProxy(hub)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		r.Host = hub.Host
+		hubProxy.ServeHTTP(w, r)
+	}))
+	defer mirror.Close()
+
+	d := NewDaemon(t)
+	if err := d.Start("--registry-mirror", mirror.URL); err != nil {
+		t.Fatalf("Could not start daemon with a registry mirror: %v", err)
+	}
+	defer d.Stop()
+
+	out, err := d.Cmd("pull", "busybox:latest")
+	if err != nil {
+		t.Fatalf("Could not pull busybox through the mirror: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "mirror: "+mirror.URL+"/v1/") {
+		t.Fatalf("Expected the layers to be pulled from the mirror, got %q", out)
+	}
+	if strings.Contains(out, "endpoint: ") {
+		t.Fatalf("Expected the registry not to be used when the mirror has the layers, got %q", out)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var layers int
+	for _, p := range paths {
+		if strings.HasPrefix(p, "/v1/images/") && strings.HasSuffix(p, "/layer") {
+			layers++
+		}
+	}
+	if layers == 0 {
+		t.Fatalf("Expected the layers to be requested from the mirror, got %v", paths)
+	}
+
+	logDone("daemon - pull the layers of the Docker Hub through a registry mirror")
+}
+
+func TestDaemonRestartAlwaysContainersOnBoot(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox(); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	for _, args := range [][]string{
+		{"--name", "db"},
+		{"--name", "web", "--link", "db:db"},
+		{"--name", "stopped"},
+	} {
+		args = append(append([]string{"-d", "--restart=always"}, args...), "busybox:latest", "top")
+		if out, err := d.Cmd("run", args...); err != nil {
+			t.Fatalf("Could not run %v: err=%v\n%s", args, err, out)
+		}
+	}
+	if out, err := d.Cmd("stop", "stopped"); err != nil {
+		t.Fatalf("Could not stop the container: err=%v\n%s", err, out)
+	}
+
+	if err := d.Restart(); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	for name, expected := range map[string]string{"db": "true", "web": "true", "stopped": "false"} {
+		out, err := d.Cmd("inspect", "--format", "{{.State.Running}}", name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: err=%v\n%s", name, err, out)
+		}
+		if strings.TrimSpace(out) != expected {
+			t.Fatalf("Expected %s to be running: %s after the daemon restart, got %q", name, expected, out)
+		}
+	}
+
+	logDone("daemon - containers with the always restart policy are started on boot")
+}
+
+// Running containers are only re-attached with --live-restore, the daemon
+// stops them on shutdown otherwise
+func TestDaemonRestartPersistsContainerState(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("create", "--name", "created", "-e", "FOO=bar", "busybox", "true"); err != nil {
+		t.Fatalf("Could not create the container: err=%v\n%s", err, out)
+	}
+	if out, err := d.Cmd("run", "--name", "exited", "busybox", "sh", "-c", "exit 3"); err == nil {
+		t.Fatalf("Expected the container to exit with 3: %s", out)
+	}
+	pids := map[string]string{}
+	for _, name := range []string{"running", "died"} {
+		if out, err := d.Cmd("run", "-d", "--name", name, "busybox", "top"); err != nil {
+			t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+		}
+		out, err := d.Cmd("inspect", "--format", "{{.State.Pid}}", name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: err=%v\n%s", name, err, out)
+		}
+		pids[name] = strings.TrimSpace(out)
+	}
+
+	if err := d.Stop(); err != nil {
+		t.Fatalf("Could not stop daemon: %v", err)
+	}
+	// One of the processes dies while no daemon runs
+	pid, err := strconv.Atoi(pids["died"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Start("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon: %v", err)
+	}
+
+	out, err := d.Cmd("ps", "-a", "--format", "{{.Names}}")
+	if err != nil {
+		t.Fatalf("Could not run ps: err=%v\n%s", err, out)
+	}
+	for _, name := range []string{"created", "exited", "running", "died"} {
+		if !strings.Contains(out, name) {
+			t.Fatalf("Expected %s to be listed after the daemon restart, got %q", name, out)
+		}
+	}
+	for name, expected := range map[string]string{
+		"created": "false 0 0",
+		"exited":  "false 3 0",
+		// Reattached to its process
+		"running": "true 0 " + pids["running"],
+		// Its exit code is lost with the daemon
+		"died": "false -1 0",
+	} {
+		out, err := d.Cmd("inspect", "--format", "{{.State.Running}} {{.State.ExitCode}} {{.State.Pid}}", name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: err=%v\n%s", name, err, out)
+		}
+		if strings.TrimSpace(out) != expected {
+			t.Fatalf("Expected the state of %s to be %q after the daemon restart, got %q", name, expected, out)
+		}
+	}
+
+	if out, err := d.Cmd("inspect", "--format", "{{.Config.Env}}", "created"); err != nil || !strings.Contains(out, "FOO=bar") {
+		t.Fatalf("Expected the config of the container to be reloaded, got %q: %v", out, err)
+	}
+
+	// The containers still work once reloaded
+	if out, err := d.Cmd("start", "-a", "created"); err != nil {
+		t.Fatalf("Could not start the reloaded container: err=%v\n%s", err, out)
+	}
+	if out, err := d.Cmd("start", "died"); err != nil {
+		t.Fatalf("Could not start the reloaded container: err=%v\n%s", err, out)
+	}
+	if out, err := d.Cmd("stop", "-t", "1", "running"); err != nil {
+		t.Fatalf("Could not stop the reattached container: err=%v\n%s", err, out)
+	}
+
+	logDone("daemon - the containers and their state persist through a daemon restart")
+}
+
+func TestDaemonLiveRestoreKeepsContainersRunning(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "live", "busybox", "sleep", "300"); err != nil {
+		t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+	out, err := d.Cmd("inspect", "--format", "{{.State.Pid}}", "live")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, out)
+	}
+	pid := strings.TrimSpace(out)
+
+	if err := d.Stop(); err != nil {
+		t.Fatalf("Could not stop daemon: %v", err)
+	}
+	if _, err := os.Stat("/proc/" + pid); err != nil {
+		t.Fatalf("Expected the process of the container to outlive the daemon: %v", err)
+	}
+
+	if err := d.Start("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon: %v", err)
+	}
+	out, err = d.Cmd("inspect", "--format", "{{.State.Running}} {{.State.Pid}}", "live")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, out)
+	}
+	if strings.TrimSpace(out) != "true "+pid {
+		t.Fatalf("Expected the container to still run with the pid %s, got %q", pid, out)
+	}
+
+	// The container taken back is stopped like any other
+	if out, err := d.Cmd("stop", "-t", "1", "live"); err != nil {
+		t.Fatalf("Could not stop the container: err=%v\n%s", err, out)
+	}
+	if out, err := d.Cmd("inspect", "--format", "{{.State.Running}}", "live"); err != nil || strings.TrimSpace(out) != "false" {
+		t.Fatalf("Expected the container to be stopped, got %q: %v", out, err)
+	}
+
+	logDone("daemon - containers keep running through a daemon restart with --live-restore")
+}
+
+func TestDaemonLiveRestoreKeepsOutput(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "talker", "busybox", "sh", "-c", "i=0; while true; do echo line $i; i=$((i+1)); sleep 0.2; done"); err != nil {
+		t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+	out, err := d.Cmd("inspect", "--format", "{{.State.Pid}}", "talker")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, out)
+	}
+	pid := strings.TrimSpace(out)
+
+	if err := d.Stop(); err != nil {
+		t.Fatalf("Could not stop daemon: %v", err)
+	}
+	// The container keeps writing while no daemon reads its output
+	time.Sleep(time.Second)
+	if err := d.Start("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon: %v", err)
+	}
+
+	out, err = d.Cmd("inspect", "--format", "{{.State.Running}} {{.State.Pid}}", "talker")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, out)
+	}
+	if strings.TrimSpace(out) != "true "+pid {
+		t.Fatalf("Expected the container writing to its stdout to still run with the pid %s, got %q", pid, out)
+	}
+
+	countLines := func() int {
+		out, err := d.Cmd("logs", "talker")
+		if err != nil {
+			t.Fatalf("Could not get the logs: err=%v\n%s", err, out)
+		}
+		return strings.Count(out, "line ")
+	}
+	before := countLines()
+	time.Sleep(2 * time.Second)
+	if after := countLines(); after <= before {
+		t.Fatalf("Expected the output of the reattached container to be logged, got %d lines then %d", before, after)
+	}
+
+	out, err = d.Cmd("exec", "talker", "true")
+	if err == nil || !strings.Contains(out, "reattached after a daemon restart") {
+		t.Fatalf("Expected exec in a reattached container to fail, got %v: %q", err, out)
+	}
+
+	logDone("daemon - the output of containers is kept through a daemon restart with --live-restore")
+}
+
+func TestDaemonLiveRestoreRestartPolicy(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "always", "--restart=always", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+	if err := d.Restart("--live-restore"); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+	out, err := d.Cmd("inspect", "--format", "{{.State.Pid}}", "always")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, out)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The reattached container is restarted when its process dies
+	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+	restarted := false
+	for i := 0; i < 50 && !restarted; i++ {
+		time.Sleep(100 * time.Millisecond)
+		out, err = d.Cmd("inspect", "--format", "{{.State.Running}} {{.State.Pid}}", "always")
+		restarted = err == nil && strings.HasPrefix(out, "true") && strings.TrimSpace(out) != fmt.Sprintf("true %d", pid)
+	}
+	if !restarted {
+		t.Fatalf("Expected the reattached container to be restarted by its restart policy, got %q", out)
+	}
+
+	logDone("daemon - the restart policy applies to reattached containers")
+}
+
+func TestDaemonLiveRestoreOnFailureUnknownExitCode(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "onfailure", "--restart=on-failure", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+	if err := d.Restart("--live-restore"); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	// The exit code of the reattached container is unknown, it isn't
+	// taken for a failure
+	if out, err := d.Cmd("stop", "-t", "1", "onfailure"); err != nil {
+		t.Fatalf("Could not stop the reattached container: err=%v\n%s", err, out)
+	}
+	time.Sleep(2 * time.Second)
+	out, err := d.Cmd("inspect", "--format", "{{.State.Running}} {{.State.ExitCode}}", "onfailure")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, out)
+	}
+	if strings.TrimSpace(out) != "false -1" {
+		t.Fatalf("Expected the reattached container to stay stopped with an unknown exit code, got %q", out)
+	}
+
+	logDone("daemon - on-failure doesn't restart reattached containers with an unknown exit code")
+}
+
+func TestDaemonLiveRestoreStartAfterStop(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "live", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+	if err := d.Restart("--live-restore"); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	if out, err := d.Cmd("stop", "-t", "1", "live"); err != nil {
+		t.Fatalf("Could not stop the reattached container: err=%v\n%s", err, out)
+	}
+	// The state the previous daemon left in the exec driver is gone
+	if out, err := d.Cmd("start", "live"); err != nil {
+		t.Fatalf("Could not start the container again: err=%v\n%s", err, out)
+	}
+	out, err := d.Cmd("inspect", "--format", "{{.State.Running}}", "live")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, out)
+	}
+	if strings.TrimSpace(out) != "true" {
+		t.Fatalf("Expected the container to run again, got %q", out)
+	}
+
+	logDone("daemon - a reattached container can be stopped and started again")
+}
+
+func TestDaemonLiveRestoreHealthCheck(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "checked", "--health-cmd", "test ! -f /unhealthy", "--health-interval", "1s", "--health-retries", "1", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+	if err := d.Restart("--live-restore"); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	// The check of the reattached container runs again
+	status := func() string {
+		out, err := d.Cmd("inspect", "--format", "{{.State.Health.Status}}", "checked")
+		if err != nil {
+			t.Fatalf("Could not inspect the container: err=%v\n%s", err, out)
+		}
+		return strings.TrimSpace(out)
+	}
+	healthy := false
+	for i := 0; i < 50 && !healthy; i++ {
+		time.Sleep(100 * time.Millisecond)
+		healthy = status() == "healthy"
+	}
+	if !healthy {
+		t.Fatalf("Expected the reattached container to be checked healthy, got %q", status())
+	}
+
+	pid, err := d.Cmd("inspect", "--format", "{{.State.Pid}}", "checked")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, pid)
+	}
+	if err := ioutil.WriteFile(fmt.Sprintf("/proc/%s/root/unhealthy", strings.TrimSpace(pid)), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	unhealthy := false
+	for i := 0; i < 50 && !unhealthy; i++ {
+		time.Sleep(100 * time.Millisecond)
+		unhealthy = status() == "unhealthy"
+	}
+	if !unhealthy {
+		t.Fatalf("Expected the reattached container to be checked unhealthy, got %q", status())
+	}
+
+	logDone("daemon - the health check of reattached containers runs again")
+}
+
+func TestDaemonLinkHealthTimeout(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--link-health-timeout", "2s"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "db", "--health-cmd", "exit 1", "--health-interval", "1s", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+	out, err := d.Cmd("run", "--link", "db:db:healthy", "busybox", "true")
+	if err == nil || !strings.Contains(out, "Container /db is still") {
+		t.Fatalf("Expected linking to a container which doesn't get healthy to time out, got %v: %q", err, out)
+	}
+
+	logDone("daemon - linking to a container which doesn't get healthy times out")
+}
+
+func TestDaemonRestartWithHealthyLink(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox(); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	// the check passes while the file exists on the host
+	stateDir, err := ioutil.TempDir("", "docker-health-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stateDir)
+	flag := filepath.Join(stateDir, "healthy")
+	if err := ioutil.WriteFile(flag, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := d.Cmd("run", "-d", "--name", "db", "--restart", "always", "-v", stateDir+":/state", "--health-cmd", "test -e /state/healthy", "--health-interval", "1s", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run db: err=%v\n%s", err, out)
+	}
+	if out, err := d.Cmd("run", "-d", "--name", "app", "--restart", "always", "--link", "db:db:healthy", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run app: err=%v\n%s", err, out)
+	}
+
+	// the daemon must answer while app waits for db to be healthy again
+	if err := os.Remove(flag); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Restart(); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+	if err := ioutil.WriteFile(flag, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	running := func() string {
+		out, err := d.Cmd("inspect", "-f", "{{.State.Running}}", "app")
+		if err != nil {
+			t.Fatalf("Could not inspect app: err=%v\n%s", err, out)
+		}
+		return strings.TrimSpace(out)
+	}
+	for i := 0; i < 300 && running() != "true"; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if running() != "true" {
+		t.Fatal("Expected app to be started once db is healthy")
+	}
+
+	logDone("daemon - containers linking with :healthy don't hold up the restart")
+}
@@ -16,6 +16,9 @@ func TestDiffFilenameShownInOutput(t *testing.T) {
 	}
 
 	cleanCID := stripTrailingCharacters(out)
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "wait", cleanCID)); err != nil {
+		t.Fatal(out, err)
+	}
 
 	diffCmd := exec.Command(dockerBinary, "diff", cleanCID)
 	out, _, err = runCommandWithOutput(diffCmd)
@@ -25,7 +28,7 @@ func TestDiffFilenameShownInOutput(t *testing.T) {
 
 	found := false
 	for _, line := range strings.Split(out, "\n") {
-		if strings.Contains("A /root/bar", line) {
+		if line == "A /root/bar" {
 			found = true
 			break
 		}
@@ -72,6 +75,34 @@ func TestDiffEnsureDockerinitFilesAreIgnored(t *testing.T) {
 	logDone("diff - check if ignored files show up in diff")
 }
 
+// added, modified and deleted paths are each reported with their kind
+func TestDiffChangeKinds(t *testing.T) {
+	defer deleteAllContainers()
+
+	containerCmd := `echo foo > /root/added && echo bar >> /etc/passwd && rm /etc/group`
+	runCmd := exec.Command(dockerBinary, "run", "--name", "diffkinds", "busybox", "sh", "-c", containerCmd)
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatalf("failed to run the container: %s, %v", out, err)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "diff", "diffkinds"))
+	if err != nil {
+		t.Fatalf("failed to run diff: %s, %v", out, err)
+	}
+
+	changes := make(map[string]bool)
+	for _, line := range strings.Split(stripTrailingCharacters(out), "\n") {
+		changes[line] = true
+	}
+	for _, expected := range []string{"C /root", "A /root/added", "C /etc", "C /etc/passwd", "D /etc/group"} {
+		if !changes[expected] {
+			t.Errorf("Expected %q in the diff output: %s", expected, out)
+		}
+	}
+
+	logDone("diff - added, changed and deleted paths")
+}
+
 func TestDiffEnsureOnlyKmsgAndPtmx(t *testing.T) {
 	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox", "sleep", "0")
 	out, _, err := runCommandWithOutput(runCmd)
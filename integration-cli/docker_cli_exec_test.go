@@ -5,11 +5,13 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -665,3 +667,107 @@ func TestRunMutableNetworkFiles(t *testing.T) {
 	}
 	logDone("run - mutable network files")
 }
+
+func TestExecCapDropALLHasNoEffectiveCaps(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := dockerCmd(t, "run", "-d", "--name", "nocaps", "--cap-drop", "ALL", "busybox", "top")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+
+	out, _, err = dockerCmd(t, "exec", "nocaps", "cat", "/proc/self/status")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	var capEff string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "CapEff:") {
+			capEff = strings.TrimSpace(strings.TrimPrefix(line, "CapEff:"))
+		}
+	}
+	if capEff == "" {
+		t.Fatalf("CapEff not found in /proc/self/status: %s", out)
+	}
+	caps, err := strconv.ParseUint(capEff, 16, 64)
+	if err != nil {
+		t.Fatalf("failed to parse CapEff %q: %v", capEff, err)
+	}
+	if caps != 0 {
+		t.Fatalf("expected an empty effective capability set, got %s", capEff)
+	}
+
+	execCmd := exec.Command(dockerBinary, "exec", "nocaps", "sh", "-c", "mount -t tmpfs none /mnt && echo ok")
+	out, _, err = runCommandWithOutput(execCmd)
+	if err == nil || strings.Contains(out, "ok") {
+		t.Fatalf("mount should fail without CAP_SYS_ADMIN: %s, %v", out, err)
+	}
+
+	logDone("exec - container with --cap-drop=ALL has no effective capabilities")
+}
+
+func TestExecSysctlAppliedInContainer(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	defer deleteAllContainers()
+
+	hostTTL, err := ioutil.ReadFile("/proc/sys/net/ipv4/ip_default_ttl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := dockerCmd(t, "run", "-d", "--name", "sysctl", "--sysctl", "net.ipv4.ip_forward=1", "--sysctl", "net.ipv4.ip_default_ttl=42", "busybox", "top")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+
+	out, _, err = dockerCmd(t, "exec", "sysctl", "cat", "/proc/sys/net/ipv4/ip_forward")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if actual := strings.TrimSpace(out); actual != "1" {
+		t.Fatalf("expected net.ipv4.ip_forward to be 1 in the container, got %q", actual)
+	}
+
+	out, _, err = dockerCmd(t, "exec", "sysctl", "cat", "/proc/sys/net/ipv4/ip_default_ttl")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if actual := strings.TrimSpace(out); actual != "42" {
+		t.Fatalf("expected net.ipv4.ip_default_ttl to be 42 in the container, got %q", actual)
+	}
+
+	// the sysctls are set in the container's network namespace only
+	afterTTL, err := ioutil.ReadFile("/proc/sys/net/ipv4/ip_default_ttl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(afterTTL) != string(hostTTL) {
+		t.Fatalf("host net.ipv4.ip_default_ttl changed from %q to %q", hostTTL, afterTTL)
+	}
+
+	logDone("exec - sysctls are applied in the container network namespace")
+}
+
+func TestExecReadonlyRootfs(t *testing.T) {
+	testRequires(t, NativeExecDriver)
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--read-only", "-v", "/writable", "--name", "readonly", "busybox", "top"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "exec", "readonly", "touch", "/file"))
+	if err == nil {
+		t.Fatal("expected writing to the read only rootfs to fail")
+	}
+	if expected := "Read-only file system"; !strings.Contains(out, expected) {
+		t.Fatalf("expected output from failure to contain %s but contains %s", expected, out)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "readonly", "touch", "/writable/file")); err != nil {
+		t.Fatalf("expected writing to a volume to work: %s, %v", out, err)
+	}
+
+	logDone("exec - read only rootfs")
+}
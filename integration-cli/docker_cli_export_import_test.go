@@ -97,3 +97,42 @@ func TestExportContainerWithOutputAndImportImage(t *testing.T) {
 	logDone("export - export a container with output flag")
 	logDone("import - import an image with output flag")
 }
+
+// the export holds the merged filesystem of all the layers, so the imported
+// single layer image runs on its own
+func TestExportImportRoundTripRuns(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "--name", "roundtrip", "busybox", "sh", "-c", "echo exported > /exported")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	exportCmd := exec.Command(dockerBinary, "export", "roundtrip")
+	out, _, err := runCommandWithOutput(exportCmd)
+	if err != nil {
+		t.Fatalf("failed to export container: %s, %v", out, err)
+	}
+
+	importCmd := exec.Command(dockerBinary, "import", "-", "repo/roundtrip:v1")
+	importCmd.Stdin = strings.NewReader(out)
+	if out, _, err = runCommandWithOutput(importCmd); err != nil {
+		t.Fatalf("failed to import image: %s, %v", out, err)
+	}
+	defer deleteImages("repo/roundtrip:v1")
+
+	if parent, err := inspectField("repo/roundtrip:v1", "Parent"); err != nil || parent != "" {
+		t.Fatalf("Expected the imported image to have no parent, got %q (%v)", parent, err)
+	}
+
+	// the file comes from the rw layer and cat from the busybox image
+	runCmd = exec.Command(dockerBinary, "run", "--rm", "repo/roundtrip:v1", "cat", "/exported")
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		t.Fatalf("failed to run the imported image: %s, %v", out, err)
+	}
+	if actual := strings.Trim(out, "\r\n"); actual != "exported" {
+		t.Fatalf("expected output exported received %q", actual)
+	}
+
+	logDone("import - run an image imported from an export")
+}
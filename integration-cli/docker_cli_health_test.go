@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHealthUnhealthy(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := dockerCmd(t, "run", "-d", "--name", "unhealthy", "--health-cmd", "exit 1", "--health-interval", "1s", "--health-retries", "2", "busybox", "top")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := stripTrailingCharacters(out)
+
+	status, err := inspectField(id, "State.Health.Status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != "starting" {
+		t.Fatalf("Expected a new container to be starting, got %s", status)
+	}
+
+	if err := waitInspect(id, "{{.State.Health.Status}}", "unhealthy", 30); err != nil {
+		t.Fatal(err)
+	}
+
+	streak, err := inspectField(id, "State.Health.FailingStreak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streak == "0" || streak == "1" {
+		t.Fatalf("Expected at least 2 consecutive failures, got %s", streak)
+	}
+
+	out, _, err = dockerCmd(t, "ps", "--no-trunc")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, "(unhealthy)") {
+		t.Fatalf("Expected ps to show the container as unhealthy, got %s", out)
+	}
+
+	eventsCmd := exec.Command(dockerBinary, "events", "--since=0", fmt.Sprintf("--until=%d", daemonTime(t).Unix()))
+	out, _, _ = runCommandWithOutput(eventsCmd)
+	found := false
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, id) && strings.HasSuffix(line, "health_status: unhealthy") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Missing health_status event for %s in %s", id, out)
+	}
+
+	logDone("health - failing check makes the container unhealthy")
+}
+
+func TestHealthHealthy(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := dockerCmd(t, "run", "-d", "--health-cmd", "true", "--health-interval", "1s", "busybox", "top")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := stripTrailingCharacters(out)
+
+	if err := waitInspect(id, "{{.State.Health.Status}}", "healthy", 30); err != nil {
+		t.Fatal(err)
+	}
+
+	logDone("health - passing check makes the container healthy")
+}
+
+func TestHealthOptionsWithoutCmd(t *testing.T) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--health-interval", "1s", "busybox", "true"))
+	if err == nil {
+		t.Fatalf("run with --health-interval and without --health-cmd should have failed: %q", out)
+	}
+	if !strings.Contains(out, "can't be used without --health-cmd") {
+		t.Fatalf("Expected a conflicting options error, got %q", out)
+	}
+
+	logDone("health - health check options require --health-cmd")
+}
+
+func TestHealthTransitions(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	defer deleteAllContainers()
+
+	// the check passes while the file exists on the host
+	stateDir, err := ioutil.TempDir("", "docker-health-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stateDir)
+	flag := filepath.Join(stateDir, "healthy")
+	if err := ioutil.WriteFile(flag, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := dockerCmd(t, "run", "-d", "-v", stateDir+":/state", "--health-cmd", "test -e /state/healthy", "--health-interval", "1s", "--health-retries", "2", "busybox", "top")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := stripTrailingCharacters(out)
+
+	if err := waitInspect(id, "{{.State.Health.Status}}", "healthy", 30); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(flag); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitInspect(id, "{{.State.Health.Status}}", "unhealthy", 30); err != nil {
+		t.Fatal(err)
+	}
+	streak, err := inspectField(id, "State.Health.FailingStreak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := strconv.Atoi(streak); err != nil || n < 2 {
+		t.Fatalf("Expected at least 2 consecutive failures before being unhealthy, got %s", streak)
+	}
+
+	if err := ioutil.WriteFile(flag, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitInspect(id, "{{.State.Health.Status}}", "healthy", 30); err != nil {
+		t.Fatal(err)
+	}
+	streak, err = inspectField(id, "State.Health.FailingStreak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streak != "0" {
+		t.Fatalf("Expected the failing streak to be reset, got %s", streak)
+	}
+
+	// the log keeps the last results, both failing and passing ones
+	out, _, err = dockerCmd(t, "inspect", "--format", "{{range .State.Health.Log}}{{.ExitCode}} {{end}}", id)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	codes := strings.Fields(out)
+	if len(codes) == 0 || len(codes) > 5 {
+		t.Fatalf("Expected between 1 and 5 results in the health log, got %v", codes)
+	}
+	if codes[len(codes)-1] != "0" {
+		t.Fatalf("Expected the last check to pass, got %v", codes)
+	}
+	failed := false
+	for _, code := range codes {
+		if code != "0" {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Fatalf("Expected failing checks in the health log, got %v", codes)
+	}
+
+	logDone("health - container becomes unhealthy and healthy again")
+}
@@ -82,3 +82,39 @@ func TestHistoryNonExistentImage(t *testing.T) {
 	}
 	logDone("history - history on non-existent image must pass")
 }
+
+func TestHistoryCommittedLayers(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "--name", "first", "busybox", "sh", "-c", "echo first > /first")
+	out, _, _ := dockerCmd(t, "commit", "first", "testhistorycommit:first")
+	firstID := strings.TrimSpace(out)
+	defer deleteImages("testhistorycommit:first")
+	dockerCmd(t, "run", "--name", "second", "testhistorycommit:first", "sh", "-c", "echo second > /second")
+	out, _, _ = dockerCmd(t, "commit", "second", "testhistorycommit:second")
+	secondID := strings.TrimSpace(out)
+	defer deleteImages("testhistorycommit:second")
+
+	out, _, _ = dockerCmd(t, "history", "--no-trunc", "testhistorycommit:second")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 3 {
+		t.Fatalf("Expected the committed layers and busybox, got %q", out)
+	}
+	for i, layer := range []struct{ id, cmd string }{
+		{secondID, "sh -c echo second > /second"},
+		{firstID, "sh -c echo first > /first"},
+	} {
+		line := lines[i+1]
+		if !strings.HasPrefix(line, layer.id) || !strings.Contains(line, layer.cmd) {
+			t.Fatalf("Expected the layer %s committed from %q, got %q", layer.id, layer.cmd, line)
+		}
+	}
+
+	out, _, _ = dockerCmd(t, "history", "-q", "testhistorycommit:second")
+	lines = strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 || lines[0] != secondID[:12] || lines[1] != firstID[:12] {
+		t.Fatalf("Expected the IDs of the committed layers, got %q", out)
+	}
+
+	logDone("history - committed layers and their commands")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImagePruneDangling(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "-d", "busybox", "touch", "/pruned")
+	containerID := stripTrailingCharacters(out)
+	dockerCmd(t, "wait", containerID)
+	out, _, _ = dockerCmd(t, "commit", containerID)
+	danglingID := stripTrailingCharacters(out)
+	dockerCmd(t, "rm", containerID)
+
+	// an image younger than the cutoff is kept
+	out, _, _ = dockerCmd(t, "image", "prune", "--filter", "until=1h")
+	if strings.Contains(out, danglingID) {
+		t.Fatalf("Expected the new image %s to be kept, got %q", danglingID, out)
+	}
+
+	out, _, _ = dockerCmd(t, "image", "prune")
+	if !strings.Contains(out, "Deleted: "+danglingID) {
+		t.Fatalf("Expected the dangling image %s to be deleted, got %q", danglingID, out)
+	}
+	if !strings.Contains(out, "Total reclaimed space: ") {
+		t.Fatalf("Expected the reclaimed space to be reported, got %q", out)
+	}
+
+	out, _, _ = dockerCmd(t, "images", "-q", "--no-trunc")
+	if strings.Contains(out, danglingID) {
+		t.Fatalf("Expected the dangling image %s to be gone, got %q", danglingID, out)
+	}
+	if _, err := inspectField("busybox", "Id"); err != nil {
+		t.Fatalf("Expected busybox to be kept: %v", err)
+	}
+
+	logDone("image prune - delete dangling images")
+}
+
+func TestImagePruneKeepsImagesUsedByContainers(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "-d", "busybox", "touch", "/pruned")
+	containerID := stripTrailingCharacters(out)
+	dockerCmd(t, "wait", containerID)
+	out, _, _ = dockerCmd(t, "commit", containerID)
+	danglingID := stripTrailingCharacters(out)
+	dockerCmd(t, "create", danglingID)
+
+	out, _, _ = dockerCmd(t, "image", "prune")
+	if strings.Contains(out, danglingID) {
+		t.Fatalf("Expected the image %s used by a container to be kept, got %q", danglingID, out)
+	}
+
+	deleteAllContainers()
+	deleteImages(danglingID)
+
+	logDone("image prune - keep images used by containers")
+}
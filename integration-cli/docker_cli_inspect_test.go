@@ -4,6 +4,7 @@ import (
 	"os/exec"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestInspectImage(t *testing.T) {
@@ -21,3 +22,76 @@ func TestInspectImage(t *testing.T) {
 
 	logDone("inspect - inspect an image")
 }
+
+func TestInspectFormatTable(t *testing.T) {
+	defer deleteAllContainers()
+
+	for _, name := range []string{"running", "stopped_container"} {
+		out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--name", name, "busybox", "top"))
+		if err != nil {
+			t.Fatal(out, err)
+		}
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "kill", "stopped_container")); err != nil {
+		t.Fatal(out, err)
+	}
+
+	inspectCmd := exec.Command(dockerBinary, "inspect", "--format", `table {{.Name}}\t{{.State.Running}}`, "running", "stopped_container")
+	out, exitCode, err := runCommandWithOutput(inspectCmd)
+	if exitCode != 0 || err != nil {
+		t.Fatalf("failed to inspect containers: %s, %v", out, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got: %q", out)
+	}
+	if fields := strings.Fields(lines[0]); len(fields) != 2 || fields[0] != "NAME" || fields[1] != "RUNNING" {
+		t.Fatalf("Expected header NAME RUNNING, got: %q", lines[0])
+	}
+
+	column := strings.Index(lines[0], "RUNNING")
+	expected := []string{"true", "false"}
+	for i, l := range lines[1:] {
+		if strings.Index(l, expected[i]) != column {
+			t.Fatalf("Expected RUNNING column to be aligned at %d, got: %q", column, out)
+		}
+	}
+
+	logDone("inspect - format as table")
+}
+
+func TestInspectKilledContainerState(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "tokill", "busybox", "top")
+	if finished, err := inspectField("tokill", "State.FinishedAt"); err != nil || finished != "0001-01-01T00:00:00Z" {
+		t.Fatalf("Expected FinishedAt to be the zero time while running, got %q: %v", finished, err)
+	}
+
+	dockerCmd(t, "kill", "tokill")
+	out, _, _ := dockerCmd(t, "inspect", "--format", "{{.State.Running}} {{.State.ExitCode}} {{.RestartCount}} {{.State.StartedAt}} {{.State.FinishedAt}}", "tokill")
+	fields := strings.Fields(out)
+	if len(fields) != 5 || fields[0] != "false" || fields[1] != "137" || fields[2] != "0" {
+		t.Fatalf("Expected a stopped container with the exit code 137, got %q", out)
+	}
+	started, err := time.Parse(time.RFC3339Nano, fields[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	finished, err := time.Parse(time.RFC3339Nano, fields[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.IsZero() || !finished.After(started) {
+		t.Fatalf("Expected FinishedAt %s to be after StartedAt %s", finished, started)
+	}
+
+	// starting it again clears the finish time of the previous run
+	dockerCmd(t, "start", "tokill")
+	if finished, err := inspectField("tokill", "State.FinishedAt"); err != nil || finished != "0001-01-01T00:00:00Z" {
+		t.Fatalf("Expected FinishedAt to be reset on start, got %q: %v", finished, err)
+	}
+
+	logDone("inspect - state of a killed container")
+}
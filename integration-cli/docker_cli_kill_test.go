@@ -73,3 +73,100 @@ func TestKillDifferentUserContainer(t *testing.T) {
 
 	logDone("kill - kill container running sleep 10 from a different user")
 }
+
+func TestKillWithSignal(t *testing.T) {
+	defer deleteAllContainers()
+
+	// the process is pid 1 of the container, which ignores signals it has no
+	// handler for, so install one for SIGTERM
+	out, _, err := dockerCmd(t, "run", "-d", "busybox", "sh", "-c", "trap 'exit 42' TERM; while true; do sleep 1; done")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := stripTrailingCharacters(out)
+
+	if out, _, err = dockerCmd(t, "kill", "-s", "SIGTERM", id); err != nil {
+		t.Fatalf("failed to send SIGTERM: %s, %v", out, err)
+	}
+	if err := waitInspect(id, "{{.State.Running}}", "false", 10); err != nil {
+		t.Fatal(err)
+	}
+	exitCode, err := inspectField(id, "State.ExitCode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != "42" {
+		t.Fatalf("Expected the SIGTERM handler to exit with 42, got %s", exitCode)
+	}
+
+	logDone("kill - kill -s SIGTERM delivers the signal to the container")
+}
+
+func TestKillWithSignalNumber(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := dockerCmd(t, "run", "-d", "busybox", "sh", "-c", "trap 'exit 10' USR1; while true; do sleep 1; done")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := stripTrailingCharacters(out)
+
+	if out, _, err = dockerCmd(t, "kill", "-s", "10", id); err != nil {
+		t.Fatalf("failed to send signal 10: %s, %v", out, err)
+	}
+	if err := waitInspect(id, "{{.State.ExitCode}}", "10", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	logDone("kill - kill -s accepts signal numbers")
+}
+
+func TestKillWithInvalidSignal(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := dockerCmd(t, "run", "-d", "busybox", "top")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := stripTrailingCharacters(out)
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "kill", "-s", "SIGNOTASIGNAL", id))
+	if err == nil {
+		t.Fatal("kill with an invalid signal should fail")
+	}
+	if !strings.Contains(out, "Invalid signal: SIGNOTASIGNAL") {
+		t.Fatalf("Expected an invalid signal error, got %s", out)
+	}
+
+	running, err := inspectField(id, "State.Running")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running != "true" {
+		t.Fatal("Container should still be running after an invalid signal")
+	}
+
+	logDone("kill - kill -s rejects unknown signals")
+}
+
+func TestKillPausedContainerWithSignal(t *testing.T) {
+	defer deleteAllContainers()
+	defer unpauseAllContainers()
+
+	out, _, err := dockerCmd(t, "run", "-d", "busybox", "top")
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := stripTrailingCharacters(out)
+	dockerCmd(t, "pause", id)
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "kill", "-s", "SIGTERM", id))
+	if err == nil {
+		t.Fatal("sending a signal to a paused container should fail")
+	}
+	if !strings.Contains(out, "is paused") {
+		t.Fatalf("Expected a paused container error, got %s", out)
+	}
+
+	logDone("kill - kill -s rejects paused containers")
+}
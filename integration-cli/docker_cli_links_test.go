@@ -64,6 +64,27 @@ func TestLinksPingUnlinkedContainers(t *testing.T) {
 	logDone("links - ping unlinked container")
 }
 
+// Containers on the default bridge can only resolve each other by name when
+// they are linked
+func TestLinksDefaultBridgeNameResolution(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "first", "busybox", "top")
+	dockerCmd(t, "run", "-d", "--name", "second", "busybox", "top")
+
+	runCmd := exec.Command(dockerBinary, "run", "--rm", "--name", "third", "busybox", "sh", "-c", "ping -c 1 -W 1 first || ping -c 1 -W 1 second")
+	if out, _, err := runCommandWithOutput(runCmd); err == nil {
+		t.Fatalf("Unlinked containers should not resolve each other by name: %s", out)
+	}
+
+	out, _, _ := dockerCmd(t, "run", "--rm", "--link", "first:alias1", "busybox", "sh", "-c", "ping -c 1 -W 1 first && ping -c 1 -W 1 alias1 && cat /etc/hosts")
+	if strings.Contains(out, "second") {
+		t.Fatalf("Only the linked container should be in /etc/hosts:\n%s", out)
+	}
+
+	logDone("links - default bridge only resolves linked containers by name")
+}
+
 // Test for appropriate error when calling --link with an invalid target container
 func TestLinksInvalidContainerTarget(t *testing.T) {
 	defer deleteAllContainers()
@@ -337,3 +358,37 @@ func TestLinksUpdateOnRestart(t *testing.T) {
 	}
 	logDone("link - ensure containers hosts files are updated on restart")
 }
+
+func TestLinksWaitForHealthyContainer(t *testing.T) {
+	defer deleteAllContainers()
+
+	if out, _, err := dockerCmd(t, "run", "-d", "--name", "db", "--health-cmd", "test -e /tmp/ready", "--health-interval", "1s", "busybox", "sh", "-c", "sleep 3; touch /tmp/ready; exec top"); err != nil {
+		t.Fatal(out, err)
+	}
+
+	if out, _, err := dockerCmd(t, "run", "--name", "app", "--link", "db:db:healthy", "busybox", "true"); err != nil {
+		t.Fatal(out, err)
+	}
+	status, err := inspectField("db", "State.Health.Status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != "healthy" {
+		t.Fatalf("Expected the container to be started once db is healthy, db is %s", status)
+	}
+
+	links, err := inspectFieldJSON("app", "HostConfig.Links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links != `["/db:/app/db:healthy"]` {
+		t.Fatalf("Expected the link to be inspected with its condition, got %s", links)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--link", "db:db:ready", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "only healthy is supported") {
+		t.Fatalf("Expected an unknown link condition to be rejected, got %v: %q", err, out)
+	}
+
+	logDone("link - wait for the container linked to with :healthy to be healthy")
+}
@@ -2,7 +2,16 @@ package main
 
 import (
 	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -19,3 +28,116 @@ func TestLoginWithoutTTY(t *testing.T) {
 
 	logDone("login - login without TTY")
 }
+
+// credentialHelperScript is a docker-credential-test helper keeping the
+// credentials of each server in a file of the directory given
+const credentialHelperScript = `#!/bin/sh
+input=$(cat)
+case "$1" in
+store)
+	server=$(echo "$input" | sed 's/.*"ServerURL":"\([^"]*\)".*/\1/')
+	echo "$input" > "%[1]s/$(echo "$server" | tr -c 'a-zA-Z0-9\n' _)"
+	;;
+get)
+	file="%[1]s/$(echo "$input" | tr -c 'a-zA-Z0-9\n' _)"
+	if [ ! -f "$file" ]; then
+		echo "credentials not found in native keychain"
+		exit 1
+	fi
+	cat "$file"
+	;;
+erase)
+	rm -f "%[1]s/$(echo "$input" | tr -c 'a-zA-Z0-9\n' _)"
+	;;
+esac
+`
+
+func TestLoginPrivateRegistryCredentialHelper(t *testing.T) {
+	defer setupRegistry(t)()
+
+	// The registry is behind a proxy requiring basic auth
+	target, _ := url.Parse("http://" + privateRegistryURL)
+	registryProxy := httputil.NewSingleHostReverseProxy(target)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "testuser" || password != "testpassword" {
+			w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+			w.Header().Set("WWW-Authenticate", `Basic realm="private"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		registryProxy.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+	host := proxy.Listener.Addr().String()
+
+	home, err := ioutil.TempDir("", "docker-login-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	bin, store := filepath.Join(home, "bin"), filepath.Join(home, "store")
+	for _, dir := range []string{bin, store} {
+		if err := os.Mkdir(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(bin, "docker-credential-test"), []byte(fmt.Sprintf(credentialHelperScript, store)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(home, ".dockercfg"), []byte(`{"credsStore":"test"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env := appendBaseEnv([]string{"HOME=" + home, "PATH=" + bin + ":" + os.Getenv("PATH")})
+	docker := func(args ...string) (string, error) {
+		cmd := exec.Command(dockerBinary, args...)
+		cmd.Env = env
+		out, _, err := runCommandWithOutput(cmd)
+		return out, err
+	}
+
+	repo := privateRegistryURL + "/private/busybox"
+	dockerCmd(t, "tag", "busybox", repo)
+	defer deleteImages(repo)
+	dockerCmd(t, "push", repo)
+	private := host + "/private/busybox"
+	defer deleteImages(private)
+
+	if out, err := docker("pull", private); err == nil {
+		t.Fatalf("Expected the pull without credentials to fail: %s", out)
+	}
+
+	if out, err := docker("login", "-u", "testuser", "-p", "testpassword", "-e", "test@example.com", host); err != nil {
+		t.Fatalf("Failed to log in: %s, %v", out, err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(home, ".dockercfg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"credsStore": "test"`) || !strings.Contains(string(b), "test@example.com") || strings.Contains(string(b), `"auth": "dGVzd`) {
+		t.Fatalf("Expected the password to be kept out of the config file, got %s", b)
+	}
+	files, err := ioutil.ReadDir(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("Expected the credentials to be in the store, got %v", files)
+	}
+
+	if out, err := docker("pull", private); err != nil {
+		t.Fatalf("Expected the pull to use the stored credentials: %s, %v", out, err)
+	}
+
+	if out, err := docker("logout", host); err != nil {
+		t.Fatalf("Failed to log out: %s, %v", out, err)
+	}
+	if files, _ := ioutil.ReadDir(store); len(files) != 0 {
+		t.Fatalf("Expected the credentials to be erased from the store, got %v", files)
+	}
+	deleteImages(private)
+	if out, err := docker("pull", private); err == nil {
+		t.Fatalf("Expected the pull to fail after logging out: %s", out)
+	}
+
+	logDone("login - log in to a private registry with a credential helper and pull")
+}
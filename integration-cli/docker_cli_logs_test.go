@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"regexp"
 	"strings"
@@ -335,3 +336,74 @@ func TestLogsFollowSlowStdoutConsumer(t *testing.T) {
 
 	logDone("logs - follow slow consumer")
 }
+
+func TestLogsNoneLogDriver(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "-d", "--log-driver=none", "busybox", "echo", "testline")
+	id := stripTrailingCharacters(out)
+	dockerCmd(t, "wait", id)
+
+	logsCmd := exec.Command(dockerBinary, "logs", id)
+	out, _, err := runCommandWithOutput(logsCmd)
+	if err == nil {
+		t.Fatalf("Logs should fail with the none driver, got %q", out)
+	}
+	if !strings.Contains(out, "supported only for") || !strings.Contains(out, "none") {
+		t.Fatalf("Expected an error about the none driver, got %q", out)
+	}
+
+	logDone("logs - not available with the none driver")
+}
+
+func TestLogsUnknownLogDriver(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "--log-driver=bogus", "busybox", "true")
+	if out, _, err := runCommandWithOutput(runCmd); err == nil || !strings.Contains(out, "Unknown logging driver: bogus") {
+		t.Fatalf("Expected an unknown logging driver error, got %q, %v", out, err)
+	}
+	runCmd = exec.Command(dockerBinary, "run", "--log-driver=syslog", "--log-opt", "syslog-facility=bogus", "busybox", "true")
+	if out, _, err := runCommandWithOutput(runCmd); err == nil || !strings.Contains(out, "invalid syslog-facility") {
+		t.Fatalf("Expected an invalid facility error, got %q, %v", out, err)
+	}
+
+	logDone("logs - unknown logging drivers and options are rejected")
+}
+
+func TestLogsRotatedFiles(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	defer deleteAllContainers()
+
+	// 200 lines take about 16k of json, written to files of at most 4k
+	out, _, _ := dockerCmd(t, "run", "-d", "--log-opt", "max-size=4k", "--log-opt", "max-file=10", "busybox", "sh", "-c", "for i in $(seq 1 200); do echo line$i; done")
+	id := stripTrailingCharacters(out)
+	dockerCmd(t, "wait", id)
+
+	logPath, err := inspectField(id, "LogPath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(logPath + ".1"); err != nil {
+		t.Fatalf("Expected the log file to be rotated: %v", err)
+	}
+
+	out, _, _ = dockerCmd(t, "logs", id)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 200 {
+		t.Fatalf("Expected 200 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if expected := fmt.Sprintf("line%d", i+1); line != expected {
+			t.Fatalf("Expected %s, got %s", expected, line)
+		}
+	}
+
+	out, _, _ = dockerCmd(t, "logs", "--tail", "100", id)
+	lines = strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 100 || lines[0] != "line101" || lines[99] != "line200" {
+		t.Fatalf("Expected lines 101 to 200, got %d lines from %s to %s", len(lines), lines[0], lines[len(lines)-1])
+	}
+
+	logDone("logs - rotated log files are read as one stream")
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNetworkRunResolvesByName(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "network", "create", "testnet")
+	id := strings.TrimSpace(out)
+	defer exec.Command(dockerBinary, "network", "rm", "testnet").Run()
+
+	out, _, _ = dockerCmd(t, "network", "ls")
+	if !strings.Contains(out, id[:12]) || !strings.Contains(out, "testnet") {
+		t.Fatalf("Expected the network to be listed, got %q", out)
+	}
+
+	dockerCmd(t, "run", "-d", "--name", "first", "--net=testnet", "busybox", "top")
+	ip, err := inspectField("first", "NetworkSettings.IPAddress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _, _ = dockerCmd(t, "network", "inspect", "testnet")
+	if !strings.Contains(out, `"IPAddress": "`+ip+`"`) {
+		t.Fatalf("Expected first to be attached to the network with the IP %s, got %q", ip, out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--net=testnet", "busybox", "ping", "-c", "1", "-W", "2", "first"))
+	if err != nil {
+		t.Fatalf("Expected first to be resolved and reached from its network: %s, %v", out, err)
+	}
+	if !strings.Contains(out, ip) {
+		t.Fatalf("Expected first to be resolved to %s, got %q", ip, out)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "busybox", "ping", "-c", "1", "-W", "1", ip)); err == nil {
+		t.Fatalf("Expected first not to be reached from the default bridge: %s", out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "network", "rm", "testnet"))
+	if err == nil || !strings.Contains(out, "has active endpoints") {
+		t.Fatalf("Expected a network with a running container not to be removed, got %v: %q", err, out)
+	}
+
+	logDone("network - containers on a network resolve each other by name")
+}
+
+func TestNetworkRenameUpdatesHosts(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "network", "create", "testnet")
+	defer exec.Command(dockerBinary, "network", "rm", "testnet").Run()
+
+	dockerCmd(t, "run", "-d", "--name", "peer", "--net=testnet", "busybox", "top")
+	dockerCmd(t, "run", "-d", "--name", "first", "--hostname", "myhost", "--net=testnet", "busybox", "top")
+	dockerCmd(t, "rename", "first", "second")
+
+	out, _, _ := dockerCmd(t, "exec", "peer", "cat", "/etc/hosts")
+	if !strings.Contains(out, "second myhost") || strings.Contains(out, "first") {
+		t.Fatalf("Expected the hosts of peer to resolve the new name only, got %q", out)
+	}
+
+	// the record under the new name goes away when the container stops
+	dockerCmd(t, "stop", "second")
+	out, _, _ = dockerCmd(t, "exec", "peer", "cat", "/etc/hosts")
+	if strings.Contains(out, "myhost") {
+		t.Fatalf("Expected the hosts of peer not to resolve the stopped container, got %q", out)
+	}
+
+	logDone("network - renaming a container updates the hosts of its network")
+}
+
+func TestNetworkCreateSubnet(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "network", "create", "--subnet", "10.123.45.0/24", "subnetnet")
+	defer exec.Command(dockerBinary, "network", "rm", "subnetnet").Run()
+
+	out, _, _ := dockerCmd(t, "run", "--net=subnetnet", "busybox", "ip", "-o", "-4", "addr", "show", "eth0")
+	fields := strings.Fields(out)
+	if len(fields) < 4 {
+		t.Fatalf("Unexpected address of eth0: %q", out)
+	}
+	ip, _, err := net.ParseCIDR(fields[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, subnet, _ := net.ParseCIDR("10.123.45.0/24")
+	if !subnet.Contains(ip) {
+		t.Fatalf("Expected an address in 10.123.45.0/24, got %s", ip)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "network", "create", "--subnet", "10.123.45.0/24", "subnetnet"))
+	if err == nil || !strings.Contains(out, "already exists") {
+		t.Fatalf("Expected a network name to be used once, got %v: %q", err, out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--net=nosuchnet", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "No such network: nosuchnet") {
+		t.Fatalf("Expected running on an unknown network to fail, got %v: %q", err, out)
+	}
+
+	dockerCmd(t, "network", "rm", "subnetnet")
+	out, _, _ = dockerCmd(t, "network", "ls")
+	if strings.Contains(out, "subnetnet") {
+		t.Fatalf("Expected the network to be removed, got %q", out)
+	}
+
+	logDone("network - create a network with a subnet")
+}
+
+func TestNetworkRunStaticIP(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "network", "create", "--subnet", "172.20.0.0/24", "staticnet")
+	defer exec.Command(dockerBinary, "network", "rm", "staticnet").Run()
+
+	dockerCmd(t, "run", "-d", "--name", "static", "--net=staticnet", "--ip", "172.20.0.5", "busybox", "top")
+	ip, err := inspectField("static", "NetworkSettings.IPAddress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip != "172.20.0.5" {
+		t.Fatalf("Expected the IP 172.20.0.5, got %s", ip)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--net=staticnet", "--ip", "172.20.0.5", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "already reserved") {
+		t.Fatalf("Expected an IP in use to be rejected, got %v: %q", err, out)
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--net=staticnet", "--ip", "172.21.0.5", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "outside the subnet") {
+		t.Fatalf("Expected an IP outside the subnet to be rejected, got %v: %q", err, out)
+	}
+
+	// The IP is kept while the container is stopped, and released once removed
+	dockerCmd(t, "stop", "static")
+	dockerCmd(t, "start", "static")
+	if ip, _ := inspectField("static", "NetworkSettings.IPAddress"); ip != "172.20.0.5" {
+		t.Fatalf("Expected the IP 172.20.0.5 after a restart, got %s", ip)
+	}
+	dockerCmd(t, "rm", "-f", "static")
+	dockerCmd(t, "run", "--rm", "--net=staticnet", "--ip", "172.20.0.5", "busybox", "true")
+
+	logDone("network - run a container with a static IP")
+}
+
+func TestNetworkCreatePublishPortsRejected(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "network", "create", "portsnet")
+	defer exec.Command(dockerBinary, "network", "rm", "portsnet").Run()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "create", "--net=portsnet", "-p", "80:80", "busybox", "top"))
+	if err == nil || !strings.Contains(out, "Conflicting options: -p and -P") {
+		t.Fatalf("Expected -p to be rejected on a user-defined network, got %v: %q", err, out)
+	}
+
+	// the daemon checks it as well, before the container is created
+	config := map[string]interface{}{
+		"Image": "busybox",
+		"Cmd":   []string{"top"},
+		"HostConfig": map[string]interface{}{
+			"NetworkMode":  "portsnet",
+			"PortBindings": map[string]interface{}{"80/tcp": []map[string]string{{"HostPort": "8080"}}},
+		},
+	}
+	body, err := sockRequest("POST", "/containers/create?name=portscontainer", config)
+	if err == nil || !strings.Contains(string(body), "Conflicting options: -p and -P") {
+		t.Fatalf("Expected the daemon to reject the published ports, got %v: %q", err, body)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "inspect", "portscontainer")); err == nil {
+		t.Fatalf("Expected the container not to be created: %q", out)
+	}
+
+	logDone("network - publishing ports on a user-defined network is rejected at create")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"reflect"
@@ -609,6 +610,38 @@ func TestPsRightTagName(t *testing.T) {
 	logDone("ps - right tags for containers")
 }
 
+func TestPsNoTrunc(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "notrunc", "busybox", "sh", "-c", "while true; do sleep 1; done")
+	id, err := inspectField("notrunc", "Id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id) != 64 {
+		t.Fatalf("Expected a 64 characters ID, got %q", id)
+	}
+
+	out, _, _ := dockerCmd(t, "ps", "--no-trunc")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one container listed, got %q", out)
+	}
+	if f := strings.Fields(lines[1]); f[0] != id {
+		t.Fatalf("Expected the full ID %s, got %s", id, f[0])
+	}
+	if !strings.Contains(lines[1], "while true; do sleep 1; done") {
+		t.Fatalf("Expected the full command, got %q", lines[1])
+	}
+
+	out, _, _ = dockerCmd(t, "ps")
+	if strings.Contains(out, id) || !strings.Contains(out, id[:12]) {
+		t.Fatalf("Expected the ID to be truncated without --no-trunc, got %q", out)
+	}
+
+	logDone("ps - full ID and command with --no-trunc")
+}
+
 func TestPsLinkedWithNoTrunc(t *testing.T) {
 	defer deleteAllContainers()
 	if out, err := exec.Command(dockerBinary, "run", "--name=first", "-d", "busybox", "top").CombinedOutput(); err != nil {
@@ -656,3 +689,105 @@ func TestPsGroupPortRange(t *testing.T) {
 
 	logDone("ps - port range")
 }
+
+func TestPsQuietAll(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "-d", "busybox", "true")
+	stoppedID := strings.TrimSpace(out)
+	dockerCmd(t, "wait", stoppedID)
+	out, _, _ = dockerCmd(t, "run", "-d", "busybox", "top")
+	runningID := strings.TrimSpace(out)
+
+	out, _, _ = dockerCmd(t, "ps", "-q")
+	if strings.Contains(out, stoppedID[:12]) || !strings.Contains(out, runningID[:12]) {
+		t.Fatalf("Expected only the running container, got %q", out)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if len(line) != 12 {
+			t.Fatalf("Expected truncated IDs only, got %q", out)
+		}
+	}
+
+	out, _, _ = dockerCmd(t, "ps", "-aq")
+	if !strings.Contains(out, stoppedID[:12]+"\n") || !strings.Contains(out, runningID[:12]+"\n") {
+		t.Fatalf("Expected the stopped and running containers, got %q", out)
+	}
+
+	out, _, _ = dockerCmd(t, "ps", "-aq", "--no-trunc")
+	if !strings.Contains(out, stoppedID+"\n") || !strings.Contains(out, runningID+"\n") {
+		t.Fatalf("Expected the full IDs of both containers, got %q", out)
+	}
+
+	// Filtering on the exit code lists stopped containers without -a
+	out, _, _ = dockerCmd(t, "ps", "-q", "--no-trunc", "--filter", "exited=0")
+	if strings.TrimSpace(out) != stoppedID {
+		t.Fatalf("Expected only the stopped container, got %q", out)
+	}
+
+	logDone("ps - quiet with and without all")
+}
+
+func TestPsFormatTable(t *testing.T) {
+	defer deleteAllContainers()
+
+	for _, name := range []string{"first", "second_container"} {
+		out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--name", name, "busybox", "top"))
+		if err != nil {
+			t.Fatal(out, err)
+		}
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "ps", "--format", `table {{.Names}}\t{{.Image}}`))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got: %q", out)
+	}
+	if fields := strings.Fields(lines[0]); !reflect.DeepEqual(fields, []string{"NAMES", "IMAGE"}) {
+		t.Fatalf("Expected header NAMES IMAGE, got: %q", lines[0])
+	}
+	column := strings.Index(lines[0], "IMAGE")
+	for _, l := range lines[1:] {
+		if strings.Index(l, "busybox") != column {
+			t.Fatalf("Expected IMAGE column to be aligned at %d, got: %q", column, out)
+		}
+	}
+
+	logDone("ps - format as table")
+}
+
+func TestPsFormatCustomAndJSON(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "formatted", "busybox", "top")
+
+	out, _, _ := dockerCmd(t, "ps", "--format", `{{.Names}}\t{{.Image}}`)
+	if fields := strings.Split(strings.TrimSpace(out), "\n"); len(fields) != 1 || !reflect.DeepEqual(strings.Fields(fields[0]), []string{"formatted", "busybox"}) {
+		t.Fatalf("Expected the name and image of the container, got %q", out)
+	}
+
+	out, _, _ = dockerCmd(t, "ps", "--format", "json")
+	var row map[string]string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &row); err != nil {
+		t.Fatalf("Expected a JSON object per container, got %q: %v", out, err)
+	}
+	if row["Names"] != "formatted" || !strings.HasPrefix(row["Status"], "Up") {
+		t.Fatalf("Unexpected fields of the container: %v", row)
+	}
+
+	out, _, _ = dockerCmd(t, "ps", "--format", "table")
+	if !strings.HasPrefix(out, "CONTAINER ID") {
+		t.Fatalf("Expected the default columns, got %q", out)
+	}
+
+	out, _, _, err := runCommandWithStdoutStderr(exec.Command(dockerBinary, "ps", "--format", "{{.Names"))
+	if err == nil || out != "" {
+		t.Fatalf("Expected an invalid template to fail before any output, got %v: %q", err, out)
+	}
+
+	logDone("ps - format with a template and as json")
+}
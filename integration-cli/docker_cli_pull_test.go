@@ -1,10 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"strings"
 	"testing"
+
+	"github.com/docker/docker/utils"
 )
 
 // See issue docker/docker#8141
@@ -132,3 +135,52 @@ func TestPullImageOfficialNames(t *testing.T) {
 	}
 	logDone("pull - pull official names")
 }
+
+func TestPullPushFormatJSON(t *testing.T) {
+	defer setupRegistry(t)()
+
+	repoName := fmt.Sprintf("%v/dockercli/busybox:json", privateRegistryURL)
+	dockerCmd(t, "tag", "busybox", repoName)
+	defer deleteImages(repoName)
+
+	// checkMessages asserts out is made of JSON messages only
+	checkMessages := func(out string) []utils.JSONMessage {
+		var messages []utils.JSONMessage
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			var jm utils.JSONMessage
+			if err := json.Unmarshal([]byte(line), &jm); err != nil {
+				t.Fatalf("Expected a JSON message, got %q: %v", line, err)
+			}
+			if jm.ProgressMessage != "" {
+				t.Fatalf("Expected the progress bar to be left out, got %q", line)
+			}
+			messages = append(messages, jm)
+		}
+		return messages
+	}
+
+	out, _, _ := dockerCmd(t, "push", "--format", "json", repoName)
+	checkMessages(out)
+
+	dockerCmd(t, "rmi", repoName)
+	out, _, _ = dockerCmd(t, "pull", "--format", "json", repoName)
+	found := false
+	for _, jm := range checkMessages(out) {
+		if jm.Status == "Download complete" && jm.ID != "" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Expected the layers downloaded to be reported, got %q", out)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "pull", "--format", "json", privateRegistryURL+"/dockercli/nosuchimage"))
+	if err == nil {
+		t.Fatalf("Expected pulling a missing image to fail, got %q", out)
+	}
+	if !strings.Contains(out, `"errorDetail":`) {
+		t.Fatalf("Expected the error to be printed as JSON, got %q", out)
+	}
+
+	logDone("pull - push and pull with the progress formatted as JSON")
+}
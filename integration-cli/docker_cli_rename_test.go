@@ -1,9 +1,12 @@
 package main
 
 import (
+	"bufio"
+	"fmt"
 	"os/exec"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRenameStoppedContainer(t *testing.T) {
@@ -119,3 +122,99 @@ func TestRenameInvalidName(t *testing.T) {
 
 	logDone("rename - invalid container name")
 }
+
+func TestRenameUpdatesReferences(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "--name", "first_name", "-d", "busybox", "sh", "-c", "while true; do echo hello; sleep 1; done")
+	cleanedContainerID := stripTrailingCharacters(out)
+	since := daemonTime(t).Unix()
+
+	dockerCmd(t, "rename", "first_name", "new_name")
+
+	name, err := inspectField(cleanedContainerID, "Name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "/new_name" {
+		t.Fatalf("Expected the container to be named /new_name, got %s", name)
+	}
+
+	// the container can be attached to by its new name
+	attachCmd := exec.Command(dockerBinary, "attach", "--no-stdin", "--sig-proxy=false", "new_name")
+	stdout, err := attachCmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := attachCmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	lines := make(chan string)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		lines <- line
+	}()
+	select {
+	case line := <-lines:
+		if strings.TrimSpace(line) != "hello" {
+			t.Fatalf("Expected hello from the attached container, got %q", line)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for output from the attached container")
+	}
+	attachCmd.Process.Kill()
+	attachCmd.Wait()
+
+	// but no longer by its old one
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "attach", "--no-stdin", "first_name")); err == nil {
+		t.Fatalf("Attaching by the old name should have failed: %s", out)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", "first_name")); err == nil {
+		t.Fatalf("Stopping by the old name should have failed: %s", out)
+	}
+
+	dockerCmd(t, "stop", "-t", "1", "new_name")
+	if running, err := inspectField(cleanedContainerID, "State.Running"); err != nil || running != "false" {
+		t.Fatalf("Expected the container to be stopped by its new name, running: %s, %v", running, err)
+	}
+
+	out, _, _ = dockerCmd(t, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", daemonTime(t).Unix()))
+	var renamed bool
+	for _, event := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(event)
+		if len(fields) > 0 && fields[len(fields)-1] == "rename" && strings.Contains(event, cleanedContainerID) {
+			renamed = true
+		}
+	}
+	if !renamed {
+		t.Fatalf("Expected a rename event for %s, got:\n%s", cleanedContainerID, out)
+	}
+
+	logDone("rename - references are updated and an event is logged")
+}
+
+func TestRenameToNameInUse(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "--name", "first_name", "-d", "busybox", "top")
+	cleanedContainerID := stripTrailingCharacters(out)
+	dockerCmd(t, "run", "--name", "taken_name", "-d", "busybox", "top")
+
+	runCmd := exec.Command(dockerBinary, "rename", "first_name", "taken_name")
+	if out, _, err := runCommandWithOutput(runCmd); err == nil || !strings.Contains(out, "Conflict") {
+		t.Fatalf("Renaming to a name in use should have failed: %s\n%v", out, err)
+	}
+
+	name, err := inspectField(cleanedContainerID, "Name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "/first_name" {
+		t.Fatalf("Expected the container to keep the name /first_name, got %s", name)
+	}
+	if id, err := inspectField("first_name", "Id"); err != nil || id != cleanedContainerID {
+		t.Fatalf("Expected first_name to still refer to %s, got %s, %v", cleanedContainerID, id, err)
+	}
+
+	logDone("rename - renaming to a name in use fails")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os/exec"
 	"strings"
 	"testing"
@@ -244,3 +245,30 @@ func TestContainerRestartwithGoodContainer(t *testing.T) {
 
 	logDone("restart - for a good container with restart policy, MaximumRetryCount is not 0 and RestartCount is 0")
 }
+
+func TestRestartCatContainerSameIDSingleEvent(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "-d", "-i", "busybox", "cat")
+	id := strings.TrimSpace(out)
+	since := daemonTime(t).Unix()
+
+	dockerCmd(t, "restart", "-t", "1", id)
+
+	out, _, _ = dockerCmd(t, "inspect", "--format", "{{.Id}} {{.State.Running}}", id)
+	if strings.TrimSpace(out) != id+" true" {
+		t.Fatalf("Expected %s to be running again, got %q", id, out)
+	}
+
+	out, _, _ = dockerCmd(t, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", daemonTime(t).Unix()+1), "--filter", "container="+id)
+	var events []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		events = append(events, fields[len(fields)-1])
+	}
+	if strings.Join(events, ",") != "die,restart" {
+		t.Fatalf("Expected the process to die and a single restart event, got %v in %q", events, out)
+	}
+
+	logDone("restart - restart the cat container with a single restart event")
+}
@@ -44,6 +44,43 @@ func TestRmContainerWithVolume(t *testing.T) {
 	logDone("rm - volume")
 }
 
+func TestRmVolumePersistsAcrossContainers(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	defer deleteAllContainers()
+
+	dockerCmd(t, "create", "--name", "data", "-v", "/data", "busybox", "true")
+	volumePath, err := inspectFieldMap("data", "Volumes", "/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the data outlives the container which wrote it
+	dockerCmd(t, "run", "--name", "writer", "--volumes-from", "data", "busybox", "sh", "-c", "echo persisted > /data/file")
+	dockerCmd(t, "rm", "-v", "writer")
+	out, _, _ := dockerCmd(t, "run", "--name", "reader", "--volumes-from", "data", "busybox", "cat", "/data/file")
+	if strings.TrimSpace(out) != "persisted" {
+		t.Fatalf("Expected the file written by the removed container, got %q", out)
+	}
+
+	// the volume is kept while another container references it
+	dockerCmd(t, "rm", "-v", "data")
+	if _, err := os.Stat(volumePath); err != nil {
+		t.Fatalf("The volume was removed while still in use: %v", err)
+	}
+	out, _, _ = dockerCmd(t, "start", "-a", "reader")
+	if strings.TrimSpace(out) != "persisted" {
+		t.Fatalf("Expected the file to still be readable, got %q", out)
+	}
+
+	// and removed with the last container using it
+	dockerCmd(t, "rm", "-v", "reader")
+	if _, err := os.Stat(volumePath); !os.IsNotExist(err) {
+		t.Fatalf("Expected the volume to be removed with its last container, got %v", err)
+	}
+
+	logDone("rm - volume persists until its last container is removed")
+}
+
 func TestRmRunningContainer(t *testing.T) {
 	defer deleteAllContainers()
 
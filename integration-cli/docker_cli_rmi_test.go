@@ -171,3 +171,42 @@ func TestRmiBlank(t *testing.T) {
 	}
 	logDone("rmi- blank image name")
 }
+
+func TestRmiAfterContainerRemoved(t *testing.T) {
+	defer deleteAllContainers()
+	image := "rmi-after-container"
+	defer deleteImages(image)
+
+	id, err := buildImage(image, `FROM busybox
+RUN dd if=/dev/zero of=/file bs=1024 count=1024`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", image, "top"))
+	if err != nil {
+		t.Fatalf("Could not run container: %s, %v", out, err)
+	}
+	containerID := stripTrailingCharacters(out)
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "rmi", image))
+	if err == nil || !strings.Contains(out, "is using it") {
+		t.Fatalf("Expected rmi to fail while the container is running: %s, %v", out, err)
+	}
+
+	dockerCmd(t, "stop", containerID)
+	dockerCmd(t, "rm", containerID)
+
+	out, _, _ = dockerCmd(t, "rmi", image)
+	if !strings.Contains(out, "Untagged: "+image+":latest") {
+		t.Fatalf("Expected the image to be untagged, got %q", out)
+	}
+	if !strings.Contains(out, "Deleted: "+id) {
+		t.Fatalf("Expected the image to be deleted, got %q", out)
+	}
+	if !strings.Contains(out, "Freed: ") {
+		t.Fatalf("Expected the freed space to be reported, got %q", out)
+	}
+
+	logDone("rmi - delete an image once its container is removed")
+}
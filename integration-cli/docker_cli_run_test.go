@@ -71,6 +71,287 @@ func TestRunWithoutMemoryswapLimit(t *testing.T) {
 	logDone("run - without memory swap limit")
 }
 
+// the memory limit should be kept when swap is unlimited
+func TestRunUnlimitedMemoryswapKeepsMemoryLimit(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "unlimitedswap", "-m", "100m", "--memory-swap", "-1", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatalf("failed to run container: %v, output: %q", err, out)
+	}
+
+	memory, err := inspectFieldJSON("unlimitedswap", "HostConfig.Memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := strconv.Itoa(100 * 1024 * 1024); memory != expected {
+		t.Fatalf("expected memory limit %s, got %s", expected, memory)
+	}
+
+	memorySwap, err := inspectFieldJSON("unlimitedswap", "HostConfig.MemorySwap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if memorySwap != "-1" {
+		t.Fatalf("expected unlimited memory swap (-1), got %s", memorySwap)
+	}
+
+	logDone("run - unlimited memory swap keeps the memory limit")
+}
+
+// a swap limit needs a memory limit as a base
+func TestRunMemoryswapWithoutMemory(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "--memory-swap", "-1", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err == nil {
+		t.Fatalf("run with --memory-swap and without --memory should have failed: %q", out)
+	}
+	if !strings.Contains(out, "--memory-swap can't be used without --memory") {
+		t.Fatalf("expected a conflicting options error, got: %q", out)
+	}
+
+	logDone("run - memory swap without memory limit fails")
+}
+
+func TestRunSysctlNotNamespaced(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "--sysctl", "vm.swappiness=10", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err == nil {
+		t.Fatalf("run with a non namespaced sysctl should have failed: %q", out)
+	}
+	if !strings.Contains(out, `sysctl "vm.swappiness" is not allowed`) {
+		t.Fatalf("expected a sysctl error, got: %q", out)
+	}
+
+	runCmd = exec.Command(dockerBinary, "run", "--net=host", "--sysctl", "net.ipv4.ip_forward=1", "busybox", "true")
+	out, _, err = runCommandWithOutput(runCmd)
+	if err == nil {
+		t.Fatalf("run with a network sysctl and --net=host should have failed: %q", out)
+	}
+	if !strings.Contains(out, "can't be used with network sysctls") {
+		t.Fatalf("expected a conflicting options error, got: %q", out)
+	}
+
+	logDone("run - non namespaced sysctls are rejected")
+}
+
+func TestRunTmpfs(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "--read-only", "--tmpfs", "/run:rw,size=64m", "--tmpfs", "/scratch", "busybox", "sh", "-c", "echo hi > /run/f && cat /run/f && mount")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to run container: %v, output: %q", err, out)
+	}
+	if !strings.HasPrefix(out, "hi\n") {
+		t.Fatalf("expected to write to the tmpfs, got: %q", out)
+	}
+	if !strings.Contains(out, "tmpfs on /run type tmpfs") || !strings.Contains(out, "size=65536k") {
+		t.Fatalf("expected /run to be a 64m tmpfs, got: %q", out)
+	}
+	if !strings.Contains(out, "tmpfs on /scratch type tmpfs") {
+		t.Fatalf("expected /scratch to be a tmpfs, got: %q", out)
+	}
+
+	runCmd = exec.Command(dockerBinary, "run", "--tmpfs", "/run:size=lots", "busybox", "true")
+	out, _, err = runCommandWithOutput(runCmd)
+	if err == nil {
+		t.Fatalf("run with an invalid tmpfs option should have failed: %q", out)
+	}
+	if !strings.Contains(out, "invalid value for tmpfs option") {
+		t.Fatalf("expected a tmpfs option error, got: %q", out)
+	}
+
+	logDone("run - mount tmpfs directories")
+}
+
+func TestRunInitReapsZombies(t *testing.T) {
+	defer deleteAllContainers()
+
+	// The main process never waits, the orphaned sleep is left to the init
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--init", "busybox", "sh", "-c", "sh -c 'sleep 0.1 &'; exec sleep 60")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to run container: %v, output: %q", err, out)
+	}
+	id := strings.TrimSpace(out)
+	if err := waitRun(id); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+
+	execCmd := exec.Command(dockerBinary, "exec", id, "sh", "-c", "cat /proc/[0-9]*/stat")
+	out, _, err = runCommandWithOutput(execCmd)
+	if err != nil {
+		t.Fatalf("failed to exec: %v, output: %q", err, out)
+	}
+	if !strings.HasPrefix(out, "1 (init) ") {
+		t.Fatalf("expected the init to be pid 1, got: %q", out)
+	}
+	if strings.Contains(out, ") Z ") {
+		t.Fatalf("expected no zombie to remain, got: %q", out)
+	}
+
+	// The init forwards SIGTERM, which sleep doesn't ignore as pid 1 would
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", "-t", "5", id)); err != nil {
+		t.Fatalf("failed to stop the container: %v, output: %q", err, out)
+	}
+	exitCode, err := inspectField(id, "State.ExitCode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != "143" {
+		t.Fatalf("expected sleep to be terminated by SIGTERM, got exit code %s", exitCode)
+	}
+
+	logDone("run - the init reaps zombies and forwards signals")
+}
+
+func TestRunOomKillDisable(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "-m", "32m", "--oom-kill-disable", "busybox", "top")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to run container: %v, output: %q", err, out)
+	}
+	out, err = readCgroupFile(strings.TrimSpace(out), "memory", "memory.oom_control")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "oom_kill_disable 1") {
+		t.Fatalf("expected the OOM killer to be disabled, got: %q", out)
+	}
+
+	logDone("run - oom kill disable with memory limit")
+}
+
+func TestRunOomKillDisableWithoutMemory(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "--oom-kill-disable", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err == nil {
+		t.Fatalf("run with --oom-kill-disable and without --memory should have failed: %q", out)
+	}
+	if !strings.Contains(out, "--oom-kill-disable can't be used without --memory") {
+		t.Fatalf("expected a conflicting options error, got: %q", out)
+	}
+
+	logDone("run - oom kill disable without memory limit fails")
+}
+
+func TestRunOomScoreAdj(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "--oom-score-adj=-500", "busybox", "cat", "/proc/self/oom_score_adj")
+	if actual := strings.TrimSpace(out); actual != "-500" {
+		t.Fatalf("Expected an oom_score_adj of -500, got %q", actual)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--oom-score-adj=1001", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "Invalid --oom-score-adj") {
+		t.Fatalf("Expected an out of range oom score adjustment to be rejected, got %v: %q", err, out)
+	}
+
+	logDone("run - set the oom score adjustment")
+}
+
+func TestRunPidsLimit(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--pids-limit", "10", "busybox", "top")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to run container: %v, output: %q", err, out)
+	}
+	limit, err := readCgroupFile(strings.TrimSpace(out), "pids", "pids.max")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit != "10" {
+		t.Fatalf("expected pids.max to be 10, got %q", limit)
+	}
+
+	// the shell and its children can't start more than 10 processes, so
+	// some of the forks fail with EAGAIN. The sleeps are killed before the
+	// shell exits.
+	forkLoop := `pids=""; i=0; while [ $i -lt 20 ]; do sleep 10 >/dev/null 2>&1 & pids="$pids $!"; i=$((i+1)); done; echo pids:$pids`
+	runCmd = exec.Command(dockerBinary, "run", "--rm", "--pids-limit", "10", "busybox", "sh", "-c", forkLoop+"; kill $pids")
+	out, _, _ = runCommandWithOutput(runCmd)
+	if !strings.Contains(out, "Resource temporarily unavailable") {
+		t.Fatalf("expected forks to fail with EAGAIN, got: %q", out)
+	}
+
+	// the limit only applies to the container
+	hostOut, err := exec.Command("sh", "-c", forkLoop).CombinedOutput()
+	if i := strings.Index(string(hostOut), "pids:"); i >= 0 {
+		defer exec.Command("kill", strings.Fields(string(hostOut[i+len("pids:"):]))...).Run()
+	}
+	if err != nil || strings.Contains(string(hostOut), "Resource temporarily unavailable") {
+		t.Fatalf("forking on the host failed: %v, output: %q", err, hostOut)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "busybox", "true")); err != nil {
+		t.Fatalf("failed to run a container without pids limit: %v, output: %q", err, out)
+	}
+
+	logDone("run - pids limit")
+}
+
+func TestRunUlimits(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "--ulimit", "nofile=1024:2048", "--ulimit", "nproc=512", "busybox", "sh", "-c", "ulimit -n; ulimit -Hn; ulimit -p")
+	if actual := strings.Fields(out); !reflect.DeepEqual(actual, []string{"1024", "2048", "512"}) {
+		t.Fatalf("Expected the limits 1024, 2048 and 512, got %q", out)
+	}
+
+	for _, val := range []string{"nofile=2048:1024", "nofile=1024:lots", "notalimit=1"} {
+		out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--ulimit", val, "busybox", "true"))
+		if err == nil {
+			t.Fatalf("Expected --ulimit %s to be rejected, got %q", val, out)
+		}
+	}
+
+	logDone("run - set the ulimits of a container")
+}
+
+func TestRunBlkioWeight(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--blkio-weight", "300", "busybox", "top")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to run container: %v, output: %q", err, out)
+	}
+	weight, err := readCgroupFile(strings.TrimSpace(out), "blkio", "blkio.weight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weight != "300" {
+		t.Fatalf("expected blkio.weight to be 300, got %q", weight)
+	}
+
+	for _, args := range [][]string{
+		{"--blkio-weight", "5"},
+		{"--device-read-bps", "/dev/null:1mb"},
+	} {
+		runCmd = exec.Command(dockerBinary, append(append([]string{"run"}, args...), "busybox", "true")...)
+		if out, _, err := runCommandWithOutput(runCmd); err == nil {
+			t.Fatalf("expected %v to be rejected, got %q", args, out)
+		}
+	}
+
+	logDone("run - blkio weight")
+}
+
 // "test" should be printed
 func TestRunEchoStdoutWitCPULimit(t *testing.T) {
 	defer deleteAllContainers()
@@ -187,6 +468,53 @@ func TestRunExitCodeOne(t *testing.T) {
 	logDone("run - exit with 1")
 }
 
+// the stdin of a container run with -i is closed at the end of a file
+// redirected in, so the process in the container exits without being killed
+func TestRunStdinEOF(t *testing.T) {
+	defer deleteAllContainers()
+
+	f, err := ioutil.TempFile("", "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("line1\nline2\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	runCmd := exec.Command(dockerBinary, "run", "-i", "--name", "stdineof", "busybox", "wc", "-l")
+	runCmd.Stdin = f
+	errChan := make(chan error)
+	var out string
+	go func() {
+		var err error
+		out, _, err = runCommandWithOutput(runCmd)
+		errChan <- err
+	}()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Fatal(err, out)
+		}
+	case <-time.After(15 * time.Second):
+		runCmd.Process.Kill()
+		t.Fatal("the container did not exit at the end of its input")
+	}
+	if actual := strings.TrimSpace(out); actual != "2" {
+		t.Fatalf("Expected the 2 lines of the input to be read, got %q", actual)
+	}
+	if code, err := inspectField("stdineof", "State.ExitCode"); err != nil || code != "0" {
+		t.Fatalf("Expected the container to exit with 0, got %q, %v", code, err)
+	}
+
+	logDone("run - stdin is closed at the end of the input")
+}
+
 // it should be possible to pipe in data via stdin to a process running in a container
 // some versions of lxc might make this test fail
 func TestRunStdinPipe(t *testing.T) {
@@ -266,6 +594,134 @@ func TestRunDetachedContainerIDPrinting(t *testing.T) {
 	logDone("run - print container ID in detached mode")
 }
 
+// The ID is printed once the container is running, so it can be attached to
+// without waiting
+func TestRunDetachedAttachImmediately(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "-d", "-i", "busybox", "cat")
+	id := stripTrailingCharacters(out)
+	if running, err := inspectField(id, "State.Running"); err != nil || running != "true" {
+		t.Fatalf("Expected the container to be running once its ID is printed, running: %s, %v", running, err)
+	}
+
+	attachCmd := exec.Command(dockerBinary, "attach", "--sig-proxy=false", id)
+	stdin, err := attachCmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := attachCmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := attachCmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer attachCmd.Process.Kill()
+
+	lines := make(chan string)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		lines <- line
+	}()
+	if _, err := stdin.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case line := <-lines:
+		if strings.TrimSpace(line) != "hello" {
+			t.Fatalf("Expected hello from the attached container, got %q", line)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for output from the attached container")
+	}
+
+	logDone("run - attach right after a detached run")
+}
+
+// The ID is not printed when the process fails to start
+func TestRunDetachedStartFailure(t *testing.T) {
+	testRequires(t, NativeExecDriver)
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox", "/bin/nada")
+	out, stderr, _, err := runCommandWithStdoutStderr(runCmd)
+	if err == nil {
+		t.Fatalf("Expected the run to fail, got %q", out)
+	}
+	if out != "" {
+		t.Fatalf("Expected no container ID when the container failed to start, got %q", out)
+	}
+	if !strings.Contains(stderr, "Cannot start container") {
+		t.Fatalf("Expected a start error, got %q", stderr)
+	}
+
+	logDone("run - detached run reports start failures")
+}
+
+// an attached container is killed once its client stops sending input for
+// the idle timeout
+func TestRunIdleTimeoutKillsContainer(t *testing.T) {
+	defer deleteAllContainers()
+
+	name := "idletimeout"
+	runCmd := exec.Command(dockerBinary, "run", "-i", "--name", name, "--idle-timeout", "3s", "busybox", "cat")
+	stdin, err := runCmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := runCmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan error, 1)
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		exited <- runCmd.Wait()
+	}()
+
+	// every input resets the timer, so the container outlives the timeout
+	for _, msg := range []string{"hello", "world"} {
+		if _, err := fmt.Fprintln(stdin, msg); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case line := <-lines:
+			if line != msg {
+				t.Fatalf("Expected %q, got %q", msg, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timeout waiting for %q", msg)
+		}
+		time.Sleep(2 * time.Second)
+	}
+	if running, err := inspectField(name, "State.Running"); err != nil || running != "true" {
+		t.Fatalf("Expected the container to still run while receiving input, got %s (%v)", running, err)
+	}
+
+	// stop sending
+	select {
+	case <-exited:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the idle container to be killed")
+	}
+	if running, err := inspectField(name, "State.Running"); err != nil || running != "false" {
+		t.Fatalf("Expected the container to be killed, got running %s (%v)", running, err)
+	}
+	if exitCode, err := inspectField(name, "State.ExitCode"); err != nil || exitCode != "137" {
+		t.Fatalf("Expected the container to be killed with SIGKILL, got exit code %s (%v)", exitCode, err)
+	}
+
+	logDone("run - idle timeout kills the container")
+}
+
 // the working directory should be set correctly
 func TestRunWorkingDirectory(t *testing.T) {
 	defer deleteAllContainers()
@@ -815,6 +1271,56 @@ func TestRunUserByIDZero(t *testing.T) {
 	logDone("run - user by id, zero uid")
 }
 
+func TestRunUserByIDUnknown(t *testing.T) {
+	defer deleteAllContainers()
+
+	// Numeric ids don't need an entry in the container's /etc/passwd
+	cmd := exec.Command(dockerBinary, "run", "-u", "1000", "busybox", "id", "-u")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if strings.TrimSpace(out) != "1000" {
+		t.Fatalf("expected uid 1000 got %s", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "-u", "1000:1000", "busybox", "id")
+	out, _, err = runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if !strings.HasPrefix(out, "uid=1000 gid=1000") {
+		t.Fatalf("expected uid and gid 1000 got %s", out)
+	}
+
+	logDone("run - user and group by id, not in the container")
+}
+
+func TestRunUserGroupDropsSupplementaryGroups(t *testing.T) {
+	defer deleteAllContainers()
+
+	// root is a member of wheel in busybox, unless a group is given
+	cmd := exec.Command(dockerBinary, "run", "-u", "root:root", "busybox", "id")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if !strings.HasPrefix(out, "uid=0(root) gid=0(root)") || strings.Contains(out, "wheel") {
+		t.Fatalf("expected root without supplementary groups got %s", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "-u", "root:daemon", "busybox", "id")
+	out, _, err = runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if !strings.HasPrefix(out, "uid=0(root) gid=1(daemon)") || strings.Contains(out, "wheel") {
+		t.Fatalf("expected root in the daemon group got %s", out)
+	}
+
+	logDone("run - user with a group, supplementary groups dropped")
+}
+
 func TestRunUserNotFound(t *testing.T) {
 	defer deleteAllContainers()
 
@@ -824,6 +1330,12 @@ func TestRunUserNotFound(t *testing.T) {
 		t.Fatal("unknown user should cause container to fail")
 	}
 
+	// The group is resolved in the container too
+	cmd = exec.Command(dockerBinary, "run", "-u", "root:notmygroup", "busybox", "id")
+	if _, err := runCommand(cmd); err == nil {
+		t.Fatal("unknown group should cause container to fail")
+	}
+
 	logDone("run - user not found")
 }
 
@@ -974,6 +1486,59 @@ func TestRunEnvironmentOverride(t *testing.T) {
 	logDone("run - verify environment override")
 }
 
+func TestRunEnvFile(t *testing.T) {
+	defer deleteAllContainers()
+
+	tmpDir, err := ioutil.TempDir("", "TestRunEnvFile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	envFile := filepath.Join(tmpDir, "app.env")
+	content := "# settings of the app\nFOO=file\n\nBAR=file value\nPASSTHROUGH\n"
+	if err := ioutil.WriteFile(envFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(dockerBinary, "run", "-d", "--env-file", envFile, "-e", "FOO=flag", "busybox", "top")
+	cmd.Env = appendBaseEnv([]string{"PASSTHROUGH=from the client"})
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	id := strings.TrimSpace(out)
+
+	out, _, err = dockerCmd(t, "exec", id, "env")
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	env := strings.Split(strings.TrimSpace(out), "\n")
+	for _, expected := range []string{"FOO=flag", "BAR=file value", "PASSTHROUGH=from the client"} {
+		found := false
+		for _, e := range env {
+			if e == expected {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("Expected %s in the environment, got %q", expected, env)
+		}
+	}
+	if strings.Contains(out, "FOO=file") {
+		t.Fatalf("Expected -e to override the env file, got %q", env)
+	}
+
+	if err := ioutil.WriteFile(envFile, []byte("FOO=bar\nBAD VAR=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--env-file", envFile, "busybox", "true"))
+	if err == nil || !strings.Contains(out, "app.env:2: variable 'BAD VAR' has white spaces") {
+		t.Fatalf("Expected a malformed env file error, got %q: %v", out, err)
+	}
+
+	logDone("run - environment from an env file, overridden by -e")
+}
+
 func TestRunContainerNetwork(t *testing.T) {
 	defer deleteAllContainers()
 
@@ -1055,11 +1620,35 @@ func TestRunFullHostnameSet(t *testing.T) {
 		t.Fatal(err, out)
 	}
 
-	if actual := strings.Trim(out, "\r\n"); actual != "foo.bar.baz" {
-		t.Fatalf("expected hostname 'foo.bar.baz', received %s", actual)
+	if actual := strings.Trim(out, "\r\n"); actual != "foo.bar.baz" {
+		t.Fatalf("expected hostname 'foo.bar.baz', received %s", actual)
+	}
+
+	logDone("run - test fully qualified hostname set with -h")
+}
+
+func TestRunHostnameAndDomainname(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "--hostname=myhost", "busybox", "hostname")
+	if actual := strings.TrimSpace(out); actual != "myhost" {
+		t.Fatalf("expected hostname 'myhost', received %s", actual)
+	}
+
+	out, _, _ = dockerCmd(t, "run", "--hostname=myhost", "--domainname=example.com", "busybox", "sh", "-c", "cat /proc/sys/kernel/domainname /etc/hostname; grep myhost /etc/hosts")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || lines[0] != "example.com" || lines[1] != "myhost.example.com" || !strings.HasSuffix(lines[2], "\tmyhost.example.com myhost") {
+		t.Fatalf("expected the domain name example.com in the kernel, /etc/hostname and /etc/hosts, got %q", out)
+	}
+
+	out, _, _ = dockerCmd(t, "run", "-d", "busybox", "top")
+	id := strings.TrimSpace(out)
+	out, _, _ = dockerCmd(t, "exec", id, "hostname")
+	if actual := strings.TrimSpace(out); actual != id[:12] {
+		t.Fatalf("expected the default hostname %s, received %s", id[:12], actual)
 	}
 
-	logDone("run - test fully qualified hostname set with -h")
+	logDone("run - hostname and domainname set with --hostname and --domainname")
 }
 
 func TestRunPrivilegedCanMknod(t *testing.T) {
@@ -1169,6 +1758,18 @@ func TestRunCapDropALLAddMknodCanMknod(t *testing.T) {
 	logDone("run - test --cap-drop=ALL --cap-add=MKNOD can mknod")
 }
 
+func TestRunCapDropALLAddOnlyGivesIt(t *testing.T) {
+	defer deleteAllContainers()
+
+	// NET_BIND_SERVICE is the capability 10
+	out, _, _ := dockerCmd(t, "run", "--cap-drop=ALL", "--cap-add=NET_BIND_SERVICE", "busybox", "grep", "CapEff", "/proc/self/status")
+	if actual := strings.TrimSpace(out); actual != "CapEff:\t0000000000000400" {
+		t.Fatalf("expected only NET_BIND_SERVICE to be effective, received %q", actual)
+	}
+
+	logDone("run - test --cap-drop=ALL --cap-add=NET_BIND_SERVICE gives only it")
+}
+
 func TestRunCapAddInvalid(t *testing.T) {
 	defer deleteAllContainers()
 
@@ -1245,6 +1846,44 @@ func TestRunPrivilegedCanMount(t *testing.T) {
 	logDone("run - test privileged can mount")
 }
 
+func TestRunPrivilegedCapDropCannotMount(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--name", "privdrop", "--privileged", "--cap-drop=SYS_ADMIN", "busybox", "sh", "-c", "mount -t tmpfs none /tmp && echo ok"))
+	if err == nil || strings.Contains(out, "ok") {
+		t.Fatalf("Expected a privileged container without SYS_ADMIN not to mount, got %v: %q", err, out)
+	}
+	if privileged, err := inspectField("privdrop", "HostConfig.Privileged"); err != nil || privileged != "true" {
+		t.Fatalf("Expected the container to be marked as privileged, got %q, %v", privileged, err)
+	}
+
+	// Devices of the host are still available
+	dockerCmd(t, "run", "--privileged", "--cap-drop=SYS_ADMIN", "busybox", "ls", "/dev/mem")
+
+	logDone("run - test privileged with --cap-drop honors the drops")
+}
+
+func TestRunPrivilegedIgnoresSeccomp(t *testing.T) {
+	defer deleteAllContainers()
+
+	f, err := ioutil.TempFile("", "seccomp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "mount", "action": "SCMP_ACT_ERRNO"}]}`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	out, _, _ := dockerCmd(t, "run", "--privileged", "--security-opt", "seccomp="+f.Name(), "busybox", "sh", "-c", "mount -t tmpfs none /tmp && echo ok")
+	if actual := strings.TrimSpace(out); actual != "ok" {
+		t.Fatalf("expected output ok received %s", actual)
+	}
+
+	logDone("run - test privileged has no seccomp filter")
+}
+
 func TestRunUnPrivilegedCannotMount(t *testing.T) {
 	defer deleteAllContainers()
 
@@ -1390,6 +2029,28 @@ func TestRunAddingOptionalDevices(t *testing.T) {
 	logDone("run - test --device argument")
 }
 
+func TestRunDevicePermissions(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "--device", "/dev/zero:/dev/zero-ro:r", "busybox", "sh", "-c", "ls -l /dev/zero-ro && dd if=/dev/zero-ro of=/dev/null bs=1 count=1")
+	fields := strings.Fields(out)
+	if len(fields) < 6 || fields[0][0] != 'c' || fields[4] != "1," || fields[5] != "5" {
+		t.Fatalf("Expected the character device 1, 5 at /dev/zero-ro, got %q", out)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--device", "/dev/zero:/dev/zero-ro:r", "busybox", "sh", "-c", "echo x > /dev/zero-ro"))
+	if err == nil {
+		t.Fatalf("Expected a read only device not to be written to, got %q", out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--device", "/dev/zero:/dev/zero:rx", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "Invalid device permissions") {
+		t.Fatalf("Expected invalid permissions to be rejected, got %v: %q", err, out)
+	}
+
+	logDone("run - test --device permissions")
+}
+
 func TestRunModeHostname(t *testing.T) {
 	testRequires(t, SameHostDaemon)
 	defer deleteAllContainers()
@@ -1536,6 +2197,78 @@ func TestRunDnsOptions(t *testing.T) {
 	logDone("run - dns options")
 }
 
+func TestRunDnsResolverOptions(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "run", "--dns=8.8.8.8", "--dns=8.8.4.4", "--dns-search=example.com", "--dns-option=ndots:2", "--dns-option=timeout:1", "busybox", "cat", "/etc/resolv.conf")
+	expected := "nameserver 8.8.8.8\nnameserver 8.8.4.4\nsearch example.com\noptions ndots:2 timeout:1"
+	if actual := strings.TrimSpace(out); actual != expected {
+		t.Fatalf("expected %q, but says: %q", expected, actual)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--dns-option=ndots:2 rotate", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "is not a valid DNS option") {
+		t.Fatalf("Expected an invalid DNS option to be rejected, got %v: %q", err, out)
+	}
+
+	logDone("run - dns resolver options")
+}
+
+func TestRunDnsResolverOptionsFilterLocalhost(t *testing.T) {
+	defer deleteAllContainers()
+	testRequires(t, SameHostDaemon)
+
+	origResolvConf, err := ioutil.ReadFile("/etc/resolv.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpResolvConf := []byte("search example.com\nnameserver 127.0.1.1\nnameserver 12.34.56.78\n")
+	if err := ioutil.WriteFile("/etc/resolv.conf", tmpResolvConf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ioutil.WriteFile("/etc/resolv.conf", origResolvConf, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// the nameservers of the host are filtered as without --dns-option
+	out, _, _ := dockerCmd(t, "run", "--dns-option=ndots:3", "busybox", "cat", "/etc/resolv.conf")
+	expected := "nameserver 12.34.56.78\nsearch example.com\noptions ndots:3"
+	if actual := strings.TrimSpace(out); actual != expected {
+		t.Fatalf("expected %q, but says: %q", expected, actual)
+	}
+
+	logDone("run - dns resolver options filter the localhost nameservers of the host")
+}
+
+func TestRunDnsIgnoresHostResolverOptions(t *testing.T) {
+	defer deleteAllContainers()
+	testRequires(t, SameHostDaemon)
+
+	origResolvConf, err := ioutil.ReadFile("/etc/resolv.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpResolvConf := []byte("search example.com\nnameserver 12.34.56.78\noptions ndots:3\n")
+	if err := ioutil.WriteFile("/etc/resolv.conf", tmpResolvConf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ioutil.WriteFile("/etc/resolv.conf", origResolvConf, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// only the options given with --dns-option are written with --dns
+	out, _, _ := dockerCmd(t, "run", "--dns=8.8.8.8", "busybox", "cat", "/etc/resolv.conf")
+	if options := resolvconf.GetOptions([]byte(out)); len(options) != 0 {
+		t.Fatalf("expected no options, but has: %v", options)
+	}
+
+	logDone("run - dns ignores the resolver options of the host")
+}
+
 func TestRunDnsOptionsBasedOnHostResolvConf(t *testing.T) {
 	defer deleteAllContainers()
 	testRequires(t, SameHostDaemon)
@@ -1850,6 +2583,30 @@ func TestRunAddHost(t *testing.T) {
 	logDone("run - add-host option")
 }
 
+func TestRunAddHostMultipleWithLinks(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "linked", "busybox", "top")
+	ip, err := inspectField("linked", "NetworkSettings.IPAddress")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, _ := dockerCmd(t, "run", "--link", "linked:linked", "--add-host=db:10.0.0.5", "--add-host=cache:10.0.0.6", "busybox", "cat", "/etc/hosts")
+	for _, expected := range []string{"10.0.0.5\tdb\n", "10.0.0.6\tcache\n", ip + "\tlinked "} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("Expected %q in /etc/hosts, got %q", expected, out)
+		}
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--add-host=db:10.0.0", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "invalid IP address in add-host") {
+		t.Fatalf("Expected an invalid IP in --add-host to be rejected, got %v: %q", err, out)
+	}
+
+	logDone("run - add-host with several entries and links")
+}
+
 // Regression test for #6983
 func TestRunAttachStdErrOnlyTTYMode(t *testing.T) {
 	defer deleteAllContainers()
@@ -2069,6 +2826,35 @@ func TestRunWorkdirExistsAndIsFile(t *testing.T) {
 	logDone("run - error on existing file for workdir")
 }
 
+func TestRunWorkdirCreated(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := dockerCmd(t, "run", "-w", "/nonexistent", "busybox", "sh", "-c", "pwd && test -d /nonexistent")
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if strings.TrimSpace(out) != "/nonexistent" {
+		t.Fatalf("Expected /nonexistent to be created and the cwd, got %q", out)
+	}
+
+	// The directory is created in the volume mounted over it, not under it
+	out, _, err = dockerCmd(t, "run", "-v", "/data", "-w", "/data/app", "busybox", "pwd")
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if strings.TrimSpace(out) != "/data/app" {
+		t.Fatalf("Expected /data/app to be created in the volume, got %q", out)
+	}
+
+	runCmd := exec.Command(dockerBinary, "run", "-v", "/tmp:/data:ro", "-w", "/data/docker-workdir-test", "busybox", "pwd")
+	out, _, err = runCommandWithOutput(runCmd)
+	if err == nil || !strings.Contains(out, "is in a read only volume") {
+		t.Fatalf("Expected an error for a workdir in a read only volume, got %q: %v", out, err)
+	}
+
+	logDone("run - workdir created, in a volume when mounted there")
+}
+
 func TestRunExitOnStdinClose(t *testing.T) {
 	name := "testrunexitonstdinclose"
 	defer deleteAllContainers()
@@ -2274,6 +3060,40 @@ func TestRunEntrypoint(t *testing.T) {
 	logDone("run - entrypoint")
 }
 
+func TestRunEntrypointOverrideAndClear(t *testing.T) {
+	name := "testrunentrypointoverride"
+	defer deleteImages(name)
+	defer deleteAllContainers()
+	if _, err := buildImage(name,
+		`FROM busybox
+		ENTRYPOINT ["echo", "entrypoint"]
+		CMD ["cmd"]`,
+		true); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, _ := dockerCmd(t, "run", name)
+	if actual := strings.TrimSpace(out); actual != "entrypoint cmd" {
+		t.Fatalf("Expected the entrypoint of the image to run, got %q", actual)
+	}
+
+	out, _, _ = dockerCmd(t, "run", "--entrypoint", "/bin/cat", name, "/etc/hostname")
+	if strings.Contains(out, "entrypoint") || strings.TrimSpace(out) == "" {
+		t.Fatalf("Expected /bin/cat to run instead of the entrypoint, got %q", out)
+	}
+
+	out, _, _ = dockerCmd(t, "run", "--entrypoint=", name, "echo", "direct")
+	if actual := strings.TrimSpace(out); actual != "direct" {
+		t.Fatalf("Expected the command to run without an entrypoint, got %q", actual)
+	}
+
+	if out, err := inspectField(name, "Config.Entrypoint"); err != nil || !strings.Contains(out, "echo") {
+		t.Fatalf("Expected the image to keep its entrypoint, got %q, %v", out, err)
+	}
+
+	logDone("run - override and clear the entrypoint")
+}
+
 func TestRunBindMounts(t *testing.T) {
 	testRequires(t, SameHostDaemon)
 	defer deleteAllContainers()
@@ -2384,6 +3204,35 @@ func TestRunCidFileCheckIDLength(t *testing.T) {
 	logDone("run - cidfile contains long id")
 }
 
+// An existing CIDFile is neither overwritten nor removed
+func TestRunCidFileExists(t *testing.T) {
+	defer deleteAllContainers()
+
+	tmpDir, err := ioutil.TempDir("", "TestRunCidFile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpCidFile := path.Join(tmpDir, "cid")
+	if err := ioutil.WriteFile(tmpCidFile, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--cidfile", tmpCidFile, "busybox", "true"))
+	if err == nil || !strings.Contains(out, "Container ID file found") {
+		t.Fatalf("Expected run to refuse an existing cidfile, got %v: %q", err, out)
+	}
+	buffer, err := ioutil.ReadFile(tmpCidFile)
+	if err != nil {
+		t.Fatalf("Expected the existing cidfile to be kept: %v", err)
+	}
+	if string(buffer) != "previous" {
+		t.Fatalf("Expected the existing cidfile not to be overwritten, got %q", buffer)
+	}
+
+	logDone("run - refuse an existing cidfile")
+}
+
 func TestRunNetworkNotInitializedNoneMode(t *testing.T) {
 	defer deleteAllContainers()
 
@@ -2999,6 +3848,37 @@ func TestContainerNetworkMode(t *testing.T) {
 	logDone("run - container shared network namespace")
 }
 
+func TestRunNetContainerSharesIP(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "busybox", "top"))
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	id := strings.TrimSpace(out)
+	ip, err := inspectField(id, "NetworkSettings.IPAddress")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--net=container:"+id, "busybox", "ip", "-o", "-4", "addr", "show", "eth0"))
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if !strings.Contains(out, " "+ip+"/") {
+		t.Fatalf("Expected the container to have the IP %s of %s, got %q", ip, id, out)
+	}
+
+	for _, mode := range []string{"host", "container:" + id} {
+		out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--net="+mode, "-p", "8080:80", "busybox", "true"))
+		if err == nil || !strings.Contains(out, "can't be used with -p or -P") {
+			t.Fatalf("Expected publishing a port with --net=%s to fail, got %v: %q", mode, err, out)
+		}
+	}
+
+	logDone("run - container shared network namespace has the same IP")
+}
+
 func TestRunModePidHost(t *testing.T) {
 	testRequires(t, NativeExecDriver, SameHostDaemon)
 	defer deleteAllContainers()
@@ -3033,6 +3913,35 @@ func TestRunModePidHost(t *testing.T) {
 	logDone("run - pid host mode")
 }
 
+func TestRunModePidContainer(t *testing.T) {
+	testRequires(t, NativeExecDriver)
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "-i", "busybox", "cat"))
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	id := strings.TrimSpace(out)
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", fmt.Sprintf("--pid=container:%s", id), "busybox", "ps", "-o", "args"))
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if !regexp.MustCompile(`(?m)^cat$`).MatchString(out) {
+		t.Fatalf("Expected the cat process of %s to be visible, got %q", id, out)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", id)); err != nil {
+		t.Fatal(err, out)
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", fmt.Sprintf("--pid=container:%s", id), "busybox", "true"))
+	if err == nil || !strings.Contains(out, "cannot join PID of a non running container") {
+		t.Fatalf("Expected joining the PID namespace of a stopped container to fail, got %v: %q", err, out)
+	}
+
+	logDone("run - pid container mode")
+}
+
 func TestRunTLSverify(t *testing.T) {
 	cmd := exec.Command(dockerBinary, "ps")
 	out, ec, err := runCommandWithOutput(cmd)
@@ -3323,3 +4232,119 @@ func TestRunVolumesFromRestartAfterRemoved(t *testing.T) {
 
 	logDone("run - can restart a volumes-from container after producer is removed")
 }
+
+func TestRunLabels(t *testing.T) {
+	defer deleteAllContainers()
+
+	name := "test_run_labels"
+	expected := map[string]string{"env": "prod", "team": "core"}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--name", name, "-l", "env=prod", "-l", "team=core", "busybox", "true")); err != nil {
+		t.Fatal(out, err)
+	}
+
+	actual := make(map[string]string)
+	if err := inspectFieldAndMarshall(name, "Config.Labels", &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected %s got %s", expected, actual)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-l", "=prod", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "label key cannot be empty") {
+		t.Fatalf("Expected run with an empty label key to fail, got: %s", out)
+	}
+
+	logDone("run - labels")
+}
+
+func TestRunConfigFile(t *testing.T) {
+	defer deleteAllContainers()
+
+	tmpDir, err := ioutil.TempDir("", "docker-run-config-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	configFile := filepath.Join(tmpDir, "app.yml")
+	content := "image: busybox\ncommand: [cat]\nenvironment:\n  - FOO=bar\nunknown: key\n"
+	if err := ioutil.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "-i", "--name", "fromfile", "--config-file", configFile)
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to run container from a config file: %v, output: %q", err, out)
+	}
+
+	running, err := inspectField("fromfile", "State.Running")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running != "true" {
+		t.Fatal("Container started from a config file should be running")
+	}
+	cmd, err := inspectFieldJSON("fromfile", "Config.Cmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd != `["cat"]` {
+		t.Fatalf("Expected the command of the config file, got %s", cmd)
+	}
+	env, err := inspectFieldJSON("fromfile", "Config.Env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(env, `"FOO=bar"`) {
+		t.Fatalf("Expected the environment of the config file, got %s", env)
+	}
+
+	logDone("run - run a container from a YAML config file")
+}
+
+func TestRunSeccompProfile(t *testing.T) {
+	defer deleteAllContainers()
+
+	writeProfile := func(profile string) string {
+		f, err := ioutil.TempFile("", "seccomp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(profile); err != nil {
+			t.Fatal(err)
+		}
+		return f.Name()
+	}
+	profile := writeProfile(`{
+	"defaultAction": "SCMP_ACT_ALLOW",
+	"syscalls": [
+		{"name": "mkdir", "action": "SCMP_ACT_ERRNO"},
+		{"name": "mkdirat", "action": "SCMP_ACT_ERRNO"}
+	]
+}`)
+	defer os.Remove(profile)
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--security-opt", "seccomp="+profile, "busybox", "mkdir", "/tmp/dir"))
+	if err == nil || !strings.Contains(out, "Operation not permitted") {
+		t.Fatalf("Expected mkdir to be blocked by the seccomp profile, got %v: %q", err, out)
+	}
+
+	dockerCmd(t, "run", "-d", "--name", "filtered", "--security-opt", "seccomp="+profile, "busybox", "top")
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "filtered", "mkdir", "/tmp/dir")); err == nil {
+		t.Fatalf("Expected mkdir to be blocked in an exec too: %q", out)
+	}
+
+	dockerCmd(t, "run", "--security-opt", "seccomp=unconfined", "busybox", "mkdir", "/tmp/dir")
+
+	unknown := writeProfile(`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "nosuchcall", "action": "SCMP_ACT_ERRNO"}]}`)
+	defer os.Remove(unknown)
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--security-opt", "seccomp="+unknown, "busybox", "true"))
+	if err == nil || !strings.Contains(out, `Unknown syscall "nosuchcall" in the seccomp profile`) {
+		t.Fatalf("Expected a profile with an unknown syscall to be rejected, got %v: %q", err, out)
+	}
+
+	logDone("run - seccomp profile blocks a syscall")
+}
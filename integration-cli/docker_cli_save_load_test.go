@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -422,3 +423,81 @@ func TestSaveDirectoryPermissions(t *testing.T) {
 
 	logDone("save - ensure directories exist in exported layers")
 }
+
+// a removed tag is restored by loading the saved image, as often as needed
+func TestSaveLoadRestoresTag(t *testing.T) {
+	repoName := "save-load-restore:v1"
+	defer deleteImages(repoName)
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "tag", "busybox:latest", repoName)); err != nil {
+		t.Fatalf("failed to tag repo: %s, %v", out, err)
+	}
+	imageID, err := inspectField(repoName, "Id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "save-load-restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	archivePath := filepath.Join(tmpDir, "image.tar")
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "save", "-o", archivePath, repoName)); err != nil {
+		t.Fatalf("failed to save image: %s, %v", out, err)
+	}
+
+	// the manifest lists the tag and every layer of the image
+	extractDir := filepath.Join(tmpDir, "extracted")
+	os.Mkdir(extractDir, 0777)
+	if out, _, err := runCommandWithOutput(exec.Command("tar", "-xf", archivePath, "-C", extractDir)); err != nil {
+		t.Fatalf("failed to extract the archive: %s, %v", out, err)
+	}
+	manifestJson, err := ioutil.ReadFile(filepath.Join(extractDir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest []struct {
+		Config   string
+		RepoTags []string
+		Layers   []string
+	}
+	if err := json.Unmarshal(manifestJson, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != 1 || !reflect.DeepEqual(manifest[0].RepoTags, []string{repoName}) {
+		t.Fatalf("Expected a manifest entry for %s, got %s", repoName, manifestJson)
+	}
+	if expected := imageID + "/layer.tar"; manifest[0].Layers[len(manifest[0].Layers)-1] != expected {
+		t.Fatalf("Expected %s to be the last layer, got %v", expected, manifest[0].Layers)
+	}
+	for _, layer := range manifest[0].Layers {
+		if _, err := os.Stat(filepath.Join(extractDir, layer)); err != nil {
+			t.Fatalf("Layer %s listed in the manifest is missing: %v", layer, err)
+		}
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "rmi", repoName)); err != nil {
+		t.Fatalf("failed to remove the tag: %s, %v", out, err)
+	}
+	if _, err := inspectField(repoName, "Id"); err == nil {
+		t.Fatalf("Expected %s to be removed", repoName)
+	}
+
+	// loading twice must give the same result
+	for i := 0; i < 2; i++ {
+		if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "load", "-i", archivePath)); err != nil {
+			t.Fatalf("failed to load image: %s, %v", out, err)
+		}
+		loadedID, err := inspectField(repoName, "Id")
+		if err != nil {
+			t.Fatalf("Expected %s to be restored: %v", repoName, err)
+		}
+		if loadedID != imageID {
+			t.Fatalf("Expected %s to point to %s, got %s", repoName, imageID, loadedID)
+		}
+	}
+
+	logDone("load - restores a removed tag")
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io/ioutil"
 	"os/exec"
 	"strings"
 	"testing"
@@ -240,3 +242,61 @@ func TestStartMultipleContainers(t *testing.T) {
 
 	logDone("start - start multiple containers continue on one failed")
 }
+
+func TestStartAttachCreatedInteractive(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "create", "-i", "busybox", "cat")
+	id := strings.TrimSpace(out)
+
+	startCmd := exec.Command(dockerBinary, "start", "-a", "-i", id)
+	startCmd.Stdin = strings.NewReader("hello from stdin\n")
+	out, _, err := runCommandWithOutput(startCmd)
+	if err != nil {
+		t.Fatalf("start -a -i failed: %v, output: %q", err, out)
+	}
+	if out != "hello from stdin\n" {
+		t.Fatalf("Expected the stdin to be attached and echoed back, got %q", out)
+	}
+
+	// Attaching to a running container doesn't start it again
+	dockerCmd(t, "run", "-d", "--name", "running", "busybox", "sh", "-c", "while [ ! -e /tmp/stop ]; do echo waiting; sleep 0.1; done; echo done")
+	if err := waitRun("running"); err != nil {
+		t.Fatal(err)
+	}
+	startedAt, err := inspectField("running", "State.StartedAt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attachCmd := exec.Command(dockerBinary, "start", "-a", "running")
+	stdout, err := attachCmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := attachCmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// once the output comes through, the client is attached
+	reader := bufio.NewReader(stdout)
+	if line, err := reader.ReadString('\n'); err != nil || line != "waiting\n" {
+		t.Fatalf("Expected to be attached to the running container, got %q: %v", line, err)
+	}
+	dockerCmd(t, "exec", "running", "touch", "/tmp/stop")
+
+	rest, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := attachCmd.Wait(); err != nil {
+		t.Fatalf("start -a of a running container failed: %v, output: %q", err, rest)
+	}
+	if !strings.HasSuffix(string(rest), "done\n") {
+		t.Fatalf("Expected to stay attached until the container exits, got %q", rest)
+	}
+	if out, err := inspectField("running", "State.StartedAt"); err != nil || out != startedAt {
+		t.Fatalf("Expected the running container not to be started again, started at %q then %q: %v", startedAt, out, err)
+	}
+
+	logDone("start - attach to the stdin of a created container")
+}
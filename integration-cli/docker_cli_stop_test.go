@@ -0,0 +1,154 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// a container exiting on SIGTERM should not be killed
+func TestStopSendsSigtermBeforeKill(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "trapterm", "busybox", "sh", "-c", "trap 'echo got SIGTERM; exit 0' TERM; while true; do sleep 1; done")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", "-t", "10", "trapterm")); err != nil {
+		t.Fatal(out, err)
+	}
+
+	exitCode, err := inspectField("trapterm", "State.ExitCode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != "0" {
+		t.Fatalf("Expected the container to exit cleanly on SIGTERM, got exit code %s", exitCode)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "logs", "trapterm"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, "got SIGTERM") {
+		t.Fatalf("Expected the container to receive SIGTERM, got logs: %q", out)
+	}
+
+	logDone("stop - sends SIGTERM first")
+}
+
+// a container ignoring SIGTERM should be killed after the timeout
+func TestStopKillsAfterTimeout(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "ignoreterm", "busybox", "sh", "-c", "trap '' TERM; while true; do sleep 1; done")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", "-t", "1", "ignoreterm")); err != nil {
+		t.Fatal(out, err)
+	}
+
+	exitCode, err := inspectField("ignoreterm", "State.ExitCode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != "137" {
+		t.Fatalf("Expected the container to be killed with SIGKILL, got exit code %s", exitCode)
+	}
+
+	logDone("stop - kills after the timeout")
+}
+
+// a container run with --stop-signal should receive it instead of SIGTERM
+func TestStopSendsStopSignal(t *testing.T) {
+	defer deleteAllContainers()
+
+	// SIGTERM would be ignored, so only SIGUSR1 stops the container cleanly
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "trapusr1", "--stop-signal", "SIGUSR1", "busybox", "sh", "-c", "trap '' TERM; trap 'echo got SIGUSR1; exit 0' USR1; while true; do sleep 1; done")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	stopSignal, err := inspectField("trapusr1", "Config.StopSignal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopSignal != "SIGUSR1" {
+		t.Fatalf("Expected the stop signal to be SIGUSR1, got %q", stopSignal)
+	}
+
+	start := time.Now()
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", "-t", "10", "trapusr1")); err != nil {
+		t.Fatal(out, err)
+	}
+	if elapsed := time.Since(start); elapsed >= 10*time.Second {
+		t.Fatalf("Expected the container to stop within the grace period, took %s", elapsed)
+	}
+
+	exitCode, err := inspectField("trapusr1", "State.ExitCode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != "0" {
+		t.Fatalf("Expected the container to exit cleanly on SIGUSR1 and not to be killed, got exit code %s", exitCode)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "logs", "trapusr1"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, "got SIGUSR1") {
+		t.Fatalf("Expected the container to receive SIGUSR1, got logs: %q", out)
+	}
+
+	logDone("stop - sends the container's stop signal")
+}
+
+// SIGINT is what a shell waits for to exit gracefully, it is never ignored
+// when a trap is set
+func TestStopSendsSigint(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "trapint", "--stop-signal=SIGINT", "busybox", "sh", "-c", "trap 'echo got SIGINT; exit 3' INT; while true; do sleep 1; done")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", "trapint")); err != nil {
+		t.Fatal(out, err)
+	}
+
+	exitCode, err := inspectField("trapint", "State.ExitCode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != "3" {
+		t.Fatalf("Expected the container to exit from its SIGINT trap, got exit code %s", exitCode)
+	}
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "logs", "trapint"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, "got SIGINT") {
+		t.Fatalf("Expected the container to receive SIGINT, got logs: %q", out)
+	}
+
+	logDone("stop - sends SIGINT as the stop signal")
+}
+
+func TestRunInvalidStopSignal(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--stop-signal", "SIGFOO", "busybox", "true"))
+	if err == nil {
+		t.Fatalf("run with an invalid stop signal should have failed: %q", out)
+	}
+	if !strings.Contains(out, "Invalid signal: SIGFOO") {
+		t.Fatalf("Expected an invalid signal error, got %q", out)
+	}
+
+	logDone("run - invalid stop signal is rejected")
+}
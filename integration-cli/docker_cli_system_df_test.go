@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dfLine returns the fields of the summary line of docker system df for kind
+func dfLine(t *testing.T, out, kind string) []string {
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == kind {
+			return fields
+		}
+	}
+	t.Fatalf("No %s line in %q", kind, out)
+	return nil
+}
+
+func countLines(out string) int {
+	seen := map[string]struct{}{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" {
+			seen[line] = struct{}{}
+		}
+	}
+	return len(seen)
+}
+
+func TestSystemDf(t *testing.T) {
+	name := "testsystemdf"
+	defer deleteImages(name)
+	defer deleteAllContainers()
+
+	if _, err := buildImage(name, `FROM busybox
+RUN dd if=/dev/zero of=/file bs=1024 count=1024`, true); err != nil {
+		t.Fatal(err)
+	}
+	out, _, _ := dockerCmd(t, "run", "-d", name, "dd", "if=/dev/zero", "of=/written", "bs=1024", "count=1024")
+	containerID := stripTrailingCharacters(out)
+	dockerCmd(t, "wait", containerID)
+
+	out, _, _ = dockerCmd(t, "images", "-q", "--no-trunc")
+	images := countLines(out)
+	out, _, _ = dockerCmd(t, "ps", "-a", "-q")
+	containers := countLines(out)
+
+	out, _, _ = dockerCmd(t, "system", "df")
+	fields := dfLine(t, out, "Images")
+	if fields[1] != fmt.Sprint(images) {
+		t.Fatalf("Expected %d images, got %q", images, out)
+	}
+	if fields[3] == "0" {
+		t.Fatalf("Expected the images to use some space, got %q", out)
+	}
+	fields = dfLine(t, out, "Containers")
+	if fields[1] != fmt.Sprint(containers) {
+		t.Fatalf("Expected %d containers, got %q", containers, out)
+	}
+	if fields[3] == "0" {
+		t.Fatalf("Expected the containers to use some space, got %q", out)
+	}
+	dfLine(t, out, "Volumes")
+
+	out, _, _ = dockerCmd(t, "system", "df", "-v")
+	found := false
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == containerID[:12] {
+			found = true
+			if fields[3] == "0" {
+				t.Fatalf("Expected container %s to use some space, got %q", containerID, line)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("Expected container %s to be listed, got %q", containerID, out)
+	}
+	if !strings.Contains(out, name+":latest") {
+		t.Fatalf("Expected image %s to be listed, got %q", name, out)
+	}
+
+	out, _, _ = dockerCmd(t, "system", "df", "-v", "--no-trunc")
+	if !strings.Contains(out, containerID+" ") {
+		t.Fatalf("Expected the full ID of container %s, got %q", containerID, out)
+	}
+
+	logDone("system df - report the disk usage of images and containers")
+}
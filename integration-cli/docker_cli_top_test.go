@@ -106,3 +106,52 @@ func TestTopPrivileged(t *testing.T) {
 
 	logDone("top - sleep process should be listed in privileged mode")
 }
+
+func TestTopCatProcess(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-i", "-d", "busybox", "cat")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to start the container: %s, %v", out, err)
+	}
+	id := stripTrailingCharacters(out)
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "top", id, "-ef"))
+	if err != nil {
+		t.Fatalf("failed to run top: %s, %v", out, err)
+	}
+	lines := strings.Split(stripTrailingCharacters(out), "\n")
+	if !strings.Contains(lines[0], "PID") || !strings.Contains(lines[0], "CMD") {
+		t.Fatalf("Expected the ps -ef titles, got %q", lines[0])
+	}
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], "cat") {
+		t.Fatalf("Expected top to list only the cat process, got %q", out)
+	}
+
+	logDone("top - cat process is listed with ps arguments")
+}
+
+func TestTopStoppedContainer(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to start the container: %s, %v", out, err)
+	}
+	id := stripTrailingCharacters(out)
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "wait", id)); err != nil {
+		t.Fatal(out, err)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "top", id))
+	if err == nil {
+		t.Fatalf("Expected top to fail for a stopped container, got %s", out)
+	}
+	if !strings.Contains(out, "is not running") {
+		t.Fatalf("Expected a not running error, got %s", out)
+	}
+
+	logDone("top - rejects stopped containers")
+}
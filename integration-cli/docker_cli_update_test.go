@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestUpdateRunningContainer(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "-i", "-c", "512", "-m", "64m", "busybox", "cat"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+
+	if out, _, err := dockerCmd(t, "update", "--cpu-shares", "1024", "-m", "128m", id); err != nil || strings.TrimSpace(out) != id {
+		t.Fatalf("Expected the container to be updated, got %q: %v", out, err)
+	}
+
+	for _, c := range []struct{ subsystem, file, expected string }{
+		{"cpu", "cpu.shares", "1024"},
+		{"memory", "memory.limit_in_bytes", "134217728"},
+	} {
+		out, err := readCgroupFile(id, c.subsystem, c.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != c.expected {
+			t.Fatalf("Expected %s in %s, got %q", c.expected, c.file, out)
+		}
+	}
+
+	// The new limits are kept when the container restarts
+	cpuShares, err := inspectField(id, "HostConfig.CpuShares")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpuShares != "1024" {
+		t.Fatalf("Expected the host config to be updated, got CpuShares %s", cpuShares)
+	}
+
+	logDone("update - cpu shares and memory of a running container")
+}
+
+func TestUpdateStoppedContainer(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "create", "busybox", "top"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+
+	dockerCmd(t, "update", "-c", "256", id)
+	dockerCmd(t, "start", id)
+	out, err = readCgroupFile(id, "cpu", "cpu.shares")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "256" {
+		t.Fatalf("Expected the container to start with the new cpu shares, got %q", out)
+	}
+
+	logDone("update - cpu shares of a stopped container")
+}
+
+func TestUpdateInvalid(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "-i", "busybox", "cat"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+
+	for _, args := range [][]string{
+		{"update", id},
+		{"update", "-m", "1m", id},
+		{"update", "--memory-swap", "64m", id},
+		{"update", "-c", "512", "nonexistent"},
+	} {
+		out, _, err := runCommandWithOutput(exec.Command(dockerBinary, args...))
+		if err == nil {
+			t.Fatalf("Expected %v to fail, got %q", args, out)
+		}
+	}
+
+	logDone("update - invalid updates fail")
+}
+
+func TestUpdateBlkioWeight(t *testing.T) {
+	testRequires(t, SameHostDaemon)
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--blkio-weight", "300", "busybox", "top"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+
+	if out, _, err := dockerCmd(t, "update", "--blkio-weight", "600", id); err != nil || strings.TrimSpace(out) != id {
+		t.Fatalf("Expected the container to be updated, got %q: %v", out, err)
+	}
+	out, err = readCgroupFile(id, "blkio", "blkio.weight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "600" {
+		t.Fatalf("Expected a blkio weight of 600, got %q", out)
+	}
+	if weight, err := inspectField(id, "HostConfig.BlkioWeight"); err != nil || weight != "600" {
+		t.Fatalf("Expected the host config to be updated, got BlkioWeight %s: %v", weight, err)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "update", "--blkio-weight", "2000", id)); err == nil {
+		t.Fatalf("Expected a blkio weight out of range to be rejected, got %q", out)
+	}
+
+	logDone("update - blkio weight of a running container")
+}
@@ -35,3 +35,22 @@ func TestVersionEnsureSucceeds(t *testing.T) {
 
 	logDone("version - verify that it works and that the output is properly formatted")
 }
+
+func TestVersionPinnedAPIVersion(t *testing.T) {
+	defer deleteAllContainers()
+
+	// An older version of the API is still served by the daemon
+	dockerCmd(t, "--api-version=1.16", "run", "--name", "pinned", "busybox", "true")
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "--api-version=1.16", "stats", "pinned"))
+	if err == nil || !strings.Contains(out, "docker stats requires API version 1.17, but the daemon only supports 1.16") {
+		t.Fatalf("Expected stats to require a newer API version, got %v: %q", err, out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "--api-version=99.0", "ps"))
+	if err == nil || !strings.Contains(out, "Invalid --api-version 99.0") {
+		t.Fatalf("Expected a version newer than the client to be rejected, got %v: %q", err, out)
+	}
+
+	logDone("version - pin the API version of the client")
+}
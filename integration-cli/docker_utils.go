@@ -759,6 +759,32 @@ func inspectFieldMap(name, path, field string) (string, error) {
 	return inspectFilter(name, fmt.Sprintf("index .%s %q", path, field))
 }
 
+// readCgroupFile reads a cgroup file of a running container from the host,
+// the cgroup filesystem not being mounted in containers
+func readCgroupFile(name, subsystem, file string) (string, error) {
+	pid, err := inspectField(name, "State.Pid")
+	if err != nil {
+		return "", err
+	}
+	cgroups, err := ioutil.ReadFile(fmt.Sprintf("/proc/%s/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(cgroups), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, s := range strings.Split(parts[1], ",") {
+			if s == subsystem {
+				content, err := ioutil.ReadFile(filepath.Join("/sys/fs/cgroup", subsystem, parts[2], file))
+				return strings.TrimSpace(string(content)), err
+			}
+		}
+	}
+	return "", fmt.Errorf("no %s cgroup found for the container %s", subsystem, name)
+}
+
 func getIDByName(name string) (string, error) {
 	return inspectField(name, "Id")
 }
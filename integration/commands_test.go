@@ -396,6 +396,48 @@ func TestAttachDisconnect(t *testing.T) {
 	container.WaitStop(-1 * time.Second)
 }
 
+// Expected behaviour: the stdin of a container run with -i and without a tty is
+// closed when the input of the client ends, so /bin/cat exits by itself
+func TestRunStdinEOF(t *testing.T) {
+	stdout, stdoutPipe := io.Pipe()
+	stdin := ioutil.NopCloser(strings.NewReader("hello\nworld\n"))
+
+	cli := client.NewDockerCli(stdin, stdoutPipe, ioutil.Discard, "", testDaemonProto, testDaemonAddr, nil)
+	defer cleanup(globalEngine, t)
+
+	c := make(chan struct{})
+	go func() {
+		defer close(c)
+		defer stdoutPipe.Close()
+		if err := cli.CmdRun("-i", unitTestImageID, "/bin/cat"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	setTimeout(t, "Reading the output of the container timed out", 5*time.Second, func() {
+		out, err := ioutil.ReadAll(stdout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != "hello\nworld\n" {
+			t.Fatalf("Expected the input to be echoed, got %q", out)
+		}
+	})
+
+	// No one closes the stdin of the container but the end of the input
+	setTimeout(t, "CmdRun timed out, the stdin of the container was not closed", 5*time.Second, func() {
+		<-c
+	})
+
+	container := globalDaemon.List()[0]
+	if container.IsRunning() {
+		t.Fatal("/bin/cat is still running after the end of its input")
+	}
+	if code := container.ExitCode; code != 0 {
+		t.Fatalf("Expected /bin/cat to exit with 0, got %d", code)
+	}
+}
+
 // Expected behaviour: container gets deleted automatically after exit
 func TestRunAutoRemove(t *testing.T) {
 	t.Skip("Fixme. Skipping test for now, race condition")
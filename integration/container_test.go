@@ -3,10 +3,12 @@ package docker
 import (
 	"io"
 	"io/ioutil"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/runconfig"
+	"golang.org/x/net/context"
 )
 
 func TestRestartStdin(t *testing.T) {
@@ -154,6 +156,139 @@ func TestTty(t *testing.T) {
 	}
 }
 
+func TestStdoutStderrPipes(t *testing.T) {
+	daemon := mkDaemon(t)
+	defer nuke(daemon)
+	container, _, err := daemon.Create(&runconfig.Config{
+		Image: GetTestImage(daemon).ID,
+		Cmd:   []string{"sh", "-c", "echo hello; echo oops >&2"},
+	},
+		&runconfig.HostConfig{},
+		"",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer daemon.Rm(container)
+
+	stdout := container.StdoutPipe()
+	defer stdout.Close()
+	stderr := container.StderrPipe()
+	defer stderr.Close()
+	if err := container.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the pipes are read without waiting for the container, they return
+	// io.EOF when it exits
+	type result struct {
+		output []byte
+		err    error
+	}
+	readAll := func(r io.Reader) chan result {
+		c := make(chan result, 1)
+		go func() {
+			output, err := ioutil.ReadAll(r)
+			c <- result{output, err}
+		}()
+		return c
+	}
+	expect := func(name string, c chan result, expected string) {
+		select {
+		case r := <-c:
+			if r.err != nil {
+				t.Fatal(r.err)
+			}
+			if string(r.output) != expected {
+				t.Fatalf("Unexpected %s. Expected %q, received: %q", name, expected, string(r.output))
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timeout waiting for the %s pipe to be closed", name)
+		}
+	}
+	stdoutResult, stderrResult := readAll(stdout), readAll(stderr)
+	expect("stdout", stdoutResult, "hello\n")
+	expect("stderr", stderrResult, "oops\n")
+}
+
+func TestWaitStopContext(t *testing.T) {
+	daemon := mkDaemon(t)
+	defer nuke(daemon)
+	container, _, err := daemon.Create(&runconfig.Config{
+		Image: GetTestImage(daemon).ID,
+		Cmd:   []string{"cat"},
+
+		OpenStdin: true,
+	},
+		&runconfig.HostConfig{},
+		"",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer daemon.Rm(container)
+
+	stdin := container.StdinPipe()
+	if err := container.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := container.WaitStopContext(ctx); err != context.Canceled {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("WaitStopContext took %s to return after cancel", elapsed)
+	}
+	if !container.IsRunning() {
+		t.Fatal("Cancelling the wait shouldn't stop the container")
+	}
+
+	if err := stdin.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := container.WaitStopContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKillExitStatus(t *testing.T) {
+	daemon := mkDaemon(t)
+	defer nuke(daemon)
+	container, _, err := daemon.Create(&runconfig.Config{
+		Image: GetTestImage(daemon).ID,
+		Cmd:   []string{"cat"},
+
+		OpenStdin: true,
+	},
+		&runconfig.HostConfig{},
+		"",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer daemon.Rm(container)
+
+	if err := container.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := container.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	exitStatus, err := container.WaitStopStatus(10 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exitStatus.Signaled || exitStatus.Signal != syscall.SIGKILL {
+		t.Fatalf("Expected the container to be killed by SIGKILL, got %+v", exitStatus)
+	}
+}
+
 func BenchmarkRunSequential(b *testing.B) {
 	daemon := mkDaemon(b)
 	defer nuke(daemon)
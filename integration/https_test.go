@@ -82,3 +82,22 @@ func TestHttpsInfoRogueServerCert(t *testing.T) {
 
 	})
 }
+
+// TestHttpsInfoNoClientCert connects via HTTPS to the info endpoint without
+// presenting a client certificate and checks that the daemon rejects it.
+func TestHttpsInfoNoClientCert(t *testing.T) {
+	tlsConfig := getTlsConfig("client-cert.pem", "client-key.pem", t)
+	tlsConfig.Certificates = nil
+	cli := client.NewDockerCli(nil, ioutil.Discard, ioutil.Discard, "", testDaemonProto,
+		testDaemonHttpsAddr, tlsConfig)
+
+	setTimeout(t, "Reading command output time out", 10*time.Second, func() {
+		err := cli.CmdInfo()
+		if err == nil {
+			t.Fatal("Expected error but got nil")
+		}
+		if !strings.Contains(err.Error(), errBadCertificate) {
+			t.Fatalf("Expected error: %s, got instead: %s", errBadCertificate, err)
+		}
+	})
+}
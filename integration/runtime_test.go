@@ -355,6 +355,55 @@ func TestDaemonCreate(t *testing.T) {
 	}
 }
 
+func TestDaemonCreateAndStart(t *testing.T) {
+	daemon := mkDaemon(t)
+	defer nuke(daemon)
+
+	container, _, err := daemon.Create(&runconfig.Config{
+		Image: GetTestImage(daemon).ID,
+		Cmd:   []string{"top"},
+	},
+		&runconfig.HostConfig{},
+		"programmatic",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer daemon.Rm(container)
+
+	if err := container.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer container.Kill()
+	if !container.IsRunning() {
+		t.Fatalf("Expected the container to be running")
+	}
+
+	var found bool
+	for _, c := range daemon.List() {
+		if c.ID == container.ID {
+			found = c.IsRunning()
+		}
+	}
+	if !found {
+		t.Fatalf("Expected the running container to be listed")
+	}
+	if c, err := daemon.Get("programmatic"); err != nil || c != container {
+		t.Fatalf("Unable to get the container by its name: %v", err)
+	}
+
+	// the host config is verified as for docker create
+	if _, _, err := daemon.Create(&runconfig.Config{
+		Image: GetTestImage(daemon).ID,
+		Cmd:   []string{"true"},
+	},
+		&runconfig.HostConfig{Memory: 524287},
+		"",
+	); err == nil || !strings.Contains(err.Error(), "Minimum memory limit") {
+		t.Fatalf("Expected a too low memory limit to be rejected, got %v", err)
+	}
+}
+
 func TestDestroy(t *testing.T) {
 	daemon := mkDaemon(t)
 	defer nuke(daemon)
@@ -9,6 +9,9 @@ import (
 
 /*
 Read in a line delimited file with environment variables enumerated
+
+Blank lines and lines starting with '#' are skipped. A line without '=' passes
+the variable of the same name through from the current environment.
 */
 func ParseEnvFile(filename string) ([]string, error) {
 	fh, err := os.Open(filename)
@@ -19,26 +22,35 @@ func ParseEnvFile(filename string) ([]string, error) {
 
 	lines := []string{}
 	scanner := bufio.NewScanner(fh)
-	for scanner.Scan() {
-		line := scanner.Text()
+	for n := 1; scanner.Scan(); n++ {
+		// trim the front of a line, but nothing else
+		line := strings.TrimLeft(scanner.Text(), whiteSpaces)
 		// line is not empty, and not starting with '#'
-		if len(line) > 0 && !strings.HasPrefix(line, "#") {
-			if strings.Contains(line, "=") {
-				data := strings.SplitN(line, "=", 2)
-
-				// trim the front of a variable, but nothing else
-				variable := strings.TrimLeft(data[0], whiteSpaces)
-				if strings.ContainsAny(variable, whiteSpaces) {
-					return []string{}, ErrBadEnvVariable{fmt.Sprintf("variable '%s' has white spaces", variable)}
-				}
-
-				// pass the value through, no trimming
-				lines = append(lines, fmt.Sprintf("%s=%s", variable, data[1]))
-			} else {
-				// if only a pass-through variable is given, clean it up.
-				lines = append(lines, fmt.Sprintf("%s=%s", strings.TrimSpace(line), os.Getenv(line)))
-			}
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		data := strings.SplitN(line, "=", 2)
+		variable := data[0]
+		if len(data) == 1 {
+			// if only a pass-through variable is given, clean it up.
+			variable = strings.TrimRight(variable, whiteSpaces)
+		}
+		if variable == "" {
+			return []string{}, ErrBadEnvVariable{fmt.Sprintf("%s:%d: no variable name", filename, n)}
+		}
+		if strings.ContainsAny(variable, whiteSpaces) {
+			return []string{}, ErrBadEnvVariable{fmt.Sprintf("%s:%d: variable '%s' has white spaces", filename, n, variable)}
 		}
+
+		if len(data) == 2 {
+			// pass the value through, no trimming
+			lines = append(lines, fmt.Sprintf("%s=%s", variable, data[1]))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s=%s", variable, os.Getenv(variable)))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return []string{}, err
 	}
 	return lines, nil
 }
@@ -0,0 +1,78 @@
+package opts
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func tmpEnvFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "envfile-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestParseEnvFile(t *testing.T) {
+	os.Setenv("ENVFILE_TEST_PASSTHROUGH", "from the host")
+	defer os.Unsetenv("ENVFILE_TEST_PASSTHROUGH")
+
+	name := tmpEnvFile(t, `# a comment
+FOO=bar
+  INDENTED=value with  spaces
+
+	# an indented comment
+EMPTY=
+EQUALS=a=b
+ENVFILE_TEST_PASSTHROUGH
+ENVFILE_TEST_UNSET  
+`)
+	defer os.Remove(name)
+
+	lines, err := ParseEnvFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"FOO=bar",
+		"INDENTED=value with  spaces",
+		"EMPTY=",
+		"EQUALS=a=b",
+		"ENVFILE_TEST_PASSTHROUGH=from the host",
+		"ENVFILE_TEST_UNSET=",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("Expected %q, got %q", expected, lines)
+	}
+}
+
+func TestParseEnvFileBadLines(t *testing.T) {
+	for content, msg := range map[string]string{
+		"FOO=bar\nBAD VAR=1\n": ":2: variable 'BAD VAR' has white spaces",
+		"FOO BAR\n":            ":1: variable 'FOO BAR' has white spaces",
+		"FOO=bar\n\n=value\n":  ":3: no variable name",
+	} {
+		name := tmpEnvFile(t, content)
+		_, err := ParseEnvFile(name)
+		os.Remove(name)
+		if _, ok := err.(ErrBadEnvVariable); !ok {
+			t.Fatalf("Expected a bad variable error for %q, got %v", content, err)
+		}
+		if !strings.Contains(err.Error(), name+msg) {
+			t.Fatalf("Expected the error for %q to contain %q, got %q", content, name+msg, err)
+		}
+	}
+}
+
+func TestParseEnvFileNotFound(t *testing.T) {
+	if _, err := ParseEnvFile("/nonexistent/env/file"); !os.IsNotExist(err) {
+		t.Fatalf("Expected a not exist error, got %v", err)
+	}
+}
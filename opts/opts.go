@@ -10,14 +10,28 @@ import (
 
 	"github.com/docker/docker/api"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/utils"
 )
 
 var (
-	alphaRegexp  = regexp.MustCompile(`[a-zA-Z]`)
-	domainRegexp = regexp.MustCompile(`^(:?(:?[a-zA-Z0-9]|(:?[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9]))(:?\.(:?[a-zA-Z0-9]|(:?[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9])))*)\.?\s*$`)
+	alphaRegexp    = regexp.MustCompile(`[a-zA-Z]`)
+	domainRegexp   = regexp.MustCompile(`^(:?(:?[a-zA-Z0-9]|(:?[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9]))(:?\.(:?[a-zA-Z0-9]|(:?[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9])))*)\.?\s*$`)
+	labelKeyRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]*[a-zA-Z0-9])?$`)
+
+	// validIpcSysctls are the IPC namespaced sysctls without a common prefix
+	validIpcSysctls = map[string]bool{
+		"kernel.msgmax":          true,
+		"kernel.msgmnb":          true,
+		"kernel.msgmni":          true,
+		"kernel.sem":             true,
+		"kernel.shmall":          true,
+		"kernel.shmmax":          true,
+		"kernel.shmmni":          true,
+		"kernel.shm_rmid_forced": true,
+	}
 )
 
 func ListVar(values *[]string, names []string, usage string) {
@@ -141,7 +155,7 @@ func ValidateAttach(val string) (string, error) {
 }
 
 func ValidateLink(val string) (string, error) {
-	if _, err := parsers.PartParser("name:alias", val); err != nil {
+	if _, _, _, err := parsers.ParseLink(val); err != nil {
 		return val, err
 	}
 	return val, nil
@@ -206,6 +220,14 @@ func ValidateDnsSearch(val string) (string, error) {
 	return validateDomain(val)
 }
 
+// Validates an option of resolvconf, like ndots:2 or rotate
+func ValidateDnsOption(val string) (string, error) {
+	if val = strings.TrimSpace(val); val == "" || strings.ContainsAny(val, " \t") {
+		return "", fmt.Errorf("%q is not a valid DNS option", val)
+	}
+	return val, nil
+}
+
 func validateDomain(val string) (string, error) {
 	if alphaRegexp.FindString(val) == "" {
 		return "", fmt.Errorf("%s is not a valid domain", val)
@@ -235,3 +257,59 @@ func ValidateLabel(val string) (string, error) {
 	}
 	return val, nil
 }
+
+// ValidateLabelKey checks that a label key is not empty and only contains
+// the characters of the reverse DNS notation, e.g. com.example.release-date.
+func ValidateLabelKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("label key cannot be empty")
+	}
+	if !labelKeyRegexp.MatchString(key) {
+		return fmt.Errorf("invalid label key %q: only alphanumerics, '.', '-' and '_' are allowed, starting and ending with an alphanumeric", key)
+	}
+	return nil
+}
+
+// ValidateMetaLabel validates a container or image label given as key[=value].
+func ValidateMetaLabel(val string) (string, error) {
+	key := strings.SplitN(val, "=", 2)[0]
+	if err := ValidateLabelKey(key); err != nil {
+		return "", err
+	}
+	return val, nil
+}
+
+// IsNetworkSysctl returns whether key is a sysctl of the network namespace
+func IsNetworkSysctl(key string) bool {
+	return strings.HasPrefix(key, "net.")
+}
+
+// ValidateSysctl validates a sysctl given as key=value. Only the sysctls of
+// the IPC and network namespaces are allowed, as the others would change
+// the host kernel configuration.
+func ValidateSysctl(val string) (string, error) {
+	arr := strings.SplitN(val, "=", 2)
+	if len(arr) != 2 || arr[0] == "" {
+		return "", fmt.Errorf("bad format for sysctl: %q, expected key=value", val)
+	}
+	key := arr[0]
+	if validIpcSysctls[key] || strings.HasPrefix(key, "fs.mqueue.") || IsNetworkSysctl(key) {
+		return val, nil
+	}
+	return "", fmt.Errorf("sysctl %q is not allowed: only the sysctls of the IPC and network namespaces can be set in a container", key)
+}
+
+// ValidateTmpfs validates a tmpfs mount given as path[:options], where path
+// is absolute and options are the comma separated tmpfs mount options.
+func ValidateTmpfs(val string) (string, error) {
+	arr := strings.SplitN(val, ":", 2)
+	if !path.IsAbs(arr[0]) || path.Clean(arr[0]) == "/" {
+		return "", fmt.Errorf("bad format for tmpfs: %q, the mount point must be an absolute path other than /", val)
+	}
+	if len(arr) == 2 {
+		if err := mount.ValidateTmpfsOptions(arr[1]); err != nil {
+			return "", fmt.Errorf("bad format for tmpfs: %q: %v", val, err)
+		}
+	}
+	return val, nil
+}
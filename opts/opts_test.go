@@ -64,6 +64,19 @@ func TestListOpts(t *testing.T) {
 	}
 }
 
+func TestValidateDnsOption(t *testing.T) {
+	for _, option := range []string{"ndots:2", "rotate", "single-request-reopen", " timeout:1 "} {
+		if ret, err := ValidateDnsOption(option); err != nil || ret != strings.TrimSpace(option) {
+			t.Fatalf("ValidateDnsOption(`%s`) got %s %v", option, ret, err)
+		}
+	}
+	for _, option := range []string{"", " ", "ndots:2 rotate"} {
+		if ret, err := ValidateDnsOption(option); err == nil {
+			t.Fatalf("ValidateDnsOption(`%s`) should have failed, got %s", option, ret)
+		}
+	}
+}
+
 func TestValidateDnsSearch(t *testing.T) {
 	valid := []string{
 		`.`,
@@ -152,3 +165,68 @@ func TestValidateExtraHosts(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateMetaLabel(t *testing.T) {
+	valid := []string{"env=prod", "com.example.team=core", "release-date=2015-03-01", "k1", "a=b=c"}
+	for _, label := range valid {
+		if _, err := ValidateMetaLabel(label); err != nil {
+			t.Fatalf("ValidateMetaLabel(`%s`) got %s", label, err)
+		}
+	}
+
+	invalid := []string{"=value", "", "-env=prod", "com.example.=x", "my label=x", "k$y=v"}
+	for _, label := range invalid {
+		if _, err := ValidateMetaLabel(label); err == nil {
+			t.Fatalf("ValidateMetaLabel(`%s`) succeeded; expected failure on invalid key", label)
+		}
+	}
+}
+
+func TestValidateSysctl(t *testing.T) {
+	valid := []string{
+		"net.ipv4.ip_forward=1",
+		"kernel.shmmax=68719476736",
+		"kernel.sem=250 32000 100 128",
+		"fs.mqueue.msg_max=100",
+	}
+	invalid := []string{
+		"kernel.hostname=foo",
+		"vm.swappiness=10",
+		"net.ipv4.ip_forward",
+		"=1",
+	}
+	for _, sysctl := range valid {
+		if ret, err := ValidateSysctl(sysctl); err != nil || ret != sysctl {
+			t.Fatalf("ValidateSysctl(`%s`) should succeed: %v", sysctl, err)
+		}
+	}
+	for _, sysctl := range invalid {
+		if _, err := ValidateSysctl(sysctl); err == nil {
+			t.Fatalf("ValidateSysctl(`%s`) should have failed", sysctl)
+		}
+	}
+}
+
+func TestValidateTmpfs(t *testing.T) {
+	valid := []string{
+		"/run",
+		"/run:rw,size=64m",
+		"/tmp:exec,mode=1777",
+	}
+	invalid := []string{
+		"run",
+		"/",
+		"/run:size=64x",
+		"/run:bind",
+	}
+	for _, tmpfs := range valid {
+		if ret, err := ValidateTmpfs(tmpfs); err != nil || ret != tmpfs {
+			t.Fatalf("ValidateTmpfs(`%s`) should succeed: %v", tmpfs, err)
+		}
+	}
+	for _, tmpfs := range invalid {
+		if _, err := ValidateTmpfs(tmpfs); err == nil {
+			t.Fatalf("ValidateTmpfs(`%s`) should have failed", tmpfs)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package mount
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultTmpfsOptions are applied to a tmpfs mount before the options asked
+// for, which can override them
+const DefaultTmpfsOptions = "noexec,nosuid,nodev"
+
+var (
+	tmpfsFlagOptions = map[string]struct{}{
+		"ro": {}, "rw": {},
+		"exec": {}, "noexec": {},
+		"suid": {}, "nosuid": {},
+		"dev": {}, "nodev": {},
+		"sync": {}, "async": {}, "dirsync": {},
+		"atime": {}, "noatime": {},
+		"diratime": {}, "nodiratime": {},
+		"relatime": {}, "norelatime": {},
+		"strictatime": {}, "nostrictatime": {},
+		"mand": {}, "nomand": {},
+	}
+	tmpfsDataOptions = map[string]*regexp.Regexp{
+		"size":      regexp.MustCompile(`^[0-9]+[kmgKMG%]?$`),
+		"nr_blocks": regexp.MustCompile(`^[0-9]+[kmgKMG]?$`),
+		"nr_inodes": regexp.MustCompile(`^[0-9]+[kmgKMG]?$`),
+		"mode":      regexp.MustCompile(`^[0-7]{1,4}$`),
+		"uid":       regexp.MustCompile(`^[0-9]+$`),
+		"gid":       regexp.MustCompile(`^[0-9]+$`),
+	}
+)
+
+// ValidateTmpfsOptions checks the comma separated options of a tmpfs mount,
+// like rw,size=64m
+func ValidateTmpfsOptions(options string) error {
+	if options == "" {
+		return nil
+	}
+	for _, o := range strings.Split(options, ",") {
+		if _, ok := tmpfsFlagOptions[o]; ok {
+			continue
+		}
+		kv := strings.SplitN(o, "=", 2)
+		valid, ok := tmpfsDataOptions[kv[0]]
+		if !ok {
+			return fmt.Errorf("unknown tmpfs option %q", o)
+		}
+		if len(kv) != 2 || !valid.MatchString(kv[1]) {
+			return fmt.Errorf("invalid value for tmpfs option %q", o)
+		}
+	}
+	return nil
+}
+
+// TmpfsOptions returns the options of a tmpfs mount with the defaults
+// prepended
+func TmpfsOptions(options string) string {
+	if options == "" {
+		return DefaultTmpfsOptions
+	}
+	return DefaultTmpfsOptions + "," + options
+}
+
+// ParseTmpfsOptions validates the options of a tmpfs mount and returns the
+// mount() flags and data they stand for, the defaults included
+func ParseTmpfsOptions(options string) (int, string, error) {
+	if err := ValidateTmpfsOptions(options); err != nil {
+		return 0, "", err
+	}
+	flags, data := parseOptions(TmpfsOptions(options))
+	return flags, data, nil
+}
@@ -0,0 +1,38 @@
+package mount
+
+import (
+	"testing"
+)
+
+func TestValidateTmpfsOptions(t *testing.T) {
+	for _, options := range []string{"", "rw", "rw,size=64m", "exec,mode=1777", "size=50%,nr_inodes=1k,uid=1000,gid=1000"} {
+		if err := ValidateTmpfsOptions(options); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", options, err)
+		}
+	}
+	for _, options := range []string{"bind", "rw,", "size", "size=64x", "mode=999", "uid=root", "foo=bar"} {
+		if err := ValidateTmpfsOptions(options); err == nil {
+			t.Errorf("Expected %q to be invalid", options)
+		}
+	}
+}
+
+func TestParseTmpfsOptions(t *testing.T) {
+	flags, data, err := ParseTmpfsOptions("exec,size=64m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != "size=64m" {
+		t.Fatalf("Expected size=64m got %s", data)
+	}
+	if expected := NOSUID | NODEV; flags != expected {
+		t.Fatalf("Expected %d got %d", expected, flags)
+	}
+
+	if flags, _, _ := ParseTmpfsOptions(""); flags != NOEXEC|NOSUID|NODEV {
+		t.Fatalf("Expected the default flags %d got %d", NOEXEC|NOSUID|NODEV, flags)
+	}
+	if _, _, err := ParseTmpfsOptions("size=big"); err == nil {
+		t.Fatal("Expected invalid options to be rejected")
+	}
+}
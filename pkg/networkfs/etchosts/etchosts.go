@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"regexp"
 )
 
@@ -65,3 +66,37 @@ func Update(path, IP, hostname string) error {
 	var re = regexp.MustCompile(fmt.Sprintf("(\\S*)(\\t%s)", regexp.QuoteMeta(hostname)))
 	return ioutil.WriteFile(path, re.ReplaceAll(old, []byte(IP+"$2")), 0644)
 }
+
+// Add appends the records to the hosts file at path
+func Add(path string, recs []Record) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, r := range recs {
+		if _, err := r.WriteTo(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the lines of the records from the hosts file at path
+func Delete(path string, recs []Record) error {
+	old, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	deleted := make(map[string]struct{})
+	for _, r := range recs {
+		deleted[fmt.Sprintf("%s\t%s", r.IP, r.Hosts)] = struct{}{}
+	}
+	content := bytes.NewBuffer(nil)
+	for _, line := range bytes.SplitAfter(old, []byte("\n")) {
+		if _, ok := deleted[string(bytes.TrimSuffix(line, []byte("\n")))]; !ok {
+			content.Write(line)
+		}
+	}
+	return ioutil.WriteFile(path, content.Bytes(), 0644)
+}
@@ -132,3 +132,44 @@ func TestUpdate(t *testing.T) {
 		t.Fatalf("Expected to find '%s' got '%s'", expected, content)
 	}
 }
+
+func TestAddDelete(t *testing.T) {
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := Build(file.Name(), "10.11.12.13", "testhostname", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	recs := []Record{
+		{Hosts: "web 0123456789ab", IP: "110.0.0.2"},
+		{Hosts: "web 0123456789ab", IP: "10.0.0.2"},
+	}
+	if err := Add(file.Name(), recs); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "110.0.0.2\tweb 0123456789ab\n10.0.0.2\tweb 0123456789ab\n"; !bytes.HasSuffix(content, []byte(expected)) {
+		t.Fatalf("Expected to find '%s' at the end of '%s'", expected, content)
+	}
+
+	if err := Delete(file.Name(), recs[1:]); err != nil {
+		t.Fatal(err)
+	}
+	content, err = ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "ff02::2\tip6-allrouters\n110.0.0.2\tweb 0123456789ab\n"; !bytes.HasSuffix(content, []byte(expected)) {
+		t.Fatalf("Expected to find '%s' at the end of '%s'", expected, content)
+	}
+	if !bytes.HasPrefix(content, []byte("10.11.12.13\ttesthostname\n")) {
+		t.Fatalf("Expected the other records to be kept, got '%s'", content)
+	}
+}
@@ -30,6 +30,7 @@ var (
 	nsIPv6Regexp      = regexp.MustCompile(`(?m)^nameserver\s+` + ipv6Address + `\s*\n*`)
 	nsRegexp          = regexp.MustCompile(`^\s*nameserver\s*((` + ipv4Address + `)|(` + ipv6Address + `))\s*$`)
 	searchRegexp      = regexp.MustCompile(`^\s*search\s*(([^\s]+\s*)*)$`)
+	optionsRegexp     = regexp.MustCompile(`^\s*options\s*(([^\s]+\s*)*)$`)
 )
 
 var lastModified struct {
@@ -171,7 +172,21 @@ func GetSearchDomains(resolvConf []byte) []string {
 	return domains
 }
 
-func Build(path string, dns, dnsSearch []string) error {
+// GetOptions returns the options (if any) listed in /etc/resolv.conf, from
+// all the options lines
+func GetOptions(resolvConf []byte) []string {
+	options := []string{}
+	for _, line := range getLines(resolvConf, []byte("#")) {
+		match := optionsRegexp.FindSubmatch(line)
+		if match == nil {
+			continue
+		}
+		options = append(options, strings.Fields(string(match[1]))...)
+	}
+	return options
+}
+
+func Build(path string, dns, dnsSearch, dnsOptions []string) error {
 	content := bytes.NewBuffer(nil)
 	for _, dns := range dns {
 		if _, err := content.WriteString("nameserver " + dns + "\n"); err != nil {
@@ -185,6 +200,11 @@ func Build(path string, dns, dnsSearch []string) error {
 			}
 		}
 	}
+	if len(dnsOptions) > 0 {
+		if _, err := content.WriteString("options " + strings.Join(dnsOptions, " ") + "\n"); err != nil {
+			return err
+		}
+	}
 
 	return ioutil.WriteFile(path, content.Bytes(), 0644)
 }
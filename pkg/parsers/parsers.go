@@ -62,6 +62,23 @@ func ParseTCPAddr(addr string, defaultAddr string) (string, error) {
 	return fmt.Sprintf("tcp://%s:%d", host, p), nil
 }
 
+// ParseLink parses a link given as name:alias, optionally followed by the
+// :healthy condition to wait for the container linked to to be healthy
+func ParseLink(val string) (name, alias string, healthy bool, err error) {
+	parts := strings.Split(val, ":")
+	if len(parts) == 3 {
+		if parts[2] != "healthy" {
+			return "", "", false, fmt.Errorf("Invalid link condition %s in %s, only healthy is supported", parts[2], val)
+		}
+		healthy = true
+		parts = parts[:2]
+	}
+	if len(parts) != 2 {
+		return "", "", false, fmt.Errorf("Invalid format to parse.  %s should match template name:alias[:healthy]", val)
+	}
+	return parts[0], parts[1], healthy, nil
+}
+
 // Get a repos name and returns the right reposName + tag|digest
 // The tag can be confusing because of a port in a repository name.
 //     Ex: localhost.localdomain:5000/samalba/hipache:latest
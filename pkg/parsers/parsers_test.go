@@ -92,6 +92,29 @@ func TestParsePortMapping(t *testing.T) {
 	}
 }
 
+func TestParseLink(t *testing.T) {
+	for _, link := range []struct {
+		val, name, alias string
+		healthy          bool
+	}{
+		{"db:db", "db", "db", false},
+		{"mysql:db:healthy", "mysql", "db", true},
+	} {
+		name, alias, healthy, err := ParseLink(link.val)
+		if err != nil {
+			t.Fatalf("Expected %s to be parsed: %v", link.val, err)
+		}
+		if name != link.name || alias != link.alias || healthy != link.healthy {
+			t.Fatalf("Expected %s to be parsed as %s, %s, %t, got %s, %s, %t", link.val, link.name, link.alias, link.healthy, name, alias, healthy)
+		}
+	}
+	for _, val := range []string{"db", "mysql:db:ready", "a:b:healthy:c"} {
+		if _, _, _, err := ParseLink(val); err == nil {
+			t.Fatalf("Expected %s to be rejected", val)
+		}
+	}
+}
+
 func TestParsePortRange(t *testing.T) {
 	if start, end, err := ParsePortRange("8000-8080"); err != nil || start != 8000 || end != 8080 {
 		t.Fatalf("Error: %s or Expecting {start,end} values {8000,8080} but found {%d,%d}.", err, start, end)
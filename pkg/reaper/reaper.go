@@ -0,0 +1,8 @@
+// Package reaper implements the init process run by docker run --init. It
+// starts the command of the container as its child, forwards it the signals
+// it receives and reaps the orphaned processes of the container.
+package reaper
+
+// InitPath is where the init binary is mounted in the container. It is the
+// name under which the init is registered with reexec.
+const InitPath = "/dev/init"
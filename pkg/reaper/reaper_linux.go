@@ -0,0 +1,109 @@
+// +build linux
+
+package reaper
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/docker/docker/pkg/reexec"
+)
+
+// prSetChildSubreaper is PR_SET_CHILD_SUBREAPER, missing from syscall
+const prSetChildSubreaper = 36
+
+func init() {
+	reexec.Register(InitPath, initializer)
+}
+
+func initializer() {
+	if len(os.Args) < 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s COMMAND [ARG...]\n", os.Args[0])
+		os.Exit(1)
+	}
+	exitCode, err := Run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(exitCode)
+}
+
+// Run starts the command given and waits for it to exit, forwarding it every
+// signal received and reaping every other child meanwhile. It returns the exit
+// code of the command, 128 plus the signal number when it was killed. When the
+// command can't be run, the exit code is 127 if it wasn't found and 126
+// otherwise, like a shell does.
+//
+// The caller is made a subreaper, so the orphaned descendants of the command
+// are reaped too when it isn't pid 1.
+func Run(args []string) (int, error) {
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return 127, err
+	}
+
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); errno != 0 {
+		// Before Linux 3.4, only pid 1 reaps orphans
+		if os.Getpid() != 1 {
+			fmt.Fprintf(os.Stderr, "Unable to become a subreaper: %v\n", errno)
+		}
+	}
+
+	// Catch the signals before the command starts, so none is missed
+	sigs := make(chan os.Signal, 32)
+	signal.Notify(sigs)
+	defer signal.Stop(sigs)
+
+	cmd := &exec.Cmd{
+		Path:   path,
+		Args:   args,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	if err := cmd.Start(); err != nil {
+		return 126, err
+	}
+
+	// The command is waited for with the other children, cmd.Wait would
+	// race with the reaping
+	pid := cmd.Process.Pid
+	for sig := range sigs {
+		if sig != syscall.SIGCHLD {
+			syscall.Kill(pid, sig.(syscall.Signal))
+			continue
+		}
+		if exitCode, exited := reap(pid); exited {
+			return exitCode, nil
+		}
+	}
+	panic("unreachable")
+}
+
+// reap waits for every child which exited, without blocking. It returns the
+// exit code of pid if it was one of them.
+func reap(pid int) (exitCode int, exited bool) {
+	for {
+		var ws syscall.WaitStatus
+		p, err := syscall.Wait4(-1, &ws, syscall.WNOHANG, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil || p <= 0 {
+			return exitCode, exited
+		}
+		if p == pid {
+			exitCode, exited = exitStatus(ws), true
+		}
+	}
+}
+
+func exitStatus(ws syscall.WaitStatus) int {
+	if ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return ws.ExitStatus()
+}
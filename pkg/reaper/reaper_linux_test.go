@@ -0,0 +1,49 @@
+// +build linux
+
+package reaper
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestRunExitCode(t *testing.T) {
+	for _, c := range []struct {
+		args     []string
+		exitCode int
+	}{
+		{[]string{"true"}, 0},
+		{[]string{"sh", "-c", "exit 3"}, 3},
+		{[]string{"sh", "-c", "kill -TERM $$"}, 128 + int(syscall.SIGTERM)},
+	} {
+		exitCode, err := Run(c.args)
+		if err != nil {
+			t.Fatalf("%v: %v", c.args, err)
+		}
+		if exitCode != c.exitCode {
+			t.Fatalf("%v: expected the exit code %d, got %d", c.args, c.exitCode, exitCode)
+		}
+	}
+}
+
+func TestRunNotFound(t *testing.T) {
+	exitCode, err := Run([]string{"/nonexistent/command"})
+	if err == nil {
+		t.Fatal("Expected an error for a missing command")
+	}
+	if exitCode != 127 {
+		t.Fatalf("Expected the exit code 127, got %d", exitCode)
+	}
+}
+
+func TestRunReapsOrphans(t *testing.T) {
+	// The sleep is orphaned when the subshell exits, and reparented to the
+	// test which must reap it before the command exits
+	if _, err := Run([]string{"sh", "-c", "(sleep 0.1 &); sleep 0.5"}); err != nil {
+		t.Fatal(err)
+	}
+	var ws syscall.WaitStatus
+	if pid, err := syscall.Wait4(-1, &ws, syscall.WNOHANG, nil); err != syscall.ECHILD {
+		t.Fatalf("Expected every child to be reaped, got pid %d: %v", pid, err)
+	}
+}
@@ -0,0 +1,35 @@
+// Package seccomp installs the syscall filters of containers. The filter is
+// installed by a small init, run in the container as the user of the process
+// once its capabilities are dropped, which then execs the command.
+package seccomp
+
+// InitPath is where the init installing the filter is mounted in the
+// container. It is the name under which the init is registered with reexec.
+const InitPath = "/dev/seccomp-init"
+
+// Action is what a seccomp filter does when a syscall is made.
+type Action int
+
+const (
+	// Kill kills the process making the syscall.
+	Kill Action = iota
+	// Errno fails the syscall with EPERM.
+	Errno
+	// Trap sends SIGSYS to the process making the syscall.
+	Trap
+	// Allow lets the syscall run.
+	Allow
+)
+
+// Filter is a syscall filter: the action of each syscall listed, and the
+// default action of the others.
+type Filter struct {
+	DefaultAction Action     `json:"default_action"`
+	Syscalls      []*Syscall `json:"syscalls"`
+}
+
+// Syscall is the action of a seccomp filter for a syscall, given by name.
+type Syscall struct {
+	Name   string `json:"name"`
+	Action Action `json:"action"`
+}
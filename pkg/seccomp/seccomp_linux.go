@@ -0,0 +1,182 @@
+// +build linux,amd64
+
+package seccomp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/docker/docker/pkg/reexec"
+)
+
+const (
+	// AUDIT_ARCH_X86_64, the architecture reported in the seccomp data
+	auditArch = 0xc000003e
+	// Set in the numbers of the syscalls of the x32 ABI
+	x32SyscallBit = 0x40000000
+
+	prSetSeccomp      = 22
+	prSetNoNewPrivs   = 38
+	seccompModeFilter = 2
+
+	retKill  = 0x00000000
+	retTrap  = 0x00030000
+	retErrno = 0x00050000
+	retAllow = 0x7fff0000
+
+	// Offsets in the seccomp data, struct seccomp_data
+	offsetNr   = 0
+	offsetArch = 4
+
+	bpfLdWAbs = syscall.BPF_LD | syscall.BPF_W | syscall.BPF_ABS
+	bpfJeqK   = syscall.BPF_JMP | syscall.BPF_JEQ | syscall.BPF_K
+	bpfJgeK   = syscall.BPF_JMP | syscall.BPF_JGE | syscall.BPF_K
+	bpfRetK   = syscall.BPF_RET | syscall.BPF_K
+)
+
+func init() {
+	reexec.Register(InitPath, initializer)
+}
+
+// initializer installs the filter given as JSON in its first argument, and
+// execs the command given by the others. Everything exec needs is prepared
+// before the filter is installed, so that it's the only syscall left to be
+// allowed by the filter.
+func initializer() {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: %s FILTER COMMAND [ARG...]\n", os.Args[0])
+		os.Exit(1)
+	}
+	var filter Filter
+	if err := json.Unmarshal([]byte(os.Args[1]), &filter); err != nil {
+		fatal(fmt.Errorf("invalid seccomp filter: %v", err))
+	}
+	path, err := exec.LookPath(os.Args[2])
+	if err != nil {
+		fatal(err)
+	}
+	argv0, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		fatal(err)
+	}
+	argv, err := syscall.SlicePtrFromStrings(os.Args[2:])
+	if err != nil {
+		fatal(err)
+	}
+	envv, err := syscall.SlicePtrFromStrings(os.Environ())
+	if err != nil {
+		fatal(err)
+	}
+
+	// The filter is installed in the calling thread only, the one making
+	// the exec
+	runtime.LockOSThread()
+	if err := Install(&filter); err != nil {
+		fatal(err)
+	}
+	_, _, e := syscall.RawSyscall(syscall.SYS_EXECVE,
+		uintptr(unsafe.Pointer(argv0)),
+		uintptr(unsafe.Pointer(&argv[0])),
+		uintptr(unsafe.Pointer(&envv[0])))
+	fatal(fmt.Errorf("exec %s: %v", path, e))
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// IsSupported returns whether seccomp filters can be installed on this
+// architecture.
+func IsSupported() bool {
+	return true
+}
+
+// SyscallNumber returns the number of a syscall, given by name.
+func SyscallNumber(name string) (uint32, bool) {
+	nr, ok := syscallNumbers[name]
+	return nr, ok
+}
+
+func actionValue(action Action) (uint32, error) {
+	switch action {
+	case Kill:
+		return retKill, nil
+	case Errno:
+		return retErrno | uint32(syscall.EPERM), nil
+	case Trap:
+		return retTrap, nil
+	case Allow:
+		return retAllow, nil
+	}
+	return 0, fmt.Errorf("unknown seccomp action %d", action)
+}
+
+func stmt(code uint16, k uint32) syscall.SockFilter {
+	return syscall.SockFilter{Code: code, K: k}
+}
+
+func jump(code uint16, k uint32, jt, jf uint8) syscall.SockFilter {
+	return syscall.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
+
+// compile compiles a seccomp filter to a BPF program. The syscalls of another
+// architecture kill the process, and those of the x32 ABI fail, so that the
+// filter can't be bypassed with them.
+func compile(filter *Filter) ([]syscall.SockFilter, error) {
+	defaultAction, err := actionValue(filter.DefaultAction)
+	if err != nil {
+		return nil, err
+	}
+	prog := []syscall.SockFilter{
+		stmt(bpfLdWAbs, offsetArch),
+		jump(bpfJeqK, auditArch, 1, 0),
+		stmt(bpfRetK, retKill),
+		stmt(bpfLdWAbs, offsetNr),
+		jump(bpfJgeK, x32SyscallBit, 0, 1),
+		stmt(bpfRetK, retErrno|uint32(syscall.EPERM)),
+	}
+	for _, s := range filter.Syscalls {
+		nr, ok := SyscallNumber(s.Name)
+		if !ok {
+			return nil, fmt.Errorf("unknown syscall %q", s.Name)
+		}
+		action, err := actionValue(s.Action)
+		if err != nil {
+			return nil, err
+		}
+		prog = append(prog, jump(bpfJeqK, nr, 0, 1), stmt(bpfRetK, action))
+	}
+	prog = append(prog, stmt(bpfRetK, defaultAction))
+	return prog, nil
+}
+
+// Install installs a seccomp filter in the calling thread, which keeps it
+// across execve. The no_new_privs flag is set first, so that no capability is
+// needed to install it and it can't be escaped with a setuid binary; the
+// thread can't gain privileges with execve anymore.
+func Install(filter *Filter) error {
+	if filter == nil {
+		return nil
+	}
+	prog, err := compile(filter)
+	if err != nil {
+		return err
+	}
+	if _, _, e := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); e != 0 {
+		return fmt.Errorf("setting no_new_privs: %v", e)
+	}
+	fprog := syscall.SockFprog{
+		Len:    uint16(len(prog)),
+		Filter: &prog[0],
+	}
+	if _, _, e := syscall.RawSyscall(syscall.SYS_PRCTL, prSetSeccomp, seccompModeFilter, uintptr(unsafe.Pointer(&fprog))); e != 0 {
+		return fmt.Errorf("installing the seccomp filter: %v", e)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// +build linux,amd64
+
+package seccomp
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	prog, err := compile(&Filter{
+		DefaultAction: Allow,
+		Syscalls:      []*Syscall{{Name: "mkdir", Action: Errno}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The checks of the architecture and of the x32 ABI, a jump and a
+	// return for mkdir, and the default return
+	if len(prog) != 9 {
+		t.Fatalf("Expected a program of 9 instructions, got %d", len(prog))
+	}
+	if s := prog[6]; s.K != syscall.SYS_MKDIR {
+		t.Fatalf("Expected a check of mkdir, got %+v", s)
+	}
+	if s := prog[7]; s.K != retErrno|uint32(syscall.EPERM) {
+		t.Fatalf("Expected mkdir to fail with EPERM, got %+v", s)
+	}
+	if s := prog[8]; s.K != retAllow {
+		t.Fatalf("Expected the other syscalls to be allowed, got %+v", s)
+	}
+
+	for _, filter := range []*Filter{
+		{DefaultAction: Allow, Syscalls: []*Syscall{{Name: "nosuchcall", Action: Errno}}},
+		{DefaultAction: Allow, Syscalls: []*Syscall{{Name: "mkdir", Action: Action(42)}}},
+		{DefaultAction: Action(42)},
+	} {
+		if _, err := compile(filter); err == nil {
+			t.Fatalf("Expected an error compiling %+v", filter)
+		}
+	}
+}
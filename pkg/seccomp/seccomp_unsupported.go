@@ -0,0 +1,24 @@
+// +build !linux !amd64
+
+package seccomp
+
+import "fmt"
+
+// IsSupported returns whether seccomp filters can be installed on this
+// architecture.
+func IsSupported() bool {
+	return false
+}
+
+// SyscallNumber returns the number of a syscall, given by name.
+func SyscallNumber(name string) (uint32, bool) {
+	return 0, false
+}
+
+// Install installs a seccomp filter in the calling thread.
+func Install(filter *Filter) error {
+	if filter != nil {
+		return fmt.Errorf("seccomp filters are not supported on this platform")
+	}
+	return nil
+}
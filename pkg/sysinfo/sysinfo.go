@@ -12,6 +12,9 @@ import (
 type SysInfo struct {
 	MemoryLimit            bool
 	SwapLimit              bool
+	PidsLimit              bool
+	BlkioWeight            bool
+	BlkioReadBpsDevice     bool
 	IPv4ForwardingDisabled bool
 	AppArmor               bool
 }
@@ -37,6 +40,26 @@ func New(quiet bool) *SysInfo {
 		}
 	}
 
+	if cgroupPidsMountpoint, err := cgroups.FindCgroupMountpoint("pids"); err != nil {
+		if !quiet {
+			log.Warnf("Your kernel does not support cgroup pids limit.")
+		}
+	} else {
+		_, err = ioutil.ReadFile(path.Join(cgroupPidsMountpoint, "pids.max"))
+		sysInfo.PidsLimit = err == nil
+	}
+
+	if cgroupBlkioMountpoint, err := cgroups.FindCgroupMountpoint("blkio"); err != nil {
+		if !quiet {
+			log.Warnf("Your kernel does not support cgroup blkio.")
+		}
+	} else {
+		_, err1 := ioutil.ReadFile(path.Join(cgroupBlkioMountpoint, "blkio.weight"))
+		sysInfo.BlkioWeight = err1 == nil
+		_, err2 := ioutil.ReadFile(path.Join(cgroupBlkioMountpoint, "blkio.throttle.read_bps_device"))
+		sysInfo.BlkioReadBpsDevice = err2 == nil
+	}
+
 	// Check if AppArmor seems to be enabled on this system.
 	if _, err := os.Stat("/sys/kernel/security/apparmor"); os.IsNotExist(err) {
 		sysInfo.AppArmor = false
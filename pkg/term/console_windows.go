@@ -17,9 +17,16 @@ const (
 	ENABLE_PROCESSED_INPUT = 0x0001
 	ENABLE_QUICK_EDIT_MODE = 0x0040
 	ENABLE_WINDOW_INPUT    = 0x0008
+	// Makes the console send VT sequences for the keys which have one, as
+	// a terminal would, supported since Windows 10
+	ENABLE_VIRTUAL_TERMINAL_INPUT = 0x0200
 	// If parameter is a screen buffer handle, additional values
 	ENABLE_PROCESSED_OUTPUT   = 0x0001
 	ENABLE_WRAP_AT_EOL_OUTPUT = 0x0002
+	// Makes the console interpret the VT sequences written to it, supported
+	// since Windows 10
+	ENABLE_VIRTUAL_TERMINAL_PROCESSING = 0x0004
+	DISABLE_NEWLINE_AUTO_RETURN        = 0x0008
 )
 
 var kernel32DLL = syscall.NewLazyDLL("kernel32.dll")
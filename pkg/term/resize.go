@@ -0,0 +1,86 @@
+package term
+
+import (
+	"os"
+	gosignal "os/signal"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/pkg/signal"
+)
+
+// resizeDelay is how long a ResizeListener waits after a resize before
+// reporting the new size. Dragging the corner of a window sends a burst of
+// SIGWINCH, which is reported once.
+const resizeDelay = 100 * time.Millisecond
+
+// ResizeListener calls a function with the new size of a terminal whenever
+// it is resized, until it is closed.
+type ResizeListener struct {
+	fd       uintptr
+	onResize func(*Winsize)
+	getSize  func(uintptr) (*Winsize, error)
+	delay    time.Duration
+
+	signals chan os.Signal
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewResizeListener starts listening for SIGWINCH and calls onResize with
+// the size of the terminal fd after it was resized. Close must be called to
+// stop listening.
+func NewResizeListener(fd uintptr, onResize func(*Winsize)) *ResizeListener {
+	l := newResizeListener(fd, onResize, GetWinsize, resizeDelay)
+	gosignal.Notify(l.signals, signal.SIGWINCH)
+	return l
+}
+
+func newResizeListener(fd uintptr, onResize func(*Winsize), getSize func(uintptr) (*Winsize, error), delay time.Duration) *ResizeListener {
+	l := &ResizeListener{
+		fd:       fd,
+		onResize: onResize,
+		getSize:  getSize,
+		delay:    delay,
+		signals:  make(chan os.Signal, 1),
+		done:     make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+func (l *ResizeListener) run() {
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-l.signals:
+			// the resizes until the timer fires are reported at once
+			if timer == nil {
+				timer = time.After(l.delay)
+			}
+		case <-timer:
+			timer = nil
+			ws, err := l.getSize(l.fd)
+			if err != nil || ws == nil {
+				continue
+			}
+			select {
+			case <-l.done:
+				return
+			default:
+				l.onResize(ws)
+			}
+		}
+	}
+}
+
+// Close stops listening for resizes. It is safe to call it more than once.
+func (l *ResizeListener) Close() error {
+	l.once.Do(func() {
+		gosignal.Stop(l.signals)
+		close(l.done)
+	})
+	return nil
+}
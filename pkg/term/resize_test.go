@@ -0,0 +1,68 @@
+package term
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/signal"
+)
+
+func TestResizeListenerCoalescesResizes(t *testing.T) {
+	var height uint16
+	getSize := func(fd uintptr) (*Winsize, error) {
+		return &Winsize{Height: height, Width: 80}, nil
+	}
+	sizes := make(chan Winsize, 10)
+	l := newResizeListener(0, func(ws *Winsize) { sizes <- *ws }, getSize, 50*time.Millisecond)
+	defer l.Close()
+
+	// a burst of resizes is reported once, with the last size
+	height = 10
+	for i := 0; i < 5; i++ {
+		l.signals <- signal.SIGWINCH
+	}
+	select {
+	case ws := <-sizes:
+		if ws.Height != 10 || ws.Width != 80 {
+			t.Fatalf("Expected a size of 10x80, got %dx%d", ws.Height, ws.Width)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the resize")
+	}
+	select {
+	case ws := <-sizes:
+		t.Fatalf("Expected a single resize, got another one to %dx%d", ws.Height, ws.Width)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// a later resize is reported again
+	height = 20
+	l.signals <- signal.SIGWINCH
+	select {
+	case ws := <-sizes:
+		if ws.Height != 20 {
+			t.Fatalf("Expected a height of 20, got %d", ws.Height)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the second resize")
+	}
+}
+
+func TestResizeListenerClose(t *testing.T) {
+	called := make(chan struct{}, 1)
+	getSize := func(fd uintptr) (*Winsize, error) {
+		return &Winsize{Height: 10, Width: 80}, nil
+	}
+	l := newResizeListener(0, func(ws *Winsize) { called <- struct{}{} }, getSize, 50*time.Millisecond)
+
+	l.signals <- signal.SIGWINCH
+	l.Close()
+	// closing twice is harmless
+	l.Close()
+
+	select {
+	case <-called:
+		t.Fatal("Resize reported after the listener was closed")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,19 @@
+package term
+
+// RestoreOnPanic restores the terminal of state if the calling function
+// panics, and then panics again. It must be deferred right after changing
+// the terminal, so that a panic can't leave it in raw mode:
+//
+//	state, err := term.MakeRaw(fd)
+//	if err != nil {
+//		return err
+//	}
+//	defer term.RestoreOnPanic(state)
+func RestoreOnPanic(state *State) {
+	if r := recover(); r != nil {
+		if state != nil {
+			state.Restore()
+		}
+		panic(r)
+	}
+}
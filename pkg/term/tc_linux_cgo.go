@@ -27,6 +27,7 @@ func MakeRaw(fd uintptr) (*State, error) {
 	if err := tcset(fd, &newState); err != 0 {
 		return nil, err
 	}
+	oldState.fd = fd
 	return &oldState, nil
 }
 
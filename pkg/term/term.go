@@ -6,6 +6,7 @@ import (
 	"errors"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"unsafe"
 )
@@ -14,8 +15,13 @@ var (
 	ErrInvalidState = errors.New("Invalid terminal state")
 )
 
+// State is a saved state of a terminal, which can be restored once
 type State struct {
 	termios Termios
+	fd      uintptr
+
+	mu       sync.Mutex
+	restored bool
 }
 
 type Winsize struct {
@@ -51,22 +57,38 @@ func IsTerminal(fd uintptr) bool {
 }
 
 // Restore restores the terminal connected to the given file descriptor to a
-// previous state.
+// previous state. Restoring the terminal the state was saved from again is a
+// no-op, until the terminal is changed with the state again.
 func RestoreTerminal(fd uintptr, state *State) error {
 	if state == nil {
 		return ErrInvalidState
 	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.restored && fd == state.fd {
+		return nil
+	}
 	if err := tcset(fd, &state.termios); err != 0 {
 		return err
 	}
+	if fd == state.fd {
+		state.restored = true
+	}
 	return nil
 }
 
+// Restore restores the terminal the state was saved from. It is safe to call
+// it more than once.
+func (state *State) Restore() error {
+	return RestoreTerminal(state.fd, state)
+}
+
 func SaveState(fd uintptr) (*State, error) {
 	var oldState State
 	if err := tcget(fd, &oldState.termios); err != 0 {
 		return nil, err
 	}
+	oldState.fd = fd
 
 	return &oldState, nil
 }
@@ -78,10 +100,20 @@ func DisableEcho(fd uintptr, state *State) error {
 	if err := tcset(fd, &newState); err != 0 {
 		return err
 	}
+	state.mu.Lock()
+	state.restored = false
+	state.mu.Unlock()
 	handleInterrupt(fd, state)
 	return nil
 }
 
+// SetRawTerminalOutput prepares the terminal fd to display the output of a
+// program running with a tty. Unix terminals interpret VT sequences already,
+// so it returns a nil state: there is nothing to restore.
+func SetRawTerminalOutput(fd uintptr) (*State, error) {
+	return nil, nil
+}
+
 func SetRawTerminal(fd uintptr) (*State, error) {
 	oldState, err := MakeRaw(fd)
 	if err != nil {
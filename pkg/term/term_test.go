@@ -0,0 +1,103 @@
+// +build linux
+
+package term
+
+import (
+	"testing"
+
+	"github.com/kr/pty"
+)
+
+func TestRestoreTwice(t *testing.T) {
+	master, slave, err := pty.Open()
+	if err != nil {
+		t.Skipf("No pty available: %v", err)
+	}
+	defer master.Close()
+	defer slave.Close()
+	fd := slave.Fd()
+
+	var original Termios
+	if err := tcget(fd, &original); err != 0 {
+		t.Fatal(err)
+	}
+
+	state, err := MakeRaw(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw Termios
+	if err := tcget(fd, &raw); err != 0 {
+		t.Fatal(err)
+	}
+	if raw == original {
+		t.Fatal("Expected MakeRaw to change the terminal")
+	}
+
+	if err := state.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreTerminal(fd, state); err != nil {
+		t.Fatalf("Restoring twice should not fail: %v", err)
+	}
+
+	var restored Termios
+	if err := tcget(fd, &restored); err != 0 {
+		t.Fatal(err)
+	}
+	if restored != original {
+		t.Fatalf("Expected the terminal to be restored to %+v, got %+v", original, restored)
+	}
+
+	// the second restore is a no-op: it doesn't undo later changes
+	if _, err := MakeRaw(fd); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	var current Termios
+	if err := tcget(fd, &current); err != 0 {
+		t.Fatal(err)
+	}
+	if current != raw {
+		t.Fatal("Expected restoring an already restored state to leave the terminal alone")
+	}
+}
+
+func TestRestoreOnPanic(t *testing.T) {
+	master, slave, err := pty.Open()
+	if err != nil {
+		t.Skipf("No pty available: %v", err)
+	}
+	defer master.Close()
+	defer slave.Close()
+	fd := slave.Fd()
+
+	var original Termios
+	if err := tcget(fd, &original); err != 0 {
+		t.Fatal(err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("Expected the panic to be propagated, got %v", r)
+			}
+		}()
+		state, err := MakeRaw(fd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer RestoreOnPanic(state)
+		panic("boom")
+	}()
+
+	var restored Termios
+	if err := tcget(fd, &restored); err != 0 {
+		t.Fatal(err)
+	}
+	if restored != original {
+		t.Fatal("Expected the terminal to be restored after the panic")
+	}
+}
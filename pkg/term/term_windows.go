@@ -2,8 +2,15 @@
 
 package term
 
+import "sync"
+
+// State is a saved state of a console, which can be restored once
 type State struct {
 	mode uint32
+	fd   uintptr
+
+	mu       sync.Mutex
+	restored bool
 }
 
 type Winsize struct {
@@ -20,8 +27,8 @@ func GetWinsize(fd uintptr) (*Winsize, error) {
 	if err != nil {
 		return nil, err
 	}
-	ws.Height = uint16(info.srWindow.Right - info.srWindow.Left + 1)
-	ws.Width = uint16(info.srWindow.Bottom - info.srWindow.Top + 1)
+	ws.Height = uint16(info.srWindow.Bottom - info.srWindow.Top + 1)
+	ws.Width = uint16(info.srWindow.Right - info.srWindow.Left + 1)
 
 	ws.x = 0 // todo azlinux -- this is the pixel size of the Window, and not currently used by any caller
 	ws.y = 0
@@ -40,9 +47,27 @@ func IsTerminal(fd uintptr) bool {
 }
 
 // Restore restores the terminal connected to the given file descriptor to a
-// previous state.
+// previous state. Restoring the console the state was saved from again is a
+// no-op, until the console is changed with the state again.
 func RestoreTerminal(fd uintptr, state *State) error {
-	return SetConsoleMode(fd, state.mode)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.restored && fd == state.fd {
+		return nil
+	}
+	if err := SetConsoleMode(fd, state.mode); err != nil {
+		return err
+	}
+	if fd == state.fd {
+		state.restored = true
+	}
+	return nil
+}
+
+// Restore restores the console the state was saved from. It is safe to call
+// it more than once.
+func (state *State) Restore() error {
+	return RestoreTerminal(state.fd, state)
 }
 
 func SaveState(fd uintptr) (*State, error) {
@@ -50,14 +75,18 @@ func SaveState(fd uintptr) (*State, error) {
 	if e != nil {
 		return nil, e
 	}
-	return &State{mode}, nil
+	return &State{mode: mode, fd: fd}, nil
 }
 
 // see http://msdn.microsoft.com/en-us/library/windows/desktop/ms683462(v=vs.85).aspx for these flag settings
 func DisableEcho(fd uintptr, state *State) error {
-	state.mode &^= (ENABLE_ECHO_INPUT)
-	state.mode |= (ENABLE_PROCESSED_INPUT | ENABLE_LINE_INPUT)
-	return SetConsoleMode(fd, state.mode)
+	mode := state.mode
+	mode &^= (ENABLE_ECHO_INPUT)
+	mode |= (ENABLE_PROCESSED_INPUT | ENABLE_LINE_INPUT)
+	state.mu.Lock()
+	state.restored = false
+	state.mu.Unlock()
+	return SetConsoleMode(fd, mode)
 }
 
 func SetRawTerminal(fd uintptr) (*State, error) {
@@ -73,17 +102,37 @@ func SetRawTerminal(fd uintptr) (*State, error) {
 // mode and returns the previous state of the terminal so that it can be
 // restored.
 func MakeRaw(fd uintptr) (*State, error) {
-	var state *State
 	state, err := SaveState(fd)
 	if err != nil {
 		return nil, err
 	}
 
 	// see http://msdn.microsoft.com/en-us/library/windows/desktop/ms683462(v=vs.85).aspx for these flag settings
-	state.mode &^= (ENABLE_ECHO_INPUT | ENABLE_PROCESSED_INPUT | ENABLE_LINE_INPUT)
-	err = SetConsoleMode(fd, state.mode)
+	mode := state.mode
+	mode &^= (ENABLE_ECHO_INPUT | ENABLE_PROCESSED_INPUT | ENABLE_LINE_INPUT)
+	// keys are sent as VT sequences when the console supports it, and as
+	// plain characters by older consoles
+	if err := SetConsoleMode(fd, mode|ENABLE_VIRTUAL_TERMINAL_INPUT); err != nil {
+		if err := SetConsoleMode(fd, mode); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// SetRawTerminalOutput makes the console screen buffer fd interpret the VT
+// sequences written to it, such as the colors and cursor movements of a
+// program running in a container with a tty. It returns the previous state
+// of the console so that it can be restored, or an error if the console
+// doesn't support VT sequences.
+func SetRawTerminalOutput(fd uintptr) (*State, error) {
+	state, err := SaveState(fd)
 	if err != nil {
 		return nil, err
 	}
+	mode := state.mode | ENABLE_PROCESSED_OUTPUT | ENABLE_VIRTUAL_TERMINAL_PROCESSING | DISABLE_NEWLINE_AUTO_RETURN
+	if err := SetConsoleMode(fd, mode); err != nil {
+		return nil, err
+	}
 	return state, nil
 }
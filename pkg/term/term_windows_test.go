@@ -0,0 +1,84 @@
+// +build windows
+
+package term
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMakeRawRestoreConsole(t *testing.T) {
+	conin, err := os.OpenFile("CONIN$", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("No console available: %v", err)
+	}
+	defer conin.Close()
+	fd := conin.Fd()
+
+	original, err := GetConsoleMode(fd)
+	if err != nil {
+		t.Skipf("No console available: %v", err)
+	}
+
+	state, err := MakeRaw(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.mode != original {
+		t.Fatalf("Expected the state to hold the saved mode %#x, got %#x", original, state.mode)
+	}
+	raw, err := GetConsoleMode(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw&(ENABLE_ECHO_INPUT|ENABLE_LINE_INPUT|ENABLE_PROCESSED_INPUT) != 0 {
+		t.Fatalf("Expected echo, line and processed input to be disabled, got mode %#x", raw)
+	}
+
+	if err := state.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreTerminal(fd, state); err != nil {
+		t.Fatalf("Restoring twice should not fail: %v", err)
+	}
+	restored, err := GetConsoleMode(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != original {
+		t.Fatalf("Expected the console mode to be restored to %#x, got %#x", original, restored)
+	}
+}
+
+func TestSetRawTerminalOutputRestore(t *testing.T) {
+	conout, err := os.OpenFile("CONOUT$", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("No console available: %v", err)
+	}
+	defer conout.Close()
+	fd := conout.Fd()
+
+	original, err := GetConsoleMode(fd)
+	if err != nil {
+		t.Skipf("No console available: %v", err)
+	}
+
+	state, err := SetRawTerminalOutput(fd)
+	if err != nil {
+		t.Skipf("The console doesn't support VT sequences: %v", err)
+	}
+	mode, err := GetConsoleMode(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode&ENABLE_VIRTUAL_TERMINAL_PROCESSING == 0 {
+		t.Fatalf("Expected VT processing to be enabled, got mode %#x", mode)
+	}
+
+	if err := state.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := GetConsoleMode(fd); err != nil || restored != original {
+		t.Fatalf("Expected the console mode to be restored to %#x, got %#x (%v)", original, restored, err)
+	}
+}
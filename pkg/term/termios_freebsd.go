@@ -61,5 +61,6 @@ func MakeRaw(fd uintptr) (*State, error) {
 		return nil, err
 	}
 
+	oldState.fd = fd
 	return &oldState, nil
 }
@@ -42,5 +42,6 @@ func MakeRaw(fd uintptr) (*State, error) {
 	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, setTermios, uintptr(unsafe.Pointer(&newState))); err != 0 {
 		return nil, err
 	}
+	oldState.fd = fd
 	return &oldState, nil
 }
@@ -84,6 +84,9 @@ func Parse(val string) (*Ulimit, error) {
 	hard := soft // in case no hard was set
 	if len(limitVals) == 2 {
 		hard, err = strconv.ParseInt(limitVals[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
 	}
 	if soft > hard {
 		return nil, fmt.Errorf("ulimit soft limit must be less than or equal to hard limit: %d > %d", soft, hard)
@@ -102,5 +105,5 @@ func (u *Ulimit) GetRlimit() (*Rlimit, error) {
 }
 
 func (u *Ulimit) String() string {
-	return fmt.Sprintf("%s=%s:%s", u.Name, u.Soft, u.Hard)
+	return fmt.Sprintf("%s=%d:%d", u.Name, u.Soft, u.Hard)
 }
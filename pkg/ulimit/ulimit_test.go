@@ -1,6 +1,9 @@
 package ulimit
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func TestParseInvalidLimitType(t *testing.T) {
 	if _, err := Parse("notarealtype=1024:1024"); err == nil {
@@ -39,3 +42,33 @@ func TestParseInvalidValueType(t *testing.T) {
 		t.Fatal("expected error on bad value type")
 	}
 }
+
+func TestParse(t *testing.T) {
+	for val, expected := range map[string]Ulimit{
+		"nofile=1024:2048": {Name: "nofile", Soft: 1024, Hard: 2048},
+		"nproc=512":        {Name: "nproc", Soft: 512, Hard: 512},
+	} {
+		u, err := Parse(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *u != expected {
+			t.Fatalf("Expected %v for %s, got %v", expected, val, *u)
+		}
+		if u.String() != fmt.Sprintf("%s=%d:%d", expected.Name, expected.Soft, expected.Hard) {
+			t.Fatalf("Unexpected string %q for %s", u.String(), val)
+		}
+	}
+}
+
+func TestParseInvalidHardLimit(t *testing.T) {
+	if _, err := Parse("nofile=1024:2048"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("nofile=0:asdf"); err == nil {
+		t.Fatal("expected error on bad hard limit")
+	}
+	if _, err := Parse("nofile=2048:1024"); err == nil {
+		t.Fatal("expected error on hard limit less than soft limit")
+	}
+}
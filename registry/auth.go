@@ -21,6 +21,10 @@ import (
 const (
 	// Where we store the config file
 	CONFIGFILE = ".dockercfg"
+
+	// credsStoreKey is the key of the config file naming the credential
+	// helper which keeps the passwords, instead of the config file itself
+	credsStoreKey = "credsStore"
 )
 
 var (
@@ -36,8 +40,16 @@ type AuthConfig struct {
 }
 
 type ConfigFile struct {
-	Configs  map[string]AuthConfig `json:"configs,omitempty"`
-	rootPath string
+	Configs map[string]AuthConfig `json:"configs,omitempty"`
+	// CredsStore is the name of the credential helper keeping the passwords,
+	// if any
+	CredsStore string `json:"-"`
+	rootPath   string
+	// stored are the servers whose credentials are in the credential store
+	stored map[string]struct{}
+	// pending are the stored servers whose credentials weren't read from
+	// the credential store yet
+	pending map[string]struct{}
 }
 
 type RequestAuthorization struct {
@@ -173,7 +185,8 @@ func LoadConfig(rootPath string) (*ConfigFile, error) {
 		return &configFile, err
 	}
 
-	if err := json.Unmarshal(b, &configFile.Configs); err != nil {
+	var configs map[string]json.RawMessage
+	if err := json.Unmarshal(b, &configs); err != nil {
 		arr := strings.Split(string(b), "\n")
 		if len(arr) < 2 {
 			return &configFile, fmt.Errorf("The Auth config file is empty")
@@ -196,11 +209,28 @@ func LoadConfig(rootPath string) (*ConfigFile, error) {
 		// *TODO: Switch to using IndexServerName() instead?
 		configFile.Configs[IndexServerAddress()] = authConfig
 	} else {
-		for k, authConfig := range configFile.Configs {
-			authConfig.Username, authConfig.Password, err = decodeAuth(authConfig.Auth)
-			if err != nil {
+		if raw, ok := configs[credsStoreKey]; ok {
+			if err := json.Unmarshal(raw, &configFile.CredsStore); err != nil {
+				return &configFile, fmt.Errorf("Invalid %s in the Auth config file", credsStoreKey)
+			}
+			delete(configs, credsStoreKey)
+		}
+		helper := configFile.credentialHelper()
+		for k, raw := range configs {
+			authConfig := AuthConfig{}
+			if err := json.Unmarshal(raw, &authConfig); err != nil {
 				return &configFile, err
 			}
+			if helper != nil && authConfig.Auth == "" {
+				// Read from the store once needed, see GetAuthConfig
+				configFile.stored[k] = struct{}{}
+				configFile.pending[k] = struct{}{}
+			} else {
+				authConfig.Username, authConfig.Password, err = decodeAuth(authConfig.Auth)
+				if err != nil {
+					return &configFile, err
+				}
+			}
 			authConfig.Auth = ""
 			authConfig.ServerAddress = k
 			configFile.Configs[k] = authConfig
@@ -209,24 +239,101 @@ func LoadConfig(rootPath string) (*ConfigFile, error) {
 	return &configFile, nil
 }
 
+// credentialHelper returns the helper of the credential store of the config
+// file, or nil when the passwords are kept in the file
+func (configFile *ConfigFile) credentialHelper() *credentialHelper {
+	if configFile.CredsStore == "" {
+		return nil
+	}
+	if configFile.stored == nil {
+		configFile.stored = make(map[string]struct{})
+	}
+	if configFile.pending == nil {
+		configFile.pending = make(map[string]struct{})
+	}
+	return &credentialHelper{name: configFile.CredsStore}
+}
+
+// GetAuthConfig returns the auth config of a server, reading its credentials
+// from the credential store the first time. A store which fails, like a
+// helper which isn't installed, is logged and the server is returned without
+// credentials.
+func (configFile *ConfigFile) GetAuthConfig(serverAddress string) (AuthConfig, bool) {
+	if _, ok := configFile.pending[serverAddress]; ok {
+		delete(configFile.pending, serverAddress)
+		username, secret, found, err := configFile.credentialHelper().get(serverAddress)
+		if err != nil {
+			log.Warnf("Could not read the credentials of %s: %v", serverAddress, err)
+		} else if !found {
+			delete(configFile.Configs, serverAddress)
+			delete(configFile.stored, serverAddress)
+		} else {
+			authConfig := configFile.Configs[serverAddress]
+			authConfig.Username, authConfig.Password = username, secret
+			configFile.Configs[serverAddress] = authConfig
+		}
+	}
+	authConfig, ok := configFile.Configs[serverAddress]
+	return authConfig, ok
+}
+
+// LoadAllCredentials reads the credentials of the servers which weren't read
+// from the credential store yet, for the config file to be sent as a whole
+func (configFile *ConfigFile) LoadAllCredentials() {
+	for k := range configFile.pending {
+		configFile.GetAuthConfig(k)
+	}
+}
+
 // save the auth config
+//
+// With a credential store, the passwords are given to its helper and only the
+// emails are kept in the file. The credentials of the servers removed from the
+// config are erased from the store.
 func SaveConfig(configFile *ConfigFile) error {
 	confFile := path.Join(configFile.rootPath, CONFIGFILE)
-	if len(configFile.Configs) == 0 {
+	helper := configFile.credentialHelper()
+	if helper != nil {
+		for k := range configFile.stored {
+			if _, ok := configFile.Configs[k]; ok {
+				continue
+			}
+			if err := helper.erase(k); err != nil {
+				return err
+			}
+			delete(configFile.stored, k)
+		}
+	}
+	if len(configFile.Configs) == 0 && helper == nil {
 		os.Remove(confFile)
 		return nil
 	}
 
-	configs := make(map[string]AuthConfig, len(configFile.Configs))
+	configs := make(map[string]interface{}, len(configFile.Configs)+1)
 	for k, authConfig := range configFile.Configs {
 		authCopy := authConfig
 
-		authCopy.Auth = encodeAuth(&authCopy)
+		if helper != nil {
+			// The credentials which weren't read are still in the store
+			_, stored := configFile.stored[k]
+			if !stored || authConfig.Username != "" || authConfig.Password != "" {
+				if err := helper.store(k, authConfig); err != nil {
+					return err
+				}
+				configFile.stored[k] = struct{}{}
+			}
+			authCopy.Auth = ""
+		} else {
+			authCopy.Auth = encodeAuth(&authCopy)
+		}
 		authCopy.Username = ""
 		authCopy.Password = ""
 		authCopy.ServerAddress = ""
 		configs[k] = authCopy
 	}
+	if helper != nil {
+		configs[credsStoreKey] = configFile.CredsStore
+	}
 
 	b, err := json.MarshalIndent(configs, "", "\t")
 	if err != nil {
@@ -469,7 +576,7 @@ func tryV2TokenAuthLogin(authConfig *AuthConfig, params map[string]string, regis
 func (config *ConfigFile) ResolveAuthConfig(index *IndexInfo) AuthConfig {
 	configKey := index.GetAuthConfigKey()
 	// First try the happy case
-	if c, found := config.Configs[configKey]; found || index.Official {
+	if c, found := config.GetAuthConfig(configKey); found || index.Official {
 		return c
 	}
 
@@ -488,9 +595,10 @@ func (config *ConfigFile) ResolveAuthConfig(index *IndexInfo) AuthConfig {
 
 	// Maybe they have a legacy config file, we will iterate the keys converting
 	// them to the new format and testing
-	for registry, config := range config.Configs {
+	for registry := range config.Configs {
 		if configKey == convertToHostname(registry) {
-			return config
+			c, _ := config.GetAuthConfig(registry)
+			return c
 		}
 	}
 
@@ -3,6 +3,8 @@ package registry
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -74,6 +76,118 @@ func TestSameAuthDataPostSave(t *testing.T) {
 	}
 }
 
+// testCredentialHelper installs a docker-credential-test helper keeping the
+// credentials of each server in a file of dir, and returns the function
+// restoring the PATH
+func testCredentialHelper(t *testing.T, dir string) func() {
+	script := `#!/bin/sh
+input=$(cat)
+case "$1" in
+store)
+	server=$(echo "$input" | sed 's/.*"ServerURL":"\([^"]*\)".*/\1/')
+	echo "$input" > "` + dir + `/$(echo "$server" | tr -c 'a-zA-Z0-9\n' _)"
+	;;
+get)
+	file="` + dir + `/$(echo "$input" | tr -c 'a-zA-Z0-9\n' _)"
+	if [ ! -f "$file" ]; then
+		echo "credentials not found in native keychain"
+		exit 1
+	fi
+	cat "$file"
+	;;
+erase)
+	rm -f "` + dir + `/$(echo "$input" | tr -c 'a-zA-Z0-9\n' _)"
+	;;
+esac
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "docker-credential-test"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+":"+path)
+	return func() { os.Setenv("PATH", path) }
+}
+
+func TestCredentialHelperSaveLoad(t *testing.T) {
+	configFile, err := setupTempConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configFile.rootPath)
+	defer testCredentialHelper(t, configFile.rootPath)()
+	configFile.CredsStore = "test"
+
+	if err := SaveConfig(configFile); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(configFile.rootPath, CONFIGFILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"credsStore": "test"`) || strings.Contains(string(b), encodeAuth(&AuthConfig{Username: "docker-user", Password: "docker-pass"})) {
+		t.Fatalf("Expected the credentials to be kept out of the config file, got %s", b)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(configFile.rootPath, "testIndex")); err != nil || !strings.Contains(string(b), `"Secret":"docker-pass"`) {
+		t.Fatalf("Expected the credentials to be in the store, got %s: %v", b, err)
+	}
+
+	loaded, err := LoadConfig(configFile.rootPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.CredsStore != "test" {
+		t.Fatalf("Expected the credential store to be loaded, got %q", loaded.CredsStore)
+	}
+	if authConfig := loaded.Configs["testIndex"]; authConfig.Password != "" {
+		t.Fatalf("Expected the credentials to be read from the store once needed, got %+v", authConfig)
+	}
+	authConfig, _ := loaded.GetAuthConfig("testIndex")
+	if authConfig.Username != "docker-user" || authConfig.Password != "docker-pass" || authConfig.Email != "docker@docker.io" {
+		t.Fatalf("Expected the credentials of the store, got %+v", authConfig)
+	}
+
+	// Removing a server erases it from the store
+	delete(loaded.Configs, "testIndex")
+	if err := SaveConfig(loaded); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(configFile.rootPath, "testIndex")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the credentials to be erased, got %v", err)
+	}
+	if loaded, err = LoadConfig(configFile.rootPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Configs["testIndex"]; ok || len(loaded.Configs) != 1 {
+		t.Fatalf("Expected the other server only, got %v", loaded.Configs)
+	}
+}
+
+func TestCredentialHelperMissing(t *testing.T) {
+	configFile, err := setupTempConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configFile.rootPath)
+	restore := testCredentialHelper(t, configFile.rootPath)
+	configFile.CredsStore = "test"
+	if err := SaveConfig(configFile); err != nil {
+		t.Fatal(err)
+	}
+	restore()
+	if err := os.Remove(filepath.Join(configFile.rootPath, "docker-credential-test")); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadConfig(configFile.rootPath)
+	if err != nil {
+		t.Fatalf("Expected a missing credential helper not to fail the load: %v", err)
+	}
+	authConfig, ok := loaded.GetAuthConfig("testIndex")
+	if !ok || authConfig.Password != "" || authConfig.Email != "docker@docker.io" {
+		t.Fatalf("Expected the server without its credentials, got %+v", authConfig)
+	}
+}
+
 func TestResolveAuthConfigIndexServer(t *testing.T) {
 	configFile, err := setupTempConfigFile()
 	if err != nil {
@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// credentialHelperPrefix is prepended to the name of a credential store
+	// to find the binary of its helper, like docker-credential-secretservice
+	credentialHelperPrefix = "docker-credential-"
+
+	// credentialsNotFound is what a helper answers when it has no
+	// credentials for a server
+	credentialsNotFound = "credentials not found in native keychain"
+)
+
+// credentials are the messages exchanged with a credential helper
+type credentials struct {
+	ServerURL string `json:"ServerURL,omitempty"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// credentialHelper keeps the credentials in an external store, through the
+// docker-credential-<name> binary. The binary is run with the action as its
+// argument:
+//
+//	store: reads the credentials of a server as JSON from its stdin
+//	get:   reads a server address from its stdin and writes its credentials as JSON
+//	erase: reads a server address from its stdin and forgets its credentials
+type credentialHelper struct {
+	name string
+}
+
+func (h *credentialHelper) run(action string, input []byte) ([]byte, error) {
+	cmd := exec.Command(credentialHelperPrefix+h.name, action)
+	cmd.Stdin = bytes.NewReader(input)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = strings.TrimSpace(stderr.String())
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("Error running the %s credential helper: %s", h.name, msg)
+	}
+	return out, nil
+}
+
+func (h *credentialHelper) store(serverAddress string, authConfig AuthConfig) error {
+	b, err := json.Marshal(credentials{
+		ServerURL: serverAddress,
+		Username:  authConfig.Username,
+		Secret:    authConfig.Password,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = h.run("store", b)
+	return err
+}
+
+// get returns the credentials of a server, or found is false when the store
+// has none
+func (h *credentialHelper) get(serverAddress string) (username, secret string, found bool, err error) {
+	out, err := h.run("get", []byte(serverAddress))
+	if err != nil {
+		if strings.Contains(err.Error(), credentialsNotFound) {
+			return "", "", false, nil
+		}
+		return "", "", false, err
+	}
+	var c credentials
+	if err := json.Unmarshal(out, &c); err != nil {
+		return "", "", false, fmt.Errorf("Invalid answer of the %s credential helper: %v", h.name, err)
+	}
+	return c.Username, c.Secret, true, nil
+}
+
+func (h *credentialHelper) erase(serverAddress string) error {
+	_, err := h.run("erase", []byte(serverAddress))
+	if err != nil && strings.Contains(err.Error(), credentialsNotFound) {
+		return nil
+	}
+	return err
+}
@@ -445,10 +445,22 @@ func handlerAuth(w http.ResponseWriter, r *http.Request) {
 }
 
 func handlerSearch(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("q") == "nosearch" {
+		apiError(w, "Not found", 404)
+		return
+	}
+	results := []SearchResult{
+		{Name: "fakeimage", StarCount: 42},
+		{Name: "fakeimage2", StarCount: 7},
+		{Name: "fakeimage3", StarCount: 0},
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("n")); err == nil && n < len(results) {
+		results = results[:n]
+	}
 	result := &SearchResults{
 		Query:      "fakequery",
-		NumResults: 1,
-		Results:    []SearchResult{{Name: "fakeimage", StarCount: 42}},
+		NumResults: len(results),
+		Results:    results,
 	}
 	writeResponse(w, result, 200)
 }
@@ -726,7 +726,7 @@ func TestPushImageJSONIndex(t *testing.T) {
 
 func TestSearchRepositories(t *testing.T) {
 	r := spawnTestRegistrySession(t)
-	results, err := r.SearchRepositories("fakequery")
+	results, err := r.SearchRepositories("fakequery", 1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -736,6 +736,17 @@ func TestSearchRepositories(t *testing.T) {
 	assertEqual(t, results.NumResults, 1, "Expected 1 search results")
 	assertEqual(t, results.Query, "fakequery", "Expected 'fakequery' as query")
 	assertEqual(t, results.Results[0].StarCount, 42, "Expected 'fakeimage' a ot hae 42 stars")
+
+	results, err = r.SearchRepositories("fakequery", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, results.NumResults, 3, "Expected all the search results without a limit")
+
+	_, err = r.SearchRepositories("nosearch", 0)
+	if err == nil || !strings.Contains(err.Error(), "does not support search") {
+		t.Fatalf("Expected a registry without search to be reported, got %v", err)
+	}
 }
 
 func TestValidRemoteName(t *testing.T) {
@@ -89,6 +89,9 @@ func (s *Service) Auth(job *engine.Job) engine.Status {
 //	'metaHeaders': extra HTTP headers to include in the request to the registry.
 //		The headers should be passed as a json-encoded dictionary.
 //
+//	'limit': the maximum number of results, between 1 and 100. The registry
+//		decides how many are returned when it is not set.
+//
 // Output:
 //	Results are sent as a collection of structured messages (using engine.Table).
 //	Each result is sent as a separate message.
@@ -101,9 +104,13 @@ func (s *Service) Search(job *engine.Job) engine.Status {
 		term        = job.Args[0]
 		metaHeaders = map[string][]string{}
 		authConfig  = &AuthConfig{}
+		limit       = job.GetenvInt("limit")
 	)
 	job.GetenvJson("authConfig", authConfig)
 	job.GetenvJson("metaHeaders", metaHeaders)
+	if limit < 0 || limit > 100 {
+		return job.Errorf("Limit %d is outside the range of [1, 100]", limit)
+	}
 
 	repoInfo, err := ResolveRepositoryInfo(job, term)
 	if err != nil {
@@ -118,7 +125,7 @@ func (s *Service) Search(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
-	results, err := r.SearchRepositories(repoInfo.GetSearchTerm())
+	results, err := r.SearchRepositories(repoInfo.GetSearchTerm(), limit)
 	if err != nil {
 		return job.Error(err)
 	}
@@ -129,6 +136,10 @@ func (s *Service) Search(job *engine.Job) engine.Status {
 		outs.Add(out)
 	}
 	outs.ReverseSort()
+	// Not every registry supports a limit
+	if limit > 0 && len(outs.Data) > limit {
+		outs.Data = outs.Data[:limit]
+	}
 	if _, err := outs.WriteListTo(job.Stdout); err != nil {
 		return job.Error(err)
 	}
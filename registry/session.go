@@ -571,9 +571,15 @@ func shouldRedirect(response *http.Response) bool {
 	return response.StatusCode >= 300 && response.StatusCode < 400
 }
 
-func (r *Session) SearchRepositories(term string) (*SearchResults, error) {
+// SearchRepositories queries the index for the repositories matching term.
+// At most limit results are asked for, or the number the index defaults to
+// when limit is 0.
+func (r *Session) SearchRepositories(term string, limit int) (*SearchResults, error) {
 	log.Debugf("Index server: %s", r.indexEndpoint)
 	u := r.indexEndpoint.VersionString(1) + "search?q=" + url.QueryEscape(term)
+	if limit > 0 {
+		u += "&n=" + strconv.Itoa(limit)
+	}
 	req, err := r.reqFactory.NewRequest("GET", u, nil)
 	if err != nil {
 		return nil, err
@@ -587,7 +593,11 @@ func (r *Session) SearchRepositories(term string) (*SearchResults, error) {
 		return nil, err
 	}
 	defer res.Body.Close()
-	if res.StatusCode != 200 {
+	switch res.StatusCode {
+	case 200:
+	case 404, 405, 501:
+		return nil, utils.NewHTTPRequestError(fmt.Sprintf("The registry %s does not support search", r.indexEndpoint), res)
+	default:
 		return nil, utils.NewHTTPRequestError(fmt.Sprintf("Unexpected status code %d", res.StatusCode), res)
 	}
 	result := new(SearchResults)
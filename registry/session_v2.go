@@ -5,7 +5,11 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/registry/v2"
@@ -205,10 +209,25 @@ func (r *Session) GetV2ImageBlobReader(ep *Endpoint, imageName, sumType, sum str
 	return res.Body, l, err
 }
 
+// BlobUploadChunkSize is the size of the chunks blobs are pushed in
+var BlobUploadChunkSize int64 = 5 * 1024 * 1024
+
+// blobUploadRetryDelay is the time to wait before resuming an interrupted
+// blob upload
+var blobUploadRetryDelay = time.Second
+
+// maxBlobUploadRetries is how many times in a row a blob upload can be
+// interrupted without any progress before the push is given up
+const maxBlobUploadRetries = 5
+
 // Push the image to the server for storage.
-// 'layer' is an uncompressed reader of the blob to be pushed.
+// 'blob' holds the size bytes of the blob to be pushed. It is sent in chunks
+// of BlobUploadChunkSize, and when a chunk fails the upload resumes from the
+// last offset acknowledged by the server, so completed chunks are not sent
+// again. If progress isn't nil, it wraps the reader of every chunk starting
+// at offset.
 // The server will generate it's own checksum calculation.
-func (r *Session) PutV2ImageBlob(ep *Endpoint, imageName, sumType, sumStr string, blobRdr io.Reader, auth *RequestAuthorization) error {
+func (r *Session) PutV2ImageBlob(ep *Endpoint, imageName, sumType, sumStr string, blob io.ReaderAt, size int64, progress func(chunk io.Reader, offset int64) io.Reader, auth *RequestAuthorization) error {
 	routeURL, err := getV2Builder(ep).BuildBlobUploadURL(imageName)
 	if err != nil {
 		return err
@@ -227,11 +246,58 @@ func (r *Session) PutV2ImageBlob(ep *Endpoint, imageName, sumType, sumStr string
 	if err != nil {
 		return err
 	}
-	location := res.Header.Get("Location")
+	res.Body.Close()
+	if res.StatusCode != 202 {
+		if res.StatusCode == 401 {
+			return errLoginRequired
+		}
+		return utils.NewHTTPRequestError(fmt.Sprintf("Server error: %d trying to start the upload of %s blob - %s:%s", res.StatusCode, imageName, sumType, sumStr), res)
+	}
+	location, err := resolveLocation(routeURL, res.Header.Get("Location"))
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	for retries := 0; offset < size; {
+		n := size - offset
+		if n > BlobUploadChunkSize {
+			n = BlobUploadChunkSize
+		}
+		var chunk io.Reader = io.NewSectionReader(blob, offset, n)
+		if progress != nil {
+			chunk = progress(chunk, offset)
+		}
+		next, acked, err := r.patchV2BlobChunk(location, chunk, offset, n, auth)
+		if err == nil {
+			location, offset, retries = next, acked, 0
+			continue
+		}
+		if err == errLoginRequired || retries == maxBlobUploadRetries {
+			return err
+		}
+		retries++
+		log.Debugf("Pushing the chunk of %s at %d failed, resuming: %s", sumStr, offset, err)
+		time.Sleep(blobUploadRetryDelay)
+
+		// the server holds bytes when it acked some or refused a chunk at 0
+		nonEmpty := offset > 0
+		if jerr, ok := err.(*utils.JSONError); ok && jerr.Code == 416 {
+			nonEmpty = true
+		}
+		next, acked, err = r.getV2BlobUploadStatus(location, nonEmpty, auth)
+		if err != nil {
+			return err
+		}
+		if acked > offset {
+			retries = 0
+		}
+		location, offset = next, acked
+	}
 
 	method := "PUT"
 	log.Debugf("[registry] Calling %q %s", method, location)
-	req, err = r.reqFactory.NewRequest(method, location, ioutil.NopCloser(blobRdr))
+	req, err = r.reqFactory.NewRequest(method, location, nil)
 	if err != nil {
 		return err
 	}
@@ -262,6 +328,114 @@ func (r *Session) PutV2ImageBlob(ep *Endpoint, imageName, sumType, sumStr string
 	return nil
 }
 
+// patchV2BlobChunk sends the size bytes of chunk, starting at offset in the
+// blob, to the upload at location. It returns the location of the next chunk
+// and the offset following the bytes acknowledged by the server.
+func (r *Session) patchV2BlobChunk(location string, chunk io.Reader, offset, size int64, auth *RequestAuthorization) (string, int64, error) {
+	method := "PATCH"
+	log.Debugf("[registry] Calling %q %s", method, location)
+	req, err := r.reqFactory.NewRequest(method, location, ioutil.NopCloser(chunk))
+	if err != nil {
+		return "", 0, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+size-1))
+	if err := auth.Authorize(req); err != nil {
+		return "", 0, err
+	}
+	res, _, err := r.doRequest(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 202 {
+		if res.StatusCode == 401 {
+			return "", 0, errLoginRequired
+		}
+		// 416 means the server doesn't have the bytes before offset,
+		// the status of the upload tells where to continue
+		return "", 0, utils.NewHTTPRequestError(fmt.Sprintf("Server error: %d trying to push the chunk at %d", res.StatusCode, offset), res)
+	}
+	return uploadRange(location, res, true)
+}
+
+// getV2BlobUploadStatus asks the server how much of the upload at location it
+// stored, nonEmpty telling whether it's known to hold any byte. It returns the
+// location to continue the upload at and the offset following the stored
+// bytes.
+func (r *Session) getV2BlobUploadStatus(location string, nonEmpty bool, auth *RequestAuthorization) (string, int64, error) {
+	method := "GET"
+	log.Debugf("[registry] Calling %q %s", method, location)
+	req, err := r.reqFactory.NewRequest(method, location, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := auth.Authorize(req); err != nil {
+		return "", 0, err
+	}
+	res, _, err := r.doRequest(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 204 {
+		if res.StatusCode == 401 {
+			return "", 0, errLoginRequired
+		}
+		return "", 0, utils.NewHTTPRequestError(fmt.Sprintf("Server error: %d trying to get the status of upload %s", res.StatusCode, location), res)
+	}
+	return uploadRange(location, res, nonEmpty)
+}
+
+// uploadRange reads the Location and Range headers of a response about the
+// upload at location. The range always starts at 0 and is inclusive, but the
+// registry sends 0-0 both for an empty upload and for a single byte: it's the
+// single byte only when the server is known to hold bytes, from nonEmpty.
+func uploadRange(location string, res *http.Response, nonEmpty bool) (string, int64, error) {
+	if next := res.Header.Get("Location"); next != "" {
+		var err error
+		if location, err = resolveLocation(location, next); err != nil {
+			return "", 0, err
+		}
+	}
+	rng := res.Header.Get("Range")
+	if rng == "" {
+		return location, 0, nil
+	}
+	parts := strings.SplitN(rng, "-", 2)
+	if len(parts) != 2 || parts[0] != "0" {
+		return "", 0, fmt.Errorf("Invalid upload range %q", rng)
+	}
+	end, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || end < 0 {
+		return "", 0, fmt.Errorf("Invalid upload range %q", rng)
+	}
+	if end == 0 && !nonEmpty {
+		return location, 0, nil
+	}
+	return location, end + 1, nil
+}
+
+// resolveLocation resolves a Location header, which may be relative, against
+// the url of the request it answered
+func resolveLocation(base, location string) (string, error) {
+	if location == "" {
+		return "", fmt.Errorf("Missing upload location")
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	l, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(l).String(), nil
+}
+
 // Finally Push the (signed) manifest of the blobs we've just pushed
 func (r *Session) PutV2ImageManifest(ep *Endpoint, imageName, tagName string, manifestRdr io.Reader, auth *RequestAuthorization) (string, error) {
 	routeURL, err := getV2Builder(ep).BuildManifestURL(imageName, tagName)
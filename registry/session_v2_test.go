@@ -0,0 +1,223 @@
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/utils"
+)
+
+const uploadPath = "/v2/foo/bar/blobs/uploads/"
+
+// mockBlobUpload is a v2 registry receiving a single blob upload
+type mockBlobUpload struct {
+	sync.Mutex
+	server *httptest.Server
+	data   []byte
+	// ranges holds the Content-Range of every chunk received
+	ranges []string
+	// sent counts the bytes received in chunks, stored or not
+	sent   int
+	digest string
+	// fail is given the number of every chunk received. It returns whether
+	// the chunk fails, and how many of its bytes are stored anyway.
+	fail func(n int) (failed bool, keep int)
+}
+
+func newMockBlobUpload(fail func(int) (bool, int)) *mockBlobUpload {
+	m := &mockBlobUpload{fail: fail}
+	m.server = httptest.NewServer(m)
+	return m
+}
+
+func (m *mockBlobUpload) writeRange(w http.ResponseWriter) {
+	w.Header().Set("Location", m.server.URL+uploadPath+"uuid")
+	// like the distribution registry, 0-0 for an empty upload too
+	end := len(m.data) - 1
+	if end < 0 {
+		end = 0
+	}
+	w.Header().Set("Range", fmt.Sprintf("0-%d", end))
+}
+
+func (m *mockBlobUpload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.Lock()
+	defer m.Unlock()
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == uploadPath:
+		m.writeRange(w)
+		w.WriteHeader(202)
+	case r.Method == "PATCH" && r.URL.Path == uploadPath+"uuid":
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		m.sent += len(body)
+		rng := r.Header.Get("Content-Range")
+		m.ranges = append(m.ranges, rng)
+		if start, _ := strconv.Atoi(strings.SplitN(rng, "-", 2)[0]); start != len(m.data) {
+			m.writeRange(w)
+			w.WriteHeader(416)
+			return
+		}
+		if failed, keep := m.fail(len(m.ranges)); failed {
+			m.data = append(m.data, body[:keep]...)
+			w.WriteHeader(500)
+			return
+		}
+		m.data = append(m.data, body...)
+		m.writeRange(w)
+		w.WriteHeader(202)
+	case r.Method == "GET" && r.URL.Path == uploadPath+"uuid":
+		m.writeRange(w)
+		w.WriteHeader(204)
+	case r.Method == "PUT" && r.URL.Path == uploadPath+"uuid":
+		m.digest = r.URL.Query().Get("digest")
+		w.WriteHeader(201)
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+func pushMockBlob(t *testing.T, m *mockBlobUpload, blob []byte) error {
+	u, err := url.Parse(m.server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep := &Endpoint{URL: u, Version: APIVersion2}
+	r, err := NewSession(&AuthConfig{}, utils.NewHTTPRequestFactory(), ep, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := NewRequestAuthorization(&AuthConfig{}, ep, "repository", "foo/bar", []string{"push"})
+	return r.PutV2ImageBlob(ep, "foo/bar", "sha256", "1234", bytes.NewReader(blob), int64(len(blob)), nil, auth)
+}
+
+func TestPutV2ImageBlobResumes(t *testing.T) {
+	defer func(size int64) { BlobUploadChunkSize = size }(BlobUploadChunkSize)
+	defer func(delay time.Duration) { blobUploadRetryDelay = delay }(blobUploadRetryDelay)
+	BlobUploadChunkSize = 4
+	blobUploadRetryDelay = 0
+
+	for _, c := range []struct {
+		name   string
+		blob   string
+		fail   func(int) (bool, int)
+		ranges []string
+		sent   int
+	}{
+		{
+			name:   "no failure",
+			blob:   "0123456789",
+			fail:   func(int) (bool, int) { return false, 0 },
+			ranges: []string{"0-3", "4-7", "8-9"},
+			sent:   10,
+		},
+		{
+			name:   "chunk dropped",
+			blob:   "0123456789",
+			fail:   func(n int) (bool, int) { return n == 2, 0 },
+			ranges: []string{"0-3", "4-7", "4-7", "8-9"},
+			sent:   14,
+		},
+		{
+			name:   "response lost",
+			blob:   "0123456789",
+			fail:   func(n int) (bool, int) { return n == 2, 4 },
+			ranges: []string{"0-3", "4-7", "8-9"},
+			sent:   10,
+		},
+		{
+			name:   "chunk cut",
+			blob:   "0123456789",
+			fail:   func(n int) (bool, int) { return n == 2, 2 },
+			ranges: []string{"0-3", "4-7", "6-9"},
+			sent:   12,
+		},
+		{
+			name:   "first chunk dropped",
+			blob:   "0123456789",
+			fail:   func(n int) (bool, int) { return n == 1, 0 },
+			ranges: []string{"0-3", "0-3", "4-7", "8-9"},
+			sent:   14,
+		},
+		{
+			name:   "single byte",
+			blob:   "0",
+			fail:   func(int) (bool, int) { return false, 0 },
+			ranges: []string{"0-0"},
+			sent:   1,
+		},
+		{
+			name:   "single byte, response lost",
+			blob:   "0",
+			fail:   func(n int) (bool, int) { return n == 1, 1 },
+			ranges: []string{"0-0", "0-0"},
+			sent:   2,
+		},
+		{
+			name:   "first byte kept",
+			blob:   "0123456789",
+			fail:   func(n int) (bool, int) { return n == 1, 1 },
+			ranges: []string{"0-3", "0-3", "1-4", "5-8", "9-9"},
+			sent:   17,
+		},
+		{
+			name:   "last chunk of a single byte",
+			blob:   "01234",
+			fail:   func(n int) (bool, int) { return n == 2, 1 },
+			ranges: []string{"0-3", "4-4"},
+			sent:   5,
+		},
+	} {
+		blob := []byte(c.blob)
+		m := newMockBlobUpload(c.fail)
+		if err := pushMockBlob(t, m, blob); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		m.server.Close()
+		if !bytes.Equal(m.data, blob) {
+			t.Fatalf("%s: expected the registry to store %q, got %q", c.name, blob, m.data)
+		}
+		if !reflect.DeepEqual(m.ranges, c.ranges) {
+			t.Fatalf("%s: expected the chunks %v, got %v", c.name, c.ranges, m.ranges)
+		}
+		if m.sent != c.sent {
+			t.Fatalf("%s: expected %d bytes to be sent, got %d", c.name, c.sent, m.sent)
+		}
+		if m.digest != "sha256:1234" {
+			t.Fatalf("%s: expected the upload to be completed with its digest, got %q", c.name, m.digest)
+		}
+	}
+}
+
+func TestPutV2ImageBlobGivesUp(t *testing.T) {
+	defer func(size int64) { BlobUploadChunkSize = size }(BlobUploadChunkSize)
+	defer func(delay time.Duration) { blobUploadRetryDelay = delay }(blobUploadRetryDelay)
+	BlobUploadChunkSize = 4
+	blobUploadRetryDelay = 0
+
+	m := newMockBlobUpload(func(n int) (bool, int) { return n > 1, 0 })
+	defer m.server.Close()
+	if err := pushMockBlob(t, m, []byte("0123456789")); err == nil {
+		t.Fatal("Expected the push to fail")
+	}
+	if expected := 2 + maxBlobUploadRetries; len(m.ranges) != expected {
+		t.Fatalf("Expected %d chunks to be sent, got %v", expected, m.ranges)
+	}
+	if m.digest != "" {
+		t.Fatal("Expected the upload not to be completed")
+	}
+}
@@ -1,6 +1,8 @@
 package runconfig
 
 import (
+	"time"
+
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/nat"
 )
@@ -35,6 +37,21 @@ type Config struct {
 	OnBuild         []string
 	SecurityOpt     []string
 	Labels          map[string]string
+	Healthcheck     *HealthConfig `json:",omitempty"`
+	StopSignal      string        // Signal sent to stop the container, SIGTERM if empty
+	IdleTimeout     time.Duration `json:",omitempty"` // Kill the container when an attached client sends no input for this long, 0 disables
+}
+
+// HealthConfig holds the configuration of the health check of a container
+type HealthConfig struct {
+	// Test is the command run in the container to check its health. The
+	// container is healthy when it exits with 0.
+	Test []string
+	// Interval is the time to wait between two checks
+	Interval time.Duration
+	// Retries is the number of consecutive failures needed to consider the
+	// container unhealthy
+	Retries int
 }
 
 func ContainerConfigFromJob(job *engine.Job) *Config {
@@ -56,6 +73,8 @@ func ContainerConfigFromJob(job *engine.Job) *Config {
 		WorkingDir:      job.Getenv("WorkingDir"),
 		NetworkDisabled: job.GetenvBool("NetworkDisabled"),
 		MacAddress:      job.Getenv("MacAddress"),
+		StopSignal:      job.Getenv("StopSignal"),
+		IdleTimeout:     time.Duration(job.GetenvInt64("IdleTimeout")),
 	}
 	job.GetenvJson("ExposedPorts", &config.ExposedPorts)
 	job.GetenvJson("Volumes", &config.Volumes)
@@ -70,6 +89,7 @@ func ContainerConfigFromJob(job *engine.Job) *Config {
 	}
 
 	job.GetenvJson("Labels", &config.Labels)
+	job.GetenvJson("Healthcheck", &config.Healthcheck)
 
 	if Entrypoint := job.GetenvList("Entrypoint"); Entrypoint != nil {
 		config.Entrypoint = Entrypoint
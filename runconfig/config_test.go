@@ -2,6 +2,7 @@ package runconfig
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -262,3 +263,33 @@ func TestMerge(t *testing.T) {
 	}
 
 }
+
+func TestMergeEntrypoint(t *testing.T) {
+	imageConf := func() *Config {
+		return &Config{Entrypoint: []string{"/entrypoint"}, Cmd: []string{"default"}}
+	}
+
+	userConf := &Config{}
+	if err := Merge(userConf, imageConf()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(userConf.Entrypoint, []string{"/entrypoint"}) || !reflect.DeepEqual(userConf.Cmd, []string{"default"}) {
+		t.Fatalf("Expected the entrypoint and cmd of the image, got %v %v", userConf.Entrypoint, userConf.Cmd)
+	}
+
+	userConf = &Config{Entrypoint: []string{"/bin/cat"}}
+	if err := Merge(userConf, imageConf()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(userConf.Entrypoint, []string{"/bin/cat"}) || len(userConf.Cmd) != 0 {
+		t.Fatalf("Expected the entrypoint to be overridden without the cmd of the image, got %v %v", userConf.Entrypoint, userConf.Cmd)
+	}
+
+	userConf = &Config{Entrypoint: []string{}, Cmd: []string{"sh"}}
+	if err := Merge(userConf, imageConf()); err != nil {
+		t.Fatal(err)
+	}
+	if len(userConf.Entrypoint) != 0 || !reflect.DeepEqual(userConf.Cmd, []string{"sh"}) {
+		t.Fatalf("Expected the entrypoint to be cleared, got %v %v", userConf.Entrypoint, userConf.Cmd)
+	}
+}
@@ -0,0 +1,167 @@
+package runconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	log "github.com/Sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// ContainerFile is the configuration of a single container read from a
+// compose-style YAML file, as given to `docker run --config-file`.
+type ContainerFile struct {
+	Image       string
+	Command     []string
+	Environment []string
+	Volumes     []string
+	Ports       []string
+	Links       []string
+}
+
+// containerFileYAML is the YAML document of a ContainerFile
+type containerFileYAML struct {
+	Image       string          `yaml:"image"`
+	Command     yamlCommand     `yaml:"command"`
+	Environment yamlEnvironment `yaml:"environment"`
+	Volumes     []string        `yaml:"volumes"`
+	Ports       []string        `yaml:"ports"`
+	Links       []string        `yaml:"links"`
+}
+
+var containerFileKeys = map[string]bool{
+	"image":       true,
+	"command":     true,
+	"environment": true,
+	"volumes":     true,
+	"ports":       true,
+	"links":       true,
+}
+
+// yamlCommand is a command given as a list, or as a string split into
+// words like a shell does
+type yamlCommand []string
+
+func (c *yamlCommand) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var args []string
+	if err := unmarshal(&args); err == nil {
+		*c = args
+		return nil
+	}
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	words, err := splitWords(s)
+	if err != nil {
+		return err
+	}
+	*c = words
+	return nil
+}
+
+// yamlEnvironment is an environment given as a list of KEY=value, or as a
+// mapping of the variables to their values, in order
+type yamlEnvironment []string
+
+func (e *yamlEnvironment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*e = list
+		return nil
+	}
+	// the values are read as strings, so yes stays yes rather than true
+	var (
+		order  yaml.MapSlice
+		values map[string]string
+	)
+	if err := unmarshal(&order); err != nil {
+		return err
+	}
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+	for _, item := range order {
+		key := fmt.Sprint(item.Key)
+		*e = append(*e, key+"="+values[key])
+	}
+	return nil
+}
+
+// LoadContainerFile reads the container configuration from the YAML file at
+// path. Unknown keys are reported with a warning and ignored.
+func LoadContainerFile(path string) (*ContainerFile, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys yaml.MapSlice
+	if err := yaml.Unmarshal(content, &keys); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, item := range keys {
+		if key := fmt.Sprint(item.Key); !containerFileKeys[key] {
+			log.Warnf("%s: unknown key %q, ignoring it", path, key)
+		}
+	}
+
+	var doc containerFileYAML
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &ContainerFile{
+		Image:       doc.Image,
+		Command:     doc.Command,
+		Environment: doc.Environment,
+		Volumes:     doc.Volumes,
+		Ports:       doc.Ports,
+		Links:       doc.Links,
+	}, nil
+}
+
+// splitWords splits a command given as a string into its arguments at
+// whitespace, like a shell does: 'single' and "double" quotes keep the
+// whitespace they enclose and a backslash escapes the next character,
+// outside single quotes.
+func splitWords(s string) ([]string, error) {
+	var (
+		words   []string
+		word    []rune
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, c := range s {
+		switch {
+		case escaped:
+			word = append(word, c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word = append(word, c)
+			}
+		case c == '"' || c == '\'':
+			quote, inWord = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, string(word))
+				word, inWord = word[:0], false
+			}
+		default:
+			word = append(word, c)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", s)
+	}
+	if inWord {
+		words = append(words, string(word))
+	}
+	return words, nil
+}
@@ -0,0 +1,200 @@
+package runconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeContainerFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "docker-config-file-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestLoadContainerFile(t *testing.T) {
+	path := writeContainerFile(t, `# a test container
+image: busybox:latest
+command: ["sh", "-c", "echo hello # not a comment"]
+environment:
+  FOO: bar
+  URL: "http://example.com/"
+volumes:
+  - /data
+  - /tmp:/host-tmp:ro
+ports: ["8080:80", '53/udp']
+links:
+  - db:database
+restart: always
+`)
+	defer os.Remove(path)
+
+	cf, err := LoadContainerFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &ContainerFile{
+		Image:       "busybox:latest",
+		Command:     []string{"sh", "-c", "echo hello # not a comment"},
+		Environment: []string{"FOO=bar", "URL=http://example.com/"},
+		Volumes:     []string{"/data", "/tmp:/host-tmp:ro"},
+		Ports:       []string{"8080:80", "53/udp"},
+		Links:       []string{"db:database"},
+	}
+	if !reflect.DeepEqual(cf, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, cf)
+	}
+}
+
+func TestLoadContainerFileCommandString(t *testing.T) {
+	for content, expected := range map[string][]string{
+		"command: top -b\n":                       {"top", "-b"},
+		"command: sh -c \"echo a  b\"\n":          {"sh", "-c", "echo a  b"},
+		"command: sh -c 'echo \"$HOME\"' x\\ y\n": {"sh", "-c", "echo \"$HOME\"", "x y"},
+		"command: \"sh -c 'exit 3'\"\n":           {"sh", "-c", "exit 3"},
+	} {
+		path := writeContainerFile(t, "image: busybox\n"+content+"environment:\n  - FOO=bar\n")
+		cf, err := LoadContainerFile(path)
+		os.Remove(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(cf.Command, expected) {
+			t.Fatalf("Expected the command %q for %q, got %q", expected, content, cf.Command)
+		}
+		if !reflect.DeepEqual(cf.Environment, []string{"FOO=bar"}) {
+			t.Fatalf("Unexpected environment %v", cf.Environment)
+		}
+	}
+}
+
+func TestLoadContainerFileQuotedKeysAndComments(t *testing.T) {
+	path := writeContainerFile(t, `"image": busybox # the base image
+'command': [sh, -c, "echo 'a: b'"]  # comment
+environment:
+  "FOO:BAR": baz # comment
+ports: ["80:80", 53/udp] # comment
+volumes:
+  - /data # comment
+`)
+	defer os.Remove(path)
+
+	cf, err := LoadContainerFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &ContainerFile{
+		Image:       "busybox",
+		Command:     []string{"sh", "-c", "echo 'a: b'"},
+		Environment: []string{"FOO:BAR=baz"},
+		Volumes:     []string{"/data"},
+		Ports:       []string{"80:80", "53/udp"},
+	}
+	if !reflect.DeepEqual(cf, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, cf)
+	}
+}
+
+func TestLoadContainerFileMappings(t *testing.T) {
+	path := writeContainerFile(t, `debug: &debug yes
+image: busybox
+command: >
+  sh -c
+  "echo hello"
+environment: {ZED: last, ALPHA: 1.0, DEBUG: *debug}
+`)
+	defer os.Remove(path)
+
+	cf, err := LoadContainerFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"sh", "-c", "echo hello"}; !reflect.DeepEqual(cf.Command, expected) {
+		t.Fatalf("Expected the command %q, got %q", expected, cf.Command)
+	}
+	// the variables keep their order and their values are read as written
+	if expected := []string{"ZED=last", "ALPHA=1.0", "DEBUG=yes"}; !reflect.DeepEqual(cf.Environment, expected) {
+		t.Fatalf("Expected the environment %q, got %q", expected, cf.Environment)
+	}
+}
+
+func TestLoadContainerFileInvalid(t *testing.T) {
+	for _, content := range []string{
+		"image busybox\n",
+		"volumes:\n  - /data\n  foo: bar\n",
+		"ports: [80\n",
+		"image: [busybox]\n",
+		"volumes: /data\n",
+		"command: sh -c 'echo\n",
+		"command: {sh: -c}\n",
+		"environment: FOO=bar\n",
+	} {
+		path := writeContainerFile(t, content)
+		_, err := LoadContainerFile(path)
+		os.Remove(path)
+		if err == nil {
+			t.Fatalf("Expected an error for %q", content)
+		}
+		if !strings.HasPrefix(err.Error(), path) {
+			t.Fatalf("Expected the error to name the file, got %v", err)
+		}
+	}
+}
+
+func TestParseRunConfigFile(t *testing.T) {
+	path := writeContainerFile(t, `image: busybox
+command: [cat]
+environment:
+  - FOO=file
+  - BAR=file
+volumes:
+  - /data
+ports:
+  - 8080:80
+links:
+  - db:database:healthy
+`)
+	defer os.Remove(path)
+
+	envFile := writeContainerFile(t, "BAR=envfile\n")
+	defer os.Remove(envFile)
+
+	config, hostConfig, _, err := parseRun([]string{"--config-file", path, "--env-file", envFile, "-e", "FOO=flag", "-p", "9090:80", "--link", "other:database"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Image != "busybox" || !reflect.DeepEqual(config.Cmd, []string{"cat"}) {
+		t.Fatalf("Unexpected image %q or command %v", config.Image, config.Cmd)
+	}
+	// the last value of a variable wins
+	if expected := []string{"FOO=file", "BAR=file", "BAR=envfile", "FOO=flag"}; !reflect.DeepEqual(config.Env, expected) {
+		t.Fatalf("Expected the env file and the flags to win over the config file, got %v", config.Env)
+	}
+	if _, exists := config.Volumes["/data"]; !exists {
+		t.Fatalf("Expected the /data volume, got %v", config.Volumes)
+	}
+	if !reflect.DeepEqual(hostConfig.Links, []string{"other:database"}) {
+		t.Fatalf("Expected the flags to win over the file links, got %v", hostConfig.Links)
+	}
+	for port, bindings := range hostConfig.PortBindings {
+		if port.Port() == "80" && (len(bindings) != 1 || bindings[0].HostPort != "9090") {
+			t.Fatalf("Expected the flags to win over the file ports, got %v", bindings)
+		}
+	}
+
+	config, _, _, err = parseRun([]string{"--config-file", path, "ubuntu", "top"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Image != "ubuntu" || !reflect.DeepEqual(config.Cmd, []string{"top"}) {
+		t.Fatalf("Expected the command line image and command, got %q %v", config.Image, config.Cmd)
+	}
+}
@@ -29,6 +29,12 @@ func (n NetworkMode) IsNone() bool {
 	return n == "none"
 }
 
+// IsUserDefined indicates whether the container is attached to a network
+// created with docker network create
+func (n NetworkMode) IsUserDefined() bool {
+	return !(n == "" || n == "bridge" || n.IsHost() || n.IsContainer() || n.IsNone())
+}
+
 type IpcMode string
 
 // IsPrivate indicates whether container use it's private ipc stack
@@ -71,29 +77,52 @@ type PidMode string
 
 // IsPrivate indicates whether container use it's private pid stack
 func (n PidMode) IsPrivate() bool {
-	return !(n.IsHost())
+	return !(n.IsHost() || n.IsContainer())
 }
 
 func (n PidMode) IsHost() bool {
 	return n == "host"
 }
 
+func (n PidMode) IsContainer() bool {
+	parts := strings.SplitN(string(n), ":", 2)
+	return len(parts) > 1 && parts[0] == "container"
+}
+
 func (n PidMode) Valid() bool {
 	parts := strings.Split(string(n), ":")
 	switch mode := parts[0]; mode {
 	case "", "host":
+	case "container":
+		if len(parts) != 2 || parts[1] == "" {
+			return false
+		}
 	default:
 		return false
 	}
 	return true
 }
 
+func (n PidMode) Container() string {
+	parts := strings.SplitN(string(n), ":", 2)
+	if len(parts) > 1 {
+		return parts[1]
+	}
+	return ""
+}
+
 type DeviceMapping struct {
 	PathOnHost        string
 	PathInContainer   string
 	CgroupPermissions string
 }
 
+// ThrottleDevice limits the IO rate of a block device
+type ThrottleDevice struct {
+	Path string
+	Rate uint64 // Bytes per second
+}
+
 type RestartPolicy struct {
 	Name              string
 	MaximumRetryCount int
@@ -112,16 +141,21 @@ type HostConfig struct {
 	MemorySwap      int64  // Total memory usage (memory + swap); set `-1` to disable swap
 	CpuShares       int64  // CPU shares (relative weight vs. other containers)
 	CpusetCpus      string // CpusetCpus 0-2, 0,1
+	OomKillDisable  bool   // Whether to disable the OOM killer; requires a Memory limit
+	OomScoreAdj     int    // Adjustment of the OOM score, from -1000 to 1000
+	PidsLimit       int64  // Maximum number of processes; 0 or -1 for no limit
 	Privileged      bool
 	PortBindings    nat.PortMap
 	Links           []string
 	PublishAllPorts bool
 	Dns             []string
 	DnsSearch       []string
+	DnsOptions      []string
 	ExtraHosts      []string
 	VolumesFrom     []string
 	Devices         []DeviceMapping
 	NetworkMode     NetworkMode
+	IPAddress       string // Static IPv4 address on a user-defined network
 	IpcMode         IpcMode
 	PidMode         PidMode
 	CapAdd          []string
@@ -129,9 +163,15 @@ type HostConfig struct {
 	RestartPolicy   RestartPolicy
 	SecurityOpt     []string
 	ReadonlyRootfs  bool
+	Init            bool              // Run an init as pid 1, which forwards signals and reaps processes
+	Sysctls         map[string]string // Namespaced kernel parameters to set in the container
+	Tmpfs           map[string]string // Mount points of tmpfs mounts, with their options
 	Ulimits         []*ulimit.Ulimit
 	LogConfig       LogConfig
 	CgroupParent    string // Parent cgroup.
+
+	BlkioWeight        int64            // Block IO weight (relative weight vs. other containers), from 10 to 1000
+	BlkioDeviceReadBps []ThrottleDevice // Limits of the read rate of devices
 }
 
 // This is used by the create command when you want to set both the
@@ -177,13 +217,19 @@ func ContainerHostConfigFromJob(job *engine.Job) *HostConfig {
 		MemorySwap:      job.GetenvInt64("MemorySwap"),
 		CpuShares:       job.GetenvInt64("CpuShares"),
 		CpusetCpus:      job.Getenv("CpusetCpus"),
+		OomKillDisable:  job.GetenvBool("OomKillDisable"),
+		OomScoreAdj:     job.GetenvInt("OomScoreAdj"),
+		PidsLimit:       job.GetenvInt64("PidsLimit"),
 		Privileged:      job.GetenvBool("Privileged"),
 		PublishAllPorts: job.GetenvBool("PublishAllPorts"),
 		NetworkMode:     NetworkMode(job.Getenv("NetworkMode")),
+		IPAddress:       job.Getenv("IPAddress"),
 		IpcMode:         IpcMode(job.Getenv("IpcMode")),
 		PidMode:         PidMode(job.Getenv("PidMode")),
 		ReadonlyRootfs:  job.GetenvBool("ReadonlyRootfs"),
+		Init:            job.GetenvBool("Init"),
 		CgroupParent:    job.Getenv("CgroupParent"),
+		BlkioWeight:     job.GetenvInt64("BlkioWeight"),
 	}
 
 	// FIXME: This is for backward compatibility, if people use `Cpuset`
@@ -199,6 +245,9 @@ func ContainerHostConfigFromJob(job *engine.Job) *HostConfig {
 	job.GetenvJson("RestartPolicy", &hostConfig.RestartPolicy)
 	job.GetenvJson("Ulimits", &hostConfig.Ulimits)
 	job.GetenvJson("LogConfig", &hostConfig.LogConfig)
+	job.GetenvJson("Sysctls", &hostConfig.Sysctls)
+	job.GetenvJson("Tmpfs", &hostConfig.Tmpfs)
+	job.GetenvJson("BlkioDeviceReadBps", &hostConfig.BlkioDeviceReadBps)
 	hostConfig.SecurityOpt = job.GetenvList("SecurityOpt")
 	if Binds := job.GetenvList("Binds"); Binds != nil {
 		hostConfig.Binds = Binds
@@ -212,6 +261,9 @@ func ContainerHostConfigFromJob(job *engine.Job) *HostConfig {
 	if DnsSearch := job.GetenvList("DnsSearch"); DnsSearch != nil {
 		hostConfig.DnsSearch = DnsSearch
 	}
+	if DnsOptions := job.GetenvList("DnsOptions"); DnsOptions != nil {
+		hostConfig.DnsOptions = DnsOptions
+	}
 	if ExtraHosts := job.GetenvList("ExtraHosts"); ExtraHosts != nil {
 		hostConfig.ExtraHosts = ExtraHosts
 	}
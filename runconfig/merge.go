@@ -20,6 +20,9 @@ func Merge(userConf, imageConf *Config) error {
 	if userConf.CpuShares == 0 {
 		userConf.CpuShares = imageConf.CpuShares
 	}
+	if userConf.Healthcheck == nil {
+		userConf.Healthcheck = imageConf.Healthcheck
+	}
 	if len(userConf.ExposedPorts) == 0 {
 		userConf.ExposedPorts = imageConf.ExposedPorts
 	} else if imageConf.ExposedPorts != nil {
@@ -99,6 +102,7 @@ func Merge(userConf, imageConf *Config) error {
 			userConf.Cmd = imageConf.Cmd
 		}
 
+		// An empty entrypoint which is not nil was cleared on purpose
 		if userConf.Entrypoint == nil {
 			userConf.Entrypoint = imageConf.Entrypoint
 		}
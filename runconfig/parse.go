@@ -2,9 +2,13 @@ package runconfig
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/opts"
@@ -19,11 +23,25 @@ var (
 	ErrInvalidWorkingDirectory          = fmt.Errorf("The working directory is invalid. It needs to be an absolute path.")
 	ErrConflictContainerNetworkAndLinks = fmt.Errorf("Conflicting options: --net=container can't be used with links. This would result in undefined behavior.")
 	ErrConflictContainerNetworkAndDns   = fmt.Errorf("Conflicting options: --net=container can't be used with --dns. This configuration is invalid.")
-	ErrConflictNetworkHostname          = fmt.Errorf("Conflicting options: -h and the network mode (--net)")
+	ErrConflictNetworkHostname          = fmt.Errorf("Conflicting options: -h, --domainname and the network mode (--net)")
 	ErrConflictHostNetworkAndDns        = fmt.Errorf("Conflicting options: --net=host can't be used with --dns. This configuration is invalid.")
 	ErrConflictHostNetworkAndLinks      = fmt.Errorf("Conflicting options: --net=host can't be used with links. This would result in undefined behavior.")
+	ErrConflictSwapWithoutMemory        = fmt.Errorf("Conflicting options: --memory-swap can't be used without --memory. The swap limit needs a memory limit as a base.")
+	ErrConflictHealthcheckWithoutCmd    = fmt.Errorf("Conflicting options: --health-interval and --health-retries can't be used without --health-cmd.")
+	ErrConflictNetworkAndSysctls        = fmt.Errorf("Conflicting options: --net=host and --net=container can't be used with network sysctls. They would change the configuration of the shared network namespace.")
+	ErrConflictIpcAndSysctls            = fmt.Errorf("Conflicting options: --ipc=host and --ipc=container can't be used with IPC sysctls. They would change the configuration of the shared IPC namespace.")
+	ErrConflictNetworkPublishPorts      = fmt.Errorf("Conflicting options: --net=host and --net=container can't be used with -p or -P. The ports of a shared network stack can't be published.")
+	ErrConflictUserNetworkPublishPorts  = fmt.Errorf("Conflicting options: -p and -P can't be used with a user-defined network (--net=NETWORK). Publishing ports is not supported on these networks.")
+	ErrConflictIdleTimeoutNoStdin       = fmt.Errorf("Conflicting options: --idle-timeout can't be used without -i, there would be no input to wait for.")
+	ErrConflictIPAddressNetwork         = fmt.Errorf("Conflicting options: --ip can only be used with a user-defined network (--net=NETWORK).")
+	ErrConflictOomKillDisableNoMemory   = fmt.Errorf("Conflicting options: --oom-kill-disable can't be used without --memory. A container without a memory limit and no OOM killer can exhaust the memory of the host.")
+
+	validNetworkName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
 )
 
+// maxUTSNameLen is the longest hostname or domain name of the kernel
+const maxUTSNameLen = 64
+
 func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSet, error) {
 	var (
 		// FIXME: use utils.ListOpts for attach and volumes?
@@ -41,6 +59,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flExpose      = opts.NewListOpts(nil)
 		flDns         = opts.NewListOpts(opts.ValidateIPAddress)
 		flDnsSearch   = opts.NewListOpts(opts.ValidateDnsSearch)
+		flDnsOptions  = opts.NewListOpts(opts.ValidateDnsOption)
 		flExtraHosts  = opts.NewListOpts(opts.ValidateExtraHost)
 		flVolumesFrom = opts.NewListOpts(nil)
 		flLxcOpts     = opts.NewListOpts(nil)
@@ -49,6 +68,10 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flCapDrop     = opts.NewListOpts(nil)
 		flSecurityOpt = opts.NewListOpts(nil)
 		flLabelsFile  = opts.NewListOpts(nil)
+		flLoggingOpts = opts.NewListOpts(nil)
+		flSysctls     = opts.NewListOpts(opts.ValidateSysctl)
+		flTmpfs       = opts.NewListOpts(opts.ValidateTmpfs)
+		flReadBps     = opts.NewListOpts(nil)
 
 		flNetwork         = cmd.Bool([]string{"#n", "#-networking"}, true, "Enable networking for this container")
 		flPrivileged      = cmd.Bool([]string{"#privileged", "-privileged"}, false, "Give extended privileges to this container")
@@ -59,18 +82,31 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flContainerIDFile = cmd.String([]string{"#cidfile", "-cidfile"}, "", "Write the container ID to the file")
 		flEntrypoint      = cmd.String([]string{"#entrypoint", "-entrypoint"}, "", "Overwrite the default ENTRYPOINT of the image")
 		flHostname        = cmd.String([]string{"h", "-hostname"}, "", "Container host name")
+		flDomainname      = cmd.String([]string{"-domainname"}, "", "Container NIS domain name")
 		flMemoryString    = cmd.String([]string{"m", "-memory"}, "", "Memory limit")
 		flMemorySwap      = cmd.String([]string{"-memory-swap"}, "", "Total memory (memory + swap), '-1' to disable swap")
+		flOomKillDisable  = cmd.Bool([]string{"-oom-kill-disable"}, false, "Disable OOM Killer (requires --memory)")
+		flOomScoreAdj     = cmd.Int([]string{"-oom-score-adj"}, 0, "Tune host's OOM preferences (-1000 to 1000)")
+		flPidsLimit       = cmd.Int64([]string{"-pids-limit"}, 0, "Tune container pids limit (set -1 for unlimited)")
+		flBlkioWeight     = cmd.Int64([]string{"-blkio-weight"}, 0, "Block IO (relative weight), between 10 and 1000")
 		flUser            = cmd.String([]string{"u", "-user"}, "", "Username or UID (format: <name|uid>[:<group|gid>])")
 		flWorkingDir      = cmd.String([]string{"w", "-workdir"}, "", "Working directory inside the container")
 		flCpuShares       = cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
 		flCpusetCpus      = cmd.String([]string{"#-cpuset", "-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
 		flNetMode         = cmd.String([]string{"-net"}, "bridge", "Set the Network mode for the container")
 		flMacAddress      = cmd.String([]string{"-mac-address"}, "", "Container MAC address (e.g. 92:d0:c6:0a:29:33)")
+		flIPAddress       = cmd.String([]string{"-ip"}, "", "Container IPv4 address on a user-defined network (e.g. 172.20.0.5)")
+		flStopSignal      = cmd.String([]string{"-stop-signal"}, "", "Signal to stop a container, SIGTERM by default")
+		flIdleTimeout     = cmd.Duration([]string{"-idle-timeout"}, 0, "Kill the container when an attached client sends no input for this long")
 		flIpcMode         = cmd.String([]string{"-ipc"}, "", "IPC namespace to use")
 		flRestartPolicy   = cmd.String([]string{"-restart"}, "no", "Restart policy to apply when a container exits")
 		flReadonlyRootfs  = cmd.Bool([]string{"-read-only"}, false, "Mount the container's root filesystem as read only")
+		flInit            = cmd.Bool([]string{"-init"}, false, "Run an init inside the container that forwards signals and reaps processes")
 		flLoggingDriver   = cmd.String([]string{"-log-driver"}, "", "Logging driver for container")
+		flConfigFile      = cmd.String([]string{"-config-file"}, "", "Read the container configuration from a YAML file")
+		flHealthCmd       = cmd.String([]string{"-health-cmd"}, "", "Command to run to check health")
+		flHealthInterval  = cmd.Duration([]string{"-health-interval"}, 0, "Time between running the check (default 30s)")
+		flHealthRetries   = cmd.Int([]string{"-health-retries"}, 0, "Consecutive failures needed to report unhealthy (default 3)")
 		flCgroupParent    = cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
 	)
 
@@ -86,6 +122,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	cmd.Var(&flExpose, []string{"#expose", "-expose"}, "Expose a port or a range of ports")
 	cmd.Var(&flDns, []string{"#dns", "-dns"}, "Set custom DNS servers")
 	cmd.Var(&flDnsSearch, []string{"-dns-search"}, "Set custom DNS search domains")
+	cmd.Var(&flDnsOptions, []string{"-dns-option"}, "Set custom DNS options")
 	cmd.Var(&flExtraHosts, []string{"-add-host"}, "Add a custom host-to-IP mapping (host:ip)")
 	cmd.Var(&flVolumesFrom, []string{"#volumes-from", "-volumes-from"}, "Mount volumes from the specified container(s)")
 	cmd.Var(&flLxcOpts, []string{"#lxc-conf", "-lxc-conf"}, "Add custom lxc options")
@@ -93,12 +130,29 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	cmd.Var(&flCapDrop, []string{"-cap-drop"}, "Drop Linux capabilities")
 	cmd.Var(&flSecurityOpt, []string{"-security-opt"}, "Security Options")
 	cmd.Var(flUlimits, []string{"-ulimit"}, "Ulimit options")
-
-	cmd.Require(flag.Min, 1)
+	cmd.Var(&flLoggingOpts, []string{"-log-opt"}, "Log driver options")
+	cmd.Var(&flSysctls, []string{"-sysctl"}, "Sysctl options")
+	cmd.Var(&flTmpfs, []string{"-tmpfs"}, "Mount a tmpfs directory")
+	cmd.Var(&flReadBps, []string{"-device-read-bps"}, "Limit read rate (bytes per second) from a device")
 
 	if err := utils.ParseFlags(cmd, args, true); err != nil {
 		return nil, nil, cmd, err
 	}
+	// the image can also be given by the config file
+	if cmd.NArg() < 1 && *flConfigFile == "" {
+		return nil, nil, cmd, fmt.Errorf("%q requires a minimum of 1 argument, or an image in --config-file", cmd.Name())
+	}
+
+	var containerFile *ContainerFile
+	if *flConfigFile != "" {
+		var err error
+		if containerFile, err = LoadContainerFile(*flConfigFile); err != nil {
+			return nil, nil, cmd, err
+		}
+		if err := mergeContainerFile(containerFile, &flVolumes, &flPublish, &flLinks); err != nil {
+			return nil, nil, cmd, err
+		}
+	}
 
 	// Validate input params
 	if *flWorkingDir != "" && !path.IsAbs(*flWorkingDir) {
@@ -111,13 +165,14 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 			return nil, nil, cmd, fmt.Errorf("%s is not a valid mac address", *flMacAddress)
 		}
 	}
+
 	var (
 		attachStdin  = flAttach.Get("stdin")
 		attachStdout = flAttach.Get("stdout")
 		attachStderr = flAttach.Get("stderr")
 	)
 
-	if *flNetMode != "bridge" && *flNetMode != "none" && *flHostname != "" {
+	if (NetworkMode(*flNetMode).IsHost() || NetworkMode(*flNetMode).IsContainer()) && (*flHostname != "" || *flDomainname != "") {
 		return nil, nil, cmd, ErrConflictNetworkHostname
 	}
 
@@ -125,7 +180,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, ErrConflictHostNetworkAndLinks
 	}
 
-	if *flNetMode == "container" && flLinks.Len() > 0 {
+	if NetworkMode(*flNetMode).IsContainer() && flLinks.Len() > 0 {
 		return nil, nil, cmd, ErrConflictContainerNetworkAndLinks
 	}
 
@@ -133,7 +188,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, ErrConflictHostNetworkAndDns
 	}
 
-	if *flNetMode == "container" && flDns.Len() > 0 {
+	if NetworkMode(*flNetMode).IsContainer() && flDns.Len() > 0 {
 		return nil, nil, cmd, ErrConflictContainerNetworkAndDns
 	}
 
@@ -157,6 +212,9 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 
 	var MemorySwap int64
 	if *flMemorySwap != "" {
+		if flMemory == 0 {
+			return nil, nil, cmd, ErrConflictSwapWithoutMemory
+		}
 		if *flMemorySwap == "-1" {
 			MemorySwap = -1
 		} else {
@@ -168,6 +226,35 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		}
 	}
 
+	if *flIdleTimeout < 0 {
+		return nil, nil, cmd, fmt.Errorf("Invalid idle timeout: %s", *flIdleTimeout)
+	}
+	if *flIdleTimeout > 0 && !*flStdin {
+		return nil, nil, cmd, ErrConflictIdleTimeoutNoStdin
+	}
+
+	if *flOomKillDisable && flMemory == 0 {
+		return nil, nil, cmd, ErrConflictOomKillDisableNoMemory
+	}
+	if err := ValidateOomScoreAdj(*flOomScoreAdj); err != nil {
+		return nil, nil, cmd, err
+	}
+
+	if *flPidsLimit < -1 {
+		return nil, nil, cmd, fmt.Errorf("Invalid --pids-limit %d: use a positive number, or -1 for unlimited", *flPidsLimit)
+	}
+	if err := ValidateBlkioWeight(*flBlkioWeight); err != nil {
+		return nil, nil, cmd, err
+	}
+	var readBps []ThrottleDevice
+	for _, val := range flReadBps.GetAll() {
+		td, err := ParseThrottleDevice(val)
+		if err != nil {
+			return nil, nil, cmd, err
+		}
+		readBps = append(readBps, td)
+	}
+
 	var binds []string
 	// add any bind targets to the list of container volumes
 	for bind := range flVolumes.GetMap() {
@@ -193,8 +280,22 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	if len(parsedArgs) > 1 {
 		runCmd = parsedArgs[1:]
 	}
+	if containerFile != nil {
+		if image == "" {
+			image = containerFile.Image
+		}
+		if len(runCmd) == 0 {
+			runCmd = containerFile.Command
+		}
+		if image == "" {
+			return nil, nil, cmd, fmt.Errorf("No image given on the command line or in %s", *flConfigFile)
+		}
+	}
 	if *flEntrypoint != "" {
 		entrypoint = []string{*flEntrypoint}
+	} else if cmd.IsSet("-entrypoint") {
+		// --entrypoint="" clears the entrypoint of the image
+		entrypoint = []string{}
 	}
 
 	lxcConf, err := parseKeyValueOpts(flLxcOpts)
@@ -203,14 +304,26 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	}
 
 	var (
-		domainname string
+		domainname = *flDomainname
 		hostname   = *flHostname
 		parts      = strings.SplitN(hostname, ".", 2)
 	)
-	if len(parts) > 1 {
+	// The domain name is the end of a full hostname, unless it is given
+	if len(parts) > 1 && domainname == "" {
 		hostname = parts[0]
 		domainname = parts[1]
 	}
+	// The hostname set in the container is the full one
+	fullHostname := hostname
+	if domainname != "" {
+		fullHostname = hostname + "." + domainname
+	}
+	if err := validateUTSName(fullHostname, "hostname"); err != nil {
+		return nil, nil, cmd, err
+	}
+	if err := validateUTSName(domainname, "domainname"); err != nil {
+		return nil, nil, cmd, err
+	}
 
 	ports, portBindings, err := nat.ParsePortSpecs(flPublish.GetAll())
 	if err != nil {
@@ -248,17 +361,39 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		deviceMappings = append(deviceMappings, deviceMapping)
 	}
 
-	// collect all the environment variables for the container
+	// collect all the environment variables for the container, the config
+	// file being overridden by the env files and the flags
+	fileEnv := []string{}
+	if containerFile != nil {
+		for _, e := range containerFile.Environment {
+			e, err := opts.ValidateEnv(e)
+			if err != nil {
+				return nil, nil, cmd, err
+			}
+			fileEnv = append(fileEnv, e)
+		}
+	}
 	envVariables, err := readKVStrings(flEnvFile.GetAll(), flEnv.GetAll())
 	if err != nil {
 		return nil, nil, cmd, err
 	}
+	envVariables = append(fileEnv, envVariables...)
 
 	// collect all the labels for the container
 	labels, err := readKVStrings(flLabelsFile.GetAll(), flLabels.GetAll())
 	if err != nil {
 		return nil, nil, cmd, err
 	}
+	for _, label := range labels {
+		if _, err := opts.ValidateMetaLabel(label); err != nil {
+			return nil, nil, cmd, err
+		}
+	}
+
+	securityOpts, err := parseSecurityOpts(flSecurityOpt.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
 
 	ipcMode := IpcMode(*flIpcMode)
 	if !ipcMode.Valid() {
@@ -280,6 +415,34 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, err
 	}
 
+	loggingOpts, err := parseLoggingOpts(*flLoggingDriver, flLoggingOpts.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
+	if err := ValidateNetworkPorts(netMode, portBindings, *flPublishAll); err != nil {
+		return nil, nil, cmd, err
+	}
+
+	if err := ValidateIPAddress(netMode, *flIPAddress); err != nil {
+		return nil, nil, cmd, err
+	}
+
+	sysctls := convertKVStringsToMap(flSysctls.GetAll())
+	if err := ValidateSysctls(sysctls, netMode, ipcMode); err != nil {
+		return nil, nil, cmd, err
+	}
+
+	tmpfs, err := parseTmpfs(flTmpfs.GetAll(), binds, flVolumes.GetMap())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
+	healthConfig, err := parseHealthcheck(*flHealthCmd, *flHealthInterval, *flHealthRetries)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	config := &Config{
 		Hostname:        hostname,
 		Domainname:      domainname,
@@ -304,6 +467,9 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		Entrypoint:      entrypoint,
 		WorkingDir:      *flWorkingDir,
 		Labels:          convertKVStringsToMap(labels),
+		Healthcheck:     healthConfig,
+		StopSignal:      *flStopSignal,
+		IdleTimeout:     *flIdleTimeout,
 	}
 
 	hostConfig := &HostConfig{
@@ -314,26 +480,37 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		MemorySwap:      MemorySwap,
 		CpuShares:       *flCpuShares,
 		CpusetCpus:      *flCpusetCpus,
+		OomKillDisable:  *flOomKillDisable,
+		OomScoreAdj:     *flOomScoreAdj,
+		PidsLimit:       *flPidsLimit,
 		Privileged:      *flPrivileged,
 		PortBindings:    portBindings,
 		Links:           flLinks.GetAll(),
 		PublishAllPorts: *flPublishAll,
 		Dns:             flDns.GetAll(),
 		DnsSearch:       flDnsSearch.GetAll(),
+		DnsOptions:      flDnsOptions.GetAll(),
 		ExtraHosts:      flExtraHosts.GetAll(),
 		VolumesFrom:     flVolumesFrom.GetAll(),
 		NetworkMode:     netMode,
+		IPAddress:       *flIPAddress,
 		IpcMode:         ipcMode,
 		PidMode:         pidMode,
 		Devices:         deviceMappings,
 		CapAdd:          flCapAdd.GetAll(),
 		CapDrop:         flCapDrop.GetAll(),
 		RestartPolicy:   restartPolicy,
-		SecurityOpt:     flSecurityOpt.GetAll(),
+		SecurityOpt:     securityOpts,
 		ReadonlyRootfs:  *flReadonlyRootfs,
+		Init:            *flInit,
+		Sysctls:         sysctls,
+		Tmpfs:           tmpfs,
 		Ulimits:         flUlimits.GetList(),
-		LogConfig:       LogConfig{Type: *flLoggingDriver},
+		LogConfig:       LogConfig{Type: *flLoggingDriver, Config: loggingOpts},
 		CgroupParent:    *flCgroupParent,
+
+		BlkioWeight:        *flBlkioWeight,
+		BlkioDeviceReadBps: readBps,
 	}
 
 	// When allocating stdin in attached mode, close stdin at client disconnect
@@ -359,6 +536,28 @@ func readKVStrings(files []string, override []string) ([]string, error) {
 	return envVariables, nil
 }
 
+// parseSecurityOpts reads the profiles of the seccomp options, given as
+// seccomp=path, so the daemon gets them as seccomp:profile
+func parseSecurityOpts(securityOpts []string) ([]string, error) {
+	parsed := make([]string, 0, len(securityOpts))
+	for _, opt := range securityOpts {
+		if !strings.HasPrefix(opt, "seccomp=") && !strings.HasPrefix(opt, "seccomp:") {
+			parsed = append(parsed, opt)
+			continue
+		}
+		profile := opt[len("seccomp="):]
+		if profile != "unconfined" {
+			data, err := ioutil.ReadFile(profile)
+			if err != nil {
+				return nil, fmt.Errorf("--security-opt: reading the seccomp profile: %v", err)
+			}
+			profile = string(data)
+		}
+		parsed = append(parsed, "seccomp:"+profile)
+	}
+	return parsed, nil
+}
+
 // converts ["key=value"] to {"key":"value"}
 func convertKVStringsToMap(values []string) map[string]string {
 	result := make(map[string]string, len(values))
@@ -411,6 +610,196 @@ func parseRestartPolicy(policy string) (RestartPolicy, error) {
 	return p, nil
 }
 
+// mergeContainerFile adds the volumes, ports and links of the container file
+// to the ones given on the command line. On conflict, the command line wins:
+// the file entries setting the same container path, container port or link
+// alias are dropped. The environment of the file is read by readKVStrings.
+func mergeContainerFile(file *ContainerFile, volumes, publish, links *opts.ListOpts) error {
+	merge := func(list *opts.ListOpts, entries []string, keyOf func(string) string) error {
+		cliKeys := make(map[string]bool)
+		for _, e := range list.GetAll() {
+			cliKeys[keyOf(e)] = true
+		}
+		for _, e := range entries {
+			if cliKeys[keyOf(e)] {
+				continue
+			}
+			if err := list.Set(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// volumes are given as [host-dir:]container-dir[:rw|ro]
+	if err := merge(volumes, file.Volumes, func(v string) string {
+		if arr := strings.Split(v, ":"); len(arr) > 1 {
+			return arr[1]
+		}
+		return v
+	}); err != nil {
+		return err
+	}
+	// ports are given as [[ip:]host-port:]container-port[/proto]
+	if err := merge(publish, file.Ports, func(p string) string {
+		return p[strings.LastIndex(p, ":")+1:]
+	}); err != nil {
+		return err
+	}
+	// links are given as name:alias[:healthy], invalid ones fail when set
+	return merge(links, file.Links, func(l string) string {
+		if _, alias, _, err := parsers.ParseLink(l); err == nil {
+			return alias
+		}
+		return l
+	})
+}
+
+// ValidateSysctls checks that sysctls only holds namespaced sysctls, and
+// that their namespaces aren't shared with the host or another container.
+func ValidateSysctls(sysctls map[string]string, netMode NetworkMode, ipcMode IpcMode) error {
+	for key, value := range sysctls {
+		if _, err := opts.ValidateSysctl(key + "=" + value); err != nil {
+			return err
+		}
+		if opts.IsNetworkSysctl(key) {
+			if !netMode.IsPrivate() {
+				return ErrConflictNetworkAndSysctls
+			}
+		} else if !ipcMode.IsPrivate() {
+			return ErrConflictIpcAndSysctls
+		}
+	}
+	return nil
+}
+
+// ValidateNetworkPorts checks that no port is published when the network
+// stack is shared with the host or another container, or when the container
+// is attached to a user-defined network.
+func ValidateNetworkPorts(netMode NetworkMode, portBindings nat.PortMap, publishAll bool) error {
+	if len(portBindings) == 0 && !publishAll {
+		return nil
+	}
+	if netMode.IsHost() || netMode.IsContainer() {
+		return ErrConflictNetworkPublishPorts
+	}
+	if netMode.IsUserDefined() {
+		return ErrConflictUserNetworkPublishPorts
+	}
+	return nil
+}
+
+// validateUTSName checks that a hostname or domain name can be set in the UTS
+// namespace of a container
+func validateUTSName(name, flag string) error {
+	if len(name) > maxUTSNameLen {
+		return fmt.Errorf("--%s: %s is longer than %d characters", flag, name, maxUTSNameLen)
+	}
+	if strings.ContainsAny(name, " \t\n/") {
+		return fmt.Errorf("--%s: invalid name %q", flag, name)
+	}
+	return nil
+}
+
+// ValidateIPAddress checks that a static IP address is a valid IPv4 address,
+// asked for on a user-defined network.
+func ValidateIPAddress(netMode NetworkMode, ip string) error {
+	if ip == "" {
+		return nil
+	}
+	if !netMode.IsUserDefined() {
+		return ErrConflictIPAddressNetwork
+	}
+	if parsed := net.ParseIP(ip); parsed == nil || parsed.To4() == nil {
+		return fmt.Errorf("%s is not a valid IPv4 address", ip)
+	}
+	return nil
+}
+
+// parseTmpfs maps the mount points of the --tmpfs flags to their options.
+// A mount point can't be given twice, nor be a volume too.
+func parseTmpfs(tmpfsList, binds []string, volumes map[string]struct{}) (map[string]string, error) {
+	volumeDests := make(map[string]struct{})
+	for v := range volumes {
+		volumeDests[path.Clean(v)] = struct{}{}
+	}
+	for _, bind := range binds {
+		volumeDests[path.Clean(strings.Split(bind, ":")[1])] = struct{}{}
+	}
+
+	tmpfs := make(map[string]string)
+	for _, t := range tmpfsList {
+		var (
+			arr     = strings.SplitN(t, ":", 2)
+			dest    = path.Clean(arr[0])
+			options string
+		)
+		if len(arr) == 2 {
+			options = arr[1]
+		}
+		if _, exists := tmpfs[dest]; exists {
+			return nil, fmt.Errorf("Duplicate --tmpfs mount point %s", dest)
+		}
+		if _, exists := volumeDests[dest]; exists {
+			return nil, fmt.Errorf("Conflicting options: --tmpfs %s is also a volume", dest)
+		}
+		tmpfs[dest] = options
+	}
+	return tmpfs, nil
+}
+
+// ValidateTmpfs checks the mount points and options of tmpfs mounts
+func ValidateTmpfs(tmpfs map[string]string) error {
+	for dest, options := range tmpfs {
+		t := dest
+		if options != "" {
+			t += ":" + options
+		}
+		if _, err := opts.ValidateTmpfs(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseHealthcheck builds the health check configuration from the --health-*
+// flags. The command is run with /bin/sh -c.
+func parseHealthcheck(healthCmd string, interval time.Duration, retries int) (*HealthConfig, error) {
+	if healthCmd == "" {
+		if interval != 0 || retries != 0 {
+			return nil, ErrConflictHealthcheckWithoutCmd
+		}
+		return nil, nil
+	}
+	if interval < 0 {
+		return nil, fmt.Errorf("--health-interval can't be negative")
+	}
+	if retries < 0 {
+		return nil, fmt.Errorf("--health-retries can't be negative")
+	}
+	return &HealthConfig{
+		Test:     []string{"/bin/sh", "-c", healthCmd},
+		Interval: interval,
+		Retries:  retries,
+	}, nil
+}
+
+// parseLoggingOpts converts the key=value --log-opt values to a map
+func parseLoggingOpts(loggingDriver string, loggingOpts []string) (map[string]string, error) {
+	if len(loggingOpts) == 0 {
+		return nil, nil
+	}
+	if loggingDriver == "none" {
+		return nil, fmt.Errorf("Invalid logging opts for driver %s", loggingDriver)
+	}
+	for _, o := range loggingOpts {
+		if !strings.Contains(o, "=") {
+			return nil, fmt.Errorf("invalid log opt format %s, expected key=value", o)
+		}
+	}
+	return convertKVStringsToMap(loggingOpts), nil
+}
+
 // options will come in the format of name.key=value or name.option
 func parseDriverOpts(opts opts.ListOpts) (map[string][]string, error) {
 	out := make(map[string][]string, len(opts.GetAll()))
@@ -451,11 +840,51 @@ func parseNetMode(netMode string) (NetworkMode, error) {
 			return "", fmt.Errorf("invalid container format container:<name|id>")
 		}
 	default:
-		return "", fmt.Errorf("invalid --net: %s", netMode)
+		// The name of a user-defined network
+		if !validNetworkName.MatchString(netMode) {
+			return "", fmt.Errorf("invalid --net: %s", netMode)
+		}
 	}
 	return NetworkMode(netMode), nil
 }
 
+// ValidateOomScoreAdj checks that an OOM score adjustment is in the range the
+// kernel accepts
+func ValidateOomScoreAdj(adj int) error {
+	if adj < -1000 || adj > 1000 {
+		return fmt.Errorf("Invalid --oom-score-adj %d: the range is [-1000, 1000]", adj)
+	}
+	return nil
+}
+
+// ValidateBlkioWeight checks that a block IO weight is in the range the
+// kernel accepts, or 0 to leave it unset
+func ValidateBlkioWeight(weight int64) error {
+	if weight != 0 && (weight < 10 || weight > 1000) {
+		return fmt.Errorf("Invalid --blkio-weight %d: the range is [10, 1000]", weight)
+	}
+	return nil
+}
+
+// ParseThrottleDevice parses the IO rate limit of a device given as
+// path:rate, where the rate is a number of bytes per second with an optional
+// unit, like /dev/sda:1mb
+func ParseThrottleDevice(val string) (ThrottleDevice, error) {
+	i := strings.LastIndex(val, ":")
+	if i < 0 {
+		return ThrottleDevice{}, fmt.Errorf("Invalid device rate %s: the format is <device-path>:<number>[<unit>]", val)
+	}
+	device, rate := val[:i], val[i+1:]
+	if !path.IsAbs(device) {
+		return ThrottleDevice{}, fmt.Errorf("Invalid device rate %s: %s is not an absolute path", val, device)
+	}
+	bytes, err := units.RAMInBytes(rate)
+	if err != nil || bytes < 0 {
+		return ThrottleDevice{}, fmt.Errorf("Invalid device rate %s: %s is not a number of bytes per second", val, rate)
+	}
+	return ThrottleDevice{Path: device, Rate: uint64(bytes)}, nil
+}
+
 func ParseDevice(device string) (DeviceMapping, error) {
 	src := ""
 	dst := ""
@@ -474,9 +903,15 @@ func ParseDevice(device string) (DeviceMapping, error) {
 		return DeviceMapping{}, fmt.Errorf("Invalid device specification: %s", device)
 	}
 
+	if src == "" {
+		return DeviceMapping{}, fmt.Errorf("Invalid device specification: %s", device)
+	}
 	if dst == "" {
 		dst = src
 	}
+	if !validDeviceMode(permissions) {
+		return DeviceMapping{}, fmt.Errorf("Invalid device permissions %q in %s, must be a combination of r, w and m", permissions, device)
+	}
 
 	deviceMapping := DeviceMapping{
 		PathOnHost:        src,
@@ -485,3 +920,19 @@ func ParseDevice(device string) (DeviceMapping, error) {
 	}
 	return deviceMapping, nil
 }
+
+// validDeviceMode checks the cgroup permissions of a device: read (r),
+// write (w) and mknod (m), each given at most once
+func validDeviceMode(mode string) bool {
+	if mode == "" || len(mode) > 3 {
+		return false
+	}
+	seen := map[rune]bool{}
+	for _, c := range mode {
+		if !strings.ContainsRune("rwm", c) || seen[c] {
+			return false
+		}
+		seen[c] = true
+	}
+	return true
+}
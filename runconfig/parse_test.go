@@ -2,7 +2,10 @@ package runconfig
 
 import (
 	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
@@ -56,4 +59,476 @@ func TestNetHostname(t *testing.T) {
 	if _, _, _, err := parseRun([]string{"-h=name", "--net=container:other", "img", "cmd"}); err != ErrConflictNetworkHostname {
 		t.Fatalf("Expected error ErrConflictNetworkHostname, got: %s", err)
 	}
+
+	if _, _, _, err := parseRun([]string{"--domainname=example.com", "--net=host", "img", "cmd"}); err != ErrConflictNetworkHostname {
+		t.Fatalf("Expected error ErrConflictNetworkHostname, got: %s", err)
+	}
+}
+
+func TestParseRunDomainname(t *testing.T) {
+	for args, expected := range map[string][2]string{
+		"-h=myhost":                              {"myhost", ""},
+		"-h=myhost.example.com":                  {"myhost", "example.com"},
+		"--domainname=example.com":               {"", "example.com"},
+		"-h=myhost.lan --domainname=example.com": {"myhost.lan", "example.com"},
+	} {
+		config, _, _, err := parseRun(append(strings.Fields(args), "img", "cmd"))
+		if err != nil {
+			t.Fatalf("%s: %v", args, err)
+		}
+		if config.Hostname != expected[0] || config.Domainname != expected[1] {
+			t.Fatalf("%s: expected the hostname %q and domain name %q, got %q and %q", args, expected[0], expected[1], config.Hostname, config.Domainname)
+		}
+	}
+
+	long := strings.Repeat("a", 60)
+	for _, args := range [][]string{
+		{"--domainname=" + long + long},
+		{"-h=" + long, "--domainname=example.com"},
+		{"--domainname=example com"},
+	} {
+		if _, _, _, err := parseRun(append(args, "img", "cmd")); err == nil {
+			t.Fatalf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestParseRunUserDefinedNetwork(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--net=my-net_1.0", "-h", "name", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hostConfig.NetworkMode.IsUserDefined() || !hostConfig.NetworkMode.IsPrivate() {
+		t.Fatalf("Expected the user-defined network my-net_1.0, got %s", hostConfig.NetworkMode)
+	}
+	for _, mode := range []string{"bridge", "host", "none", "container:other"} {
+		if NetworkMode(mode).IsUserDefined() {
+			t.Fatalf("Expected %s not to be a user-defined network", mode)
+		}
+	}
+	if _, _, _, err := parseRun([]string{"--net=-net", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for an invalid network name")
+	}
+}
+
+func TestParseRunNetworkPublishPorts(t *testing.T) {
+	for _, args := range [][]string{
+		{"--net=host", "-p", "80:80"},
+		{"--net=host", "-P"},
+		{"--net=container:other", "-p", "80"},
+		{"--net=container:other", "-P"},
+	} {
+		if _, _, _, err := parseRun(append(args, "img", "cmd")); err != ErrConflictNetworkPublishPorts {
+			t.Fatalf("%v: expected error ErrConflictNetworkPublishPorts, got: %v", args, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--net=mynet", "-p", "80:80"},
+		{"--net=mynet", "-P"},
+	} {
+		if _, _, _, err := parseRun(append(args, "img", "cmd")); err != ErrConflictUserNetworkPublishPorts {
+			t.Fatalf("%v: expected error ErrConflictUserNetworkPublishPorts, got: %v", args, err)
+		}
+	}
+
+	if _, _, _, err := parseRun([]string{"--net=container:other", "--link", "a:b", "img", "cmd"}); err != ErrConflictContainerNetworkAndLinks {
+		t.Fatalf("Expected error ErrConflictContainerNetworkAndLinks, got: %v", err)
+	}
+	if _, _, _, err := parseRun([]string{"--net=container:other", "--dns", "8.8.8.8", "img", "cmd"}); err != ErrConflictContainerNetworkAndDns {
+		t.Fatalf("Expected error ErrConflictContainerNetworkAndDns, got: %v", err)
+	}
+	if _, _, _, err := parseRun([]string{"--net=bridge", "-p", "80:80", "-P", "img", "cmd"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func TestParseRunIPAddress(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--net=mynet", "--ip", "172.20.0.5", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.IPAddress != "172.20.0.5" {
+		t.Fatalf("Expected the IP address 172.20.0.5, got %q", hostConfig.IPAddress)
+	}
+	for _, net := range []string{"bridge", "host", "none", "container:other"} {
+		if _, _, _, err := parseRun([]string{"--net=" + net, "--ip", "172.20.0.5", "img", "cmd"}); err != ErrConflictIPAddressNetwork {
+			t.Fatalf("--net=%s: expected error ErrConflictIPAddressNetwork, got: %v", net, err)
+		}
+	}
+	for _, ip := range []string{"172.20.0", "fe80::1", "name"} {
+		if _, _, _, err := parseRun([]string{"--net=mynet", "--ip", ip, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for the IP address %s", ip)
+		}
+	}
+}
+
+func TestParseRunSeccompProfile(t *testing.T) {
+	f, err := ioutil.TempFile("", "seccomp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	profile := `{"defaultAction": "SCMP_ACT_ALLOW"}`
+	if _, err := f.WriteString(profile); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	_, hostConfig, _, err := parseRun([]string{"--security-opt", "seccomp=" + f.Name(), "--security-opt", "seccomp=unconfined", "--security-opt", "label:disable", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"seccomp:" + profile, "seccomp:unconfined", "label:disable"}
+	if len(hostConfig.SecurityOpt) != len(expected) {
+		t.Fatalf("Expected the security options %q, got %q", expected, hostConfig.SecurityOpt)
+	}
+	for i := range expected {
+		if hostConfig.SecurityOpt[i] != expected[i] {
+			t.Fatalf("Expected the security options %q, got %q", expected, hostConfig.SecurityOpt)
+		}
+	}
+
+	if _, _, _, err := parseRun([]string{"--security-opt", "seccomp=/nonexistent.json", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a missing seccomp profile")
+	}
+}
+
+func TestParseRunMemorySwap(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"-m", "100m", "--memory-swap", "-1", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hostConfig.Memory != 100*1024*1024 {
+		t.Fatalf("Expected memory limit to be kept with unlimited swap, got %d", hostConfig.Memory)
+	}
+	if hostConfig.MemorySwap != -1 {
+		t.Fatalf("Expected memory swap to be -1, got %d", hostConfig.MemorySwap)
+	}
+
+	if _, _, _, err := parseRun([]string{"--memory-swap", "-1", "img", "cmd"}); err != ErrConflictSwapWithoutMemory {
+		t.Fatalf("Expected error ErrConflictSwapWithoutMemory, got: %v", err)
+	}
+
+	if _, _, _, err := parseRun([]string{"--memory-swap", "200m", "img", "cmd"}); err != ErrConflictSwapWithoutMemory {
+		t.Fatalf("Expected error ErrConflictSwapWithoutMemory, got: %v", err)
+	}
+}
+
+func TestParseRunOomKillDisable(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"-m", "100m", "--oom-kill-disable", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hostConfig.OomKillDisable {
+		t.Fatal("Expected OomKillDisable to be set")
+	}
+
+	if _, _, _, err := parseRun([]string{"--oom-kill-disable", "img", "cmd"}); err != ErrConflictOomKillDisableNoMemory {
+		t.Fatalf("Expected ErrConflictOomKillDisableNoMemory, got %v", err)
+	}
+}
+
+func TestParseRunOomScoreAdj(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--oom-score-adj=-500", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.OomScoreAdj != -500 {
+		t.Fatalf("Expected an OOM score adjustment of -500, got %d", hostConfig.OomScoreAdj)
+	}
+
+	for _, adj := range []string{"--oom-score-adj=-1001", "--oom-score-adj=1001"} {
+		if _, _, _, err := parseRun([]string{adj, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for %s", adj)
+		}
+	}
+}
+
+func TestParseRunEntrypoint(t *testing.T) {
+	config, _, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Entrypoint != nil {
+		t.Fatalf("Expected no entrypoint, got %v", config.Entrypoint)
+	}
+
+	config, _, _, err = parseRun([]string{"--entrypoint", "/bin/cat", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Entrypoint) != 1 || config.Entrypoint[0] != "/bin/cat" {
+		t.Fatalf("Expected the entrypoint /bin/cat, got %v", config.Entrypoint)
+	}
+
+	config, _, _, err = parseRun([]string{"--entrypoint=", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Entrypoint == nil || len(config.Entrypoint) != 0 {
+		t.Fatalf("Expected an empty entrypoint, got %#v", config.Entrypoint)
+	}
+}
+
+func TestParseRunStopSignal(t *testing.T) {
+	config, _, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.StopSignal != "" {
+		t.Fatalf("Expected no stop signal by default, got %q", config.StopSignal)
+	}
+
+	for _, sig := range []string{"SIGUSR1", "INT", "9"} {
+		config, _, _, err := parseRun([]string{"--stop-signal", sig, "img", "cmd"})
+		if err != nil {
+			t.Fatalf("%s: %v", sig, err)
+		}
+		if config.StopSignal != sig {
+			t.Fatalf("Expected stop signal %s, got %q", sig, config.StopSignal)
+		}
+	}
+
+}
+
+func TestParseRunIdleTimeout(t *testing.T) {
+	config, _, _, err := parseRun([]string{"-i", "--idle-timeout", "90s", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.IdleTimeout != 90*time.Second {
+		t.Fatalf("Expected an idle timeout of 90s, got %s", config.IdleTimeout)
+	}
+
+	if _, _, _, err := parseRun([]string{"--idle-timeout", "90s", "img", "cmd"}); err != ErrConflictIdleTimeoutNoStdin {
+		t.Fatalf("Expected ErrConflictIdleTimeoutNoStdin, got %v", err)
+	}
+	if _, _, _, err := parseRun([]string{"-i", "--idle-timeout", "-1s", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a negative idle timeout")
+	}
+}
+
+func TestParseRunPidMode(t *testing.T) {
+	for _, mode := range []string{"host", "container:foo"} {
+		_, hostConfig, _, err := parseRun([]string{"--pid", mode, "img", "cmd"})
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		if string(hostConfig.PidMode) != mode {
+			t.Fatalf("Expected the PID mode %s, got %s", mode, hostConfig.PidMode)
+		}
+		if hostConfig.PidMode.IsPrivate() {
+			t.Fatalf("Expected the PID mode %s not to be private", mode)
+		}
+	}
+	_, hostConfig, _, err := parseRun([]string{"--pid", "container:foo", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hostConfig.PidMode.IsContainer() || hostConfig.PidMode.Container() != "foo" {
+		t.Fatalf("Expected to join the PID namespace of foo, got %s", hostConfig.PidMode)
+	}
+
+	for _, mode := range []string{"container", "container:", "container:foo:bar", "foo"} {
+		if _, _, _, err := parseRun([]string{"--pid", mode, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for the PID mode %s", mode)
+		}
+	}
+}
+
+func TestParseRunPidsLimit(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--pids-limit", "10", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.PidsLimit != 10 {
+		t.Fatalf("Expected a pids limit of 10, got %d", hostConfig.PidsLimit)
+	}
+
+	if _, hostConfig, _, err = parseRun([]string{"--pids-limit", "-1", "img", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.PidsLimit != -1 {
+		t.Fatalf("Expected an unlimited pids limit, got %d", hostConfig.PidsLimit)
+	}
+
+	if _, _, _, err := parseRun([]string{"--pids-limit", "-2", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a pids limit of -2")
+	}
+}
+
+func TestParseRunBlkio(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--blkio-weight", "500", "--device-read-bps", "/dev/sda:1mb", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.BlkioWeight != 500 {
+		t.Fatalf("Expected a blkio weight of 500, got %d", hostConfig.BlkioWeight)
+	}
+	if len(hostConfig.BlkioDeviceReadBps) != 1 || hostConfig.BlkioDeviceReadBps[0] != (ThrottleDevice{Path: "/dev/sda", Rate: 1048576}) {
+		t.Fatalf("Expected a read limit of 1mb on /dev/sda, got %v", hostConfig.BlkioDeviceReadBps)
+	}
+
+	for _, args := range [][]string{
+		{"--blkio-weight", "5"},
+		{"--blkio-weight", "1001"},
+		{"--device-read-bps", "/dev/sda"},
+		{"--device-read-bps", "sda:1mb"},
+		{"--device-read-bps", "/dev/sda:fast"},
+	} {
+		if _, _, _, err := parseRun(append(args, "img", "cmd")); err == nil {
+			t.Fatalf("Expected an error for %v", args)
+		}
+	}
+}
+
+func TestParseRunHealthcheck(t *testing.T) {
+	config, _, _, err := parseRun([]string{"--health-cmd", "curl -f http://localhost/", "--health-interval", "5s", "--health-retries", "2", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc := config.Healthcheck
+	if hc == nil {
+		t.Fatal("Expected a health check")
+	}
+	if len(hc.Test) != 3 || hc.Test[0] != "/bin/sh" || hc.Test[2] != "curl -f http://localhost/" {
+		t.Fatalf("Unexpected health check command %v", hc.Test)
+	}
+	if hc.Interval != 5*time.Second || hc.Retries != 2 {
+		t.Fatalf("Unexpected health check interval %s or retries %d", hc.Interval, hc.Retries)
+	}
+
+	if config, _, _, err = parseRun([]string{"img", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Healthcheck != nil {
+		t.Fatalf("Expected no health check, got %v", config.Healthcheck)
+	}
+	if _, _, _, err := parseRun([]string{"--health-retries", "2", "img", "cmd"}); err != ErrConflictHealthcheckWithoutCmd {
+		t.Fatalf("Expected ErrConflictHealthcheckWithoutCmd, got %v", err)
+	}
+	if _, _, _, err := parseRun([]string{"--health-cmd", "true", "--health-interval", "-1s", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a negative interval")
+	}
+}
+
+func TestParseRunSysctls(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--sysctl", "net.ipv4.ip_forward=1", "--sysctl", "kernel.shmmax=1024", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := hostConfig.Sysctls["net.ipv4.ip_forward"]; v != "1" {
+		t.Fatalf("Unexpected net.ipv4.ip_forward %q", v)
+	}
+	if v := hostConfig.Sysctls["kernel.shmmax"]; v != "1024" {
+		t.Fatalf("Unexpected kernel.shmmax %q", v)
+	}
+
+	if _, _, _, err := parseRun([]string{"--sysctl", "kernel.hostname=foo", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a non namespaced sysctl")
+	}
+	if _, _, _, err := parseRun([]string{"--net=host", "--sysctl", "net.ipv4.ip_forward=1", "img", "cmd"}); err != ErrConflictNetworkAndSysctls {
+		t.Fatalf("Expected ErrConflictNetworkAndSysctls, got %v", err)
+	}
+	if _, _, _, err := parseRun([]string{"--net=container:other", "--sysctl", "net.ipv4.ip_forward=1", "img", "cmd"}); err != ErrConflictNetworkAndSysctls {
+		t.Fatalf("Expected ErrConflictNetworkAndSysctls, got %v", err)
+	}
+	if _, _, _, err := parseRun([]string{"--ipc=host", "--sysctl", "kernel.shmmax=1024", "img", "cmd"}); err != ErrConflictIpcAndSysctls {
+		t.Fatalf("Expected ErrConflictIpcAndSysctls, got %v", err)
+	}
+}
+
+func TestParseRunTmpfs(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--tmpfs", "/run:rw,size=64m", "--tmpfs", "/tmp/", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := hostConfig.Tmpfs["/run"]; !ok || v != "rw,size=64m" {
+		t.Fatalf("Unexpected options for /run %q", v)
+	}
+	if v, ok := hostConfig.Tmpfs["/tmp"]; !ok || v != "" {
+		t.Fatalf("Unexpected options for /tmp %q", v)
+	}
+
+	for _, args := range [][]string{
+		{"--tmpfs", "run"},
+		{"--tmpfs", "/run:size=lots"},
+		{"--tmpfs", "/run", "--tmpfs", "/run:size=1m"},
+		{"--tmpfs", "/run", "-v", "/run"},
+		{"--tmpfs", "/data", "-v", "/srv:/data"},
+	} {
+		if _, _, _, err := parseRun(append(args, "img", "cmd")); err == nil {
+			t.Fatalf("Expected an error for %v", args)
+		}
+	}
+}
+
+func TestParseRunDevice(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--device", "/dev/fuse:/dev/fuse:rw", "--device", "/dev/zero", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []DeviceMapping{
+		{PathOnHost: "/dev/fuse", PathInContainer: "/dev/fuse", CgroupPermissions: "rw"},
+		{PathOnHost: "/dev/zero", PathInContainer: "/dev/zero", CgroupPermissions: "rwm"},
+	}
+	if len(hostConfig.Devices) != len(expected) {
+		t.Fatalf("Expected %d devices, got %v", len(expected), hostConfig.Devices)
+	}
+	for i, d := range expected {
+		if hostConfig.Devices[i] != d {
+			t.Fatalf("Expected %v, got %v", d, hostConfig.Devices[i])
+		}
+	}
+
+	for _, device := range []string{
+		"/dev/fuse:/dev/fuse:",
+		"/dev/fuse:/dev/fuse:rx",
+		"/dev/fuse:/dev/fuse:rr",
+		"/dev/fuse:/dev/fuse:rwmm",
+		":/dev/fuse",
+		"/dev/fuse:/dev/fuse:rwm:extra",
+	} {
+		if _, _, _, err := parseRun([]string{"--device", device, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for --device %s", device)
+		}
+	}
+}
+
+func TestParseRunLabels(t *testing.T) {
+	config, _, _, err := parseRun([]string{"-l", "env=prod", "--label", "com.example.team=core", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(config.Labels) != 2 || config.Labels["env"] != "prod" || config.Labels["com.example.team"] != "core" {
+		t.Fatalf("Unexpected labels: %v", config.Labels)
+	}
+
+	if _, _, _, err := parseRun([]string{"-l", "=prod", "img", "cmd"}); err == nil {
+		t.Fatalf("Expected an error for an empty label key")
+	}
+}
+
+func TestParseRunLogOpts(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--log-driver", "syslog", "--log-opt", "syslog-address=tcp+tls://127.0.0.1:6514", "--log-opt", "syslog-tls-skip-verify=true", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.LogConfig.Type != "syslog" {
+		t.Fatalf("Expected syslog log driver, got %s", hostConfig.LogConfig.Type)
+	}
+	if v := hostConfig.LogConfig.Config["syslog-address"]; v != "tcp+tls://127.0.0.1:6514" {
+		t.Fatalf("Unexpected syslog-address %q", v)
+	}
+	if v := hostConfig.LogConfig.Config["syslog-tls-skip-verify"]; v != "true" {
+		t.Fatalf("Unexpected syslog-tls-skip-verify %q", v)
+	}
+
+	if _, _, _, err := parseRun([]string{"--log-driver", "none", "--log-opt", "key=value", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for log opts with the none driver")
+	}
+	if _, _, _, err := parseRun([]string{"--log-driver", "syslog", "--log-opt", "novalue", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a log opt without a value")
+	}
 }
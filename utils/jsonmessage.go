@@ -170,3 +170,32 @@ func DisplayJSONMessagesStream(in io.Reader, out io.Writer, terminalFd uintptr,
 	}
 	return nil
 }
+
+// WriteJSONMessagesStream copies the messages streamed by the daemon to out,
+// one JSON object per line, for the output to be consumed by programs. The
+// human readable progress bars are left out, their details are kept.
+func WriteJSONMessagesStream(in io.Reader, out io.Writer) error {
+	var (
+		dec = json.NewDecoder(in)
+		enc = json.NewEncoder(out)
+	)
+	for {
+		var jm JSONMessage
+		if err := dec.Decode(&jm); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		jm.ProgressMessage = ""
+		if err := enc.Encode(&jm); err != nil {
+			return err
+		}
+		if jm.Error != nil {
+			if jm.Error.Code == 401 {
+				return fmt.Errorf("Authentication is required.")
+			}
+			return jm.Error
+		}
+	}
+}
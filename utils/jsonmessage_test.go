@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -36,3 +38,27 @@ func TestProgress(t *testing.T) {
 		t.Fatalf("Expected %q, got %q", expected, jp4.String())
 	}
 }
+
+func TestWriteJSONMessagesStream(t *testing.T) {
+	in := `{"status":"Pulling fs layer","id":"511136ea3c5a"}` +
+		`{"status":"Downloading","progressDetail":{"current":50,"total":100},"progress":"[=====>  ] 50 B/100 B","id":"511136ea3c5a"}`
+	out := &bytes.Buffer{}
+	if err := WriteJSONMessagesStream(strings.NewReader(in), out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"status":"Pulling fs layer","id":"511136ea3c5a"}` + "\n" +
+		`{"status":"Downloading","progressDetail":{"current":50,"total":100},"id":"511136ea3c5a"}` + "\n"
+	if out.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, out.String())
+	}
+
+	in = `{"errorDetail":{"message":"Error: image not found"},"error":"Error: image not found"}`
+	out.Reset()
+	err := WriteJSONMessagesStream(strings.NewReader(in), out)
+	if err == nil || err.Error() != "Error: image not found" {
+		t.Fatalf("Expected the error of the stream to be returned, got %v", err)
+	}
+	if !strings.Contains(out.String(), `"errorDetail":{"message":"Error: image not found"}`) {
+		t.Fatalf("Expected the error to be written, got %q", out.String())
+	}
+}
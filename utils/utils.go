@@ -385,6 +385,8 @@ func ReplaceOrAppendEnvValues(defaults, overrides []string) []string {
 		if i, exists := cache[parts[0]]; exists {
 			defaults[i] = value
 		} else {
+			// later overrides of the same variable replace this one
+			cache[parts[0]] = len(defaults)
 			defaults = append(defaults, value)
 		}
 	}
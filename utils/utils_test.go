@@ -25,6 +25,19 @@ func TestReplaceAndAppendEnvVars(t *testing.T) {
 	}
 }
 
+func TestReplaceAndAppendEnvVarsOverridesThemselves(t *testing.T) {
+	env := ReplaceOrAppendEnvValues([]string{"HOME=/"}, []string{"FOO=file", "BAR=file", "FOO=flag", "BAR"})
+	if len(env) != 2 {
+		t.Fatalf("expected len of 2 got %v", env)
+	}
+	if env[0] != "HOME=/" {
+		t.Fatalf("expected HOME=/ got '%s'", env[0])
+	}
+	if env[1] != "FOO=flag" {
+		t.Fatalf("expected FOO=flag got '%s'", env[1])
+	}
+}
+
 // Reading a symlink to a directory must return the directory
 func TestReadSymlinkedDirectoryExistingDirectory(t *testing.T) {
 	var err error
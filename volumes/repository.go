@@ -117,6 +117,17 @@ func (r *Repository) Get(path string) *Volume {
 	return vol
 }
 
+// List returns all the volumes of the repository
+func (r *Repository) List() []*Volume {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	volumes := make([]*Volume, 0, len(r.volumes))
+	for _, v := range r.volumes {
+		volumes = append(volumes, v)
+	}
+	return volumes
+}
+
 func (r *Repository) get(path string) *Volume {
 	path, err := filepath.EvalSymlinks(path)
 	if err != nil {
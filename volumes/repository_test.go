@@ -85,6 +85,42 @@ func TestRepositoryGet(t *testing.T) {
 	}
 }
 
+func TestRepositoryList(t *testing.T) {
+	root, err := ioutil.TempDir(os.TempDir(), "volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	repo, err := newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if volumes := repo.List(); len(volumes) != 0 {
+		t.Fatalf("expected no volumes, got %d", len(volumes))
+	}
+	v, err := repo.FindOrCreateVolume("", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := repo.FindOrCreateVolume(filepath.Join(root, "bind"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	volumes := repo.List()
+	if len(volumes) != 2 {
+		t.Fatalf("expected 2 volumes, got %d", len(volumes))
+	}
+	found := map[*Volume]bool{}
+	for _, vol := range volumes {
+		found[vol] = true
+	}
+	if !found[v] || !found[v2] {
+		t.Fatalf("expected both volumes to be listed")
+	}
+}
+
 func TestRepositoryDelete(t *testing.T) {
 	root, err := ioutil.TempDir(os.TempDir(), "volumes")
 	if err != nil {